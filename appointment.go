@@ -0,0 +1,113 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package lara
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// APPOINTMENT SCHEDULING SERVICE
+
+// AppointmentStatus defines appointment's state
+//go:generate stringer -type=AppointmentStatus -output appointment_string.go
+//requires golang.org/x/tools/cmd/stringer installed locally
+//if new status added to enum, run "go generate"
+type AppointmentStatus int
+
+// AppointmentStatus enum
+const (
+	Scheduled AppointmentStatus = iota
+	Cancelled
+	CheckedIn
+)
+
+// MarshalJSON is JSON marshaller implementation for AppointmentStatus
+func (i AppointmentStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.String())
+}
+
+// Appointment is JSON encoded updatable appointment fields
+type Appointment struct {
+	Vet   string    `json:"vet"`  // login of user the appointment is booked with
+	Room  string    `json:"room"` // optional, room is not checked for conflicts if empty
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// GetAppointment is JSON encoded retrievable appointment data
+type GetAppointment struct {
+	Versioned
+	CreatorModifier
+	Appointment
+	PatientID    uint64            `json:"patientId"`
+	Patient      string            `json:"patient"`
+	OwnerID      uint64            `json:"ownerId"`
+	OwnerName    string            `json:"ownerName"` // formatted owner name (first, last, title)
+	Note         string            `json:"note"`
+	Status       AppointmentStatus `json:"status"`
+	CancelReason string            `json:"cancelReason"`
+	RecordID     uint64            `json:"recordId"` // record opened on check-in, 0 if not checked in
+}
+
+// AppointmentList is JSON encoded list of appointments
+type AppointmentList struct {
+	Items []GetAppointment `json:"items"`
+}
+
+// CreateAppointment is JSON encoded create appointment data
+type CreateAppointment struct {
+	PatientID uint64 `json:"patientId"`
+	Appointment
+	Note string `json:"note"`
+}
+
+// RescheduleAppointment is JSON encoded reschedule appointment data
+type RescheduleAppointment struct {
+	Version uint64 `json:"version"`
+	Appointment
+	Note string `json:"note"`
+}
+
+// CancelAppointment is JSON encoded cancel appointment data
+type CancelAppointment struct {
+	Version uint64 `json:"version"`
+	Reason  string `json:"reason"`
+}
+
+// CheckInAppointment is JSON encoded check-in appointment data. Record is
+// created for appointment's patient on check-in.
+type CheckInAppointment struct {
+	Version uint64 `json:"version"`
+	NewRecord
+}
+
+// AppointmentService manages appointments
+type AppointmentService interface {
+	Get(ctx context.Context, id uint64) (*GetAppointment, error)
+	Create(ctx context.Context, a *CreateAppointment) (uint64, error)
+	Reschedule(ctx context.Context, id uint64, a *RescheduleAppointment) error
+	Cancel(ctx context.Context, id uint64, c *CancelAppointment) error
+	CheckIn(ctx context.Context, id uint64, c *CheckInAppointment) (uint64, error)
+	ListByDay(ctx context.Context, day time.Time) (*AppointmentList, error)
+	ListByVet(ctx context.Context, vet string, from, to time.Time) (*AppointmentList, error)
+}
@@ -0,0 +1,85 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package lara
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// ATTACHMENT SERVICE
+
+// DefaultMaxAttachmentSize is attachment's size limit in bytes used if no
+// limit is configured
+const DefaultMaxAttachmentSize = 20 << 20
+
+// BlobStore stores binary content under keys. Keys are slash separated paths.
+type BlobStore interface {
+	// Put stores content read from r under key, existing content is replaced
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get opens content stored under key, caller must close it. Returns
+	// coded error 404 if key does not exist.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes content stored under key, missing key is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// Attachment is JSON encoded metadata of file attached to patient or record
+type Attachment struct {
+	ID          uint64    `json:"id"`
+	PatientID   uint64    `json:"patientId"`
+	RecordID    uint64    `json:"recordId"` // 0 if attached to patient only
+	FileName    string    `json:"fileName"`
+	ContentType string    `json:"contentType"` // sniffed from content
+	Size        int64     `json:"size"`        // bytes
+	Checksum    string    `json:"checksum"`    // hex encoded SHA-256 of content
+	Creator     string    `json:"creator"`     // uploader's login
+	Created     time.Time `json:"created"`
+}
+
+// AttachmentList is JSON encoded list of attachments, newest first
+type AttachmentList struct {
+	Items []Attachment `json:"items"`
+}
+
+// NewAttachment is uploaded file to attach. If RecordID is set, attachment
+// belongs to record's patient and PatientID is ignored.
+type NewAttachment struct {
+	PatientID uint64
+	RecordID  uint64
+	FileName  string
+	Content   io.Reader
+}
+
+// AttachmentService manages files attached to patients and records
+type AttachmentService interface {
+	// Create stores attachment's content and metadata. Content type is
+	// sniffed from content, unsupported type is rejected with coded error
+	// 415, content exceeding size limit with 413.
+	Create(ctx context.Context, a *NewAttachment) (uint64, error)
+	// Open returns attachment's metadata and content, caller must close it
+	Open(ctx context.Context, id uint64) (*Attachment, io.ReadCloser, error)
+	ListByRecord(ctx context.Context, recordID uint64) (*AttachmentList, error)
+	// ListByPatient lists attachments of patient including attachments of
+	// patient's records
+	ListByPatient(ctx context.Context, patientID uint64) (*AttachmentList, error)
+}
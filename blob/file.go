@@ -0,0 +1,112 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package blob contains lara.BlobStore implementations
+package blob
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/jkusniar/lara"
+	"github.com/pkg/errors"
+)
+
+// File is lara.BlobStore keeping content in files under directory Dir. Key's
+// path elements are mapped to subdirectories.
+type File struct {
+	Dir string
+}
+
+// path returns file name of key, keys escaping Dir are rejected
+func (f *File) path(key string) (string, error) {
+	if key == "" || path.Clean("/"+key) != "/"+key {
+		return "", errors.Errorf("invalid blob key %q", key)
+	}
+
+	return filepath.Join(f.Dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes content read from r to temporary file which replaces key's file
+// when whole content is written
+func (f *File) Put(ctx context.Context, key string, r io.Reader) error {
+	name, err := f.path(key)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrap(err, "create blob directory failed")
+	}
+
+	tmp, err := ioutil.TempFile(dir, ".upload-")
+	if err != nil {
+		return errors.Wrap(err, "create blob file failed")
+	}
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return errors.Wrap(err, "write blob file failed")
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return errors.Wrap(err, "close blob file failed")
+	}
+
+	return errors.Wrap(os.Rename(tmp.Name(), name), "rename blob file failed")
+}
+
+// Get opens key's file
+func (f *File) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	name, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := os.Open(name)
+	switch {
+	case os.IsNotExist(err):
+		return nil, lara.NewCodedError(404, errors.Errorf("blob %s not found", key))
+	case err != nil:
+		return nil, errors.Wrap(err, "open blob file failed")
+	}
+
+	return fd, nil
+}
+
+// Delete removes key's file
+func (f *File) Delete(ctx context.Context, key string) error {
+	name, err := f.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "remove blob file failed")
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package blob
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/jkusniar/lara"
+	"github.com/pkg/errors"
+)
+
+// Memory is lara.BlobStore keeping content in memory. Useful for testing.
+type Memory struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+// Put stores content read from r in memory
+func (m *Memory) Put(ctx context.Context, key string, r io.Reader) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return errors.Wrap(err, "read blob failed")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.blobs == nil {
+		m.blobs = make(map[string][]byte)
+	}
+	m.blobs[key] = b
+	return nil
+}
+
+// Get returns reader of key's content
+func (m *Memory) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.blobs[key]
+	if !ok {
+		return nil, lara.NewCodedError(404, errors.Errorf("blob %s not found", key))
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
+
+// Delete removes key's content
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.blobs, key)
+	return nil
+}
+
+// Len returns number of stored blobs
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.blobs)
+}
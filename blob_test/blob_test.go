@@ -0,0 +1,131 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package blob_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jkusniar/lara"
+	"github.com/jkusniar/lara/blob"
+	"github.com/pkg/errors"
+)
+
+func checkErrCode(err error, expCode int) (codeEqual bool, actualCode int) {
+	type codedError interface {
+		Code() int
+	}
+
+	if ce, ok := errors.Cause(err).(codedError); ok {
+		codeEqual = ce.Code() == expCode
+		actualCode = ce.Code()
+	}
+
+	return
+}
+
+func get(t *testing.T, s lara.BlobStore, key string) string {
+	r, err := s.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	defer r.Close()
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	return string(b)
+}
+
+// testStore runs common lara.BlobStore contract checks on s
+func testStore(t *testing.T, s lara.BlobStore) {
+	ctx := context.Background()
+
+	if err := s.Put(ctx, "ab/abcd", strings.NewReader("first")); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if c := get(t, s, "ab/abcd"); c != "first" {
+		t.Fatalf("unexpected content %s", c)
+	}
+
+	// replace
+	if err := s.Put(ctx, "ab/abcd", strings.NewReader("second")); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if c := get(t, s, "ab/abcd"); c != "second" {
+		t.Fatalf("unexpected content %s", c)
+	}
+
+	// delete, twice
+	for i := 0; i < 2; i++ {
+		if err := s.Delete(ctx, "ab/abcd"); err != nil {
+			t.Fatalf("expected nil error, but was %+v", err)
+		}
+	}
+
+	_, err := s.Get(ctx, "ab/abcd")
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+}
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lara-blob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := &blob.File{Dir: filepath.Join(dir, "store")}
+	testStore(t, s)
+
+	// keys must stay inside directory
+	for _, key := range []string{"", "../escape", "/abs", "a/../b", "a//b"} {
+		if err := s.Put(context.Background(), key, strings.NewReader("x")); err == nil {
+			t.Fatalf("expected error for key %q", key)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape")); !os.IsNotExist(err) {
+		t.Fatal("blob written outside of directory")
+	}
+
+	// no temporary files left
+	files, err := ioutil.ReadDir(filepath.Join(dir, "store", "ab"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Fatalf("unexpected files %v", files)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	s := &blob.Memory{}
+	testStore(t, s)
+
+	if s.Len() != 0 {
+		t.Fatalf("expected empty store but was %d", s.Len())
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/jkusniar/lara/cmd"
+	"github.com/jkusniar/lara/reader"
 	"github.com/jkusniar/lara/version"
 )
 
@@ -18,6 +20,11 @@ var (
 	dbPort       = flag.Uint("dbPort", uint(5432), "database port [env LARA_DB_PORT]")
 	dbName       = flag.String("dbName", "lara", "database name [env LARA_DB_NAME]")
 	dbSSLMode    = flag.String("dbSSLMode", "disable", "database connection SSL Mode [env LARA_DB_SSL_MODE]")
+	laraURL      = flag.String("laraURL", "https://localhost:8443", "lara server URL used by reader commands [env LARA_URL]")
+	laraUser     = flag.String("laraUser", "", "lara user used by reader commands [env LARA_USER]")
+	laraPass     = flag.String("laraPass", "", "lara user's password [env LARA_PASS]")
+	laraCA       = flag.String("laraCA", "", "CA certificate of lara server, if not trusted by system [env LARA_CA]")
+	replayDelay  = flag.Duration("replayDelay", time.Second, "delay between frames replayed by simulated reader")
 )
 
 func main() {
@@ -50,6 +57,38 @@ func main() {
 	case "revoke":
 		err = revoke(*dbUser, *dbPass, *dbHost, *dbName, *dbPort, *dbSSLMode,
 			flag.Args())
+	case "list":
+		err = listUsers(*dbUser, *dbPass, *dbHost, *dbName, *dbPort, *dbSSLMode,
+			flag.Args())
+	case "show":
+		err = showUser(*dbUser, *dbPass, *dbHost, *dbName, *dbPort, *dbSSLMode,
+			flag.Args())
+	case "disable":
+		err = disable(*dbUser, *dbPass, *dbHost, *dbName, *dbPort, *dbSSLMode,
+			flag.Args())
+	case "enable":
+		err = enable(*dbUser, *dbPass, *dbHost, *dbName, *dbPort, *dbSSLMode,
+			flag.Args())
+	case "passwd":
+		err = passwd(*dbUser, *dbPass, *dbHost, *dbName, *dbPort, *dbSSLMode,
+			flag.Args())
+	case "duplicates":
+		err = duplicates(*dbUser, *dbPass, *dbHost, *dbName, *dbPort, *dbSSLMode,
+			flag.Args())
+	case "merge":
+		err = merge(*dbUser, *dbPass, *dbHost, *dbName, *dbPort, *dbSSLMode,
+			flag.Args())
+	case "reader", "replay":
+		var c *reader.Client
+		c, err = readerClient(*laraURL, *laraUser, *laraPass, *laraCA)
+		if err != nil {
+			break
+		}
+		if flag.Arg(0) == "reader" {
+			err = readTags(c, flag.Args())
+		} else {
+			err = replayTags(c, *replayDelay, flag.Args())
+		}
 	default:
 		flag.Usage()
 	}
@@ -68,6 +107,15 @@ func usage() {
 	fmt.Fprintln(os.Stderr, "\tregister - register new user. Arguments: login password")
 	fmt.Fprintln(os.Stderr, "\tgrant - grant permissions to user. Arguments: login permission1,permission2,...")
 	fmt.Fprintln(os.Stderr, "\trevoke - revoke permissions from user. Arguments: login permission1,permission2,...")
+	fmt.Fprintln(os.Stderr, "\tlist - list users with their permissions")
+	fmt.Fprintln(os.Stderr, "\tshow - show user with permissions. Arguments: login")
+	fmt.Fprintln(os.Stderr, "\tdisable - disable user, preventing login. Arguments: login")
+	fmt.Fprintln(os.Stderr, "\tenable - enable previously disabled user. Arguments: login")
+	fmt.Fprintln(os.Stderr, "\tpasswd - reset user's password. Arguments: login password")
+	fmt.Fprintln(os.Stderr, "\tduplicates - list possibly duplicate owners")
+	fmt.Fprintln(os.Stderr, "\tmerge - merge duplicate owners into first one. Arguments: survivorID duplicateID1 duplicateID2 ...")
+	fmt.Fprintln(os.Stderr, "\treader - push microchips scanned by FDX-B reader to lara server. Arguments: device (serial port, or - for keyboard HID reader)")
+	fmt.Fprintln(os.Stderr, "\treplay - simulate FDX-B reader replaying frames from file. Arguments: file")
 	os.Exit(2)
 }
 
@@ -78,4 +126,8 @@ func envVars() {
 	cmd.UintVar(dbPort, "LARA_DB_PORT")
 	cmd.StringVar(dbName, "LARA_DB_NAME")
 	cmd.StringVar(dbSSLMode, "LARA_DB_SSL_MODE")
+	cmd.StringVar(laraURL, "LARA_URL")
+	cmd.StringVar(laraUser, "LARA_USER")
+	cmd.StringVar(laraPass, "LARA_PASS")
+	cmd.StringVar(laraCA, "LARA_CA")
 }
@@ -0,0 +1,104 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jkusniar/lara"
+	"github.com/jkusniar/lara/postgres"
+	"github.com/pkg/errors"
+)
+
+// ctlLogin is recorded as modifier of data changed by lara-ctl
+const ctlLogin = "lara-ctl"
+
+func duplicates(user, pass, host, name string, port uint, sslMode string, args []string) error {
+	if len(args) != 1 {
+		flag.Usage()
+	}
+
+	db, err := postgres.Open(user, pass, host, name, port, sslMode)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	service := &postgres.OwnerService{DB: db}
+	d, err := service.Duplicates(context.Background(), lara.Page{Limit: lara.MaxPageLimit})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "SCORE\tREASONS\tID\tOWNER\tADDRESS\tPHONE")
+	for _, i := range d.Items {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\t%s\n", i.Score, strings.Join(i.Reasons, ","),
+			i.Owner1.ID, i.Owner1.Name, i.Owner1.Address, i.Owner1.Phone)
+		fmt.Fprintf(w, "\t\t%d\t%s\t%s\t%s\n",
+			i.Owner2.ID, i.Owner2.Name, i.Owner2.Address, i.Owner2.Phone)
+	}
+	fmt.Fprintf(w, "%d of %d candidate pairs listed\n", len(d.Items), d.Total)
+
+	return w.Flush()
+}
+
+func merge(user, pass, host, name string, port uint, sslMode string, args []string) error {
+	if len(args) < 3 {
+		flag.Usage()
+	}
+
+	ids := make([]uint64, 0, len(args)-1)
+	for _, a := range args[1:] {
+		id, err := strconv.ParseUint(a, 10, 64)
+		if err != nil {
+			return errors.Wrapf(err, "invalid owner ID %s", a)
+		}
+		ids = append(ids, id)
+	}
+
+	db, err := postgres.Open(user, pass, host, name, port, sslMode)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := lara.ContextWithUser(context.Background(), &lara.User{Login: ctlLogin})
+	service := &postgres.OwnerService{DB: db}
+
+	// merge current versions of owners
+	versions := make([]lara.Versioned, 0, len(ids))
+	for _, id := range ids {
+		o, err := service.Get(ctx, id)
+		if err != nil {
+			return errors.Wrapf(err, "owner %d", id)
+		}
+		versions = append(versions, o.Versioned)
+	}
+
+	return service.Merge(ctx, ids[0], &lara.MergeOwners{Version: versions[0].Version,
+		Duplicates: versions[1:]})
+}
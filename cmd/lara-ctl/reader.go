@@ -0,0 +1,124 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/jkusniar/lara/reader"
+	"github.com/pkg/errors"
+)
+
+// readTags reads frames from reader device named by args[1] and pushes decoded
+// codes to lara server
+func readTags(c *reader.Client, args []string) error {
+	if len(args) != 2 {
+		flag.Usage()
+	}
+
+	d, err := reader.Open(args[1])
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return bridge(c, d)
+}
+
+// replayTags replays frames recorded in file args[1] as simulated reader device
+func replayTags(c *reader.Client, delay time.Duration, args []string) error {
+	if len(args) != 2 {
+		flag.Usage()
+	}
+
+	f, err := os.Open(args[1])
+	if err != nil {
+		return errors.Wrapf(err, "open frames file %s failed", args[1])
+	}
+	defer f.Close()
+
+	return bridge(c, reader.NewSimulator(f, delay))
+}
+
+// bridge pushes codes read from device to server until device is closed.
+// Undecodable frames and unknown tags are reported and skipped.
+func bridge(c *reader.Client, d *reader.Device) error {
+	ctx := context.Background()
+	for {
+		code, err := d.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if _, ok := err.(*reader.FrameError); ok {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		p, err := c.Scan(ctx, code)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", code, err)
+			continue
+		}
+
+		fmt.Printf("%s: patient %d %s (%s), owner %s, %s\n", code, p.PatientID,
+			p.Name, p.Species, p.OwnerName, p.OwnerAddress)
+		if !p.Active {
+			fmt.Printf("%s: tag is inactive, replaced by %q\n", code, p.ReplacedBy)
+		}
+	}
+}
+
+// readerClient creates lara server client trusting certificates in caFile, if
+// set, in addition to system certificates
+func readerClient(url, user, pass, caFile string) (*reader.Client, error) {
+	c := &reader.Client{URL: url, User: user, Pass: pass}
+	if caFile == "" {
+		return c, nil
+	}
+
+	pem, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read CA certificate %s failed", caFile)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.Errorf("no certificate found in %s", caFile)
+	}
+
+	c.HTTP = &http.Client{Timeout: 30 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	return c, nil
+}
@@ -22,7 +22,10 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
+	"os"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/jkusniar/lara"
 	"github.com/jkusniar/lara/crypto"
@@ -85,6 +88,104 @@ func revoke(user, pass, host, name string, port uint, sslMode string, args []str
 	return service.Revoke(context.Background(), args[1], p)
 }
 
+func listUsers(user, pass, host, name string, port uint, sslMode string, args []string) error {
+	if len(args) != 1 {
+		flag.Usage()
+	}
+
+	db, err := postgres.Open(user, pass, host, name, port, sslMode)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	service := &postgres.UserService{DB: db, Pass: crypto.NewPassword()}
+	l, err := service.List(context.Background())
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "LOGIN\tDISABLED\tPERMISSIONS")
+	for _, u := range l.Items {
+		printUser(w, u)
+	}
+	return w.Flush()
+}
+
+func showUser(user, pass, host, name string, port uint, sslMode string, args []string) error {
+	if len(args) != 2 {
+		flag.Usage()
+	}
+
+	db, err := postgres.Open(user, pass, host, name, port, sslMode)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	service := &postgres.UserService{DB: db, Pass: crypto.NewPassword()}
+	u, err := service.Get(context.Background(), args[1])
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "LOGIN\tDISABLED\tPERMISSIONS")
+	printUser(w, *u)
+	return w.Flush()
+}
+
+func printUser(w *tabwriter.Writer, u lara.UserAccount) {
+	fmt.Fprintf(w, "%s\t%t\t%s\n", u.Login, u.Disabled,
+		strings.Join(u.Permissions, ","))
+}
+
+func disable(user, pass, host, name string, port uint, sslMode string, args []string) error {
+	if len(args) != 2 {
+		flag.Usage()
+	}
+
+	db, err := postgres.Open(user, pass, host, name, port, sslMode)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	service := &postgres.UserService{DB: db, Pass: crypto.NewPassword()}
+	return service.Disable(context.Background(), args[1])
+}
+
+func enable(user, pass, host, name string, port uint, sslMode string, args []string) error {
+	if len(args) != 2 {
+		flag.Usage()
+	}
+
+	db, err := postgres.Open(user, pass, host, name, port, sslMode)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	service := &postgres.UserService{DB: db, Pass: crypto.NewPassword()}
+	return service.Enable(context.Background(), args[1])
+}
+
+func passwd(user, pass, host, name string, port uint, sslMode string, args []string) error {
+	if len(args) != 3 {
+		flag.Usage()
+	}
+
+	db, err := postgres.Open(user, pass, host, name, port, sslMode)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	service := &postgres.UserService{DB: db, Pass: crypto.NewPassword()}
+	return service.ResetPassword(context.Background(), args[1], args[2])
+}
+
 func extractPermissions(s string) ([]lara.PermissionType, error) {
 	perms := []string{}
 	if len(s) > 0 {
@@ -20,17 +20,24 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/jkusniar/lara"
+	"github.com/jkusniar/lara/blob"
 	"github.com/jkusniar/lara/cmd"
 	"github.com/jkusniar/lara/crypto"
 	"github.com/jkusniar/lara/http"
+	"github.com/jkusniar/lara/notify"
+	"github.com/jkusniar/lara/pdf"
 	"github.com/jkusniar/lara/postgres"
 	"github.com/jkusniar/lara/version"
 )
@@ -50,6 +57,17 @@ var (
 	tlsKey       = flag.String("tlsKey", "key.pem", "TLS private key [env LARA_TLS_KEY]")
 	tlsCert      = flag.String("tlsCert", "cert.pem", "TLS certificate [env LARA_TLS_CERT]")
 	wwwRoot      = flag.String("wwwRoot", "static", "Directory containing web client [env LARA_WWW_ROOT]")
+	clinic       = flag.String("clinic", "", "clinic name used in notifications and invoices [env LARA_CLINIC]")
+	clinicFile   = flag.String("clinicFile", "", "JSON file with clinic's invoicing data and header of printed documents (name, address, IC, DIC, ICDPH, IBAN, phone, email) [env LARA_CLINIC_FILE]")
+	smtpHost     = flag.String("smtpHost", "", "SMTP server host, email notifications disabled if empty [env LARA_SMTP_HOST]")
+	smtpPort     = flag.Uint("smtpPort", uint(25), "SMTP server port [env LARA_SMTP_PORT]")
+	smtpUser     = flag.String("smtpUser", "", "SMTP user, no authentication if empty [env LARA_SMTP_USER]")
+	smtpPass     = flag.String("smtpPass", "", "SMTP password [env LARA_SMTP_PASS]")
+	smtpFrom     = flag.String("smtpFrom", "lara@localhost", "notification sender address [env LARA_SMTP_FROM]")
+	notifyFile   = flag.String("notifyFile", "", "write notifications of all channels to file instead of sending [env LARA_NOTIFY_FILE]")
+	attachDir    = flag.String("attachDir", "attachments", "directory storing record and patient attachments [env LARA_ATTACH_DIR]")
+	attachMaxMB  = flag.Uint("attachMaxMB", uint(lara.DefaultMaxAttachmentSize>>20), "attachment size limit in megabytes [env LARA_ATTACH_MAX_MB]")
+	tagDataMaxMB = flag.Uint("tagDataMaxMB", uint(lara.DefaultMaxTagDataSize>>20), "size limit of tag's data (passport scans, certificates) in megabytes [env LARA_TAG_DATA_MAX_MB]")
 )
 
 /*
@@ -79,12 +97,19 @@ func main() {
 
 	cmd.CheckPortNum(*httpsPort, "httpsPort")
 	cmd.CheckPortNum(*dbPort, "dbPort")
+	cmd.CheckPortNum(*smtpPort, "smtpPort")
 	cmd.CheckFileExists(*rsaPriv)
 	cmd.CheckFileExists(*rsaPub)
 	cmd.CheckFileExists(*tlsKey)
 	cmd.CheckFileExists(*tlsCert)
 	cmd.CheckFileExists(*wwwRoot)
 
+	// load clinic's data
+	clinicData, err := clinicInfo()
+	if err != nil {
+		log.Fatalf("FATAL: loading clinic data failed: %+v\n", err)
+	}
+
 	// load encryption keys
 	jwt, err := crypto.NewJWTToken(*rsaPriv, *rsaPub, *hostname)
 	if err != nil {
@@ -99,25 +124,78 @@ func main() {
 	}
 	defer db.Close()
 
+	// notifications
+	notifiers, err := notifiers()
+	if err != nil {
+		log.Fatalf("FATAL: inicializing notifiers failed: %+v\n", err)
+	}
+	outbox := &postgres.OutboxService{DB: db}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if len(notifiers) > 0 {
+		go notify.NewDispatcher(outbox, notifiers).Run(ctx)
+	} else {
+		log.Println("WARNING: no notifier configured, notifications are not sent")
+	}
+
 	// server
 	sls := postgres.SimpleLovService{DB: db}
+	owners := &postgres.OwnerService{DB: db}
+	patients := &postgres.PatientService{DB: db}
+	records := &postgres.RecordService{DB: db}
+	appointments := &postgres.AppointmentService{DB: db, Loc: time.Local}
+	vaccinations := &postgres.VaccinationService{DB: db, Loc: time.Local}
+	invoices := &postgres.InvoiceService{DB: db, Loc: time.Local, Clinic: clinicData}
+	prescriptions := &postgres.PrescriptionService{DB: db}
+	attachments := &postgres.AttachmentService{DB: db,
+		Store:   &blob.File{Dir: *attachDir},
+		MaxSize: int64(*attachMaxMB) << 20}
+	tags := &postgres.TagService{DB: db, MaxDataSize: int64(*tagDataMaxMB) << 20}
 	srv := &http.Server{
-		Token:          jwt,
-		TitleService:   &sls,
-		UnitService:    &sls,
-		GenderService:  &sls,
-		SpeciesService: &sls,
-		BreedService:   &sls,
-		AddressService: &postgres.AddressService{DB: db},
-		SearchService:  &postgres.SearchService{DB: db},
-		OwnerService:   &postgres.OwnerService{DB: db},
-		PatientSevice:  &postgres.PatientService{DB: db},
-		RecordService:  &postgres.RecordService{DB: db},
-		ProductService: &postgres.ProductService{DB: db},
-		ReportService:  &postgres.ReportService{DB: db, Loc: time.Local},
-		UserService:    &postgres.UserService{DB: db, Pass: crypto.NewPassword()},
-		TagService:     &postgres.TagService{DB: db},
-		WWWRoot:        *wwwRoot,
+		Token:              jwt,
+		TitleService:       &sls,
+		UnitService:        &sls,
+		GenderService:      &sls,
+		SpeciesService:     &sls,
+		BreedService:       &sls,
+		DiagnosisService:   &sls,
+		PhraseService:      &sls,
+		AddressService:     &postgres.AddressService{DB: db},
+		SearchService:      &postgres.SearchService{DB: db},
+		OwnerService:       owners,
+		PatientSevice:      patients,
+		RecordService:      records,
+		ProductService:     &postgres.ProductService{DB: db},
+		ReportService:      &postgres.ReportService{DB: db, Loc: time.Local},
+		UserService:        &postgres.UserService{DB: db, Pass: crypto.NewPassword()},
+		TagService:         tags,
+		AppointmentService: appointments,
+		VaccinationService: vaccinations,
+		NotificationService: &notify.Service{
+			Clinic:       clinicData.Name,
+			Outbox:       outbox,
+			Owners:       owners,
+			Patients:     patients,
+			Records:      records,
+			Appointments: appointments,
+			Vaccinations: vaccinations,
+		},
+		InvoiceService: invoices,
+		DocumentService: &pdf.Service{
+			Clinic:        clinicData,
+			Owners:        owners,
+			Patients:      patients,
+			Records:       records,
+			Vaccinations:  vaccinations,
+			Invoices:      invoices,
+			Prescriptions: prescriptions,
+		},
+		StockService:         &postgres.StockService{DB: db, Loc: time.Local},
+		SupplierService:      &postgres.SupplierService{DB: db},
+		PurchaseOrderService: &postgres.PurchaseOrderService{DB: db},
+		AttachmentService:    attachments,
+		PrescriptionService:  prescriptions,
+		WWWRoot:              *wwwRoot,
 	}
 
 	// shutdown signal handler
@@ -125,6 +203,7 @@ func main() {
 	signal.Notify(quit, os.Interrupt, os.Kill, syscall.SIGTERM)
 	go func() {
 		<-quit
+		cancel()
 		if err := srv.Shutdown(); err != nil {
 			log.Fatalf("FATAL: shutdown server failed: %+v\n", err)
 		}
@@ -141,6 +220,48 @@ func main() {
 	}
 }
 
+// clinicInfo loads clinic's data from clinicFile. Clinic name from flag takes
+// precedence.
+func clinicInfo() (*lara.Clinic, error) {
+	c := &lara.Clinic{}
+	if *clinicFile != "" {
+		b, err := ioutil.ReadFile(*clinicFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, c); err != nil {
+			return nil, err
+		}
+	}
+
+	if *clinic != "" {
+		c.Name = *clinic
+	}
+
+	return c, nil
+}
+
+// notifiers creates notifiers by notification channel from flags
+func notifiers() (map[lara.NotificationChannel]lara.Notifier, error) {
+	n := make(map[lara.NotificationChannel]lara.Notifier)
+	if *notifyFile != "" {
+		f := &notify.File{Path: *notifyFile}
+		n[lara.Email] = f
+		n[lara.SMS] = f
+		return n, nil
+	}
+
+	if *smtpHost != "" {
+		s, err := notify.NewSMTP(*smtpHost, *smtpPort, *smtpUser, *smtpPass, *smtpFrom)
+		if err != nil {
+			return nil, err
+		}
+		n[lara.Email] = s
+	}
+
+	return n, nil
+}
+
 func usage() {
 	fmt.Fprintln(os.Stderr, "usage: lara [flags]")
 	fmt.Fprintln(os.Stderr, "flags:")
@@ -162,4 +283,15 @@ func envVars() {
 	cmd.StringVar(tlsKey, "LARA_TLS_KEY")
 	cmd.StringVar(tlsCert, "LARA_TLS_CERT")
 	cmd.StringVar(wwwRoot, "LARA_WWW_ROOT")
+	cmd.StringVar(clinic, "LARA_CLINIC")
+	cmd.StringVar(clinicFile, "LARA_CLINIC_FILE")
+	cmd.StringVar(smtpHost, "LARA_SMTP_HOST")
+	cmd.UintVar(smtpPort, "LARA_SMTP_PORT")
+	cmd.StringVar(smtpUser, "LARA_SMTP_USER")
+	cmd.StringVar(smtpPass, "LARA_SMTP_PASS")
+	cmd.StringVar(smtpFrom, "LARA_SMTP_FROM")
+	cmd.StringVar(notifyFile, "LARA_NOTIFY_FILE")
+	cmd.StringVar(attachDir, "LARA_ATTACH_DIR")
+	cmd.UintVar(attachMaxMB, "LARA_ATTACH_MAX_MB")
+	cmd.UintVar(tagDataMaxMB, "LARA_TAG_DATA_MAX_MB")
 }
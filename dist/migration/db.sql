@@ -199,4 +199,367 @@ UPDATE record SET billed_2 = TRUE WHERE billed = '1';
 UPDATE record SET billed_2 = FALSE WHERE billed_2 IS NULL;
 ALTER TABLE record ALTER COLUMN billed_2 SET NOT NULL;
 ALTER TABLE record DROP COLUMN billed;
-ALTER TABLE record RENAME COLUMN billed_2 TO billed;
\ No newline at end of file
+ALTER TABLE record RENAME COLUMN billed_2 TO billed;
+
+-- APPOINTMENTS
+CREATE TABLE appointment (
+  id SERIAL PRIMARY KEY,
+  patient_id integer NOT NULL REFERENCES patient,
+  vet TEXT CHECK (length(vet) <= 20) NOT NULL,
+  room TEXT,
+  start_time TIMESTAMP NOT NULL,
+  end_time TIMESTAMP NOT NULL,
+  note TEXT,
+  status integer NOT NULL DEFAULT 0,
+  cancel_reason TEXT,
+  record_id integer REFERENCES record,
+  creator TEXT CHECK (length(creator) <= 20) NOT NULL,
+  created TIMESTAMP NOT NULL,
+  modifier TEXT CHECK (length(modifier) <= 20),
+  modified TIMESTAMP,
+  version integer NOT NULL DEFAULT 0,
+  CHECK (end_time > start_time)
+);
+CREATE INDEX "idx_appointment$start_time" ON appointment USING btree (start_time);
+CREATE INDEX "idx_appointment$vet" ON appointment USING btree (vet);
+
+-- VACCINATIONS
+CREATE TABLE vaccination (
+  id SERIAL PRIMARY KEY,
+  patient_id integer NOT NULL REFERENCES patient,
+  prod_id integer NOT NULL REFERENCES lov_product,
+  batch TEXT,
+  administered date NOT NULL,
+  valid_months integer NOT NULL DEFAULT 0 CHECK (valid_months >= 0),
+  due_date date,
+  vet TEXT CHECK (length(vet) <= 20),
+  note TEXT,
+  creator TEXT CHECK (length(creator) <= 20) NOT NULL,
+  created TIMESTAMP NOT NULL,
+  modifier TEXT CHECK (length(modifier) <= 20),
+  modified TIMESTAMP,
+  version integer NOT NULL DEFAULT 0
+);
+CREATE INDEX "idx_vaccination$patient_id" ON vaccination USING btree (patient_id);
+CREATE INDEX "idx_vaccination$due_date" ON vaccination USING btree (due_date);
+
+-- NOTIFICATIONS
+CREATE TABLE notification (
+  id SERIAL PRIMARY KEY,
+  channel integer NOT NULL,
+  recipient TEXT NOT NULL,
+  subject TEXT,
+  body TEXT NOT NULL,
+  template integer NOT NULL,
+  owner_id integer NOT NULL REFERENCES owner,
+  patient_id integer REFERENCES patient,
+  status integer NOT NULL DEFAULT 0,
+  attempts integer NOT NULL DEFAULT 0,
+  last_error TEXT,
+  creator TEXT CHECK (length(creator) <= 20) NOT NULL,
+  created TIMESTAMP NOT NULL,
+  sent TIMESTAMP
+);
+CREATE INDEX "idx_notification$status" ON notification USING btree (status);
+
+-- INVOICES
+CREATE TABLE invoice (
+  id SERIAL PRIMARY KEY,
+  inv_year integer NOT NULL,
+  inv_seq integer NOT NULL,
+  inv_number character varying(10) NOT NULL UNIQUE,
+  owner_id integer NOT NULL REFERENCES owner,
+  issue_date date NOT NULL,
+  delivery_date date NOT NULL,
+  due_date date NOT NULL,
+  supplier_name TEXT NOT NULL,
+  supplier_address TEXT,
+  supplier_ic TEXT,
+  supplier_dic TEXT,
+  supplier_icdph TEXT,
+  supplier_iban TEXT,
+  supplier_phone TEXT,
+  supplier_email TEXT,
+  customer_name TEXT NOT NULL,
+  customer_address TEXT,
+  customer_ic TEXT,
+  customer_dic TEXT,
+  customer_icdph TEXT,
+  total numeric(10,2) NOT NULL,
+  creator TEXT CHECK (length(creator) <= 20) NOT NULL,
+  created TIMESTAMP NOT NULL,
+  UNIQUE(inv_year, inv_seq)
+);
+
+CREATE TABLE invoice_line (
+  id SERIAL PRIMARY KEY,
+  invoice_id integer NOT NULL REFERENCES invoice,
+  record_id integer NOT NULL REFERENCES record,
+  prod_id integer NOT NULL REFERENCES lov_product,
+  product TEXT NOT NULL,
+  unit TEXT NOT NULL,
+  plu integer,
+  prod_price numeric(8,2) NOT NULL,
+  amount numeric(10,4) NOT NULL,
+  item_price numeric(8,2) NOT NULL,
+  item_type integer NOT NULL
+);
+CREATE INDEX "idx_invoice$owner_id" ON invoice USING btree (owner_id);
+CREATE INDEX "idx_invoice_line$invoice_id" ON invoice_line USING btree (invoice_id);
+
+-- VAT
+-- prices are stored including VAT, rate is copied to record items to preserve history
+ALTER TABLE lov_product ADD COLUMN vat_rate numeric(4,2) NOT NULL DEFAULT 0 CHECK (vat_rate >= 0);
+ALTER TABLE record_item ADD COLUMN vat_rate numeric(4,2) NOT NULL DEFAULT 0 CHECK (vat_rate >= 0);
+ALTER TABLE invoice ADD COLUMN total_net numeric(10,2);
+ALTER TABLE invoice ADD COLUMN total_vat numeric(10,2);
+UPDATE invoice SET total_net = total, total_vat = 0;
+ALTER TABLE invoice ALTER COLUMN total_net SET NOT NULL;
+ALTER TABLE invoice ALTER COLUMN total_vat SET NOT NULL;
+ALTER TABLE invoice_line ADD COLUMN vat_rate numeric(4,2) NOT NULL DEFAULT 0;
+ALTER TABLE invoice_line ADD COLUMN item_net numeric(8,2);
+ALTER TABLE invoice_line ADD COLUMN item_vat numeric(8,2);
+UPDATE invoice_line SET item_net = item_price, item_vat = 0;
+ALTER TABLE invoice_line ALTER COLUMN item_net SET NOT NULL;
+ALTER TABLE invoice_line ALTER COLUMN item_vat SET NOT NULL;
+
+-- PRODUCT CATALOGUE MANAGEMENT
+ALTER TABLE lov_product ADD COLUMN creator TEXT CHECK (length(creator) <= 20);
+UPDATE lov_product SET creator = 'admin';
+ALTER TABLE lov_product ALTER COLUMN creator SET NOT NULL;
+ALTER TABLE lov_product ADD COLUMN created TIMESTAMP;
+UPDATE lov_product SET created = current_timestamp;
+ALTER TABLE lov_product ALTER COLUMN created SET NOT NULL;
+ALTER TABLE lov_product ADD COLUMN modifier TEXT CHECK (length(modifier) <= 20);
+ALTER TABLE lov_product ADD COLUMN modified TIMESTAMP;
+ALTER TABLE lov_product ADD COLUMN version integer NOT NULL DEFAULT 0;
+
+-- PRODUCT PRICE HISTORY
+CREATE TABLE product_price (
+  id SERIAL PRIMARY KEY,
+  prod_id integer NOT NULL REFERENCES lov_product,
+  price numeric(8,2) NOT NULL,
+  vat_rate numeric(4,2) NOT NULL DEFAULT 0 CHECK (vat_rate >= 0),
+  valid_from date,
+  valid_to date,
+  creator TEXT CHECK (length(creator) <= 20) NOT NULL,
+  created TIMESTAMP NOT NULL,
+  modifier TEXT CHECK (length(modifier) <= 20),
+  modified TIMESTAMP,
+  CHECK (valid_from IS NULL OR valid_to IS NULL OR valid_from <= valid_to)
+);
+CREATE INDEX product_price_prod_idx ON product_price (prod_id, valid_from);
+CREATE UNIQUE INDEX product_price_current_idx ON product_price (prod_id) WHERE valid_to IS NULL;
+INSERT INTO product_price (prod_id, price, vat_rate, creator, created)
+  SELECT id, price, vat_rate, creator, created FROM lov_product;
+
+-- STOCK MOVEMENTS
+CREATE TABLE stock_movement (
+  id SERIAL PRIMARY KEY,
+  prod_id integer NOT NULL REFERENCES lov_product,
+  mov_type integer NOT NULL,
+  quantity numeric(10,4) NOT NULL CHECK (quantity <> 0),
+  mov_date timestamp without time zone NOT NULL,
+  record_id integer REFERENCES record,
+  note text,
+  creator TEXT CHECK (length(creator) <= 20) NOT NULL,
+  created TIMESTAMP NOT NULL
+);
+CREATE INDEX "idx_stock_movement$prod_id" ON stock_movement USING btree (prod_id, mov_date);
+CREATE INDEX "idx_stock_movement$record_id" ON stock_movement USING btree (record_id);
+-- dispense material of existing records, so that their later edits post only differences
+INSERT INTO stock_movement (prod_id, mov_type, quantity, mov_date, record_id, creator, created)
+  SELECT ri.prod_id, 1, -SUM(ri.amount), r.rec_date, r.id, r.creator, current_timestamp
+  FROM record_item ri
+    JOIN record r ON r.id = ri.record_id
+  WHERE ri.item_type = 1
+  GROUP BY ri.prod_id, r.id
+  HAVING SUM(ri.amount) <> 0;
+
+-- STOCK BATCHES
+CREATE TABLE stock_batch (
+  id SERIAL PRIMARY KEY,
+  prod_id integer NOT NULL REFERENCES lov_product,
+  batch_no TEXT NOT NULL CHECK (length(batch_no) <= 30),
+  expiry date,
+  creator TEXT CHECK (length(creator) <= 20) NOT NULL,
+  created TIMESTAMP NOT NULL,
+  UNIQUE(prod_id, batch_no)
+);
+CREATE INDEX "idx_stock_batch$expiry" ON stock_batch USING btree (expiry);
+ALTER TABLE stock_movement ADD COLUMN batch_id integer REFERENCES stock_batch;
+ALTER TABLE record_item ADD COLUMN batch_id integer REFERENCES stock_batch;
+
+-- SUPPLIERS AND PURCHASE ORDERS
+ALTER TABLE lov_product ADD COLUMN min_stock numeric(10,4) CHECK (min_stock >= 0);
+CREATE TABLE supplier (
+  id SERIAL PRIMARY KEY,
+  name TEXT NOT NULL CHECK (length(name) <= 100),
+  ic TEXT CHECK (length(ic) <= 20),
+  dic TEXT CHECK (length(dic) <= 20),
+  address TEXT,
+  contact TEXT CHECK (length(contact) <= 100),
+  phone TEXT CHECK (length(phone) <= 30),
+  email TEXT CHECK (length(email) <= 100),
+  note TEXT,
+  creator TEXT CHECK (length(creator) <= 20) NOT NULL,
+  created TIMESTAMP NOT NULL,
+  modifier TEXT CHECK (length(modifier) <= 20),
+  modified TIMESTAMP,
+  version integer NOT NULL DEFAULT 0
+);
+
+CREATE TABLE purchase_order (
+  id SERIAL PRIMARY KEY,
+  supplier_id integer NOT NULL REFERENCES supplier,
+  note TEXT,
+  status integer NOT NULL,
+  creator TEXT CHECK (length(creator) <= 20) NOT NULL,
+  created TIMESTAMP NOT NULL,
+  modifier TEXT CHECK (length(modifier) <= 20),
+  modified TIMESTAMP,
+  version integer NOT NULL DEFAULT 0
+);
+
+CREATE TABLE purchase_order_line (
+  id SERIAL PRIMARY KEY,
+  order_id integer NOT NULL REFERENCES purchase_order,
+  prod_id integer NOT NULL REFERENCES lov_product,
+  amount numeric(10,4) NOT NULL CHECK (amount > 0),
+  price numeric(8,2) CHECK (price >= 0),
+  received numeric(10,4) NOT NULL DEFAULT 0 CHECK (received >= 0 AND received <= amount)
+);
+CREATE INDEX "idx_purchase_order$supplier_id" ON purchase_order USING btree (supplier_id);
+CREATE INDEX "idx_purchase_order_line$order_id" ON purchase_order_line USING btree (order_id);
+
+-- FULL-TEXT SEARCH
+-- requires postgresql-contrib (unaccent and pg_trgm extensions)
+CREATE EXTENSION IF NOT EXISTS unaccent;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- immutable wrapper of unaccent usable in expression indexes
+CREATE OR REPLACE FUNCTION f_unaccent(text) RETURNS text AS
+$func$
+SELECT public.unaccent('public.unaccent', $1)
+$func$ LANGUAGE sql IMMUTABLE;
+CREATE INDEX "idx_owner$fts_name" ON owner USING gin (to_tsvector('simple', f_unaccent(COALESCE(first_name, '') || ' ' || last_name)));
+CREATE INDEX "idx_owner$trgm_last_name" ON owner USING gin (lower(f_unaccent(last_name)) gin_trgm_ops);
+CREATE INDEX "idx_owner$fts_phone" ON owner USING gin (to_tsvector('simple', regexp_replace(lower(f_unaccent(COALESCE(phone_1, ''))), '[^0-9a-z]', '', 'g') || ' ' || regexp_replace(lower(f_unaccent(COALESCE(phone_2, ''))), '[^0-9a-z]', '', 'g')));
+CREATE INDEX "idx_owner$fts_email" ON owner USING gin (to_tsvector('simple', regexp_replace(lower(f_unaccent(COALESCE(email, ''))), '[^0-9a-z]', '', 'g')));
+CREATE INDEX "idx_owner$fts_ic" ON owner USING gin (to_tsvector('simple', regexp_replace(lower(f_unaccent(COALESCE(ic, ''))), '[^0-9a-z]', '', 'g')));
+CREATE INDEX "idx_patient$fts_name" ON patient USING gin (to_tsvector('simple', f_unaccent(name)));
+CREATE INDEX "idx_tag$fts_value" ON tag USING gin (to_tsvector('simple', regexp_replace(lower(f_unaccent(COALESCE(value, ''))), '[^0-9a-z]', '', 'g')));
+
+-- OWNER MERGE
+CREATE TABLE owner_merge (
+  id SERIAL PRIMARY KEY,
+  owner_id integer NOT NULL REFERENCES owner,
+  merged_id integer NOT NULL,
+  merged_data json NOT NULL,
+  creator TEXT CHECK (length(creator) <= 20) NOT NULL,
+  created TIMESTAMP NOT NULL
+);
+CREATE INDEX "idx_owner_merge$owner_id" ON owner_merge USING btree (owner_id);
+
+-- PATIENT OWNERSHIP TRANSFERS
+CREATE TABLE patient_transfer (
+  id SERIAL PRIMARY KEY,
+  patient_id integer NOT NULL REFERENCES patient,
+  from_owner_id integer NOT NULL REFERENCES owner,
+  to_owner_id integer NOT NULL REFERENCES owner,
+  transfer_date date NOT NULL,
+  reason TEXT,
+  creator TEXT CHECK (length(creator) <= 20) NOT NULL,
+  created TIMESTAMP NOT NULL
+);
+CREATE INDEX "idx_patient_transfer$patient_id" ON patient_transfer USING btree (patient_id);
+
+-- STRUCTURED CLINICAL RECORDS
+ALTER TABLE record ADD COLUMN subjective TEXT;
+ALTER TABLE record ADD COLUMN objective TEXT;
+ALTER TABLE record ADD COLUMN assessment TEXT;
+ALTER TABLE record ADD COLUMN plan TEXT;
+ALTER TABLE record ADD COLUMN weight numeric(7,3) CHECK (weight > 0);
+ALTER TABLE record ADD COLUMN temperature numeric(4,1) CHECK (temperature > 0);
+ALTER TABLE record ADD COLUMN heart_rate integer CHECK (heart_rate > 0);
+CREATE TABLE lov_diagnosis (
+  id SERIAL PRIMARY KEY,
+  code TEXT NOT NULL UNIQUE,
+  name TEXT NOT NULL
+);
+CREATE TABLE record_diagnosis (
+  record_id integer NOT NULL REFERENCES record,
+  diagnosis_id integer NOT NULL REFERENCES lov_diagnosis,
+  PRIMARY KEY (record_id, diagnosis_id)
+);
+CREATE INDEX "idx_record_diagnosis$diagnosis_id" ON record_diagnosis USING btree (diagnosis_id);
+
+-- PHRASE TEMPLATES
+ALTER TABLE lov_phrase DROP CONSTRAINT lov_phrase_uq;
+ALTER TABLE lov_phrase ADD COLUMN user_login TEXT CHECK (length(user_login) <= 20);
+ALTER TABLE lov_phrase ADD COLUMN version integer NOT NULL DEFAULT 0;
+CREATE UNIQUE INDEX lov_phrase_uq ON lov_phrase (name, coalesce(user_login, ''));
+
+-- ATTACHMENTS
+CREATE TABLE attachment (
+  id SERIAL PRIMARY KEY,
+  patient_id integer NOT NULL REFERENCES patient,
+  record_id integer REFERENCES record,
+  file_name TEXT NOT NULL,
+  content_type TEXT NOT NULL,
+  size bigint NOT NULL CHECK (size > 0),
+  checksum TEXT NOT NULL,
+  storage_key TEXT NOT NULL UNIQUE,
+  creator TEXT CHECK (length(creator) <= 20) NOT NULL,
+  created TIMESTAMP NOT NULL
+);
+CREATE INDEX "idx_attachment$patient_id" ON attachment USING btree (patient_id);
+CREATE INDEX "idx_attachment$record_id" ON attachment USING btree (record_id);
+
+-- PRESCRIPTIONS AND CONTROLLED DRUGS
+ALTER TABLE lov_product ADD COLUMN controlled boolean NOT NULL DEFAULT false;
+CREATE TABLE prescription (
+  id SERIAL PRIMARY KEY,
+  record_id integer NOT NULL REFERENCES record,
+  patient_id integer NOT NULL REFERENCES patient,
+  presc_date timestamp without time zone NOT NULL,
+  note TEXT,
+  creator TEXT CHECK (length(creator) <= 20) NOT NULL,
+  created TIMESTAMP NOT NULL,
+  modifier TEXT CHECK (length(modifier) <= 20),
+  modified TIMESTAMP,
+  version integer NOT NULL DEFAULT 0
+);
+
+CREATE TABLE prescription_item (
+  id SERIAL PRIMARY KEY,
+  prescription_id integer NOT NULL REFERENCES prescription,
+  prod_id integer NOT NULL REFERENCES lov_product,
+  amount numeric(10,4) NOT NULL CHECK (amount > 0),
+  dose TEXT NOT NULL,
+  frequency TEXT,
+  duration_days integer NOT NULL DEFAULT 0 CHECK (duration_days >= 0),
+  withdrawal_days integer NOT NULL DEFAULT 0 CHECK (withdrawal_days >= 0)
+);
+CREATE INDEX "idx_prescription$record_id" ON prescription USING btree (record_id);
+CREATE INDEX "idx_prescription$patient_id" ON prescription USING btree (patient_id);
+CREATE INDEX "idx_prescription_item$prescription_id" ON prescription_item USING btree (prescription_id);
+CREATE INDEX "idx_prescription_item$prod_id" ON prescription_item USING btree (prod_id);
+
+-- TAG VALIDITY AND REPLACEMENT
+ALTER TABLE tag DROP CONSTRAINT tag_value_key;
+ALTER TABLE tag ADD COLUMN valid_from DATE;
+ALTER TABLE tag ADD COLUMN valid_to DATE CHECK (valid_to >= valid_from);
+ALTER TABLE tag ADD COLUMN active boolean NOT NULL DEFAULT true;
+ALTER TABLE tag ADD COLUMN replaces integer UNIQUE REFERENCES tag;
+CREATE UNIQUE INDEX tag_active_value_uq ON tag (value) WHERE active;
+CREATE INDEX "idx_tag$value" ON tag USING btree (value);
+
+-- TAG DATA CONTENT TYPES AND THUMBNAILS
+ALTER TABLE tag ADD COLUMN data_type TEXT;
+UPDATE tag SET data_type = 'application/octet-stream' WHERE data IS NOT NULL;
+ALTER TABLE tag ADD CHECK ((data IS NULL) = (data_type IS NULL));
+ALTER TABLE tag ADD COLUMN thumbnail bytea;
+
+-- USER ADMINISTRATION
+ALTER TABLE "user" ADD COLUMN disabled boolean NOT NULL DEFAULT false;
@@ -0,0 +1,36 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package lara
+
+import "context"
+
+// -----------------------------------------------------------------------------
+// DOCUMENT RENDERING SERVICE
+
+// DocumentService renders printable documents in PDF format
+type DocumentService interface {
+	Invoice(ctx context.Context, id uint64) ([]byte, error)
+	// Record renders summary of single record
+	Record(ctx context.Context, id uint64) ([]byte, error)
+	// PatientHistory renders all patient's records
+	PatientHistory(ctx context.Context, patientID uint64) ([]byte, error)
+	VaccinationCertificate(ctx context.Context, patientID uint64) ([]byte, error)
+	Prescription(ctx context.Context, id uint64) ([]byte, error)
+}
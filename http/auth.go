@@ -64,6 +64,7 @@ func (s *Server) authenticationHandler(w http.ResponseWriter, r *http.Request) {
 
 // requireAuthorizedUser is authorization middleware.
 // Takes care of authorization token validation. Returns HTTP 401 if token missing/invalid
+// or its user was disabled after login.
 // User object is passed through request context after successful token validation.
 func (s *Server) requireAuthorizedUser(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
@@ -88,6 +89,11 @@ func (s *Server) requireAuthorizedUser(next http.Handler) http.Handler {
 			return
 		}
 
+		if err := s.UserService.CheckEnabled(r.Context(), u.Login); err != nil {
+			renderError(w, r, err)
+			return
+		}
+
 		ctx := lara.ContextWithUser(r.Context(), u)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}
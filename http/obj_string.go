@@ -4,9 +4,9 @@ package http
 
 import "fmt"
 
-const _objectType_name = "ownerpatientrecordtitleunitgendercitystreetspeciesbreedtag"
+const _objectType_name = "ownerpatientrecordtitleunitgendercitystreetspeciesbreedtagappointmentvaccinationnotificationinvoiceproductstockMovementsupplierpurchaseOrderdiagnosisphraseattachmentprescription"
 
-var _objectType_index = [...]uint8{0, 5, 12, 18, 23, 27, 33, 37, 43, 50, 55, 58}
+var _objectType_index = [...]uint8{0, 5, 12, 18, 23, 27, 33, 37, 43, 50, 55, 58, 69, 80, 92, 99, 106, 119, 127, 140, 149, 155, 165, 177}
 
 func (i objectType) String() string {
 	if i < 0 || i >= objectType(len(_objectType_index)-1) {
@@ -38,20 +38,32 @@ type Server struct {
 	srv *http.Server
 
 	// Services
-	OwnerService   lara.OwnerService
-	PatientSevice  lara.PatientService
-	RecordService  lara.RecordService
-	SearchService  lara.SearchService
-	UserService    lara.UserService
-	ProductService lara.ProductService
-	ReportService  lara.ReportService
-	TitleService   lara.TitleService
-	UnitService    lara.UnitService
-	GenderService  lara.GenderService
-	SpeciesService lara.SpeciesService
-	BreedService   lara.BreedService
-	AddressService lara.AddressService
-	TagService     lara.TagService
+	OwnerService         lara.OwnerService
+	PatientSevice        lara.PatientService
+	RecordService        lara.RecordService
+	SearchService        lara.SearchService
+	UserService          lara.UserService
+	ProductService       lara.ProductService
+	ReportService        lara.ReportService
+	TitleService         lara.TitleService
+	UnitService          lara.UnitService
+	GenderService        lara.GenderService
+	SpeciesService       lara.SpeciesService
+	BreedService         lara.BreedService
+	DiagnosisService     lara.DiagnosisService
+	PhraseService        lara.PhraseService
+	AttachmentService    lara.AttachmentService
+	AddressService       lara.AddressService
+	TagService           lara.TagService
+	AppointmentService   lara.AppointmentService
+	VaccinationService   lara.VaccinationService
+	NotificationService  lara.NotificationService
+	InvoiceService       lara.InvoiceService
+	DocumentService      lara.DocumentService
+	StockService         lara.StockService
+	SupplierService      lara.SupplierService
+	PurchaseOrderService lara.PurchaseOrderService
+	PrescriptionService  lara.PrescriptionService
 
 	// Auth
 	Token AuthToken
@@ -89,9 +101,11 @@ func (s *Server) Router() *chi.Mux {
 		// owner
 		r.Route("/owner", func(r chi.Router) {
 			r.With(requirePermission(lara.EditRecord)).Post("/", s.createOwnerHandler)
+			r.With(requirePermission(lara.ViewRecord)).Get("/duplicates", s.listDuplicateOwnersHandler)
 			r.Route("/{id}", func(r chi.Router) {
 				r.With(requirePermission(lara.ViewRecord)).Get("/", s.getOwnerHandler)
 				r.With(requirePermission(lara.EditRecord)).Put("/", s.updateOwnerHandler)
+				r.With(requirePermission(lara.EditRecord)).Post("/merge", s.mergeOwnersHandler)
 			})
 		})
 
@@ -101,6 +115,19 @@ func (s *Server) Router() *chi.Mux {
 			r.Route("/{id}", func(r chi.Router) {
 				r.With(requirePermission(lara.ViewRecord)).Get("/", s.getPatientHandler)
 				r.With(requirePermission(lara.EditRecord)).Put("/", s.updatePatientHandler)
+				r.With(requirePermission(lara.EditRecord)).Post("/transfer", s.transferPatientHandler)
+				r.With(requirePermission(lara.ViewRecord)).Get("/diagnoses", s.listPatientDiagnosesHandler)
+				r.With(requirePermission(lara.ViewRecord)).Get("/vitals", s.getPatientVitalsHandler)
+				r.With(requirePermission(lara.ViewRecord)).Get("/prescriptions", s.listPatientPrescriptionsHandler)
+				r.Route("/attachment", func(r chi.Router) {
+					r.With(requirePermission(lara.ViewRecord)).Get("/", s.listPatientAttachmentsHandler)
+					r.With(requirePermission(lara.EditRecord)).Post("/", s.createPatientAttachmentHandler)
+					r.With(requirePermission(lara.ViewRecord)).Get("/{attachmentID}",
+						s.getPatientAttachmentHandler)
+				})
+				r.With(requirePermission(lara.ViewRecord)).Get("/history.pdf", s.getPatientHistoryPDFHandler)
+				r.With(requirePermission(lara.ViewRecord)).Get("/vaccination-certificate.pdf",
+					s.getVaccinationCertificatePDFHandler)
 			})
 		})
 
@@ -110,18 +137,128 @@ func (s *Server) Router() *chi.Mux {
 			r.Route("/{id}", func(r chi.Router) {
 				r.With(requirePermission(lara.ViewRecord)).Get("/", s.getRecordHandler)
 				r.With(requirePermission(lara.EditRecord)).Put("/", s.updateRecordHandler)
+				r.With(requirePermission(lara.ViewRecord)).Get("/pdf", s.getRecordPDFHandler)
+				r.With(requirePermission(lara.ViewRecord)).Get("/prescriptions", s.listRecordPrescriptionsHandler)
+				r.Route("/attachment", func(r chi.Router) {
+					r.With(requirePermission(lara.ViewRecord)).Get("/", s.listRecordAttachmentsHandler)
+					r.With(requirePermission(lara.EditRecord)).Post("/", s.createRecordAttachmentHandler)
+					r.With(requirePermission(lara.ViewRecord)).Get("/{attachmentID}",
+						s.getRecordAttachmentHandler)
+				})
 			})
 		})
 
 		// tags
 		r.Route("/tag", func(r chi.Router) {
 			r.With(requirePermission(lara.EditRecord)).Post("/", s.createTagHandler)
+			r.With(requirePermission(lara.ViewRecord)).Post("/scan", s.scanTagHandler)
 			r.Route("/{id}", func(r chi.Router) {
 				r.With(requirePermission(lara.ViewRecord)).Get("/", s.getTagHandler)
 				r.With(requirePermission(lara.EditRecord)).Put("/", s.updateTagHandler)
+				r.With(requirePermission(lara.EditRecord)).Post("/replace", s.replaceTagHandler)
+				r.With(requirePermission(lara.EditRecord)).Post("/deactivate", s.deactivateTagHandler)
+				r.With(requirePermission(lara.ViewRecord)).Get("/data", s.getTagDataHandler)
+				r.With(requirePermission(lara.EditRecord)).Put("/data", s.putTagDataHandler)
+				r.With(requirePermission(lara.EditRecord)).Delete("/data", s.deleteTagDataHandler)
+				r.With(requirePermission(lara.ViewRecord)).Get("/thumbnail", s.getTagThumbnailHandler)
 			})
 		})
 
+		// appointments
+		r.Route("/appointment", func(r chi.Router) {
+			r.With(requirePermission(lara.EditRecord)).Post("/", s.createAppointmentHandler)
+			r.With(requirePermission(lara.ViewRecord)).Get("/by-day/{day}", s.listAppointmentsByDayHandler)
+			r.With(requirePermission(lara.ViewRecord)).Get("/by-vet/{vet}", s.listAppointmentsByVetHandler)
+			r.Route("/{id}", func(r chi.Router) {
+				r.With(requirePermission(lara.ViewRecord)).Get("/", s.getAppointmentHandler)
+				r.With(requirePermission(lara.EditRecord)).Put("/", s.rescheduleAppointmentHandler)
+				r.With(requirePermission(lara.EditRecord)).Post("/cancel", s.cancelAppointmentHandler)
+				r.With(requirePermission(lara.EditRecord)).Post("/check-in", s.checkInAppointmentHandler)
+			})
+		})
+
+		// vaccinations
+		r.Route("/vaccination", func(r chi.Router) {
+			r.With(requirePermission(lara.EditRecord)).Post("/", s.createVaccinationHandler)
+			r.With(requirePermission(lara.ViewRecord)).Post("/due", s.getDueVaccinationsHandler)
+			r.Route("/{id}", func(r chi.Router) {
+				r.With(requirePermission(lara.ViewRecord)).Get("/", s.getVaccinationHandler)
+				r.With(requirePermission(lara.EditRecord)).Put("/", s.updateVaccinationHandler)
+			})
+		})
+
+		// prescriptions
+		r.Route("/prescription", func(r chi.Router) {
+			r.With(requirePermission(lara.EditRecord)).Post("/", s.createPrescriptionHandler)
+			r.Route("/{id}", func(r chi.Router) {
+				r.With(requirePermission(lara.ViewRecord)).Get("/", s.getPrescriptionHandler)
+				r.With(requirePermission(lara.EditRecord)).Put("/", s.updatePrescriptionHandler)
+				r.With(requirePermission(lara.ViewRecord)).Get("/pdf", s.getPrescriptionPDFHandler)
+			})
+		})
+
+		// invoices
+		r.Route("/invoice", func(r chi.Router) {
+			r.With(requirePermission(lara.EditRecord)).Post("/", s.createInvoiceHandler)
+			r.With(requirePermission(lara.ViewRecord)).Get("/by-owner/{id}", s.listInvoicesByOwnerHandler)
+			r.With(requirePermission(lara.ViewRecord)).Get("/{id}", s.getInvoiceHandler)
+			r.With(requirePermission(lara.ViewRecord)).Get("/{id}/pdf", s.getInvoicePDFHandler)
+		})
+
+		// product catalogue
+		r.Route("/product", func(r chi.Router) {
+			r.With(requirePermission(lara.EditProducts)).Post("/", s.createProductHandler)
+			r.Route("/{id}", func(r chi.Router) {
+				r.With(requirePermission(lara.ViewRecord)).Get("/", s.getProductHandler)
+				r.With(requirePermission(lara.EditProducts)).Put("/", s.updateProductHandler)
+				r.With(requirePermission(lara.EditProducts)).Post("/retire", s.retireProductHandler)
+				r.With(requirePermission(lara.ViewRecord)).Get("/price", s.getProductPriceHandler)
+				r.With(requirePermission(lara.ViewRecord)).Get("/price-history", s.getProductPriceHistoryHandler)
+			})
+		})
+
+		// stock
+		r.Route("/stock", func(r chi.Router) {
+			r.With(requirePermission(lara.ViewRecord)).Get("/", s.listStockHandler)
+			r.With(requirePermission(lara.ViewRecord)).Get("/product/{id}", s.getProductStockHandler)
+			r.With(requirePermission(lara.ViewRecord)).Get("/product/{id}/movements",
+				s.listProductStockMovementsHandler)
+			r.With(requirePermission(lara.ViewRecord)).Get("/product/{id}/batches", s.listProductBatchesHandler)
+			r.With(requirePermission(lara.ViewReports)).Get("/expiring", s.listExpiringBatchesHandler)
+			r.With(requirePermission(lara.EditProducts)).Post("/movement", s.postStockMovementHandler)
+			r.With(requirePermission(lara.ViewRecord)).Get("/movement/{id}", s.getStockMovementHandler)
+		})
+
+		// suppliers
+		r.Route("/supplier", func(r chi.Router) {
+			r.With(requirePermission(lara.EditProducts)).Post("/", s.createSupplierHandler)
+			r.With(requirePermission(lara.ViewRecord)).Get("/", s.listSuppliersHandler)
+			r.Route("/{id}", func(r chi.Router) {
+				r.With(requirePermission(lara.ViewRecord)).Get("/", s.getSupplierHandler)
+				r.With(requirePermission(lara.EditProducts)).Put("/", s.updateSupplierHandler)
+				r.With(requirePermission(lara.ViewRecord)).Get("/purchase-orders",
+					s.listPurchaseOrdersBySupplierHandler)
+			})
+		})
+
+		// purchase orders
+		r.Route("/purchase-order", func(r chi.Router) {
+			r.With(requirePermission(lara.EditProducts)).Post("/", s.createPurchaseOrderHandler)
+			r.With(requirePermission(lara.ViewRecord)).Get("/suggested-reorder", s.suggestedReorderHandler)
+			r.Route("/{id}", func(r chi.Router) {
+				r.With(requirePermission(lara.ViewRecord)).Get("/", s.getPurchaseOrderHandler)
+				r.With(requirePermission(lara.EditProducts)).Put("/", s.updatePurchaseOrderHandler)
+				r.With(requirePermission(lara.EditProducts)).Post("/receive", s.receivePurchaseOrderHandler)
+				r.With(requirePermission(lara.EditProducts)).Post("/withdraw", s.withdrawPurchaseOrderHandler)
+			})
+		})
+
+		// owner notifications
+		r.Route("/notification", func(r chi.Router) {
+			r.With(requirePermission(lara.EditRecord)).Post("/", s.createNotificationHandler)
+			r.With(requirePermission(lara.ViewRecord)).Get("/{id}", s.getNotificationHandler)
+		})
+
 		// List Of Values
 		r.With(requirePermission(lara.ViewRecord)).Get("/title", s.getAllTitlesHandler)
 		r.With(requirePermission(lara.ViewRecord)).Get("/unit", s.getAllUnitsHandler)
@@ -129,6 +266,18 @@ func (s *Server) Router() *chi.Mux {
 		r.With(requirePermission(lara.ViewRecord)).Get("/species", s.getAllSpeciesHandler)
 		r.With(requirePermission(lara.ViewRecord)).Get("/breed/by-species/{id}",
 			s.getAllBreedsBySpeciesHandler)
+		r.With(requirePermission(lara.ViewRecord)).Get("/diagnosis", s.getAllDiagnosesHandler)
+		r.With(requirePermission(lara.ViewRecord)).Get("/diagnosis/{id}/patients",
+			s.listDiagnosedPatientsHandler)
+		r.Route("/phrase", func(r chi.Router) {
+			r.With(requirePermission(lara.ViewRecord)).Get("/", s.searchPhrasesHandler)
+			r.With(requirePermission(lara.EditRecord)).Post("/", s.createPhraseHandler)
+			r.Route("/{id}", func(r chi.Router) {
+				r.With(requirePermission(lara.ViewRecord)).Get("/", s.getPhraseHandler)
+				r.With(requirePermission(lara.EditRecord)).Put("/", s.updatePhraseHandler)
+				r.With(requirePermission(lara.ViewRecord)).Get("/expand", s.expandPhraseHandler)
+			})
+		})
 		r.With(requirePermission(lara.ViewRecord)).Get("/city", s.searchCityHandler)
 		r.With(requirePermission(lara.ViewRecord)).Get("/street/by-city/{id}",
 			s.searchStreetByCityHandler)
@@ -141,9 +290,22 @@ func (s *Server) Router() *chi.Mux {
 
 		// reports
 		r.With(requirePermission(lara.ViewReports)).Post("/report/income", s.getIncomeStatisticsHandler)
+		r.With(requirePermission(lara.ViewReports)).Post("/report/controlled-drugs",
+			s.getControlledDrugRegisterHandler)
 
 		// products
 		r.With(requirePermission(lara.ViewRecord)).Post("/productsearch", s.searchProductHandler)
+
+		// user administration
+		r.Route("/admin/user", func(r chi.Router) {
+			r.With(requirePermission(lara.Admin)).Get("/", s.listUsersHandler)
+			r.Route("/{login}", func(r chi.Router) {
+				r.With(requirePermission(lara.Admin)).Get("/", s.getUserHandler)
+				r.With(requirePermission(lara.Admin)).Post("/disable", s.disableUserHandler)
+				r.With(requirePermission(lara.Admin)).Post("/enable", s.enableUserHandler)
+				r.With(requirePermission(lara.Admin)).Put("/password", s.resetPasswordHandler)
+			})
+		})
 	})
 
 	return r
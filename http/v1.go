@@ -20,13 +20,17 @@
 package http
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/render"
 	"github.com/jkusniar/lara"
+	"github.com/pkg/errors"
 )
 
 //go:generate stringer -type=objectType -output obj_string.go
@@ -46,17 +50,86 @@ const (
 	species
 	breed
 	tag
+	appointment
+	vaccination
+	notification
+	invoice
+	product
+	stockMovement
+	supplier
+	purchaseOrder
+	diagnosis
+	phrase
+	attachment
+	prescription
 )
 
 func parseID(r *http.Request) (uint64, error) {
 	return strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
 }
 
-// searchHandler searches owners and pets by name. Parameter is called "q"
+// dateLayout is format of dates passed as URL or query parameters
+const dateLayout = "2006-01-02"
+
+// parseDate parses date in dateLayout format. If s is empty, def is returned.
+func parseDate(s string, def time.Time) (time.Time, error) {
+	if len(s) == 0 {
+		return def, nil
+	}
+
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return t, lara.NewCodedError(http.StatusBadRequest,
+			errors.Wrapf(err, "invalid date %s, expected format YYYY-MM-DD", s))
+	}
+
+	return t, nil
+}
+
+// parseInt parses integer query parameter, def is returned if parameter is empty
+func parseInt(s string, def int) (int, error) {
+	if len(s) == 0 {
+		return def, nil
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return i, lara.NewCodedError(http.StatusBadRequest,
+			errors.Wrapf(err, "invalid number %s", s))
+	}
+
+	return i, nil
+}
+
+// parsePage parses search page from "offset", "limit" and "sort" query
+// parameters
+func parsePage(r *http.Request) (lara.Page, error) {
+	var p lara.Page
+	var err error
+
+	q := r.URL.Query()
+	if p.Offset, err = parseInt(q.Get("offset"), 0); err != nil {
+		return p, err
+	}
+	if p.Limit, err = parseInt(q.Get("limit"), 0); err != nil {
+		return p, err
+	}
+	p.Sort = q.Get("sort")
+
+	return p, nil
+}
+
+// searchHandler searches owners and pets by name. Parameter is called "q",
+// result page is selected by "offset", "limit" and "sort" parameters.
 func (s *Server) searchHandler(w http.ResponseWriter, r *http.Request) {
-	q := r.URL.Query().Get("q")
+	page, err := parsePage(r)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
 
-	resp, err := s.SearchService.Search(r.Context(), q)
+	resp, err := s.SearchService.Search(r.Context(),
+		&lara.SearchRequest{Query: r.URL.Query().Get("q"), Page: page})
 	if err != nil {
 		renderError(w, r, err)
 		return
@@ -121,32 +194,38 @@ func (s *Server) updateOwnerHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// searchProductHandler searches products valid to specified date by name
-func (s *Server) searchProductHandler(w http.ResponseWriter, r *http.Request) {
-	var p lara.ProductSearchRequest
-	if err := render.DecodeJSON(r.Body, &p); err != nil {
+// mergeOwnersHandler merges duplicate owners from JSON encoded body into owner
+// identified by id param. Result is indicated by response status only
+// (204/4xx/5xx).
+func (s *Server) mergeOwnersHandler(w http.ResponseWriter, r *http.Request) {
+	var m lara.MergeOwners
+	if err := render.DecodeJSON(r.Body, &m); err != nil {
 		renderBadJSONError(w, r, err)
 		return
 	}
 
-	resp, err := s.ProductService.Search(r.Context(), &p)
+	id, err := parseID(r)
 	if err != nil {
-		renderError(w, r, err)
+		renderNotFoundError(w, r, owner, err)
 		return
 	}
 
-	render.JSON(w, r, resp)
+	if err := s.OwnerService.Merge(r.Context(), id, &m); err != nil {
+		renderError(w, r, err)
+	}
 }
 
-// getIncomeStatisticsHandler counts records and income for specified time period
-func (s *Server) getIncomeStatisticsHandler(w http.ResponseWriter, r *http.Request) {
-	var rr lara.ReportRequest
-	if err := render.DecodeJSON(r.Body, &rr); err != nil {
-		renderBadJSONError(w, r, err)
+// listDuplicateOwnersHandler returns JSON formatted pairs of possibly
+// duplicate owners. Page is selected by "offset", "limit" and "sort" query
+// parameters.
+func (s *Server) listDuplicateOwnersHandler(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePage(r)
+	if err != nil {
+		renderError(w, r, err)
 		return
 	}
 
-	resp, err := s.ReportService.GetIncomeStatistics(r.Context(), &rr)
+	resp, err := s.OwnerService.Duplicates(r.Context(), page)
 	if err != nil {
 		renderError(w, r, err)
 		return
@@ -155,28 +234,23 @@ func (s *Server) getIncomeStatisticsHandler(w http.ResponseWriter, r *http.Reque
 	render.JSON(w, r, resp)
 }
 
-// getPatientHandler returns JSON formatted GetPatient data by ID
-func (s *Server) getPatientHandler(w http.ResponseWriter, r *http.Request) {
-	id, err := parseID(r)
-	if err != nil {
-		renderNotFoundError(w, r, patient, err)
+// searchProductHandler searches products valid to specified date by name.
+// Result page is selected by "offset", "limit" and "sort" query parameters.
+func (s *Server) searchProductHandler(w http.ResponseWriter, r *http.Request) {
+	var p lara.ProductSearchRequest
+	if err := render.DecodeJSON(r.Body, &p); err != nil {
+		renderBadJSONError(w, r, err)
 		return
 	}
 
-	resp, err := s.PatientSevice.Get(r.Context(), id)
+	page, err := parsePage(r)
 	if err != nil {
 		renderError(w, r, err)
 		return
 	}
+	p.Page = page
 
-	render.JSON(w, r, resp)
-}
-
-// searchPatientByTagHandler returns JSON formatted PatientByTag data by tag value
-func (s *Server) searchPatientByTagHandler(w http.ResponseWriter, r *http.Request) {
-	t := chi.URLParam(r, "tag")
-
-	resp, err := s.TagService.GetPatientByTag(r.Context(), t)
+	resp, err := s.ProductService.Search(r.Context(), &p)
 	if err != nil {
 		renderError(w, r, err)
 		return
@@ -185,15 +259,15 @@ func (s *Server) searchPatientByTagHandler(w http.ResponseWriter, r *http.Reques
 	render.JSON(w, r, resp)
 }
 
-// getRecordHandler returns JSON formatted GetRecord data by ID
-func (s *Server) getRecordHandler(w http.ResponseWriter, r *http.Request) {
+// getProductHandler returns JSON formatted GetProduct data by ID
+func (s *Server) getProductHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := parseID(r)
 	if err != nil {
-		renderNotFoundError(w, r, record, err)
+		renderNotFoundError(w, r, product, err)
 		return
 	}
 
-	resp, err := s.RecordService.Get(r.Context(), id)
+	resp, err := s.ProductService.Get(r.Context(), id)
 	if err != nil {
 		renderError(w, r, err)
 		return
@@ -202,16 +276,16 @@ func (s *Server) getRecordHandler(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, resp)
 }
 
-// createRecordHandler creates new record from JSON encoded body of request.
-// New record's ID is returned in response body as text
-func (s *Server) createRecordHandler(w http.ResponseWriter, r *http.Request) {
-	var rec lara.CreateRecord
-	if err := render.DecodeJSON(r.Body, &rec); err != nil {
+// createProductHandler creates new product from JSON encoded body of request.
+// New product's ID is returned in response body as text
+func (s *Server) createProductHandler(w http.ResponseWriter, r *http.Request) {
+	var p lara.CreateProduct
+	if err := render.DecodeJSON(r.Body, &p); err != nil {
 		renderBadJSONError(w, r, err)
 		return
 	}
 
-	id, err := s.RecordService.Create(r.Context(), &rec)
+	id, err := s.ProductService.Create(r.Context(), &p)
 	if err != nil {
 		renderError(w, r, err)
 		return
@@ -220,69 +294,83 @@ func (s *Server) createRecordHandler(w http.ResponseWriter, r *http.Request) {
 	render.PlainText(w, r, fmt.Sprintf("%d", id))
 }
 
-// createPatientHandler creates new patient from JSON encoded body of request.
-// New patient's ID is returned in response body as text
-func (s *Server) createPatientHandler(w http.ResponseWriter, r *http.Request) {
-	var p lara.CreatePatient
+// updateProductHandler updates product identified by id param. Data to
+// update is JSON encoded in request's body. Result is indicated by response
+// status only (204/4xx/5xx).
+func (s *Server) updateProductHandler(w http.ResponseWriter, r *http.Request) {
+	var p lara.UpdateProduct
 	if err := render.DecodeJSON(r.Body, &p); err != nil {
 		renderBadJSONError(w, r, err)
 		return
 	}
 
-	id, err := s.PatientSevice.Create(r.Context(), &p)
+	id, err := parseID(r)
 	if err != nil {
-		renderError(w, r, err)
+		renderNotFoundError(w, r, product, err)
 		return
 	}
 
-	render.PlainText(w, r, fmt.Sprintf("%d", id))
+	if err := s.ProductService.Update(r.Context(), id, &p); err != nil {
+		renderError(w, r, err)
+	}
 }
 
-// updatePatientHandler updates existing patient identified by id param. Patient to
-// update is JSON encoded in request's body. Result is indicated by response
+// retireProductHandler stops offering product identified by id param from
+// date in JSON encoded body of request. Result is indicated by response
 // status only (204/4xx/5xx).
-func (s *Server) updatePatientHandler(w http.ResponseWriter, r *http.Request) {
-	var p lara.UpdatePatient
-	if err := render.DecodeJSON(r.Body, &p); err != nil {
+func (s *Server) retireProductHandler(w http.ResponseWriter, r *http.Request) {
+	var rp lara.RetireProduct
+	if err := render.DecodeJSON(r.Body, &rp); err != nil {
 		renderBadJSONError(w, r, err)
 		return
 	}
 
 	id, err := parseID(r)
 	if err != nil {
-		renderNotFoundError(w, r, patient, err)
+		renderNotFoundError(w, r, product, err)
 		return
 	}
 
-	if err := s.PatientSevice.Update(r.Context(), id, &p); err != nil {
+	if err := s.ProductService.Retire(r.Context(), id, &rp); err != nil {
 		renderError(w, r, err)
 	}
 }
 
-// updateRecordHandler updates existing record identified by id param. Record to
-// update is JSON encoded in request's body. Result is indicated by response
-// status only (204/4xx/5xx).
-func (s *Server) updateRecordHandler(w http.ResponseWriter, r *http.Request) {
-	var rec lara.UpdateRecord
-	if err := render.DecodeJSON(r.Body, &rec); err != nil {
-		renderBadJSONError(w, r, err)
+// getProductPriceHandler returns JSON formatted price of product identified
+// by id param valid at date given by "date" query parameter (YYYY-MM-DD),
+// today if not specified
+func (s *Server) getProductPriceHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, product, err)
 		return
 	}
 
-	id, err := parseID(r)
+	date, err := parseDate(r.URL.Query().Get("date"), time.Now())
 	if err != nil {
-		renderNotFoundError(w, r, record, err)
+		renderError(w, r, err)
 		return
 	}
 
-	if err := s.RecordService.Update(r.Context(), id, &rec); err != nil {
+	resp, err := s.ProductService.PriceAt(r.Context(), id, date)
+	if err != nil {
 		renderError(w, r, err)
+		return
 	}
+
+	render.JSON(w, r, resp)
 }
 
-// getAllTitlesHandler returns JSON formatted list of all titles
-func (s *Server) getAllTitlesHandler(w http.ResponseWriter, r *http.Request) {
-	resp, err := s.TitleService.GetAllTitles(r.Context())
+// getProductPriceHistoryHandler returns JSON formatted price timeline of
+// product identified by id param
+func (s *Server) getProductPriceHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, product, err)
+		return
+	}
+
+	resp, err := s.ProductService.PriceHistory(r.Context(), id)
 	if err != nil {
 		renderError(w, r, err)
 		return
@@ -291,9 +379,10 @@ func (s *Server) getAllTitlesHandler(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, resp)
 }
 
-// getAllTitlesHandler returns JSON formatted list of all titles
-func (s *Server) getAllUnitsHandler(w http.ResponseWriter, r *http.Request) {
-	resp, err := s.UnitService.GetAllUnits(r.Context())
+// listStockHandler returns JSON formatted current stock of all products
+// having any stock movement
+func (s *Server) listStockHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.StockService.List(r.Context())
 	if err != nil {
 		renderError(w, r, err)
 		return
@@ -302,9 +391,16 @@ func (s *Server) getAllUnitsHandler(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, resp)
 }
 
-// getAllGendersHandler returns JSON formatted list of all titles
-func (s *Server) getAllGendersHandler(w http.ResponseWriter, r *http.Request) {
-	resp, err := s.GenderService.GetAllGenders(r.Context())
+// getProductStockHandler returns JSON formatted current stock of product
+// identified by id param
+func (s *Server) getProductStockHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, product, err)
+		return
+	}
+
+	resp, err := s.StockService.Get(r.Context(), id)
 	if err != nil {
 		renderError(w, r, err)
 		return
@@ -313,9 +409,29 @@ func (s *Server) getAllGendersHandler(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, resp)
 }
 
-// getAllSpeciesHandler returns JSON formatted list of all titles
-func (s *Server) getAllSpeciesHandler(w http.ResponseWriter, r *http.Request) {
-	resp, err := s.SpeciesService.GetAllSpecies(r.Context())
+// listProductStockMovementsHandler returns JSON formatted stock movements of
+// product identified by id param. Period is given by "from" and "to" query
+// parameters (YYYY-MM-DD), last month if not specified.
+func (s *Server) listProductStockMovementsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, product, err)
+		return
+	}
+
+	to, err := parseDate(r.URL.Query().Get("to"), time.Now())
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	from, err := parseDate(r.URL.Query().Get("from"), to.AddDate(0, -1, 0))
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	resp, err := s.StockService.ListMovements(r.Context(), id, from, to)
 	if err != nil {
 		renderError(w, r, err)
 		return
@@ -324,15 +440,16 @@ func (s *Server) getAllSpeciesHandler(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, resp)
 }
 
-// getAllBreedsBySpeciesHandler returns JSON formatted breed data by species ID
-func (s *Server) getAllBreedsBySpeciesHandler(w http.ResponseWriter, r *http.Request) {
+// listProductBatchesHandler returns JSON formatted batches in stock of
+// product identified by id param
+func (s *Server) listProductBatchesHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := parseID(r)
 	if err != nil {
-		renderNotFoundError(w, r, breed, err)
+		renderNotFoundError(w, r, product, err)
 		return
 	}
 
-	resp, err := s.BreedService.GetAllBreedsBySpecies(r.Context(), id)
+	resp, err := s.StockService.Batches(r.Context(), id)
 	if err != nil {
 		renderError(w, r, err)
 		return
@@ -341,11 +458,16 @@ func (s *Server) getAllBreedsBySpeciesHandler(w http.ResponseWriter, r *http.Req
 	render.JSON(w, r, resp)
 }
 
-// searchCityHandler returns JSON formated cities queried by name
-func (s *Server) searchCityHandler(w http.ResponseWriter, r *http.Request) {
-	q := r.URL.Query().Get("q")
+// listExpiringBatchesHandler returns JSON formatted batches in stock expiring
+// within number of days given by "days" query parameter, 30 if not specified
+func (s *Server) listExpiringBatchesHandler(w http.ResponseWriter, r *http.Request) {
+	days, err := parseInt(r.URL.Query().Get("days"), 30)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
 
-	resp, err := s.AddressService.SearchCity(r.Context(), q)
+	resp, err := s.StockService.Expiring(r.Context(), days)
 	if err != nil {
 		renderError(w, r, err)
 		return
@@ -354,17 +476,33 @@ func (s *Server) searchCityHandler(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, resp)
 }
 
-// searchStreetByCityHandler returns JSON formatted street data for city queried by street name
-func (s *Server) searchStreetByCityHandler(w http.ResponseWriter, r *http.Request) {
-	q := r.URL.Query().Get("q")
+// postStockMovementHandler posts manual stock movement from JSON encoded
+// CreateStockMovement in request's body. Returns new movement's ID.
+func (s *Server) postStockMovementHandler(w http.ResponseWriter, r *http.Request) {
+	var m lara.CreateStockMovement
+	if err := render.DecodeJSON(r.Body, &m); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
 
-	cityID, err := parseID(r)
+	id, err := s.StockService.Post(r.Context(), &m)
 	if err != nil {
-		renderNotFoundError(w, r, city, err)
+		renderError(w, r, err)
 		return
 	}
 
-	resp, err := s.AddressService.SearchStreetForCity(r.Context(), cityID, q)
+	render.PlainText(w, r, fmt.Sprintf("%d", id))
+}
+
+// getStockMovementHandler returns JSON formatted GetStockMovement data by ID
+func (s *Server) getStockMovementHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, stockMovement, err)
+		return
+	}
+
+	resp, err := s.StockService.GetMovement(r.Context(), id)
 	if err != nil {
 		renderError(w, r, err)
 		return
@@ -373,15 +511,26 @@ func (s *Server) searchStreetByCityHandler(w http.ResponseWriter, r *http.Reques
 	render.JSON(w, r, resp)
 }
 
-// getTagHandler returns JSON formatted GetTag data by ID
-func (s *Server) getTagHandler(w http.ResponseWriter, r *http.Request) {
+// listSuppliersHandler returns JSON formatted list of all suppliers
+func (s *Server) listSuppliersHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.SupplierService.List(r.Context())
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// getSupplierHandler returns JSON formatted GetSupplier data by ID
+func (s *Server) getSupplierHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := parseID(r)
 	if err != nil {
-		renderNotFoundError(w, r, tag, err)
+		renderNotFoundError(w, r, supplier, err)
 		return
 	}
 
-	resp, err := s.TagService.Get(r.Context(), id)
+	resp, err := s.SupplierService.Get(r.Context(), id)
 	if err != nil {
 		renderError(w, r, err)
 		return
@@ -390,16 +539,16 @@ func (s *Server) getTagHandler(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, resp)
 }
 
-// createTagHandler creates new patient's tag from JSON encoded body of request.
-// New tag's ID is returned in response body as text
-func (s *Server) createTagHandler(w http.ResponseWriter, r *http.Request) {
-	var t lara.CreateTag
-	if err := render.DecodeJSON(r.Body, &t); err != nil {
+// createSupplierHandler creates new supplier from JSON encoded
+// CreateSupplier in request's body. Returns new supplier's ID.
+func (s *Server) createSupplierHandler(w http.ResponseWriter, r *http.Request) {
+	var sup lara.CreateSupplier
+	if err := render.DecodeJSON(r.Body, &sup); err != nil {
 		renderBadJSONError(w, r, err)
 		return
 	}
 
-	id, err := s.TagService.Create(r.Context(), &t)
+	id, err := s.SupplierService.Create(r.Context(), &sup)
 	if err != nil {
 		renderError(w, r, err)
 		return
@@ -408,23 +557,1509 @@ func (s *Server) createTagHandler(w http.ResponseWriter, r *http.Request) {
 	render.PlainText(w, r, fmt.Sprintf("%d", id))
 }
 
-// updateTagHandler updates existing patient's tag identified by id param. Tag to
+// updateSupplierHandler updates supplier identified by id param. Data to
 // update is JSON encoded in request's body. Result is indicated by response
 // status only (204/4xx/5xx).
-func (s *Server) updateTagHandler(w http.ResponseWriter, r *http.Request) {
-	var t lara.UpdateTag
-	if err := render.DecodeJSON(r.Body, &t); err != nil {
+func (s *Server) updateSupplierHandler(w http.ResponseWriter, r *http.Request) {
+	var sup lara.UpdateSupplier
+	if err := render.DecodeJSON(r.Body, &sup); err != nil {
 		renderBadJSONError(w, r, err)
 		return
 	}
 
 	id, err := parseID(r)
 	if err != nil {
-		renderNotFoundError(w, r, tag, err)
+		renderNotFoundError(w, r, supplier, err)
 		return
 	}
 
-	if err := s.TagService.Update(r.Context(), id, &t); err != nil {
+	if err := s.SupplierService.Update(r.Context(), id, &sup); err != nil {
+		renderError(w, r, err)
+	}
+}
+
+// listPurchaseOrdersBySupplierHandler returns JSON formatted list of purchase
+// orders of supplier identified by id param
+func (s *Server) listPurchaseOrdersBySupplierHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, supplier, err)
+		return
+	}
+
+	resp, err := s.PurchaseOrderService.ListBySupplier(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// getPurchaseOrderHandler returns JSON formatted GetPurchaseOrder data by ID
+func (s *Server) getPurchaseOrderHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, purchaseOrder, err)
+		return
+	}
+
+	resp, err := s.PurchaseOrderService.Get(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// createPurchaseOrderHandler creates new purchase order from JSON encoded
+// CreatePurchaseOrder in request's body. Returns new order's ID.
+func (s *Server) createPurchaseOrderHandler(w http.ResponseWriter, r *http.Request) {
+	var o lara.CreatePurchaseOrder
+	if err := render.DecodeJSON(r.Body, &o); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := s.PurchaseOrderService.Create(r.Context(), &o)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.PlainText(w, r, fmt.Sprintf("%d", id))
+}
+
+// updatePurchaseOrderHandler updates purchase order identified by id param.
+// Data to update is JSON encoded in request's body. Result is indicated by
+// response status only (204/4xx/5xx).
+func (s *Server) updatePurchaseOrderHandler(w http.ResponseWriter, r *http.Request) {
+	var o lara.UpdatePurchaseOrder
+	if err := render.DecodeJSON(r.Body, &o); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, purchaseOrder, err)
+		return
+	}
+
+	if err := s.PurchaseOrderService.Update(r.Context(), id, &o); err != nil {
+		renderError(w, r, err)
+	}
+}
+
+// receivePurchaseOrderHandler receives delivery of purchase order identified
+// by id param. Received lines are JSON encoded in request's body. Result is
+// indicated by response status only (204/4xx/5xx).
+func (s *Server) receivePurchaseOrderHandler(w http.ResponseWriter, r *http.Request) {
+	var rp lara.ReceivePurchaseOrder
+	if err := render.DecodeJSON(r.Body, &rp); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, purchaseOrder, err)
+		return
+	}
+
+	if err := s.PurchaseOrderService.Receive(r.Context(), id, &rp); err != nil {
+		renderError(w, r, err)
+	}
+}
+
+// withdrawPurchaseOrderHandler cancels rest of purchase order identified by
+// id param. Result is indicated by response status only (204/4xx/5xx).
+func (s *Server) withdrawPurchaseOrderHandler(w http.ResponseWriter, r *http.Request) {
+	var wp lara.WithdrawPurchaseOrder
+	if err := render.DecodeJSON(r.Body, &wp); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, purchaseOrder, err)
+		return
+	}
+
+	if err := s.PurchaseOrderService.Withdraw(r.Context(), id, &wp); err != nil {
+		renderError(w, r, err)
+	}
+}
+
+// suggestedReorderHandler returns JSON formatted list of products below
+// minimum stock level
+func (s *Server) suggestedReorderHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.PurchaseOrderService.SuggestedReorder(r.Context())
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// getIncomeStatisticsHandler counts records and income for specified time period
+func (s *Server) getIncomeStatisticsHandler(w http.ResponseWriter, r *http.Request) {
+	var rr lara.ReportRequest
+	if err := render.DecodeJSON(r.Body, &rr); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	resp, err := s.ReportService.GetIncomeStatistics(r.Context(), &rr)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// getControlledDrugRegisterHandler lists movements of controlled drugs and
+// records not matching their prescriptions for specified time period
+func (s *Server) getControlledDrugRegisterHandler(w http.ResponseWriter, r *http.Request) {
+	var rr lara.ReportRequest
+	if err := render.DecodeJSON(r.Body, &rr); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	resp, err := s.ReportService.GetControlledDrugRegister(r.Context(), &rr)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// getPatientHandler returns JSON formatted GetPatient data by ID
+func (s *Server) getPatientHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, patient, err)
+		return
+	}
+
+	resp, err := s.PatientSevice.Get(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// searchPatientByTagHandler returns JSON formatted PatientByTag data by tag value
+func (s *Server) searchPatientByTagHandler(w http.ResponseWriter, r *http.Request) {
+	t := chi.URLParam(r, "tag")
+
+	resp, err := s.TagService.GetPatientByTag(r.Context(), t)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// scanTagHandler returns JSON formatted PatientByTag data by tag value pushed
+// by reader bridge in JSON encoded body of request
+func (s *Server) scanTagHandler(w http.ResponseWriter, r *http.Request) {
+	var o lara.TagScan
+	if err := render.DecodeJSON(r.Body, &o); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	if o.Value == "" {
+		renderError(w, r, lara.NewCodedError(http.StatusBadRequest,
+			errors.New("value is required")))
+		return
+	}
+
+	resp, err := s.TagService.GetPatientByTag(r.Context(), o.Value)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// getRecordHandler returns JSON formatted GetRecord data by ID
+func (s *Server) getRecordHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, record, err)
+		return
+	}
+
+	resp, err := s.RecordService.Get(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// createRecordHandler creates new record from JSON encoded body of request.
+// New record's ID is returned in response body as text
+func (s *Server) createRecordHandler(w http.ResponseWriter, r *http.Request) {
+	var rec lara.CreateRecord
+	if err := render.DecodeJSON(r.Body, &rec); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := s.RecordService.Create(r.Context(), &rec)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.PlainText(w, r, fmt.Sprintf("%d", id))
+}
+
+// createPatientHandler creates new patient from JSON encoded body of request.
+// New patient's ID is returned in response body as text
+func (s *Server) createPatientHandler(w http.ResponseWriter, r *http.Request) {
+	var p lara.CreatePatient
+	if err := render.DecodeJSON(r.Body, &p); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := s.PatientSevice.Create(r.Context(), &p)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.PlainText(w, r, fmt.Sprintf("%d", id))
+}
+
+// updatePatientHandler updates existing patient identified by id param. Patient to
+// update is JSON encoded in request's body. Result is indicated by response
+// status only (204/4xx/5xx).
+func (s *Server) updatePatientHandler(w http.ResponseWriter, r *http.Request) {
+	var p lara.UpdatePatient
+	if err := render.DecodeJSON(r.Body, &p); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, patient, err)
+		return
+	}
+
+	if err := s.PatientSevice.Update(r.Context(), id, &p); err != nil {
+		renderError(w, r, err)
+	}
+}
+
+// transferPatientHandler transfers patient identified by id param to new
+// owner. Transfer is JSON encoded in request's body. Result is indicated by
+// response status only (204/4xx/5xx).
+func (s *Server) transferPatientHandler(w http.ResponseWriter, r *http.Request) {
+	var t lara.TransferPatient
+	if err := render.DecodeJSON(r.Body, &t); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, patient, err)
+		return
+	}
+
+	if err := s.PatientSevice.Transfer(r.Context(), id, &t); err != nil {
+		renderError(w, r, err)
+	}
+}
+
+// updateRecordHandler updates existing record identified by id param. Record to
+// update is JSON encoded in request's body. Result is indicated by response
+// status only (204/4xx/5xx).
+func (s *Server) updateRecordHandler(w http.ResponseWriter, r *http.Request) {
+	var rec lara.UpdateRecord
+	if err := render.DecodeJSON(r.Body, &rec); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, record, err)
+		return
+	}
+
+	if err := s.RecordService.Update(r.Context(), id, &rec); err != nil {
+		renderError(w, r, err)
+	}
+}
+
+// getAllTitlesHandler returns JSON formatted list of all titles
+func (s *Server) getAllTitlesHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.TitleService.GetAllTitles(r.Context())
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// getAllTitlesHandler returns JSON formatted list of all titles
+func (s *Server) getAllUnitsHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.UnitService.GetAllUnits(r.Context())
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// getAllGendersHandler returns JSON formatted list of all titles
+func (s *Server) getAllGendersHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.GenderService.GetAllGenders(r.Context())
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// getAllSpeciesHandler returns JSON formatted list of all titles
+func (s *Server) getAllSpeciesHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.SpeciesService.GetAllSpecies(r.Context())
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// getAllBreedsBySpeciesHandler returns JSON formatted breed data by species ID
+func (s *Server) getAllBreedsBySpeciesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, breed, err)
+		return
+	}
+
+	resp, err := s.BreedService.GetAllBreedsBySpecies(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// getAllDiagnosesHandler returns JSON formatted list of all coded diagnoses
+func (s *Server) getAllDiagnosesHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.DiagnosisService.GetAllDiagnoses(r.Context())
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// listDiagnosedPatientsHandler returns JSON formatted list of patients
+// diagnosed with diagnosis identified by id param. Page is selected by
+// "offset", "limit" and "sort" query parameters.
+func (s *Server) listDiagnosedPatientsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, diagnosis, err)
+		return
+	}
+
+	page, err := parsePage(r)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	resp, err := s.RecordService.ListDiagnosedPatients(r.Context(), id, page)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// listPatientDiagnosesHandler returns JSON formatted list of diagnoses of
+// patient identified by id param, newest first.
+func (s *Server) listPatientDiagnosesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, patient, err)
+		return
+	}
+
+	resp, err := s.RecordService.ListPatientDiagnoses(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// getPatientVitalsHandler returns JSON formatted timeline of weight,
+// temperature and heart rate of patient identified by id param.
+func (s *Server) getPatientVitalsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, patient, err)
+		return
+	}
+
+	resp, err := s.RecordService.GetPatientVitals(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// searchPhrasesHandler returns JSON formatted list of phrase templates
+// visible to current user with name or text containing "q" query parameter
+func (s *Server) searchPhrasesHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.PhraseService.SearchPhrases(r.Context(), r.URL.Query().Get("q"))
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// getPhraseHandler returns JSON formatted GetPhrase data by ID
+func (s *Server) getPhraseHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, phrase, err)
+		return
+	}
+
+	resp, err := s.PhraseService.GetPhrase(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// createPhraseHandler creates new phrase template from JSON encoded Phrase in
+// request's body. Returns new phrase's ID.
+func (s *Server) createPhraseHandler(w http.ResponseWriter, r *http.Request) {
+	var p lara.Phrase
+	if err := render.DecodeJSON(r.Body, &p); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := s.PhraseService.CreatePhrase(r.Context(), &p)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.PlainText(w, r, fmt.Sprintf("%d", id))
+}
+
+// updatePhraseHandler updates phrase template identified by id param. Data to
+// update is JSON encoded in request's body. Result is indicated by response
+// status only (204/4xx/5xx).
+func (s *Server) updatePhraseHandler(w http.ResponseWriter, r *http.Request) {
+	var p lara.UpdatePhrase
+	if err := render.DecodeJSON(r.Body, &p); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, phrase, err)
+		return
+	}
+
+	if err := s.PhraseService.UpdatePhrase(r.Context(), id, &p); err != nil {
+		renderError(w, r, err)
+	}
+}
+
+// expandPhraseHandler returns JSON formatted text of phrase template
+// identified by id param with placeholders expanded for patient identified by
+// optional "patient" query parameter.
+func (s *Server) expandPhraseHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, phrase, err)
+		return
+	}
+
+	var patientID uint64
+	if p := r.URL.Query().Get("patient"); len(p) > 0 {
+		if patientID, err = strconv.ParseUint(p, 10, 64); err != nil {
+			renderError(w, r, lara.NewCodedError(http.StatusBadRequest,
+				errors.Wrapf(err, "invalid patient %s", p)))
+			return
+		}
+	}
+
+	resp, err := s.PhraseService.ExpandPhrase(r.Context(), id, patientID)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// parseAddressSearch parses city or street search request from "q" and page
+// query parameters
+func parseAddressSearch(r *http.Request) (*lara.AddressSearchRequest, error) {
+	page, err := parsePage(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lara.AddressSearchRequest{Query: r.URL.Query().Get("q"),
+		Page: page}, nil
+}
+
+// searchCityHandler returns JSON formated cities queried by name
+func (s *Server) searchCityHandler(w http.ResponseWriter, r *http.Request) {
+	q, err := parseAddressSearch(r)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	resp, err := s.AddressService.SearchCity(r.Context(), q)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// searchStreetByCityHandler returns JSON formatted street data for city queried by street name
+func (s *Server) searchStreetByCityHandler(w http.ResponseWriter, r *http.Request) {
+	cityID, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, city, err)
+		return
+	}
+
+	q, err := parseAddressSearch(r)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	resp, err := s.AddressService.SearchStreetForCity(r.Context(), cityID, q)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// getTagHandler returns JSON formatted GetTag data by ID
+func (s *Server) getTagHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, tag, err)
+		return
+	}
+
+	resp, err := s.TagService.Get(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// createTagHandler creates new patient's tag from JSON encoded body of request.
+// New tag's ID is returned in response body as text
+func (s *Server) createTagHandler(w http.ResponseWriter, r *http.Request) {
+	var t lara.CreateTag
+	if err := render.DecodeJSON(r.Body, &t); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := s.TagService.Create(r.Context(), &t)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.PlainText(w, r, fmt.Sprintf("%d", id))
+}
+
+// updateTagHandler updates existing patient's tag identified by id param. Tag to
+// update is JSON encoded in request's body. Result is indicated by response
+// status only (204/4xx/5xx).
+func (s *Server) updateTagHandler(w http.ResponseWriter, r *http.Request) {
+	var t lara.UpdateTag
+	if err := render.DecodeJSON(r.Body, &t); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, tag, err)
+		return
+	}
+
+	if err := s.TagService.Update(r.Context(), id, &t); err != nil {
+		renderError(w, r, err)
+	}
+}
+
+// replaceTagHandler replaces patient's tag identified by id param by new tag
+// JSON encoded in request's body. New tag's ID is returned in response body
+// as text
+func (s *Server) replaceTagHandler(w http.ResponseWriter, r *http.Request) {
+	var t lara.ReplaceTag
+	if err := render.DecodeJSON(r.Body, &t); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, tag, err)
+		return
+	}
+
+	newID, err := s.TagService.Replace(r.Context(), id, &t)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.PlainText(w, r, fmt.Sprintf("%d", newID))
+}
+
+// deactivateTagHandler deactivates patient's tag identified by id param.
+// Result is indicated by response status only (204/4xx/5xx).
+func (s *Server) deactivateTagHandler(w http.ResponseWriter, r *http.Request) {
+	var t lara.DeactivateTag
+	if err := render.DecodeJSON(r.Body, &t); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, tag, err)
+		return
+	}
+
+	if err := s.TagService.Deactivate(r.Context(), id, &t); err != nil {
+		renderError(w, r, err)
+	}
+}
+
+// putTagDataHandler replaces data of tag identified by id param by binary
+// content of request's body. Result is indicated by response status only
+// (204/4xx/5xx).
+func (s *Server) putTagDataHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, tag, err)
+		return
+	}
+
+	if err := s.TagService.PutData(r.Context(), id, r.Body); err != nil {
+		renderError(w, r, err)
+	}
+}
+
+// deleteTagDataHandler removes data of tag identified by id param. Result is
+// indicated by response status only (204/4xx/5xx).
+func (s *Server) deleteTagDataHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, tag, err)
+		return
+	}
+
+	if err := s.TagService.DeleteData(r.Context(), id); err != nil {
+		renderError(w, r, err)
+	}
+}
+
+// getTagDataHandler returns binary data of tag identified by id param
+func (s *Server) getTagDataHandler(w http.ResponseWriter, r *http.Request) {
+	s.renderTagData(w, r, s.TagService.GetData)
+}
+
+// getTagThumbnailHandler returns thumbnail of image data of tag identified by
+// id param
+func (s *Server) getTagThumbnailHandler(w http.ResponseWriter, r *http.Request) {
+	s.renderTagData(w, r, s.TagService.GetThumbnail)
+}
+
+// renderTagData writes binary data of tag identified by id param returned by
+// get, displayed inline in browser
+func (s *Server) renderTagData(w http.ResponseWriter, r *http.Request,
+	get func(ctx context.Context, id uint64) (*lara.TagData, error)) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, tag, err)
+		return
+	}
+
+	d, err := get(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", d.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(d.Content)))
+	w.Header().Set("Content-Disposition", "inline")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	w.Write(d.Content)
+}
+
+// getAppointmentHandler returns JSON formatted GetAppointment data by ID
+func (s *Server) getAppointmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, appointment, err)
+		return
+	}
+
+	resp, err := s.AppointmentService.Get(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// createAppointmentHandler creates new appointment from JSON encoded body of request.
+// New appointment's ID is returned in response body as text
+func (s *Server) createAppointmentHandler(w http.ResponseWriter, r *http.Request) {
+	var a lara.CreateAppointment
+	if err := render.DecodeJSON(r.Body, &a); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := s.AppointmentService.Create(r.Context(), &a)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.PlainText(w, r, fmt.Sprintf("%d", id))
+}
+
+// rescheduleAppointmentHandler moves existing appointment identified by id param
+// to another time, vet or room. Result is indicated by response status only
+// (204/4xx/5xx).
+func (s *Server) rescheduleAppointmentHandler(w http.ResponseWriter, r *http.Request) {
+	var a lara.RescheduleAppointment
+	if err := render.DecodeJSON(r.Body, &a); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, appointment, err)
+		return
+	}
+
+	if err := s.AppointmentService.Reschedule(r.Context(), id, &a); err != nil {
+		renderError(w, r, err)
+	}
+}
+
+// cancelAppointmentHandler cancels existing appointment identified by id param.
+// Result is indicated by response status only (204/4xx/5xx).
+func (s *Server) cancelAppointmentHandler(w http.ResponseWriter, r *http.Request) {
+	var c lara.CancelAppointment
+	if err := render.DecodeJSON(r.Body, &c); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, appointment, err)
+		return
+	}
+
+	if err := s.AppointmentService.Cancel(r.Context(), id, &c); err != nil {
+		renderError(w, r, err)
+	}
+}
+
+// checkInAppointmentHandler checks patient in for appointment identified by id
+// param. ID of record created for patient is returned in response body as text
+func (s *Server) checkInAppointmentHandler(w http.ResponseWriter, r *http.Request) {
+	var c lara.CheckInAppointment
+	if err := render.DecodeJSON(r.Body, &c); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, appointment, err)
+		return
+	}
+
+	recID, err := s.AppointmentService.CheckIn(r.Context(), id, &c)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.PlainText(w, r, fmt.Sprintf("%d", recID))
+}
+
+// listAppointmentsByDayHandler returns JSON formatted list of appointments
+// starting on day param (YYYY-MM-DD)
+func (s *Server) listAppointmentsByDayHandler(w http.ResponseWriter, r *http.Request) {
+	day, err := parseDate(chi.URLParam(r, "day"), time.Time{})
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	resp, err := s.AppointmentService.ListByDay(r.Context(), day)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// listAppointmentsByVetHandler returns JSON formatted list of vet's appointments.
+// Optional query parameters "from" and "to" (YYYY-MM-DD) limit the listed days,
+// both default to today.
+func (s *Server) listAppointmentsByVetHandler(w http.ResponseWriter, r *http.Request) {
+	from, err := parseDate(r.URL.Query().Get("from"), time.Now())
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	to, err := parseDate(r.URL.Query().Get("to"), from)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	resp, err := s.AppointmentService.ListByVet(r.Context(), chi.URLParam(r, "vet"), from, to)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// getVaccinationHandler returns JSON formatted GetVaccination data by ID
+func (s *Server) getVaccinationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, vaccination, err)
+		return
+	}
+
+	resp, err := s.VaccinationService.Get(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// createVaccinationHandler creates new patient's vaccination from JSON encoded
+// body of request. New vaccination's ID is returned in response body as text
+func (s *Server) createVaccinationHandler(w http.ResponseWriter, r *http.Request) {
+	var v lara.CreateVaccination
+	if err := render.DecodeJSON(r.Body, &v); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := s.VaccinationService.Create(r.Context(), &v)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.PlainText(w, r, fmt.Sprintf("%d", id))
+}
+
+// updateVaccinationHandler updates existing vaccination identified by id param.
+// Vaccination to update is JSON encoded in request's body. Result is indicated
+// by response status only (204/4xx/5xx).
+func (s *Server) updateVaccinationHandler(w http.ResponseWriter, r *http.Request) {
+	var v lara.UpdateVaccination
+	if err := render.DecodeJSON(r.Body, &v); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, vaccination, err)
+		return
+	}
+
+	if err := s.VaccinationService.Update(r.Context(), id, &v); err != nil {
+		renderError(w, r, err)
+	}
+}
+
+// getDueVaccinationsHandler lists vaccinations falling due in time period
+// specified by JSON encoded ReportRequest
+func (s *Server) getDueVaccinationsHandler(w http.ResponseWriter, r *http.Request) {
+	var rr lara.ReportRequest
+	if err := render.DecodeJSON(r.Body, &rr); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	resp, err := s.VaccinationService.GetDue(r.Context(), &rr)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// getPrescriptionHandler returns JSON formatted GetPrescription data by ID
+func (s *Server) getPrescriptionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, prescription, err)
+		return
+	}
+
+	resp, err := s.PrescriptionService.Get(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// createPrescriptionHandler creates new record's prescription from JSON
+// encoded body of request. New prescription's ID is returned in response body
+// as text
+func (s *Server) createPrescriptionHandler(w http.ResponseWriter, r *http.Request) {
+	var p lara.CreatePrescription
+	if err := render.DecodeJSON(r.Body, &p); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := s.PrescriptionService.Create(r.Context(), &p)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.PlainText(w, r, fmt.Sprintf("%d", id))
+}
+
+// updatePrescriptionHandler updates existing prescription identified by id
+// param. Prescription to update is JSON encoded in request's body. Result is
+// indicated by response status only (204/4xx/5xx).
+func (s *Server) updatePrescriptionHandler(w http.ResponseWriter, r *http.Request) {
+	var p lara.UpdatePrescription
+	if err := render.DecodeJSON(r.Body, &p); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, prescription, err)
+		return
+	}
+
+	if err := s.PrescriptionService.Update(r.Context(), id, &p); err != nil {
+		renderError(w, r, err)
+	}
+}
+
+// listRecordPrescriptionsHandler returns JSON formatted list of prescriptions
+// issued on record identified by id param
+func (s *Server) listRecordPrescriptionsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, record, err)
+		return
+	}
+
+	resp, err := s.PrescriptionService.ListByRecord(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// listPatientPrescriptionsHandler returns JSON formatted list of prescriptions
+// issued to patient identified by id param
+func (s *Server) listPatientPrescriptionsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, patient, err)
+		return
+	}
+
+	resp, err := s.PrescriptionService.ListByPatient(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// getNotificationHandler returns JSON formatted GetNotification data by ID
+func (s *Server) getNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, notification, err)
+		return
+	}
+
+	resp, err := s.NotificationService.Get(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// createNotificationHandler renders notification for owner from JSON encoded
+// CreateNotification and stores it to outbox for delivery. ID of the stored
+// notification is returned in response body as text
+func (s *Server) createNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	var n lara.CreateNotification
+	if err := render.DecodeJSON(r.Body, &n); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := s.NotificationService.Create(r.Context(), &n)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.PlainText(w, r, fmt.Sprintf("%d", id))
+}
+
+// getInvoiceHandler returns JSON formatted GetInvoice data by ID
+func (s *Server) getInvoiceHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, invoice, err)
+		return
+	}
+
+	resp, err := s.InvoiceService.Get(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// createInvoiceHandler issues new invoice for owner's records from JSON encoded
+// body of request. New invoice's ID is returned in response body as text
+func (s *Server) createInvoiceHandler(w http.ResponseWriter, r *http.Request) {
+	var i lara.CreateInvoice
+	if err := render.DecodeJSON(r.Body, &i); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	id, err := s.InvoiceService.Create(r.Context(), &i)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.PlainText(w, r, fmt.Sprintf("%d", id))
+}
+
+// listInvoicesByOwnerHandler returns JSON formatted list of invoices issued
+// for owner identified by id param
+func (s *Server) listInvoicesByOwnerHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, owner, err)
+		return
+	}
+
+	resp, err := s.InvoiceService.ListByOwner(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// renderPDF writes document b as PDF file name, displayed inline in browser
+func renderPDF(w http.ResponseWriter, name string, b []byte) {
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", name))
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// getRecordPDFHandler returns record identified by id param as PDF summary
+func (s *Server) getRecordPDFHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, record, err)
+		return
+	}
+
+	b, err := s.DocumentService.Record(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	renderPDF(w, fmt.Sprintf("record-%d.pdf", id), b)
+}
+
+// getPatientHistoryPDFHandler returns clinical history of patient identified
+// by id param as PDF
+func (s *Server) getPatientHistoryPDFHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, patient, err)
+		return
+	}
+
+	b, err := s.DocumentService.PatientHistory(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	renderPDF(w, fmt.Sprintf("history-%d.pdf", id), b)
+}
+
+// getVaccinationCertificatePDFHandler returns vaccination certificate of
+// patient identified by id param as PDF
+func (s *Server) getVaccinationCertificatePDFHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, patient, err)
+		return
+	}
+
+	b, err := s.DocumentService.VaccinationCertificate(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	renderPDF(w, fmt.Sprintf("vaccination-certificate-%d.pdf", id), b)
+}
+
+// getPrescriptionPDFHandler returns prescription identified by id param as PDF
+func (s *Server) getPrescriptionPDFHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, prescription, err)
+		return
+	}
+
+	b, err := s.DocumentService.Prescription(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	renderPDF(w, fmt.Sprintf("prescription-%d.pdf", id), b)
+}
+
+// getInvoicePDFHandler returns invoice identified by id param as PDF
+func (s *Server) getInvoicePDFHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, invoice, err)
+		return
+	}
+
+	b, err := s.DocumentService.Invoice(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	renderPDF(w, fmt.Sprintf("invoice-%d.pdf", id), b)
+}
+
+// parseUpload returns name and content of "file" part of multipart/form-data
+// request's body
+func parseUpload(r *http.Request) (string, io.Reader, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return "", nil, lara.NewCodedError(http.StatusBadRequest,
+			errors.Wrap(err, "multipart/form-data with file expected"))
+	}
+
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return "", nil, lara.NewCodedError(http.StatusBadRequest,
+				errors.New("form field file is missing"))
+		}
+		if err != nil {
+			return "", nil, lara.NewCodedError(http.StatusBadRequest,
+				errors.Wrap(err, "multipart/form-data decode error"))
+		}
+		if p.FormName() == "file" {
+			return p.FileName(), p, nil
+		}
+	}
+}
+
+// createAttachment stores file uploaded as "file" field of multipart/form-data
+// request's body as attachment a. Returns new attachment's ID.
+func (s *Server) createAttachment(w http.ResponseWriter, r *http.Request, a *lara.NewAttachment) {
+	var err error
+	if a.FileName, a.Content, err = parseUpload(r); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	id, err := s.AttachmentService.Create(r.Context(), a)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.PlainText(w, r, fmt.Sprintf("%d", id))
+}
+
+// renderAttachment writes content of attachment identified by "attachmentID"
+// param as file download. Attachment's record or patient ID returned by
+// belongs must equal ownerID, otherwise attachment is not found.
+func (s *Server) renderAttachment(w http.ResponseWriter, r *http.Request, ownerID uint64,
+	belongs func(a *lara.Attachment) uint64) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "attachmentID"), 10, 64)
+	if err != nil {
+		renderNotFoundError(w, r, attachment, err)
+		return
+	}
+
+	a, content, err := s.AttachmentService.Open(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	defer content.Close()
+
+	if belongs(a) != ownerID {
+		renderError(w, r, lara.NewCodedError(http.StatusNotFound,
+			errors.Errorf("object with id %d not found", id)))
+		return
+	}
+
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", a.FileName))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, content)
+}
+
+// createRecordAttachmentHandler attaches file to record identified by id
+// param. File is uploaded as "file" field of multipart/form-data request's
+// body. Returns new attachment's ID.
+func (s *Server) createRecordAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, record, err)
+		return
+	}
+
+	s.createAttachment(w, r, &lara.NewAttachment{RecordID: id})
+}
+
+// listRecordAttachmentsHandler returns JSON formatted list of attachments of
+// record identified by id param
+func (s *Server) listRecordAttachmentsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, record, err)
+		return
+	}
+
+	resp, err := s.AttachmentService.ListByRecord(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// getRecordAttachmentHandler downloads content of attachment identified by
+// attachmentID param of record identified by id param
+func (s *Server) getRecordAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, record, err)
+		return
+	}
+
+	s.renderAttachment(w, r, id, func(a *lara.Attachment) uint64 { return a.RecordID })
+}
+
+// createPatientAttachmentHandler attaches file to patient identified by id
+// param. File is uploaded as "file" field of multipart/form-data request's
+// body. Returns new attachment's ID.
+func (s *Server) createPatientAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, patient, err)
+		return
+	}
+
+	s.createAttachment(w, r, &lara.NewAttachment{PatientID: id})
+}
+
+// listPatientAttachmentsHandler returns JSON formatted list of attachments of
+// patient identified by id param including attachments of patient's records
+func (s *Server) listPatientAttachmentsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, patient, err)
+		return
+	}
+
+	resp, err := s.AttachmentService.ListByPatient(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// getPatientAttachmentHandler downloads content of attachment identified by
+// attachmentID param of patient identified by id param
+func (s *Server) getPatientAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		renderNotFoundError(w, r, patient, err)
+		return
+	}
+
+	s.renderAttachment(w, r, id, func(a *lara.Attachment) uint64 { return a.PatientID })
+}
+
+// listUsersHandler returns JSON formatted list of all users
+func (s *Server) listUsersHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.UserService.List(r.Context())
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// getUserHandler returns JSON formatted UserAccount data by login param
+func (s *Server) getUserHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.UserService.Get(r.Context(), chi.URLParam(r, "login"))
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// disableUserHandler prevents user identified by login param from logging in.
+// Result is indicated by response status only (204/4xx/5xx).
+func (s *Server) disableUserHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.UserService.Disable(r.Context(), chi.URLParam(r, "login")); err != nil {
+		renderError(w, r, err)
+	}
+}
+
+// enableUserHandler allows disabled user identified by login param to log in
+// again. Result is indicated by response status only (204/4xx/5xx).
+func (s *Server) enableUserHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.UserService.Enable(r.Context(), chi.URLParam(r, "login")); err != nil {
+		renderError(w, r, err)
+	}
+}
+
+// resetPasswordHandler sets new password of user identified by login param
+// from JSON encoded body of request. Result is indicated by response status
+// only (204/4xx/5xx).
+func (s *Server) resetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var p lara.ResetPassword
+	if err := render.DecodeJSON(r.Body, &p); err != nil {
+		renderBadJSONError(w, r, err)
+		return
+	}
+
+	if err := s.UserService.ResetPassword(r.Context(), chi.URLParam(r, "login"), p.Password); err != nil {
 		renderError(w, r, err)
 	}
 }
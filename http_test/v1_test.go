@@ -20,9 +20,12 @@
 package http_test
 
 import (
+	"bytes"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"mime/multipart"
 	syshttp "net/http"
 	"net/http/httptest"
 	"os"
@@ -43,12 +46,18 @@ func (t *testAuthToken) Create(u *lara.User) (string, error) {
 	return u.Login, nil
 }
 
+// Parse returns user "disableduser" for token "disabled-token", "testuser" otherwise
 func (t *testAuthToken) Parse(token string) (*lara.User, error) {
+	if strings.TrimSpace(token) == "disabled-token" {
+		return lara.MakeUser("disableduser", []string{lara.ViewRecord.String()})
+	}
 	return lara.MakeUser("testuser",
 		[]string{
 			lara.ViewRecord.String(),
 			lara.EditRecord.String(),
-			lara.ViewReports.String()}) // authenticate, full permissions
+			lara.ViewReports.String(),
+			lara.EditProducts.String(),
+			lara.Admin.String()}) // authenticate, full permissions
 }
 
 func newHttpHandler() syshttp.Handler {
@@ -89,18 +98,45 @@ func newHttpHandler() syshttp.Handler {
 		return 2, nil
 	}
 
+	// merging into ID 42 causes version conflict
+	ownMock.MergeFn = func(id uint64, m *lara.MergeOwners) error {
+		if id == 42 {
+			return lara.NewCodedError(409, errors.New("owner 42 modified"))
+		}
+		return nil
+	}
+
+	ownMock.DuplicatesFn = func(p lara.Page) (*lara.DuplicateOwnersList, error) {
+		if p.Sort == "error" {
+			return nil, errors.New("duplicates failed")
+		}
+		return &lara.DuplicateOwnersList{Total: 1, Items: []lara.DuplicateOwners{
+			{Owner1: lara.DuplicateCandidate{Versioned: lara.Versioned{ID: 1, Version: 2},
+				Name: "Ján Kováč", Phone: "0905 123 456"},
+				Owner2: lara.DuplicateCandidate{Versioned: lara.Versioned{ID: 3},
+					Name: "Jan Kovac", Phone: "0905123456"},
+				Score: 4, Reasons: []string{"name", "phone"}}}}, nil
+	}
+
 	searchMock := mock.SearchService{}
 
 	// search for q == "error" causes error
-	searchMock.SearchFn = func(q string) (*lara.SearchResult, error) {
-		switch q {
+	searchMock.SearchFn = func(r *lara.SearchRequest) (*lara.SearchResult, error) {
+		switch r.Query {
 		case "error":
 			return nil, errors.New("search failed")
+		case "paged":
+			if r.Page != (lara.Page{Offset: 10, Limit: 5, Sort: "-name"}) {
+				return nil, errors.Errorf("unexpected page %+v", r.Page)
+			}
+			return &lara.SearchResult{Total: 11, Records: []lara.SearchRecord{}}, nil
 		default:
 			return &lara.SearchResult{Total: 2,
 				Records: []lara.SearchRecord{
-					{ID: 1, Name: "Johnny GetOwner"},
-					{ID: 2, Name: "Dunco"}}}, nil
+					{ID: 1, Name: "Johnny GetOwner",
+						Field: lara.OwnerNameField, Match: "Johnny GetOwner"},
+					{ID: 2, Name: "Dunco", Field: lara.PatientField,
+						Match: "Rex", PatientID: 3, Patient: "Rex"}}}, nil
 		}
 	}
 
@@ -121,6 +157,36 @@ func newHttpHandler() syshttp.Handler {
 		}
 	}
 
+	productMock.GetFn = func(id uint64) (*lara.GetProduct, error) {
+		return &lara.GetProduct{Versioned: lara.Versioned{ID: id, Version: 1},
+			ProductData: lara.ProductData{Name: "Prod1", UnitID: 2, PLU: "10", Price: "1.00",
+				VATRate: "20.00", MinStock: "2.0000"},
+			Unit: "Unit2"}, nil
+	}
+	productMock.CreateFn = func(p *lara.CreateProduct) (uint64, error) {
+		return 42, nil
+	}
+	productMock.UpdateFn = func(id uint64, p *lara.UpdateProduct) error {
+		if p.Version != 1 {
+			return lara.NewCodedError(409, errors.New("version mismatch"))
+		}
+		return nil
+	}
+	productMock.RetireFn = func(id uint64, r *lara.RetireProduct) error {
+		return nil
+	}
+	productMock.PriceAtFn = func(id uint64, date time.Time) (*lara.ProductPrice, error) {
+		if date.Format("2006-01-02") != "2016-06-01" {
+			return nil, lara.NewCodedError(404, errors.New("price not found"))
+		}
+		return &lara.ProductPrice{Price: "4.50", VATRate: "10.00",
+			ValidTo: time.Date(2016, time.December, 31, 0, 0, 0, 0, time.UTC)}, nil
+	}
+	productMock.PriceHistoryFn = func(id uint64) (*lara.ProductPriceList, error) {
+		return &lara.ProductPriceList{Items: []lara.ProductPrice{
+			{Price: "4.50", VATRate: "10.00"}}}, nil
+	}
+
 	reportMock := mock.ReportService{}
 	reportMock.GetIncomeStatisticsFn = func(r *lara.ReportRequest) (*lara.IncomeStatistics, error) {
 		t, _ := time.Parse("Jan 2, 2006 at 3:04pm (MST)", "Apr 22, 2003 at 1:00pm (UTC)")
@@ -133,16 +199,28 @@ func newHttpHandler() syshttp.Handler {
 			Records:         1,
 			Income:          "9.42",
 			IncomeBilled:    "6.28",
-			IncomeNotBilled: "3.14"}, nil
+			IncomeNotBilled: "3.14",
+			VAT: []lara.VATBreakdown{
+				{Rate: "20.00", Net: "7.85", VAT: "1.57", Gross: "9.42"}}}, nil
+	}
+	reportMock.GetControlledDrugRegisterFn = func(r *lara.ReportRequest) (*lara.ControlledDrugReport, error) {
+		return &lara.ControlledDrugReport{
+			Products: []lara.ControlledDrugRegister{{ProductID: 7, Product: "Ketamine", Unit: "ml",
+				Opening: "0.0000", Closing: "18.0000",
+				Entries: []lara.ControlledDrugEntry{{MovementID: 6, Type: lara.Dispense, Amount: "-2.0000",
+					Balance: "18.0000", RecordID: 11, PatientID: 5, Patient: "pet", PrescriptionID: 1}}}},
+			Discrepancies: []lara.ControlledDrugDiscrepancy{}}, nil
 	}
 
 	patientMock := mock.PatientService{}
 	patientMock.GetFn = func(id uint64) (*lara.GetPatient, error) {
 		return &lara.GetPatient{
-			Versioned: lara.Versioned{ID: 1},
-			Patient:   lara.Patient{Name: "pet"},
-			Records:   []lara.PatientsRecord{},
-			Tags:      []lara.PatientsTag{},
+			Versioned:    lara.Versioned{ID: 1},
+			Patient:      lara.Patient{Name: "pet"},
+			Records:      []lara.PatientsRecord{},
+			Tags:         []lara.PatientsTag{},
+			Vaccinations: []lara.PatientsVaccination{},
+			Transfers:    []lara.PatientsTransfer{},
 		}, nil
 	}
 	patientMock.CreateFn = func(r *lara.CreatePatient) (uint64, error) {
@@ -151,6 +229,13 @@ func newHttpHandler() syshttp.Handler {
 	patientMock.UpdateFn = func(id uint64, p *lara.UpdatePatient) error {
 		return nil
 	}
+	// transfer to owner 42 causes error
+	patientMock.TransferFn = func(id uint64, t *lara.TransferPatient) error {
+		if t.OwnerID == 42 {
+			return lara.NewCodedError(400, errors.New("owner 42 not found"))
+		}
+		return nil
+	}
 
 	recordMock := mock.RecordService{}
 	recordMock.GetFn = func(id uint64) (*lara.GetRecord, error) {
@@ -167,6 +252,11 @@ func newHttpHandler() syshttp.Handler {
 				},
 				PLU: "10",
 			}},
+			ClinicalData: lara.ClinicalData{
+				Weight:       "12.500",
+				DiagnosisIDs: []uint64{7},
+			},
+			Diagnoses: []lara.Diagnosis{{ID: 7, Code: "K08", Name: "dental disease"}},
 		}, nil
 	}
 	recordMock.CreateFn = func(*lara.CreateRecord) (uint64, error) {
@@ -175,6 +265,28 @@ func newHttpHandler() syshttp.Handler {
 	recordMock.UpdateFn = func(id uint64, r *lara.UpdateRecord) error {
 		return nil
 	}
+	recordMock.ListPatientDiagnosesFn = func(patientID uint64) (*lara.PatientDiagnosisList, error) {
+		return &lara.PatientDiagnosisList{Items: []lara.PatientDiagnosis{{
+			Diagnosis: lara.Diagnosis{ID: 7, Code: "K08", Name: "dental disease"},
+			RecordID:  1}}}, nil
+	}
+	recordMock.GetPatientVitalsFn = func(patientID uint64) (*lara.PatientVitals, error) {
+		if patientID == 42 {
+			return nil, errors.New("get vitals failed")
+		}
+		return &lara.PatientVitals{
+			Weight:      lara.VitalsSeries{Unit: "kg", Points: []lara.VitalsPoint{{RecordID: 1, Value: "12.500"}}},
+			Temperature: lara.VitalsSeries{Unit: "°C", Points: []lara.VitalsPoint{}},
+			HeartRate:   lara.VitalsSeries{Unit: "bpm", Points: []lara.VitalsPoint{}},
+		}, nil
+	}
+	recordMock.ListDiagnosedPatientsFn = func(diagnosisID uint64, p lara.Page) (*lara.DiagnosedPatientList, error) {
+		if p.Sort == "fail" {
+			return nil, lara.NewCodedError(400, errors.New("unknown sort key fail"))
+		}
+		return &lara.DiagnosedPatientList{Total: 1, Items: []lara.DiagnosedPatient{{
+			PatientID: 1, Patient: "pet", OwnerID: 2, Owner: "owner", RecordID: 1}}}, nil
+	}
 
 	makeSimpleLOVGetAllFn := func(lovType string) func() (*lara.LOVItemList, error) {
 		return func() (*lara.LOVItemList, error) {
@@ -187,6 +299,30 @@ func newHttpHandler() syshttp.Handler {
 	sls.GetAllUnitsFn = makeSimpleLOVGetAllFn("unit")
 	sls.GetAllGendersFn = makeSimpleLOVGetAllFn("gender")
 	sls.GetAllSpeciesFn = makeSimpleLOVGetAllFn("species")
+	sls.GetAllDiagnosesFn = makeSimpleLOVGetAllFn("diagnosis")
+	sls.SearchPhrasesFn = func(query string) (*lara.PhraseList, error) {
+		return &lara.PhraseList{Items: []lara.GetPhrase{{Versioned: lara.Versioned{ID: 1},
+			Phrase: lara.Phrase{Name: query, Text: "{patient} ok"}}}}, nil
+	}
+	sls.GetPhraseFn = func(id uint64) (*lara.GetPhrase, error) {
+		if id != 1 {
+			return nil, lara.NewCodedError(404, errors.New("object with id 2 not found"))
+		}
+		return &lara.GetPhrase{Versioned: lara.Versioned{ID: 1},
+			Phrase: lara.Phrase{Name: "ok", Text: "{patient} ok", Personal: true}, Author: "testuser"}, nil
+	}
+	sls.CreatePhraseFn = func(p *lara.Phrase) (uint64, error) {
+		if p.Name == "" {
+			return 0, lara.NewCodedError(400, errors.New("name is required"))
+		}
+		return 42, nil
+	}
+	sls.UpdatePhraseFn = func(id uint64, p *lara.UpdatePhrase) error {
+		return nil
+	}
+	sls.ExpandPhraseFn = func(id, patientID uint64) (*lara.ExpandedPhrase, error) {
+		return &lara.ExpandedPhrase{Text: fmt.Sprintf("patient %d ok", patientID)}, nil
+	}
 	sls.GetAllBreedsFn = func(speciesId uint64) (*lara.LOVItemList, error) {
 		if speciesId != 42 {
 			return nil, errors.New("get by id failed")
@@ -195,17 +331,20 @@ func newHttpHandler() syshttp.Handler {
 	}
 
 	addressMock := mock.AddressService{}
-	addressMock.SearchCityFn = func(query string) (*lara.CityStreetList, error) {
-		if query == "fail" {
+	addressMock.SearchCityFn = func(r *lara.AddressSearchRequest) (*lara.CityStreetList, error) {
+		if r.Query == "fail" {
 			return nil, errors.New("search city failed")
 		}
 		return &lara.CityStreetList{Total: 1, Items: []lara.CityStreet{{ID: 1, Name: "city", ZIP: "000"}}}, nil
 	}
 
-	addressMock.SearchStreetForCityFn = func(cityID uint64, query string) (*lara.CityStreetList, error) {
-		if query == "fail" {
+	addressMock.SearchStreetForCityFn = func(cityID uint64, r *lara.AddressSearchRequest) (*lara.CityStreetList, error) {
+		if r.Query == "fail" {
 			return nil, errors.New("search street failed")
 		}
+		if r.Sort == "zip" && r.Offset == 1 {
+			return &lara.CityStreetList{Total: 2, Items: []lara.CityStreet{}}, nil
+		}
 		return &lara.CityStreetList{Total: 1, Items: []lara.CityStreet{{ID: 1, Name: "street", ZIP: "000"}}}, nil
 	}
 
@@ -215,7 +354,9 @@ func newHttpHandler() syshttp.Handler {
 			Versioned: lara.Versioned{ID: 1},
 			Type:      "RFID",
 			Value:     "007",
-			Data:      []byte{},
+			DataType:  "image/png",
+			DataSize:  42,
+			Thumbnail: true,
 		}, nil
 	}
 	tagMock.CreateFn = func(r *lara.CreateTag) (uint64, error) {
@@ -224,26 +365,372 @@ func newHttpHandler() syshttp.Handler {
 	tagMock.UpdateFn = func(id uint64, p *lara.UpdateTag) error {
 		return nil
 	}
+	tagMock.ReplaceFn = func(id uint64, t *lara.ReplaceTag) (uint64, error) {
+		if id == 2 {
+			return 0, lara.NewCodedError(409, errors.New("tag 2 is inactive"))
+		}
+		return 43, nil
+	}
+	tagMock.DeactivateFn = func(id uint64, t *lara.DeactivateTag) error {
+		return nil
+	}
+	tagMock.PutDataFn = func(id uint64, content io.Reader) error {
+		b, err := ioutil.ReadAll(content)
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(string(b), "%PDF") {
+			return lara.NewCodedError(415, errors.New("tag data's content type text/plain is not supported"))
+		}
+		return nil
+	}
+	tagMock.GetDataFn = func(id uint64) (*lara.TagData, error) {
+		if id != 1 {
+			return nil, lara.NewCodedError(404, errors.Errorf("tag %d has no data", id))
+		}
+		return &lara.TagData{ContentType: "application/pdf", Content: []byte("%PDF-1.4 scan")}, nil
+	}
+	tagMock.GetThumbnailFn = func(id uint64) (*lara.TagData, error) {
+		if id != 1 {
+			return nil, lara.NewCodedError(404, errors.Errorf("tag %d has no thumbnail", id))
+		}
+		return &lara.TagData{ContentType: "image/jpeg", Content: []byte("thumb")}, nil
+	}
+	tagMock.DeleteDataFn = func(id uint64) error {
+		return nil
+	}
 	tagMock.GetPatientByTagFn = func(tagValue string) (*lara.PatientByTag, error) {
-		return &lara.PatientByTag{Name: "p", Species: "s", Breed: "b", Gender: "g",
+		return &lara.PatientByTag{PatientID: 3, Name: "p", Species: "s", Breed: "b", Gender: "g",
 			OwnerID: 1, OwnerName: "n", OwnerAddress: "a"}, nil
 	}
 
+	appointmentMock := mock.AppointmentService{}
+	appointmentMock.GetFn = func(id uint64) (*lara.GetAppointment, error) {
+		if id == 2 {
+			return nil, lara.NewCodedError(404, errors.New("appointment with ID 2 not found"))
+		}
+		return &lara.GetAppointment{
+			Versioned:   lara.Versioned{ID: id},
+			Appointment: lara.Appointment{Vet: "vet", Room: "room"},
+			PatientID:   3,
+			Status:      lara.Scheduled,
+		}, nil
+	}
+	appointmentMock.CreateFn = func(a *lara.CreateAppointment) (uint64, error) {
+		if a.Vet == "busy" {
+			return 0, lara.NewCodedError(409, errors.New("vet busy already has appointment 1 at this time"))
+		}
+		return 42, nil
+	}
+	appointmentMock.RescheduleFn = func(id uint64, a *lara.RescheduleAppointment) error {
+		return nil
+	}
+	appointmentMock.CancelFn = func(id uint64, c *lara.CancelAppointment) error {
+		if id == 3 {
+			return lara.NewCodedError(409, errors.New("appointment with id 3 is Cancelled"))
+		}
+		return nil
+	}
+	appointmentMock.CheckInFn = func(id uint64, c *lara.CheckInAppointment) (uint64, error) {
+		return 43, nil
+	}
+	appointmentMock.ListByDayFn = func(day time.Time) (*lara.AppointmentList, error) {
+		if day.Format("2006-01-02") != "2017-05-01" {
+			return nil, errors.New("list by day failed")
+		}
+		return &lara.AppointmentList{Items: []lara.GetAppointment{}}, nil
+	}
+	appointmentMock.ListByVetFn = func(vet string, from, to time.Time) (*lara.AppointmentList, error) {
+		if vet != "vet" || from.Format("2006-01-02") != "2017-05-01" || to.Format("2006-01-02") != "2017-05-07" {
+			return nil, errors.New("list by vet failed")
+		}
+		return &lara.AppointmentList{Items: []lara.GetAppointment{}}, nil
+	}
+
+	vaccinationMock := mock.VaccinationService{}
+	vaccinationMock.GetFn = func(id uint64) (*lara.GetVaccination, error) {
+		return &lara.GetVaccination{
+			Versioned:   lara.Versioned{ID: id},
+			Vaccination: lara.Vaccination{ProductID: 2, Batch: "B1", ValidMonths: 12},
+			PatientID:   3,
+			Vaccine:     "rabies",
+		}, nil
+	}
+	vaccinationMock.CreateFn = func(v *lara.CreateVaccination) (uint64, error) {
+		return 42, nil
+	}
+	vaccinationMock.UpdateFn = func(id uint64, v *lara.UpdateVaccination) error {
+		return nil
+	}
+	vaccinationMock.GetDueFn = func(r *lara.ReportRequest) (*lara.DueVaccinationList, error) {
+		return &lara.DueVaccinationList{Items: []lara.DueVaccination{{VaccinationID: 1,
+			Vaccine: "rabies", PatientID: 3, PatientName: "pet", OwnerID: 1, OwnerName: "n",
+			OwnerAddress: "a", Phone1: "0900"}}}, nil
+	}
+
+	prescriptionMock := mock.PrescriptionService{}
+	prescriptionMock.GetFn = func(id uint64) (*lara.GetPrescription, error) {
+		if id == 2 {
+			return nil, lara.NewCodedError(404, errors.Errorf("object with id %d not found", id))
+		}
+		return &lara.GetPrescription{
+			Versioned: lara.Versioned{ID: id},
+			RecordID:  5,
+			PatientID: 3,
+			Items: []lara.GetPrescriptionItem{{ID: 1,
+				PrescriptionItem: lara.PrescriptionItem{ProductID: 7, Amount: "2.0000", Dose: "1 ml",
+					Frequency: "once", WithdrawalDays: 28},
+				Product: "Ketamine", Unit: "ml", Controlled: true}},
+		}, nil
+	}
+	prescriptionMock.CreateFn = func(p *lara.CreatePrescription) (uint64, error) {
+		if len(p.Items) == 0 {
+			return 0, lara.NewCodedError(400, errors.New("items is required"))
+		}
+		return 42, nil
+	}
+	prescriptionMock.UpdateFn = func(id uint64, p *lara.UpdatePrescription) error {
+		return nil
+	}
+	prescriptionMock.ListByRecordFn = func(recordID uint64) (*lara.PrescriptionList, error) {
+		return &lara.PrescriptionList{Items: []lara.PrescriptionListItem{{ID: 1, RecordID: recordID,
+			Drugs: "Ketamine"}}}, nil
+	}
+	prescriptionMock.ListByPatientFn = func(patientID uint64) (*lara.PrescriptionList, error) {
+		return &lara.PrescriptionList{Items: []lara.PrescriptionListItem{}}, nil
+	}
+
+	notificationMock := mock.NotificationService{}
+	notificationMock.GetFn = func(id uint64) (*lara.GetNotification, error) {
+		return &lara.GetNotification{ID: 1,
+			Message: lara.Message{Channel: lara.Email, Recipient: "o@test.com",
+				Subject: "s", Body: "b"},
+			Template: lara.VaccinationDue, OwnerID: 1, PatientID: 3, Status: lara.Pending,
+		}, nil
+	}
+	notificationMock.CreateFn = func(n *lara.CreateNotification) (uint64, error) {
+		return 42, nil
+	}
+
+	invoiceMock := mock.InvoiceService{}
+	invoiceMock.GetFn = func(id uint64) (*lara.GetInvoice, error) {
+		return &lara.GetInvoice{ID: 1, Number: "2017000001", OwnerID: 1,
+			Supplier: lara.Clinic{Name: "clinic"},
+			Customer: lara.InvoiceCustomer{Name: "n"},
+			Lines: []lara.InvoiceLine{{RecordID: 2, ProductID: 3, Product: "p", Unit: "u",
+				ProductPrice: "1.00", Amount: "2.0000", ItemPrice: "2.00",
+				VATRate: "20.00", ItemNet: "1.67", ItemVAT: "0.33"}},
+			Total: "2.00", TotalNet: "1.67", TotalVAT: "0.33",
+			VAT: []lara.VATBreakdown{
+				{Rate: "20.00", Net: "1.67", VAT: "0.33", Gross: "2.00"}}}, nil
+	}
+	invoiceMock.CreateFn = func(i *lara.CreateInvoice) (uint64, error) {
+		return 42, nil
+	}
+	invoiceMock.ListByOwnerFn = func(ownerID uint64) (*lara.OwnersInvoiceList, error) {
+		return &lara.OwnersInvoiceList{Items: []lara.OwnersInvoice{{ID: 1, Number: "2017000001",
+			Total: "2.00"}}}, nil
+	}
+
+	documentMock := mock.DocumentService{}
+	pdf := func(id uint64) ([]byte, error) {
+		if id == 2 {
+			return nil, lara.NewCodedError(404, errors.New("not found"))
+		}
+		return []byte(fmt.Sprintf("%%PDF-1.4 %d", id)), nil
+	}
+	documentMock.InvoiceFn = pdf
+	documentMock.RecordFn = pdf
+	documentMock.PatientHistoryFn = pdf
+	documentMock.VaccinationCertificateFn = pdf
+	documentMock.PrescriptionFn = pdf
+
+	stockMock := mock.StockService{}
+	stockMock.ListFn = func() (*lara.StockLevelList, error) {
+		return &lara.StockLevelList{Items: []lara.StockLevel{
+			{ProductID: 1, Product: "Prod1", Unit: "Unit1", Quantity: "5.0000"}}}, nil
+	}
+	stockMock.GetFn = func(productID uint64) (*lara.StockLevel, error) {
+		if productID == 2 {
+			return nil, lara.NewCodedError(404, errors.New("not found"))
+		}
+		return &lara.StockLevel{ProductID: productID, Product: "Prod1", Unit: "Unit1",
+			Quantity: "-1.5000"}, nil
+	}
+	stockMock.ListMovementsFn = func(productID uint64, from, to time.Time) (*lara.StockMovementList, error) {
+		if from.Format("2006-01-02") != "2017-01-01" || to.Format("2006-01-02") != "2017-01-31" {
+			return nil, lara.NewCodedError(400, errors.New("unexpected period"))
+		}
+		return &lara.StockMovementList{Items: []lara.GetStockMovement{
+			{ID: 1, StockMovement: lara.StockMovement{ProductID: productID, Type: lara.Dispense,
+				Amount: "-2.0000"}, RecordID: 3}}}, nil
+	}
+	stockMock.PostFn = func(m *lara.CreateStockMovement) (uint64, error) {
+		if m.Type == lara.Dispense {
+			return 0, lara.NewCodedError(400, errors.New("Dispense movements are posted from records only"))
+		}
+		return 42, nil
+	}
+	stockMock.BatchesFn = func(productID uint64) (*lara.StockBatchList, error) {
+		return &lara.StockBatchList{Items: []lara.StockBatch{
+			{ID: 1, ProductID: productID, Number: "A123", Quantity: "4.0000",
+				Expiry: time.Date(2017, time.June, 30, 0, 0, 0, 0, time.UTC)}}}, nil
+	}
+	stockMock.ExpiringFn = func(days int) (*lara.StockBatchList, error) {
+		if days != 30 {
+			return &lara.StockBatchList{Items: []lara.StockBatch{}}, nil
+		}
+		return &lara.StockBatchList{Items: []lara.StockBatch{{ID: 1, Number: "A123"}}}, nil
+	}
+	stockMock.GetMovementFn = func(id uint64) (*lara.GetStockMovement, error) {
+		return &lara.GetStockMovement{ID: id, StockMovement: lara.StockMovement{ProductID: 1,
+			Type: lara.WriteOff, Amount: "-1.0000", Note: "broken"}}, nil
+	}
+
+	supplierMock := mock.SupplierService{}
+	supplierMock.GetFn = func(id uint64) (*lara.GetSupplier, error) {
+		if id == 2 {
+			return nil, lara.NewCodedError(404, errors.New("not found"))
+		}
+		return &lara.GetSupplier{Versioned: lara.Versioned{ID: id, Version: 1},
+			Supplier: lara.Supplier{Name: "Pharma", Phone: "0900"}}, nil
+	}
+	supplierMock.CreateFn = func(sup *lara.CreateSupplier) (uint64, error) {
+		return 42, nil
+	}
+	supplierMock.UpdateFn = func(id uint64, sup *lara.UpdateSupplier) error {
+		if sup.Version != 1 {
+			return lara.NewCodedError(409, errors.New("version mismatch"))
+		}
+		return nil
+	}
+	supplierMock.ListFn = func() (*lara.SupplierList, error) {
+		return &lara.SupplierList{Items: []lara.GetSupplier{
+			{Versioned: lara.Versioned{ID: 1}, Supplier: lara.Supplier{Name: "Pharma"}}}}, nil
+	}
+
+	purchaseMock := mock.PurchaseOrderService{}
+	purchaseMock.GetFn = func(id uint64) (*lara.GetPurchaseOrder, error) {
+		return &lara.GetPurchaseOrder{Versioned: lara.Versioned{ID: id, Version: 1},
+			SupplierID: 1, Supplier: "Pharma", Status: lara.PartiallyReceived,
+			Lines: []lara.GetPurchaseOrderLine{{ID: 1,
+				PurchaseOrderLine: lara.PurchaseOrderLine{ProductID: 4, Amount: "10.0000"},
+				Product:           "Prod4", Unit: "Unit1", Received: "5.0000"}}}, nil
+	}
+	purchaseMock.CreateFn = func(o *lara.CreatePurchaseOrder) (uint64, error) {
+		return 42, nil
+	}
+	purchaseMock.UpdateFn = func(id uint64, o *lara.UpdatePurchaseOrder) error {
+		return lara.NewCodedError(409, errors.New("purchase order 1 is PartiallyReceived"))
+	}
+	purchaseMock.ReceiveFn = func(id uint64, r *lara.ReceivePurchaseOrder) error {
+		if len(r.Lines) == 0 {
+			return lara.NewCodedError(400, errors.New("lines is required"))
+		}
+		return nil
+	}
+	purchaseMock.WithdrawFn = func(id uint64, w *lara.WithdrawPurchaseOrder) error {
+		return nil
+	}
+	purchaseMock.ListBySupplierFn = func(supplierID uint64) (*lara.SuppliersPurchaseOrderList, error) {
+		return &lara.SuppliersPurchaseOrderList{Items: []lara.SuppliersPurchaseOrder{
+			{ID: 1, Status: lara.Received}}}, nil
+	}
+	purchaseMock.SuggestedReorderFn = func() (*lara.SuggestedReorderList, error) {
+		return &lara.SuggestedReorderList{Items: []lara.SuggestedReorder{
+			{ProductID: 4, Product: "Prod4", Unit: "Unit1", MinStock: "20.0000", Stock: "5.0000",
+				Ordered: "5.0000", Suggested: "10.0000"}}}, nil
+	}
+
+	attachmentMock := mock.AttachmentService{}
+	attachmentMock.CreateFn = func(a *lara.NewAttachment) (uint64, error) {
+		b, err := ioutil.ReadAll(a.Content)
+		if err != nil {
+			return 0, err
+		}
+		if !strings.HasPrefix(string(b), "%PDF") {
+			return 0, lara.NewCodedError(415, errors.New("attachment's content type text/plain is not supported"))
+		}
+		return a.RecordID + a.PatientID, nil
+	}
+	attachmentMock.OpenFn = func(id uint64) (*lara.Attachment, io.ReadCloser, error) {
+		if id != 1 {
+			return nil, nil, lara.NewCodedError(404, errors.Errorf("object with id %d not found", id))
+		}
+		return &lara.Attachment{ID: 1, PatientID: 3, RecordID: 5, FileName: "lab.pdf",
+			ContentType: "application/pdf", Size: 12}, ioutil.NopCloser(strings.NewReader("%PDF-1.4 lab")), nil
+	}
+	attachmentMock.ListByRecordFn = func(recordID uint64) (*lara.AttachmentList, error) {
+		return &lara.AttachmentList{Items: []lara.Attachment{{ID: 1, PatientID: 3, RecordID: recordID,
+			FileName: "lab.pdf", ContentType: "application/pdf", Size: 12, Checksum: "abc", Creator: "testuser"}}}, nil
+	}
+	attachmentMock.ListByPatientFn = func(patientID uint64) (*lara.AttachmentList, error) {
+		return &lara.AttachmentList{Items: []lara.Attachment{}}, nil
+	}
+
+	userMock := mock.UserService{}
+	userMock.ListFn = func() (*lara.UserList, error) {
+		return &lara.UserList{Items: []lara.UserAccount{
+			{Login: "nurse", Permissions: []string{"EditRecord", "ViewRecord"}},
+			{Login: "vet", Permissions: []string{}, Disabled: true}}}, nil
+	}
+	userMock.GetFn = func(login string) (*lara.UserAccount, error) {
+		if login != "nurse" {
+			return nil, lara.NewCodedError(404, errors.Errorf("user %s not found", login))
+		}
+		return &lara.UserAccount{Login: login, Permissions: []string{"ViewRecord"}}, nil
+	}
+	// user "disableduser" was disabled after login
+	userMock.CheckEnabledFn = func(login string) error {
+		if login == "disableduser" {
+			return lara.NewCodedError(401, errors.New("user is disabled"))
+		}
+		return nil
+	}
+	userMock.DisableFn = func(login string) error {
+		if login == "testuser" {
+			return lara.NewCodedError(400, errors.New("users can't disable themselves"))
+		}
+		return nil
+	}
+	userMock.EnableFn = func(login string) error {
+		return nil
+	}
+	userMock.ResetPasswordFn = func(login, password string) error {
+		if password == "" {
+			return lara.NewCodedError(400, errors.New("password is required"))
+		}
+		return nil
+	}
+
 	srv := http.Server{
-		Token:          &testAuthToken{},
-		SearchService:  &searchMock,
-		OwnerService:   &ownMock,
-		PatientSevice:  &patientMock,
-		RecordService:  &recordMock,
-		ProductService: &productMock,
-		ReportService:  &reportMock,
-		TitleService:   &sls,
-		UnitService:    &sls,
-		GenderService:  &sls,
-		SpeciesService: &sls,
-		BreedService:   &sls,
-		AddressService: &addressMock,
-		TagService:     &tagMock,
+		Token:                &testAuthToken{},
+		UserService:          &userMock,
+		SearchService:        &searchMock,
+		OwnerService:         &ownMock,
+		PatientSevice:        &patientMock,
+		RecordService:        &recordMock,
+		ProductService:       &productMock,
+		ReportService:        &reportMock,
+		TitleService:         &sls,
+		UnitService:          &sls,
+		GenderService:        &sls,
+		SpeciesService:       &sls,
+		BreedService:         &sls,
+		DiagnosisService:     &sls,
+		PhraseService:        &sls,
+		AddressService:       &addressMock,
+		TagService:           &tagMock,
+		AppointmentService:   &appointmentMock,
+		VaccinationService:   &vaccinationMock,
+		NotificationService:  &notificationMock,
+		InvoiceService:       &invoiceMock,
+		DocumentService:      &documentMock,
+		StockService:         &stockMock,
+		SupplierService:      &supplierMock,
+		PurchaseOrderService: &purchaseMock,
+		AttachmentService:    &attachmentMock,
+		PrescriptionService:  &prescriptionMock,
 	}
 
 	return srv.Router()
@@ -267,20 +754,66 @@ func TestAllHandlers(t *testing.T) {
 		//Search
 		{"SearchHandler_OK",
 			"GET", "/api/v1/search?q=something", nil, 200,
-			`{"total":2,"records":[{"id":1,"name":"Johnny GetOwner","address":""},{"id":2,"name":"Dunco","address":""}]}` + "\n",
+			`{"total":2,"records":[{"id":1,"name":"Johnny GetOwner","address":"","field":"name","match":"Johnny GetOwner","patientId":0,"patient":""},{"id":2,"name":"Dunco","address":"","field":"patient","match":"Rex","patientId":3,"patient":"Rex"}]}` + "\n",
 			false},
 		{"SearchHandler_NoQuery",
 			"GET", "/api/v1/search", nil, 200,
-			`{"total":2,"records":[{"id":1,"name":"Johnny GetOwner","address":""},{"id":2,"name":"Dunco","address":""}]}` + "\n",
+			`{"total":2,"records":[{"id":1,"name":"Johnny GetOwner","address":"","field":"name","match":"Johnny GetOwner","patientId":0,"patient":""},{"id":2,"name":"Dunco","address":"","field":"patient","match":"Rex","patientId":3,"patient":"Rex"}]}` + "\n",
 			false},
 		{"SearchHandler_Error",
 			"GET", "/api/v1/search?q=error", nil, 500,
 			`search failed`, true},
+		{"SearchHandler_Paged",
+			"GET", "/api/v1/search?q=paged&offset=10&limit=5&sort=-name", nil, 200,
+			`{"total":11,"records":[]}` + "\n", false},
+		{"SearchHandler_BadOffset",
+			"GET", "/api/v1/search?q=paged&offset=x", nil, 400,
+			`invalid number x`, true},
 
 		// SearchPatientByTagHandler tests
 		{"SearchPatientByTagHandler_OK",
 			"GET", "/api/v1/search/patient-by-tag/xyz", nil, 200,
-			`{"tagType":"","name":"p","species":"s","breed":"b","gender":"g","ownerId":1,"ownerName":"n","ownerAddress":"a"}` + "\n", false},
+			`{"tagId":0,"tagType":"","active":false,"replacedBy":"","patientId":3,"name":"p","species":"s","breed":"b","gender":"g","ownerId":1,"ownerName":"n","ownerAddress":"a"}` + "\n", false},
+
+		// ScanTagHandler tests
+		{"ScanTagHandler_OK",
+			"POST", "/api/v1/tag/scan", strings.NewReader(`{"value":"941000012345678"}`), 200,
+			`{"tagId":0,"tagType":"","active":false,"replacedBy":"","patientId":3,"name":"p","species":"s","breed":"b","gender":"g","ownerId":1,"ownerName":"n","ownerAddress":"a"}` + "\n", false},
+		{"ScanTagHandler_NoValue",
+			"POST", "/api/v1/tag/scan", strings.NewReader(`{}`), 400,
+			"value is required", true},
+		{"ScanTagHandler_BadJSON",
+			"POST", "/api/v1/tag/scan", strings.NewReader(`{bad}`), 400,
+			"json decode error", true},
+
+		// user administration handlers tests
+		{"ListUsersHandler_OK",
+			"GET", "/api/v1/admin/user", nil, 200,
+			`{"items":[{"login":"nurse","permissions":["EditRecord","ViewRecord"],"disabled":false},{"login":"vet","permissions":[],"disabled":true}]}` + "\n", false},
+		{"GetUserHandler_OK",
+			"GET", "/api/v1/admin/user/nurse", nil, 200,
+			`{"login":"nurse","permissions":["ViewRecord"],"disabled":false}` + "\n", false},
+		{"GetUserHandler_NotFound",
+			"GET", "/api/v1/admin/user/nobody", nil, 404,
+			"user nobody not found", true},
+		{"DisableUserHandler_OK",
+			"POST", "/api/v1/admin/user/nurse/disable", nil, 200,
+			"", false},
+		{"DisableUserHandler_Self",
+			"POST", "/api/v1/admin/user/testuser/disable", nil, 400,
+			"users can't disable themselves", true},
+		{"EnableUserHandler_OK",
+			"POST", "/api/v1/admin/user/nurse/enable", nil, 200,
+			"", false},
+		{"ResetPasswordHandler_OK",
+			"PUT", "/api/v1/admin/user/nurse/password", strings.NewReader(`{"password":"secret"}`), 200,
+			"", false},
+		{"ResetPasswordHandler_NoPassword",
+			"PUT", "/api/v1/admin/user/nurse/password", strings.NewReader(`{}`), 400,
+			"password is required", true},
+		{"ResetPasswordHandler_BadJSON",
+			"PUT", "/api/v1/admin/user/nurse/password", strings.NewReader(`:-)`), 400,
+			"json decode error", true},
 
 		// GetOwnerHandler tests
 		{"GetOwnerHandler_OK",
@@ -338,11 +871,40 @@ func TestAllHandlers(t *testing.T) {
 			strings.NewReader(`{"firstName":"A","lastName":"B"}`),
 			500, "update owner failed", true},
 
+		// MergeOwnersHandler tests
+		{"MergeOwnersHandler_OK",
+			"POST", "/api/v1/owner/1/merge",
+			strings.NewReader(`{"version":2,"duplicates":[{"id":3,"version":0}]}`),
+			200, "", false},
+		{"MergeOwnersHandler_BadJSON",
+			"POST", "/api/v1/owner/1/merge", strings.NewReader(`{`),
+			400, "json decode error", true},
+		{"MergeOwnersHandler_BadIdFormat",
+			"POST", "/api/v1/owner/NaN/merge",
+			strings.NewReader(`{"version":2,"duplicates":[{"id":3,"version":0}]}`),
+			404, "invalid owner ID", true},
+		{"MergeOwnersHandler_Conflict",
+			"POST", "/api/v1/owner/42/merge",
+			strings.NewReader(`{"version":2,"duplicates":[{"id":3,"version":0}]}`),
+			409, "owner 42 modified", true},
+
+		// ListDuplicateOwnersHandler tests
+		{"ListDuplicateOwnersHandler_OK",
+			"GET", "/api/v1/owner/duplicates", nil, 200,
+			`{"total":1,"items":[{"owner1":{"id":1,"version":2,"name":"Ján Kováč","address":"","phone":"0905 123 456"},"owner2":{"id":3,"version":0,"name":"Jan Kovac","address":"","phone":"0905123456"},"score":4,"reasons":["name","phone"]}]}` + "\n",
+			false},
+		{"ListDuplicateOwnersHandler_BadLimit",
+			"GET", "/api/v1/owner/duplicates?limit=x", nil, 400,
+			"invalid number x", true},
+		{"ListDuplicateOwnersHandler_Error",
+			"GET", "/api/v1/owner/duplicates?sort=error", nil, 500,
+			"duplicates failed", true},
+
 		//SearchProduct
 		{"SearchProductHandler_OK",
 			"POST", "/api/v1/productsearch",
 			strings.NewReader(`{"Query":"test"}`), 200,
-			`{"total":2,"products":[{"id":1,"name":"Prod1","unit":"Unit1","price":"1.00"},{"id":2,"name":"Prod2","unit":"Unit2","price":"2.00"}]}` + "\n",
+			`{"total":2,"products":[{"id":1,"name":"Prod1","unit":"Unit1","price":"1.00","vatRate":""},{"id":2,"name":"Prod2","unit":"Unit2","price":"2.00","vatRate":""}]}` + "\n",
 			false},
 		{"SearchProductHandler_NoBody",
 			"POST", "/api/v1/productsearch", strings.NewReader(""), 400,
@@ -360,7 +922,7 @@ func TestAllHandlers(t *testing.T) {
 		{"GetIncomeStatisticsHandler_OK",
 			"POST", "/api/v1/report/income",
 			strings.NewReader(`{"ValidFrom":"2003-04-22T13:00:00Z"}`), 200,
-			`{"records":1,"income":"9.42","incomeBilled":"6.28","incomeNotBilled":"3.14"}` + "\n",
+			`{"records":1,"income":"9.42","incomeBilled":"6.28","incomeNotBilled":"3.14","vat":[{"rate":"20.00","net":"7.85","vat":"1.57","gross":"9.42"}]}` + "\n",
 			false},
 		{"GetIncomeStatisticsHandler_NoBody",
 			"POST", "/api/v1/report/income", strings.NewReader(""), 400,
@@ -374,16 +936,63 @@ func TestAllHandlers(t *testing.T) {
 			strings.NewReader(`{"ValidFrom":"2001-05-30T09:30:10+02:00"}`), 500,
 			`report failed`, true},
 
+		// Controlled-drug register
+		{"GetControlledDrugRegisterHandler_OK",
+			"POST", "/api/v1/report/controlled-drugs",
+			strings.NewReader(`{"validFrom":"2017-03-01T00:00:00Z","validTo":"2017-03-31T00:00:00Z"}`), 200,
+			`{"products":[{"productId":7,"product":"Ketamine","unit":"ml","opening":"0.0000","closing":"18.0000","entries":[{"movementId":6,"date":"0001-01-01T00:00:00Z","type":"Dispense","amount":"-2.0000","balance":"18.0000","batch":"","recordId":11,"patientId":5,"patient":"pet","prescriptionId":1,"note":"","creator":""}]}],"discrepancies":[]}` + "\n",
+			false},
+		{"GetControlledDrugRegisterHandler_BadJSON",
+			"POST", "/api/v1/report/controlled-drugs",
+			strings.NewReader(`:-)`),
+			400, "json decode error", true},
+
 		// GetPatientHandler tests
 		{"GetPatientHandler_OK",
 			"GET", "/api/v1/patient/1", nil, 200,
-			`{"id":1,"version":0,"creator":"","created":"0001-01-01T00:00:00Z","modifier":"","modified":"0001-01-01T00:00:00Z","name":"pet","birthDate":"0001-01-01T00:00:00Z","speciesId":0,"breedId":0,"genderId":0,"note":"","dead":false,"species":"","breed":"","gender":"","records":[],"tags":[]}` + "\n", false},
+			`{"id":1,"version":0,"creator":"","created":"0001-01-01T00:00:00Z","modifier":"","modified":"0001-01-01T00:00:00Z","name":"pet","birthDate":"0001-01-01T00:00:00Z","speciesId":0,"breedId":0,"genderId":0,"note":"","ownerId":0,"dead":false,"species":"","breed":"","gender":"","weight":"","weightDate":"0001-01-01T00:00:00Z","records":[],"tags":[],"vaccinations":[],"transfers":[]}` + "\n", false},
 		// failed requests tested by GetOwnerHandler tests
 
+		// TransferPatientHandler tests
+		{"TransferPatientHandler_OK",
+			"POST", "/api/v1/patient/1/transfer",
+			strings.NewReader(`{"version":0,"ownerId":2,"reason":"sold"}`),
+			200, "", false},
+		{"TransferPatientHandler_BadJSON",
+			"POST", "/api/v1/patient/1/transfer", strings.NewReader(`:-)`),
+			400, "json decode error", true},
+		{"TransferPatientHandler_BadIdFormat",
+			"POST", "/api/v1/patient/NaN/transfer",
+			strings.NewReader(`{"version":0,"ownerId":2}`),
+			404, "invalid patient ID", true},
+		{"TransferPatientHandler_OwnerNotFound",
+			"POST", "/api/v1/patient/1/transfer",
+			strings.NewReader(`{"version":0,"ownerId":42}`),
+			400, "owner 42 not found", true},
+
+		// ListPatientDiagnosesHandler tests
+		{"ListPatientDiagnosesHandler_OK",
+			"GET", "/api/v1/patient/1/diagnoses", nil, 200,
+			`{"items":[{"id":7,"code":"K08","name":"dental disease","recordId":1,"date":"0001-01-01T00:00:00Z"}]}` + "\n", false},
+		{"ListPatientDiagnosesHandler_BadIdFormat",
+			"GET", "/api/v1/patient/NaN/diagnoses", nil, 404,
+			"invalid patient ID", true},
+
+		// GetPatientVitalsHandler tests
+		{"GetPatientVitalsHandler_OK",
+			"GET", "/api/v1/patient/1/vitals", nil, 200,
+			`{"weight":{"unit":"kg","points":[{"recordId":1,"date":"0001-01-01T00:00:00Z","value":"12.500"}]},"temperature":{"unit":"°C","points":[]},"heartRate":{"unit":"bpm","points":[]}}` + "\n", false},
+		{"GetPatientVitalsHandler_BadIdFormat",
+			"GET", "/api/v1/patient/NaN/vitals", nil, 404,
+			"invalid patient ID", true},
+		{"GetPatientVitalsHandler_Error",
+			"GET", "/api/v1/patient/42/vitals", nil, 500,
+			"get vitals failed", true},
+
 		// GetRecordHandler tests
 		{"GetRecordHandler_OK",
 			"GET", "/api/v1/record/1", nil, 200,
-			`{"id":1,"version":0,"creator":"","created":"0001-01-01T00:00:00Z","modifier":"","modified":"0001-01-01T00:00:00Z","date":"0001-01-01T00:00:00Z","text":"","billed":false,"items":[{"id":2,"productId":100,"productPrice":"1.00","amount":"2.00","itemPrice":"3.00","itemType":"Labor","product":"","unit":"","plu":"10"}],"total":""}` + "\n", false},
+			`{"id":1,"version":0,"creator":"","created":"0001-01-01T00:00:00Z","modifier":"","modified":"0001-01-01T00:00:00Z","patientId":0,"date":"0001-01-01T00:00:00Z","text":"","billed":false,"items":[{"id":2,"productId":100,"productPrice":"1.00","amount":"2.00","itemPrice":"3.00","itemType":"Labor","vatRate":"","batchId":0,"itemNet":"","itemVat":"","product":"","unit":"","plu":"10","batch":"","expiry":"0001-01-01T00:00:00Z"}],"total":"","totalNet":"","totalVat":"","subjective":"","objective":"","assessment":"","plan":"","weight":"12.500","temperature":"","heartRate":0,"diagnosisIds":[7],"diagnoses":[{"id":7,"code":"K08","name":"dental disease"}]}` + "\n", false},
 		// failed requests tested by GetOwnerHandler tests
 
 		// CreateRecordHandler tests
@@ -446,6 +1055,57 @@ func TestAllHandlers(t *testing.T) {
 			"GET", "/api/v1/species", nil, 200,
 			`{"items":[{"id":42,"name":"species"}]}` + "\n", false},
 
+		// GetAllDiagnosesHandler test
+		{"GetAllDiagnosesHandler_OK",
+			"GET", "/api/v1/diagnosis", nil, 200,
+			`{"items":[{"id":42,"name":"diagnosis"}]}` + "\n", false},
+
+		// phrase handlers tests
+		{"SearchPhrasesHandler_OK",
+			"GET", "/api/v1/phrase?q=exam", nil, 200,
+			`{"items":[{"id":1,"version":0,"name":"exam","text":"{patient} ok","personal":false,"author":""}]}` + "\n", false},
+		{"GetPhraseHandler_OK",
+			"GET", "/api/v1/phrase/1", nil, 200,
+			`{"id":1,"version":0,"name":"ok","text":"{patient} ok","personal":true,"author":"testuser"}` + "\n", false},
+		{"GetPhraseHandler_NotFound",
+			"GET", "/api/v1/phrase/2", nil, 404,
+			"object with id 2 not found", true},
+		{"GetPhraseHandler_BadIdFormat",
+			"GET", "/api/v1/phrase/NaN", nil, 404,
+			"invalid phrase ID", true},
+		{"CreatePhraseHandler_OK",
+			"POST", "/api/v1/phrase",
+			strings.NewReader(`{"name":"exam","text":"{patient} ok","personal":true}`),
+			200, "42", false},
+		{"CreatePhraseHandler_NoName",
+			"POST", "/api/v1/phrase",
+			strings.NewReader(`{"text":"{patient} ok"}`),
+			400, "name is required", true},
+		{"CreatePhraseHandler_BadJSON",
+			"POST", "/api/v1/phrase", strings.NewReader(`:-)`),
+			400, "json decode error", true},
+		{"UpdatePhraseHandler_OK",
+			"PUT", "/api/v1/phrase/1",
+			strings.NewReader(`{"version":0,"name":"exam"}`),
+			200, "", false},
+		{"ExpandPhraseHandler_OK",
+			"GET", "/api/v1/phrase/1/expand?patient=5", nil, 200,
+			`{"text":"patient 5 ok"}` + "\n", false},
+		{"ExpandPhraseHandler_BadPatient",
+			"GET", "/api/v1/phrase/1/expand?patient=x", nil, 400,
+			"invalid patient x", true},
+
+		// ListDiagnosedPatientsHandler tests
+		{"ListDiagnosedPatientsHandler_OK",
+			"GET", "/api/v1/diagnosis/7/patients?limit=10", nil, 200,
+			`{"total":1,"items":[{"patientId":1,"patient":"pet","ownerId":2,"owner":"owner","recordId":1,"date":"0001-01-01T00:00:00Z"}]}` + "\n", false},
+		{"ListDiagnosedPatientsHandler_BadIdFormat",
+			"GET", "/api/v1/diagnosis/NaN/patients", nil, 404,
+			"invalid diagnosis ID", true},
+		{"ListDiagnosedPatientsHandler_BadSort",
+			"GET", "/api/v1/diagnosis/7/patients?sort=fail", nil, 400,
+			"unknown sort key fail", true},
+
 		// GetAllBreedsBySpeciesHandler tests
 		{"GetAllBreedsBySpeciesHandler_OK",
 			"GET", "/api/v1/breed/by-species/42", nil, 200,
@@ -481,15 +1141,21 @@ func TestAllHandlers(t *testing.T) {
 		{"SearchStreetByCityHandler_SqlError",
 			"GET", "/api/v1/street/by-city/1?q=fail", nil, 500,
 			"search street failed", true},
+		{"SearchStreetByCityHandler_Paged",
+			"GET", "/api/v1/street/by-city/1?offset=1&sort=zip", nil, 200,
+			`{"total":2,"items":[]}` + "\n", false},
+		{"SearchStreetByCityHandler_BadLimit",
+			"GET", "/api/v1/street/by-city/1?limit=many", nil, 400,
+			"invalid number many", true},
 
 		// GetTagHandler tests
 		{"GetTagHandler_OK",
 			"GET", "/api/v1/tag/1", nil, 200,
-			`{"id":1,"version":0,"creator":"","created":"0001-01-01T00:00:00Z","modifier":"","modified":"0001-01-01T00:00:00Z","type":"RFID","value":"007","data":""}` + "\n", false},
+			`{"id":1,"version":0,"creator":"","created":"0001-01-01T00:00:00Z","modifier":"","modified":"0001-01-01T00:00:00Z","type":"RFID","value":"007","dataType":"image/png","dataSize":42,"thumbnail":true,"microchip":null,"validFrom":"0001-01-01T00:00:00Z","validTo":"0001-01-01T00:00:00Z","active":false,"replaces":0,"replacedBy":0}` + "\n", false},
 		// CreateTagHandler tests
 		{"CreateTagHandler_OK",
 			"POST", "/api/v1/tag",
-			strings.NewReader(`{"patientId":2,"type":"RFID","value":"007", "data": "QUJD"}`),
+			strings.NewReader(`{"patientId":2,"type":"RFID","value":"007"}`),
 			200, "42", false},
 		{"CreateTagHandler_BadJSON",
 			"POST", "/api/v1/tag",
@@ -502,8 +1168,428 @@ func TestAllHandlers(t *testing.T) {
 		// UpdateTagHandler tests
 		{"UpdateTagHandler_OK",
 			"PUT", "/api/v1/tag/1",
-			strings.NewReader(`{"version":2, "value":"007", "data": "QUJD"}`),
+			strings.NewReader(`{"version":2, "value":"007"}`),
+			200, "", false},
+		{"ReplaceTagHandler_OK",
+			"POST", "/api/v1/tag/1/replace",
+			strings.NewReader(`{"version":2, "value":"008", "validFrom":"2018-01-01T00:00:00Z"}`),
+			200, "43", false},
+		{"ReplaceTagHandler_Inactive",
+			"POST", "/api/v1/tag/2/replace",
+			strings.NewReader(`{"version":2, "value":"008"}`),
+			409, "tag 2 is inactive", true},
+		{"ReplaceTagHandler_BadParam",
+			"POST", "/api/v1/tag/Nan/replace",
+			strings.NewReader(`{"version":2, "value":"008"}`),
+			404, "invalid tag ID", true},
+		{"DeactivateTagHandler_OK",
+			"POST", "/api/v1/tag/1/deactivate",
+			strings.NewReader(`{"version":2}`),
+			200, "", false},
+		{"DeactivateTagHandler_BadJSON",
+			"POST", "/api/v1/tag/1/deactivate",
+			strings.NewReader(`:-)`),
+			400, "json decode error", true},
+		{"PutTagDataHandler_OK",
+			"PUT", "/api/v1/tag/1/data", strings.NewReader("%PDF-1.4 scan"),
+			200, "", false},
+		{"PutTagDataHandler_Unsupported",
+			"PUT", "/api/v1/tag/1/data", strings.NewReader("plain"),
+			415, "content type text/plain is not supported", true},
+		{"PutTagDataHandler_BadParam",
+			"PUT", "/api/v1/tag/NaN/data", strings.NewReader("%PDF-1.4 scan"),
+			404, "invalid tag ID", true},
+		{"GetTagDataHandler_OK",
+			"GET", "/api/v1/tag/1/data", nil, 200,
+			"%PDF-1.4 scan", false},
+		{"GetTagDataHandler_NoData",
+			"GET", "/api/v1/tag/2/data", nil, 404,
+			"tag 2 has no data", true},
+		{"GetTagThumbnailHandler_OK",
+			"GET", "/api/v1/tag/1/thumbnail", nil, 200,
+			"thumb", false},
+		{"GetTagThumbnailHandler_NoThumbnail",
+			"GET", "/api/v1/tag/2/thumbnail", nil, 404,
+			"tag 2 has no thumbnail", true},
+		{"DeleteTagDataHandler_OK",
+			"DELETE", "/api/v1/tag/1/data", nil,
+			200, "", false},
+
+		// Appointment handlers tests
+		{"GetAppointmentHandler_OK",
+			"GET", "/api/v1/appointment/1", nil, 200,
+			`{"id":1,"version":0,"creator":"","created":"0001-01-01T00:00:00Z","modifier":"","modified":"0001-01-01T00:00:00Z","vet":"vet","room":"room","start":"0001-01-01T00:00:00Z","end":"0001-01-01T00:00:00Z","patientId":3,"patient":"","ownerId":0,"ownerName":"","note":"","status":"Scheduled","cancelReason":"","recordId":0}` + "\n", false},
+		{"GetAppointmentHandler_BadParam",
+			"GET", "/api/v1/appointment/Nan", nil, 404,
+			"invalid appointment ID", true},
+		{"GetAppointmentHandler_NotFound",
+			"GET", "/api/v1/appointment/2", nil, 404,
+			"appointment with ID 2 not found", true},
+		{"CreateAppointmentHandler_OK",
+			"POST", "/api/v1/appointment",
+			strings.NewReader(`{"patientId":1,"vet":"vet","start":"2017-05-01T10:00:00Z","end":"2017-05-01T10:30:00Z"}`),
+			200, "42", false},
+		{"CreateAppointmentHandler_Conflict",
+			"POST", "/api/v1/appointment",
+			strings.NewReader(`{"patientId":1,"vet":"busy","start":"2017-05-01T10:00:00Z","end":"2017-05-01T10:30:00Z"}`),
+			409, "already has appointment", true},
+		{"CreateAppointmentHandler_BadJSON",
+			"POST", "/api/v1/appointment",
+			strings.NewReader(`:-)`),
+			400, "json decode error", true},
+		{"RescheduleAppointmentHandler_OK",
+			"PUT", "/api/v1/appointment/1",
+			strings.NewReader(`{"version":1,"vet":"vet","start":"2017-05-01T11:00:00Z","end":"2017-05-01T11:30:00Z"}`),
 			200, "", false},
+		{"CancelAppointmentHandler_OK",
+			"POST", "/api/v1/appointment/1/cancel",
+			strings.NewReader(`{"version":1,"reason":"sick"}`),
+			200, "", false},
+		{"CancelAppointmentHandler_NotScheduled",
+			"POST", "/api/v1/appointment/3/cancel",
+			strings.NewReader(`{"version":1}`),
+			409, "is Cancelled", true},
+		{"CheckInAppointmentHandler_OK",
+			"POST", "/api/v1/appointment/1/check-in",
+			strings.NewReader(`{"version":1,"text":"checked in"}`),
+			200, "43", false},
+		{"ListAppointmentsByDayHandler_OK",
+			"GET", "/api/v1/appointment/by-day/2017-05-01", nil, 200,
+			`{"items":[]}` + "\n", false},
+		{"ListAppointmentsByDayHandler_BadDate",
+			"GET", "/api/v1/appointment/by-day/01.05.2017", nil, 400,
+			"invalid date", true},
+		{"ListAppointmentsByVetHandler_OK",
+			"GET", "/api/v1/appointment/by-vet/vet?from=2017-05-01&to=2017-05-07", nil, 200,
+			`{"items":[]}` + "\n", false},
+		{"ListAppointmentsByVetHandler_BadDate",
+			"GET", "/api/v1/appointment/by-vet/vet?from=2017-05-01&to=tomorrow", nil, 400,
+			"invalid date", true},
+
+		// Vaccination handlers tests
+		{"GetVaccinationHandler_OK",
+			"GET", "/api/v1/vaccination/1", nil, 200,
+			`{"id":1,"version":0,"creator":"","created":"0001-01-01T00:00:00Z","modifier":"","modified":"0001-01-01T00:00:00Z","productId":2,"batch":"B1","administered":"0001-01-01T00:00:00Z","validMonths":12,"vet":"","note":"","patientId":3,"vaccine":"rabies","due":"0001-01-01T00:00:00Z"}` + "\n", false},
+		{"GetVaccinationHandler_BadParam",
+			"GET", "/api/v1/vaccination/Nan", nil, 404,
+			"invalid vaccination ID", true},
+		{"CreateVaccinationHandler_OK",
+			"POST", "/api/v1/vaccination",
+			strings.NewReader(`{"patientId":3,"productId":2,"batch":"B1","administered":"2017-05-01T00:00:00Z","validMonths":12}`),
+			200, "42", false},
+		{"UpdateVaccinationHandler_OK",
+			"PUT", "/api/v1/vaccination/1",
+			strings.NewReader(`{"version":1,"productId":2,"batch":"B2","administered":"2017-05-01T00:00:00Z","validMonths":12}`),
+			200, "", false},
+		{"GetDueVaccinationsHandler_OK",
+			"POST", "/api/v1/vaccination/due",
+			strings.NewReader(`{"validFrom":"2017-05-01T00:00:00Z","validTo":"2017-05-31T00:00:00Z"}`), 200,
+			`{"items":[{"vaccinationId":1,"vaccine":"rabies","administered":"0001-01-01T00:00:00Z","due":"0001-01-01T00:00:00Z","patientId":3,"patientName":"pet","species":"","ownerId":1,"ownerName":"n","ownerAddress":"a","phone1":"0900","phone2":"","email":""}]}` + "\n", false},
+		{"GetDueVaccinationsHandler_BadJSON",
+			"POST", "/api/v1/vaccination/due",
+			strings.NewReader(`:-)`),
+			400, "json decode error", true},
+
+		// Prescription handlers tests
+		{"GetPrescriptionHandler_OK",
+			"GET", "/api/v1/prescription/1", nil, 200,
+			`{"id":1,"version":0,"creator":"","created":"0001-01-01T00:00:00Z","modifier":"","modified":"0001-01-01T00:00:00Z","recordId":5,"patientId":3,"date":"0001-01-01T00:00:00Z","note":"","items":[{"id":1,"productId":7,"amount":"2.0000","dose":"1 ml","frequency":"once","durationDays":0,"withdrawalDays":28,"product":"Ketamine","unit":"ml","controlled":true,"withdrawalEnd":"0001-01-01T00:00:00Z"}],"withdrawalEnd":"0001-01-01T00:00:00Z"}` + "\n", false},
+		{"GetPrescriptionHandler_BadParam",
+			"GET", "/api/v1/prescription/Nan", nil, 404,
+			"invalid prescription ID", true},
+		{"GetPrescriptionHandler_NotFound",
+			"GET", "/api/v1/prescription/2", nil, 404,
+			"object with id 2 not found", true},
+		{"CreatePrescriptionHandler_OK",
+			"POST", "/api/v1/prescription",
+			strings.NewReader(`{"recordId":5,"items":[{"productId":7,"amount":"2","dose":"1 ml","withdrawalDays":28}]}`),
+			200, "42", false},
+		{"CreatePrescriptionHandler_NoItems",
+			"POST", "/api/v1/prescription",
+			strings.NewReader(`{"recordId":5}`),
+			400, "items is required", true},
+		{"UpdatePrescriptionHandler_OK",
+			"PUT", "/api/v1/prescription/1",
+			strings.NewReader(`{"version":1,"items":[{"productId":7,"amount":"1","dose":"1 ml"}]}`),
+			200, "", false},
+		{"UpdatePrescriptionHandler_BadJSON",
+			"PUT", "/api/v1/prescription/1",
+			strings.NewReader(`:-)`),
+			400, "json decode error", true},
+		{"ListRecordPrescriptionsHandler_OK",
+			"GET", "/api/v1/record/5/prescriptions", nil, 200,
+			`{"items":[{"id":1,"recordId":5,"date":"0001-01-01T00:00:00Z","drugs":"Ketamine","withdrawalEnd":"0001-01-01T00:00:00Z"}]}` + "\n", false},
+		{"ListPatientPrescriptionsHandler_OK",
+			"GET", "/api/v1/patient/3/prescriptions", nil, 200,
+			`{"items":[]}` + "\n", false},
+		{"ListPatientPrescriptionsHandler_BadParam",
+			"GET", "/api/v1/patient/Nan/prescriptions", nil, 404,
+			"invalid patient ID", true},
+
+		// Notification handlers tests
+		{"GetNotificationHandler_OK",
+			"GET", "/api/v1/notification/1", nil, 200,
+			`{"id":1,"channel":"Email","recipient":"o@test.com","subject":"s","body":"b","template":"VaccinationDue","ownerId":1,"patientId":3,"status":"Pending","attempts":0,"lastError":"","creator":"","created":"0001-01-01T00:00:00Z","sent":"0001-01-01T00:00:00Z"}` + "\n", false},
+		{"GetNotificationHandler_BadParam",
+			"GET", "/api/v1/notification/Nan", nil, 404,
+			"invalid notification ID", true},
+		{"CreateNotificationHandler_OK",
+			"POST", "/api/v1/notification",
+			strings.NewReader(`{"template":"VaccinationDue","channel":"Email","ownerId":1,"patientId":3,"vaccinationId":1}`),
+			200, "42", false},
+		{"CreateNotificationHandler_BadTemplate",
+			"POST", "/api/v1/notification",
+			strings.NewReader(`{"template":"Birthday","channel":"Email","ownerId":1,"patientId":3}`),
+			400, "json decode error", true},
+
+		// Invoice handlers tests
+		{"GetInvoiceHandler_OK",
+			"GET", "/api/v1/invoice/1", nil, 200,
+			`{"id":1,"number":"2017000001","ownerId":1,"issueDate":"0001-01-01T00:00:00Z","deliveryDate":"0001-01-01T00:00:00Z","dueDate":"0001-01-01T00:00:00Z","supplier":{"name":"clinic","address":"","IC":"","DIC":"","ICDPH":"","IBAN":"","phone":"","email":""},"customer":{"name":"n","address":"","IC":"","DIC":"","ICDPH":""},"lines":[{"recordId":2,"productId":3,"product":"p","unit":"u","plu":"","productPrice":"1.00","amount":"2.0000","itemPrice":"2.00","itemType":"Labor","vatRate":"20.00","itemNet":"1.67","itemVat":"0.33"}],"total":"2.00","totalNet":"1.67","totalVat":"0.33","vat":[{"rate":"20.00","net":"1.67","vat":"0.33","gross":"2.00"}],"creator":"","created":"0001-01-01T00:00:00Z"}` + "\n", false},
+		{"GetInvoiceHandler_BadParam",
+			"GET", "/api/v1/invoice/Nan", nil, 404,
+			"invalid invoice ID", true},
+		{"CreateInvoiceHandler_OK",
+			"POST", "/api/v1/invoice",
+			strings.NewReader(`{"ownerId":1,"recordIds":[2,3]}`),
+			200, "42", false},
+		{"CreateInvoiceHandler_BadJSON",
+			"POST", "/api/v1/invoice",
+			strings.NewReader(`{"ownerId":1,"recordIds":"2"}`),
+			400, "json decode error", true},
+		{"ListInvoicesByOwnerHandler_OK",
+			"GET", "/api/v1/invoice/by-owner/1", nil, 200,
+			`{"items":[{"id":1,"number":"2017000001","issueDate":"0001-01-01T00:00:00Z","dueDate":"0001-01-01T00:00:00Z","total":"2.00"}]}` + "\n", false},
+
+		// Product handlers tests
+		{"GetProductHandler_OK",
+			"GET", "/api/v1/product/1", nil, 200,
+			`{"id":1,"version":1,"creator":"","created":"0001-01-01T00:00:00Z","modifier":"","modified":"0001-01-01T00:00:00Z","name":"Prod1","unitId":2,"plu":"10","price":"1.00","vatRate":"20.00","minStock":"2.0000","controlled":false,"unit":"Unit2","validTo":"0001-01-01T00:00:00Z"}` + "\n", false},
+		{"GetProductHandler_BadParam",
+			"GET", "/api/v1/product/Nan", nil, 404,
+			"invalid product ID", true},
+		{"CreateProductHandler_OK",
+			"POST", "/api/v1/product",
+			strings.NewReader(`{"name":"Prod1","unitId":2,"price":"1.00"}`),
+			200, "42", false},
+		{"CreateProductHandler_BadJSON",
+			"POST", "/api/v1/product",
+			strings.NewReader(`{"name":"Prod1","unitId":"2"}`),
+			400, "json decode error", true},
+		{"UpdateProductHandler_OK",
+			"PUT", "/api/v1/product/1",
+			strings.NewReader(`{"version":1,"name":"Prod1","unitId":2,"price":"1.50"}`),
+			200, "", false},
+		{"UpdateProductHandler_Conflict",
+			"PUT", "/api/v1/product/1",
+			strings.NewReader(`{"version":0,"name":"Prod1","unitId":2,"price":"1.50"}`),
+			409, "version mismatch", false},
+		{"RetireProductHandler_OK",
+			"POST", "/api/v1/product/1/retire",
+			strings.NewReader(`{"version":1,"validTo":"2017-05-31T00:00:00Z"}`),
+			200, "", false},
+		{"RetireProductHandler_BadParam",
+			"POST", "/api/v1/product/Nan/retire",
+			strings.NewReader(`{"version":1}`),
+			404, "invalid product ID", true},
+
+		{"GetProductPriceHandler_OK",
+			"GET", "/api/v1/product/2/price?date=2016-06-01", nil, 200,
+			`{"price":"4.50","vatRate":"10.00","validFrom":"0001-01-01T00:00:00Z","validTo":"2016-12-31T00:00:00Z","creator":"","created":"0001-01-01T00:00:00Z","modifier":"","modified":"0001-01-01T00:00:00Z"}` + "\n", false},
+		{"GetProductPriceHandler_BadDate",
+			"GET", "/api/v1/product/2/price?date=01.06.2016", nil, 400,
+			"invalid date 01.06.2016", true},
+		{"GetProductPriceHandler_NotFound",
+			"GET", "/api/v1/product/2/price?date=2000-01-01", nil, 404,
+			"price not found", false},
+		{"GetProductPriceHistoryHandler_OK",
+			"GET", "/api/v1/product/2/price-history", nil, 200,
+			`{"items":[{"price":"4.50","vatRate":"10.00","validFrom":"0001-01-01T00:00:00Z","validTo":"0001-01-01T00:00:00Z","creator":"","created":"0001-01-01T00:00:00Z","modifier":"","modified":"0001-01-01T00:00:00Z"}]}` + "\n", false},
+		{"GetProductPriceHistoryHandler_BadParam",
+			"GET", "/api/v1/product/Nan/price-history", nil, 404,
+			"invalid product ID", true},
+
+		// Stock handlers tests
+		{"ListStockHandler_OK",
+			"GET", "/api/v1/stock", nil, 200,
+			`{"items":[{"productId":1,"product":"Prod1","unit":"Unit1","quantity":"5.0000"}]}` + "\n", false},
+		{"GetProductStockHandler_OK",
+			"GET", "/api/v1/stock/product/1", nil, 200,
+			`{"productId":1,"product":"Prod1","unit":"Unit1","quantity":"-1.5000"}` + "\n", false},
+		{"GetProductStockHandler_BadParam",
+			"GET", "/api/v1/stock/product/Nan", nil, 404,
+			"invalid product ID", true},
+		{"GetProductStockHandler_NotFound",
+			"GET", "/api/v1/stock/product/2", nil, 404,
+			"not found", false},
+		{"ListProductStockMovementsHandler_OK",
+			"GET", "/api/v1/stock/product/1/movements?from=2017-01-01&to=2017-01-31", nil, 200,
+			`"type":"Dispense","amount":"-2.0000"`, true},
+		{"ListProductStockMovementsHandler_BadDate",
+			"GET", "/api/v1/stock/product/1/movements?from=1.1.2017", nil, 400,
+			"invalid date 1.1.2017", true},
+		{"ListProductBatchesHandler_OK",
+			"GET", "/api/v1/stock/product/1/batches", nil, 200,
+			`"number":"A123","expiry":"2017-06-30T00:00:00Z","quantity":"4.0000"`, true},
+		{"ListProductBatchesHandler_BadParam",
+			"GET", "/api/v1/stock/product/Nan/batches", nil, 404,
+			"invalid product ID", true},
+		{"ListExpiringBatchesHandler_Default",
+			"GET", "/api/v1/stock/expiring", nil, 200,
+			`"number":"A123"`, true},
+		{"ListExpiringBatchesHandler_Days",
+			"GET", "/api/v1/stock/expiring?days=7", nil, 200,
+			`{"items":[]}` + "\n", false},
+		{"ListExpiringBatchesHandler_BadDays",
+			"GET", "/api/v1/stock/expiring?days=week", nil, 400,
+			"invalid number week", true},
+		{"PostStockMovementHandler_OK",
+			"POST", "/api/v1/stock/movement",
+			strings.NewReader(`{"productId":1,"type":"receipt","amount":"10"}`),
+			200, "42", false},
+		{"PostStockMovementHandler_Batch",
+			"POST", "/api/v1/stock/movement",
+			strings.NewReader(`{"productId":1,"type":"receipt","amount":"10","batch":"A123","expiry":"2017-06-30T00:00:00Z"}`),
+			200, "42", false},
+		{"PostStockMovementHandler_Dispense",
+			"POST", "/api/v1/stock/movement",
+			strings.NewReader(`{"productId":1,"type":"dispense","amount":"10"}`),
+			400, "posted from records only", true},
+		{"PostStockMovementHandler_BadType",
+			"POST", "/api/v1/stock/movement",
+			strings.NewReader(`{"productId":1,"type":"sale","amount":"10"}`),
+			400, "json decode error", true},
+		{"GetStockMovementHandler_OK",
+			"GET", "/api/v1/stock/movement/7", nil, 200,
+			`{"id":7,"productId":1,"type":"WriteOff","amount":"-1.0000","date":"0001-01-01T00:00:00Z","note":"broken","batchId":0,"product":"","unit":"","recordId":0,"batch":"","expiry":"0001-01-01T00:00:00Z","creator":"","created":"0001-01-01T00:00:00Z"}` + "\n", false},
+		{"GetStockMovementHandler_BadParam",
+			"GET", "/api/v1/stock/movement/Nan", nil, 404,
+			"invalid stockMovement ID", true},
+
+		// Supplier handlers tests
+		{"ListSuppliersHandler_OK",
+			"GET", "/api/v1/supplier", nil, 200,
+			`"name":"Pharma"`, true},
+		{"GetSupplierHandler_OK",
+			"GET", "/api/v1/supplier/1", nil, 200,
+			`{"id":1,"version":1,"name":"Pharma","IC":"","DIC":"","address":"","contact":"","phone":"0900","email":"","note":"","creator":"","created":"0001-01-01T00:00:00Z","modifier":"","modified":"0001-01-01T00:00:00Z"}` + "\n", false},
+		{"GetSupplierHandler_NotFound",
+			"GET", "/api/v1/supplier/2", nil, 404,
+			"not found", false},
+		{"GetSupplierHandler_BadParam",
+			"GET", "/api/v1/supplier/Nan", nil, 404,
+			"invalid supplier ID", true},
+		{"CreateSupplierHandler_OK",
+			"POST", "/api/v1/supplier",
+			strings.NewReader(`{"name":"Pharma"}`),
+			200, "42", false},
+		{"CreateSupplierHandler_BadJSON",
+			"POST", "/api/v1/supplier",
+			strings.NewReader(`{"name":1}`),
+			400, "json decode error", true},
+		{"UpdateSupplierHandler_OK",
+			"PUT", "/api/v1/supplier/1",
+			strings.NewReader(`{"version":1,"name":"Pharma"}`),
+			200, "", false},
+		{"UpdateSupplierHandler_Conflict",
+			"PUT", "/api/v1/supplier/1",
+			strings.NewReader(`{"version":0,"name":"Pharma"}`),
+			409, "version mismatch", false},
+		{"ListPurchaseOrdersBySupplierHandler_OK",
+			"GET", "/api/v1/supplier/1/purchase-orders", nil, 200,
+			`{"items":[{"id":1,"created":"0001-01-01T00:00:00Z","status":"Received","note":""}]}` + "\n", false},
+
+		// Purchase order handlers tests
+		{"GetPurchaseOrderHandler_OK",
+			"GET", "/api/v1/purchase-order/1", nil, 200,
+			`"status":"PartiallyReceived","lines":[{"id":1,"productId":4,"amount":"10.0000","price":"","product":"Prod4","unit":"Unit1","received":"5.0000"}]`, true},
+		{"GetPurchaseOrderHandler_BadParam",
+			"GET", "/api/v1/purchase-order/Nan", nil, 404,
+			"invalid purchaseOrder ID", true},
+		{"CreatePurchaseOrderHandler_OK",
+			"POST", "/api/v1/purchase-order",
+			strings.NewReader(`{"supplierId":1,"lines":[{"productId":4,"amount":"10"}]}`),
+			200, "42", false},
+		{"UpdatePurchaseOrderHandler_Conflict",
+			"PUT", "/api/v1/purchase-order/1",
+			strings.NewReader(`{"version":1,"supplierId":1,"lines":[{"productId":4,"amount":"10"}]}`),
+			409, "is PartiallyReceived", true},
+		{"ReceivePurchaseOrderHandler_OK",
+			"POST", "/api/v1/purchase-order/1/receive",
+			strings.NewReader(`{"version":1,"lines":[{"lineId":1,"amount":"5","batch":"A1","expiry":"2018-01-31T00:00:00Z"}]}`),
+			200, "", false},
+		{"ReceivePurchaseOrderHandler_NoLines",
+			"POST", "/api/v1/purchase-order/1/receive",
+			strings.NewReader(`{"version":1}`),
+			400, "lines is required", true},
+		{"WithdrawPurchaseOrderHandler_OK",
+			"POST", "/api/v1/purchase-order/1/withdraw",
+			strings.NewReader(`{"version":1}`),
+			200, "", false},
+		{"SuggestedReorderHandler_OK",
+			"GET", "/api/v1/purchase-order/suggested-reorder", nil, 200,
+			`{"items":[{"productId":4,"product":"Prod4","unit":"Unit1","minStock":"20.0000","stock":"5.0000","ordered":"5.0000","suggested":"10.0000"}]}` + "\n", false},
+
+		// PDF document handlers tests
+		{"GetRecordPDFHandler_OK",
+			"GET", "/api/v1/record/1/pdf", nil, 200,
+			"%PDF-1.4 1", false},
+		{"GetRecordPDFHandler_BadParam",
+			"GET", "/api/v1/record/Nan/pdf", nil, 404,
+			"invalid record ID", true},
+		{"GetRecordPDFHandler_NotFound",
+			"GET", "/api/v1/record/2/pdf", nil, 404,
+			"not found", false},
+		{"GetPatientHistoryPDFHandler_OK",
+			"GET", "/api/v1/patient/3/history.pdf", nil, 200,
+			"%PDF-1.4 3", false},
+		{"GetPatientHistoryPDFHandler_BadParam",
+			"GET", "/api/v1/patient/Nan/history.pdf", nil, 404,
+			"invalid patient ID", true},
+		{"GetVaccinationCertificatePDFHandler_OK",
+			"GET", "/api/v1/patient/4/vaccination-certificate.pdf", nil, 200,
+			"%PDF-1.4 4", false},
+		{"GetVaccinationCertificatePDFHandler_NotFound",
+			"GET", "/api/v1/patient/2/vaccination-certificate.pdf", nil, 404,
+			"not found", false},
+		{"GetPrescriptionPDFHandler_OK",
+			"GET", "/api/v1/prescription/6/pdf", nil, 200,
+			"%PDF-1.4 6", false},
+		{"GetPrescriptionPDFHandler_BadParam",
+			"GET", "/api/v1/prescription/Nan/pdf", nil, 404,
+			"invalid prescription ID", true},
+		{"GetInvoicePDFHandler_OK",
+			"GET", "/api/v1/invoice/5/pdf", nil, 200,
+			"%PDF-1.4 5", false},
+		{"GetInvoicePDFHandler_BadParam",
+			"GET", "/api/v1/invoice/Nan/pdf", nil, 404,
+			"invalid invoice ID", true},
+
+		// attachment handlers tests, uploads tested by TestUploadAttachmentHandlers
+		{"ListRecordAttachmentsHandler_OK",
+			"GET", "/api/v1/record/5/attachment", nil, 200,
+			`{"items":[{"id":1,"patientId":3,"recordId":5,"fileName":"lab.pdf","contentType":"application/pdf","size":12,"checksum":"abc","creator":"testuser","created":"0001-01-01T00:00:00Z"}]}` + "\n", false},
+		{"ListPatientAttachmentsHandler_OK",
+			"GET", "/api/v1/patient/3/attachment", nil, 200,
+			`{"items":[]}` + "\n", false},
+		{"GetRecordAttachmentHandler_OK",
+			"GET", "/api/v1/record/5/attachment/1", nil, 200,
+			"%PDF-1.4 lab", false},
+		{"GetRecordAttachmentHandler_OtherRecord",
+			"GET", "/api/v1/record/6/attachment/1", nil, 404,
+			"object with id 1 not found", true},
+		{"GetRecordAttachmentHandler_NotFound",
+			"GET", "/api/v1/record/5/attachment/2", nil, 404,
+			"object with id 2 not found", true},
+		{"GetRecordAttachmentHandler_BadParam",
+			"GET", "/api/v1/record/5/attachment/NaN", nil, 404,
+			"invalid attachment ID", true},
+		{"GetPatientAttachmentHandler_OK",
+			"GET", "/api/v1/patient/3/attachment/1", nil, 200,
+			"%PDF-1.4 lab", false},
+		{"CreateRecordAttachmentHandler_NotMultipart",
+			"POST", "/api/v1/record/5/attachment", strings.NewReader("%PDF-1.4 lab"), 400,
+			"multipart/form-data with file expected", true},
 	}
 
 	handler := newHttpHandler()
@@ -537,3 +1623,67 @@ func TestAllHandlers(t *testing.T) {
 		}
 	}
 }
+
+func TestUploadAttachmentHandlers(t *testing.T) {
+	upload := func(url, field, content string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		mw.WriteField("note", "ignored")
+		fw, err := mw.CreateFormFile(field, "lab.pdf")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(content))
+		mw.Close()
+
+		req := httptest.NewRequest("POST", url, &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		req.Header.Add("Authorization", "Bearer: test-token")
+
+		resp := httptest.NewRecorder()
+		newHttpHandler().ServeHTTP(resp, req)
+		return resp
+	}
+
+	var tests = []struct {
+		name    string
+		url     string
+		field   string
+		content string
+		expCode int
+		expBody string
+	}{
+		{"CreateRecordAttachmentHandler_OK", "/api/v1/record/5/attachment", "file", "%PDF-1.4 lab", 200, "5"},
+		{"CreatePatientAttachmentHandler_OK", "/api/v1/patient/3/attachment", "file", "%PDF-1.4 lab", 200, "3"},
+		{"CreateRecordAttachmentHandler_Unsupported", "/api/v1/record/5/attachment", "file", "plain", 415,
+			"content type text/plain is not supported"},
+		{"CreateRecordAttachmentHandler_NoFile", "/api/v1/record/5/attachment", "other", "%PDF-1.4 lab", 400,
+			"form field file is missing"},
+		{"CreateRecordAttachmentHandler_BadParam", "/api/v1/record/NaN/attachment", "file", "%PDF-1.4 lab", 404,
+			"invalid record ID"},
+	}
+
+	for _, tt := range tests {
+		resp := upload(tt.url, tt.field, tt.content)
+		if tt.expCode != resp.Code {
+			t.Fatalf("%s failed. Expected return code %d but was %d",
+				tt.name, tt.expCode, resp.Code)
+		}
+		if !strings.Contains(resp.Body.String(), tt.expBody) {
+			t.Fatalf("%s failed. Expected \n--%s--\n but was \n--%s--\n",
+				tt.name, tt.expBody, resp.Body.String())
+		}
+	}
+}
+
+func TestDisabledUserToken(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/v1/search?q=test", nil)
+	req.Header.Add("Authorization", "Bearer: disabled-token")
+
+	resp := httptest.NewRecorder()
+	newHttpHandler().ServeHTTP(resp, req)
+
+	if resp.Code != 401 {
+		t.Fatalf("expected return code 401 but was %d", resp.Code)
+	}
+}
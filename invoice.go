@@ -0,0 +1,116 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package lara
+
+import (
+	"context"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// INVOICE MANAGEMENT SERVICE
+
+// Clinic is JSON encoded identification of the clinic, used as invoice's supplier
+type Clinic struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	IC      string `json:"IC"`
+	DIC     string `json:"DIC"`
+	ICDPH   string `json:"ICDPH"`
+	IBAN    string `json:"IBAN"`
+	Phone   string `json:"phone"`
+	Email   string `json:"email"`
+}
+
+// InvoiceCustomer is JSON encoded owner's data as printed on invoice
+type InvoiceCustomer struct {
+	Name    string `json:"name"` // formatted owner name (first, last, title)
+	Address string `json:"address"`
+	IC      string `json:"IC"`
+	DIC     string `json:"DIC"`
+	ICDPH   string `json:"ICDPH"`
+}
+
+// InvoiceLine is JSON encoded invoice line copied from record's item
+type InvoiceLine struct {
+	RecordID     uint64         `json:"recordId"`
+	ProductID    uint64         `json:"productId"`
+	Product      string         `json:"product"`
+	Unit         string         `json:"unit"`
+	PLU          string         `json:"plu"`
+	ProductPrice string         `json:"productPrice"`
+	Amount       string         `json:"amount"`
+	ItemPrice    string         `json:"itemPrice"` // gross, including VAT
+	ItemType     RecordItemType `json:"itemType"`
+	VATRate      string         `json:"vatRate"` // percent
+	ItemNet      string         `json:"itemNet"`
+	ItemVAT      string         `json:"itemVat"`
+}
+
+// GetInvoice is JSON encoded retrievable invoice data. Issued invoice is
+// immutable, supplier's and customer's data are copied at the time of issue.
+type GetInvoice struct {
+	ID           uint64          `json:"id"`
+	Number       string          `json:"number"` // yearly series number, YYYYNNNNNN
+	OwnerID      uint64          `json:"ownerId"`
+	IssueDate    time.Time       `json:"issueDate"`
+	DeliveryDate time.Time       `json:"deliveryDate"`
+	DueDate      time.Time       `json:"dueDate"`
+	Supplier     Clinic          `json:"supplier"`
+	Customer     InvoiceCustomer `json:"customer"`
+	Lines        []InvoiceLine   `json:"lines"`
+	Total        string          `json:"total"` // gross, including VAT
+	TotalNet     string          `json:"totalNet"`
+	TotalVAT     string          `json:"totalVat"`
+	VAT          []VATBreakdown  `json:"vat"` // totals by VAT rate
+	Creator      string          `json:"creator"`
+	Created      time.Time       `json:"created"`
+}
+
+// CreateInvoice is JSON encoded create invoice data. All records have to
+// belong to owner's patients and must not be billed yet.
+type CreateInvoice struct {
+	OwnerID      uint64    `json:"ownerId"`
+	RecordIDs    []uint64  `json:"recordIds"`
+	DeliveryDate time.Time `json:"deliveryDate"` // latest record's date if empty
+	DueDate      time.Time `json:"dueDate"`      // default due period from issue date if empty
+}
+
+// OwnersInvoice is JSON encoded owner's invoice data
+type OwnersInvoice struct {
+	ID        uint64    `json:"id"`
+	Number    string    `json:"number"`
+	IssueDate time.Time `json:"issueDate"`
+	DueDate   time.Time `json:"dueDate"`
+	Total     string    `json:"total"`
+}
+
+// OwnersInvoiceList is JSON encoded list of owner's invoices
+type OwnersInvoiceList struct {
+	Items []OwnersInvoice `json:"items"`
+}
+
+// InvoiceService issues invoices from owner's records
+type InvoiceService interface {
+	Get(ctx context.Context, id uint64) (*GetInvoice, error)
+	// Create issues new invoice and marks it's records as billed
+	Create(ctx context.Context, i *CreateInvoice) (uint64, error)
+	ListByOwner(ctx context.Context, ownerID uint64) (*OwnersInvoiceList, error)
+}
@@ -108,15 +108,60 @@ type NewRecord struct {
 	Text   string       `json:"text"`
 	Billed bool         `json:"billed"`
 	Items  []RecordItem `json:"items"`
+	ClinicalData
 }
 
-// RecordItem is JSON encoded data od record's item containing all writable data
+// ClinicalData is JSON encoded structured part of record. Free text of
+// record is kept in Text, structured fields are optional.
+type ClinicalData struct {
+	Subjective   string   `json:"subjective"`   // history, owner's observations
+	Objective    string   `json:"objective"`    // examination findings
+	Assessment   string   `json:"assessment"`   // clinical assessment
+	Plan         string   `json:"plan"`         // treatment plan
+	Weight       string   `json:"weight"`       // kg, empty if not measured (formatted decimal, precision: 7.3)
+	Temperature  string   `json:"temperature"`  // °C, empty if not measured (formatted decimal, precision: 4.1)
+	HeartRate    uint64   `json:"heartRate"`    // beats per minute, 0 if not measured
+	DiagnosisIDs []uint64 `json:"diagnosisIds"` // coded diagnoses from diagnosis LOV
+}
+
+// RecordItem is JSON encoded data od record's item containing all writable data.
+// Prices include VAT.
 type RecordItem struct {
 	ProductID    uint64         `json:"productId"`
-	ProductPrice string         `json:"productPrice"`
+	ProductPrice string         `json:"productPrice"` // product's price at record's date if empty
 	Amount       string         `json:"amount"`
-	ItemPrice    string         `json:"itemPrice"`
+	ItemPrice    string         `json:"itemPrice"` // productPrice * amount if empty
 	ItemType     RecordItemType `json:"itemType"`
+	VATRate      string         `json:"vatRate"` // percent, product's VAT rate at record's date if empty
+	BatchID      uint64         `json:"batchId"` // dispensed stock batch, 0 if not tracked
+}
+
+// MergeOwners is JSON encoded request to merge duplicate owners into survivor
+type MergeOwners struct {
+	Version    uint64      `json:"version"`    // survivor's version
+	Duplicates []Versioned `json:"duplicates"` // merged owners with their versions
+}
+
+// DuplicateCandidate is JSON encoded owner of possibly duplicate pair
+type DuplicateCandidate struct {
+	Versioned
+	Name    string `json:"name"`    // formatted owner name (first, last, title)
+	Address string `json:"address"` // owner's address
+	Phone   string `json:"phone"`   // owner's phones, comma separated
+}
+
+// DuplicateOwners is JSON encoded pair of owners likely entered twice
+type DuplicateOwners struct {
+	Owner1  DuplicateCandidate `json:"owner1"`
+	Owner2  DuplicateCandidate `json:"owner2"`
+	Score   int                `json:"score"`   // similarity, higher is more likely duplicate
+	Reasons []string           `json:"reasons"` // similar data: "name", "phone", "address"
+}
+
+// DuplicateOwnersList is JSON encoded list of duplicate candidates
+type DuplicateOwnersList struct {
+	Total int               `json:"total"`
+	Items []DuplicateOwners `json:"items"`
 }
 
 // OwnerService manages owners
@@ -124,6 +169,13 @@ type OwnerService interface {
 	Get(ctx context.Context, id uint64) (*GetOwner, error)
 	Update(ctx context.Context, id uint64, o *UpdateOwner) error
 	Create(ctx context.Context, o *CreateOwner) (uint64, error)
+	// Merge merges duplicates into survivor owner identified by id. Duplicates'
+	// patients, invoices and notifications are moved to survivor, survivor gets
+	// newest contact data and duplicates are deleted. Merge is recorded.
+	Merge(ctx context.Context, id uint64, m *MergeOwners) error
+	// Duplicates lists pairs of owners with similar name, phone or address,
+	// most similar first. The only sort key is "score".
+	Duplicates(ctx context.Context, p Page) (*DuplicateOwnersList, error)
 }
 
 // -----------------------------------------------------------------------------
@@ -144,12 +196,17 @@ type GetPatient struct {
 	Versioned
 	CreatorModifier
 	Patient
-	Dead    bool             `json:"dead"`
-	Species string           `json:"species"`
-	Breed   string           `json:"breed"`
-	Gender  string           `json:"gender"`
-	Records []PatientsRecord `json:"records"`
-	Tags    []PatientsTag    `json:"tags"`
+	OwnerID      uint64                `json:"ownerId"`
+	Dead         bool                  `json:"dead"`
+	Species      string                `json:"species"`
+	Breed        string                `json:"breed"`
+	Gender       string                `json:"gender"`
+	Weight       string                `json:"weight"`     // latest measured weight in kg, empty if never measured
+	WeightDate   time.Time             `json:"weightDate"` // date of record with latest weight
+	Records      []PatientsRecord      `json:"records"`
+	Tags         []PatientsTag         `json:"tags"`
+	Vaccinations []PatientsVaccination `json:"vaccinations"`
+	Transfers    []PatientsTransfer    `json:"transfers"` // ownership history, newest first
 }
 
 // PatientsRecord is JSON encoded patient's record data
@@ -161,9 +218,10 @@ type PatientsRecord struct {
 
 // PatientsTag is JSON encoded patient's tag data
 type PatientsTag struct {
-	ID    uint64 `json:"id"`
-	Type  string `json:"type"`
-	Value string `json:"value"`
+	ID     uint64 `json:"id"`
+	Type   string `json:"type"`
+	Value  string `json:"value"`
+	Active bool   `json:"active"`
 }
 
 // CreatePatient is JSON encoded create patient data
@@ -179,11 +237,33 @@ type UpdatePatient struct {
 	Dead bool `json:"dead"`
 }
 
+// TransferPatient is JSON encoded request to transfer patient to new owner
+type TransferPatient struct {
+	Version uint64    `json:"version"`
+	OwnerID uint64    `json:"ownerId"` // new owner
+	Date    time.Time `json:"date"`    // transfer date, today if empty
+	Reason  string    `json:"reason"`  // e.g. sold, rehomed
+}
+
+// PatientsTransfer is JSON encoded patient's ownership transfer
+type PatientsTransfer struct {
+	FromOwnerID uint64    `json:"fromOwnerId"`
+	FromOwner   string    `json:"fromOwner"` // formatted previous owner's name
+	ToOwnerID   uint64    `json:"toOwnerId"`
+	ToOwner     string    `json:"toOwner"` // formatted new owner's name
+	Date        time.Time `json:"date"`
+	Reason      string    `json:"reason"`
+	Creator     string    `json:"creator"`
+}
+
 // PatientService manages patients
 type PatientService interface {
 	Get(ctx context.Context, id uint64) (*GetPatient, error)
 	Update(ctx context.Context, id uint64, p *UpdatePatient) error
 	Create(ctx context.Context, p *CreatePatient) (uint64, error)
+	// Transfer moves patient to new owner. Previous owner, date and reason
+	// are kept in patient's ownership history.
+	Transfer(ctx context.Context, id uint64, t *TransferPatient) error
 }
 
 // -----------------------------------------------------------------------------
@@ -229,20 +309,36 @@ func (i *RecordItemType) UnmarshalJSON(data []byte) error {
 type GetRecord struct {
 	Versioned
 	CreatorModifier
-	Date   time.Time       `json:"date"`
-	Text   string          `json:"text"`
-	Billed bool            `json:"billed"`
-	Items  []GetRecordItem `json:"items"`
-	Total  string          `json:"total"`
+	PatientID uint64          `json:"patientId"`
+	Date      time.Time       `json:"date"`
+	Text      string          `json:"text"`
+	Billed    bool            `json:"billed"`
+	Items     []GetRecordItem `json:"items"`
+	Total     string          `json:"total"` // gross, including VAT
+	TotalNet  string          `json:"totalNet"`
+	TotalVAT  string          `json:"totalVat"`
+	ClinicalData
+	Diagnoses []Diagnosis `json:"diagnoses"`
+}
+
+// Diagnosis is JSON encoded coded diagnosis
+type Diagnosis struct {
+	ID   uint64 `json:"id"`
+	Code string `json:"code"`
+	Name string `json:"name"`
 }
 
 // GetRecordItem is JSON encoded retrievable record item data
 type GetRecordItem struct {
 	ID uint64 `json:"id"`
 	RecordItem
-	Product string `json:"product"`
-	Unit    string `json:"unit"`
-	PLU     string `json:"plu"`
+	ItemNet string    `json:"itemNet"` // ItemPrice without VAT
+	ItemVAT string    `json:"itemVat"` // VAT included in ItemPrice
+	Product string    `json:"product"`
+	Unit    string    `json:"unit"`
+	PLU     string    `json:"plu"`
+	Batch   string    `json:"batch"`  // batch number, empty if not tracked
+	Expiry  time.Time `json:"expiry"` // batch's expiry date
 }
 
 // CreateRecord is JSON encoded create record data
@@ -256,6 +352,55 @@ type UpdateRecord struct {
 	Version uint64       `json:"version"`
 	Text    string       `json:"text"`
 	Items   []RecordItem `json:"items"`
+	ClinicalData
+}
+
+// PatientDiagnosis is JSON encoded diagnosis of patient's record
+type PatientDiagnosis struct {
+	Diagnosis
+	RecordID uint64    `json:"recordId"`
+	Date     time.Time `json:"date"` // record's date
+}
+
+// PatientDiagnosisList is JSON encoded list of patient's diagnoses, newest first
+type PatientDiagnosisList struct {
+	Items []PatientDiagnosis `json:"items"`
+}
+
+// DiagnosedPatient is JSON encoded patient's record with diagnosis
+type DiagnosedPatient struct {
+	PatientID uint64    `json:"patientId"`
+	Patient   string    `json:"patient"`
+	OwnerID   uint64    `json:"ownerId"`
+	Owner     string    `json:"owner"` // formatted owner name (first, last, title)
+	RecordID  uint64    `json:"recordId"`
+	Date      time.Time `json:"date"` // record's date
+}
+
+// DiagnosedPatientList is JSON encoded list of records with diagnosis
+type DiagnosedPatientList struct {
+	Total int                `json:"total"`
+	Items []DiagnosedPatient `json:"items"`
+}
+
+// VitalsPoint is JSON encoded measurement taken on record's date
+type VitalsPoint struct {
+	RecordID uint64    `json:"recordId"`
+	Date     time.Time `json:"date"`
+	Value    string    `json:"value"` // formatted decimal
+}
+
+// VitalsSeries is JSON encoded series of vital sign's measurements, oldest first
+type VitalsSeries struct {
+	Unit   string        `json:"unit"`
+	Points []VitalsPoint `json:"points"`
+}
+
+// PatientVitals is JSON encoded patient's vitals timeline suitable for charting
+type PatientVitals struct {
+	Weight      VitalsSeries `json:"weight"`      // kg
+	Temperature VitalsSeries `json:"temperature"` // °C
+	HeartRate   VitalsSeries `json:"heartRate"`   // beats per minute
 }
 
 // RecordService manages records
@@ -263,27 +408,77 @@ type RecordService interface {
 	Get(ctx context.Context, id uint64) (*GetRecord, error)
 	Update(ctx context.Context, id uint64, r *UpdateRecord) error
 	Create(ctx context.Context, r *CreateRecord) (uint64, error)
+	// ListPatientDiagnoses lists diagnoses of all patient's records
+	ListPatientDiagnoses(ctx context.Context, patientID uint64) (*PatientDiagnosisList, error)
+	// ListDiagnosedPatients lists records with diagnosis. Sort keys are
+	// "date" (record's date) and "patient" (patient's name), default is "-date".
+	ListDiagnosedPatients(ctx context.Context, diagnosisID uint64, p Page) (*DiagnosedPatientList, error)
+	// GetPatientVitals returns timeline of vitals measured on patient's records
+	GetPatientVitals(ctx context.Context, patientID uint64) (*PatientVitals, error)
 }
 
 // -----------------------------------------------------------------------------
 // RECORD SEARCH SERVICE
 
+// Search pagination limits
+const (
+	DefaultPageLimit = 30  // page size used if not requested
+	MaxPageLimit     = 500 // largest allowed page size
+)
+
+// Page selects ordered part of search result. Sort is search specific sort
+// key, prefixed by "-" for descending order. Default order is used if Sort is
+// empty.
+type Page struct {
+	Offset int    // number of skipped results
+	Limit  int    // max. number of returned results, DefaultPageLimit if 0
+	Sort   string // sort key
+}
+
+// SearchRequest is owner search request. Sort keys are "relevance" (default,
+// best match first) and "name" (owner's last and first name).
+type SearchRequest struct {
+	Query string `json:"query"`
+	Page  `json:"-"`
+}
+
 // SearchResult is JSON encoded search result structure
 type SearchResult struct {
 	Total   int            `json:"total"`
 	Records []SearchRecord `json:"records"`
 }
 
-// SearchRecord is JSON encoded search record (owner)
+// SearchRecord is JSON encoded search record (owner) with owner's best
+// matching field
 type SearchRecord struct {
-	ID      uint64 `json:"id"`      // DB primary key
-	Name    string `json:"name"`    // formatted owner name (first, last, title)
-	Address string `json:"address"` // owner's address
+	ID        uint64      `json:"id"`        // DB primary key
+	Name      string      `json:"name"`      // formatted owner name (first, last, title)
+	Address   string      `json:"address"`   // owner's address
+	Field     SearchField `json:"field"`     // field matching query
+	Match     string      `json:"match"`     // matching field's value
+	PatientID uint64      `json:"patientId"` // patient hit by Patient or Tag field match, 0 otherwise
+	Patient   string      `json:"patient"`   // name of patient hit
 }
 
+// SearchField is name of field matched by search
+type SearchField string
+
+// Searched fields
+const (
+	OwnerNameField  SearchField = "name" // owner's first and last name
+	OwnerPhoneField SearchField = "phone"
+	OwnerEmailField SearchField = "email"
+	OwnerICField    SearchField = "IC"
+	PatientField    SearchField = "patient" // patient's name
+	TagField        SearchField = "tag"     // patient's tag value
+)
+
 // SearchService searches owners
 type SearchService interface {
-	Search(ctx context.Context, q string) (*SearchResult, error)
+	// Search finds owners whose name, phone, email or IC or whose patient's
+	// name or tag value matches all words of query (prefix, diacritics
+	// insensitive). Owners are ordered by rank of best matching field.
+	Search(ctx context.Context, r *SearchRequest) (*SearchResult, error)
 }
 
 // -----------------------------------------------------------------------------
@@ -325,6 +520,60 @@ type BreedService interface {
 	GetAllBreedsBySpecies(ctx context.Context, speciesID uint64) (*LOVItemList, error)
 }
 
+// DiagnosisService manages coded diagnoses. LOV item's name is diagnosis' code
+// followed by name.
+type DiagnosisService interface {
+	GetAllDiagnoses(ctx context.Context) (*LOVItemList, error)
+}
+
+// Phrase is JSON encoded phrase template for record's text. Text may contain
+// placeholders {patient}, {species}, {breed}, {gender}, {owner} and {date}
+// (today) expanded by PhraseService.ExpandPhrase.
+type Phrase struct {
+	Name     string `json:"name"`
+	Text     string `json:"text"`
+	Personal bool   `json:"personal"` // visible to its author only, clinic-wide otherwise
+}
+
+// GetPhrase is JSON encoded phrase template
+type GetPhrase struct {
+	Versioned
+	Phrase
+	Author string `json:"author"` // login of personal phrase's author, empty if clinic-wide
+}
+
+// UpdatePhrase is JSON encoded update phrase template data. Author may make
+// personal phrase clinic-wide, clinic-wide phrase can't be made personal.
+type UpdatePhrase struct {
+	Version uint64 `json:"version"`
+	Phrase
+}
+
+// PhraseList is JSON encoded list of phrase templates
+type PhraseList struct {
+	Items []GetPhrase `json:"items"`
+}
+
+// ExpandedPhrase is JSON encoded phrase template's text with placeholders
+// replaced by patient's data
+type ExpandedPhrase struct {
+	Text string `json:"text"`
+}
+
+// PhraseService manages clinic-wide and current user's personal phrase
+// templates. Other users' personal phrases are not found.
+type PhraseService interface {
+	// SearchPhrases lists phrases with name or text containing query, all
+	// phrases if query is empty
+	SearchPhrases(ctx context.Context, query string) (*PhraseList, error)
+	GetPhrase(ctx context.Context, id uint64) (*GetPhrase, error)
+	CreatePhrase(ctx context.Context, p *Phrase) (uint64, error)
+	UpdatePhrase(ctx context.Context, id uint64, p *UpdatePhrase) error
+	// ExpandPhrase expands phrase's placeholders with data of patient
+	// identified by patientID. Only {date} is expanded if patientID is 0.
+	ExpandPhrase(ctx context.Context, id, patientID uint64) (*ExpandedPhrase, error)
+}
+
 // CityStreet is JSON encoded city or street structure
 type CityStreet struct {
 	ID   uint64 `json:"id"`
@@ -338,10 +587,17 @@ type CityStreetList struct {
 	Items []CityStreet `json:"items"`
 }
 
+// AddressSearchRequest is city or street search request. Sort keys are
+// "name" (default) and "zip".
+type AddressSearchRequest struct {
+	Query string `json:"query"`
+	Page  `json:"-"`
+}
+
 // AddressService manages addresses
 type AddressService interface {
-	SearchCity(ctx context.Context, query string) (*CityStreetList, error)
-	SearchStreetForCity(ctx context.Context, cityID uint64, query string) (*CityStreetList, error)
+	SearchCity(ctx context.Context, r *AddressSearchRequest) (*CityStreetList, error)
+	SearchStreetForCity(ctx context.Context, cityID uint64, r *AddressSearchRequest) (*CityStreetList, error)
 }
 
 // -----------------------------------------------------------------------------
@@ -349,10 +605,11 @@ type AddressService interface {
 
 // Product is JSON encoded product structure
 type Product struct {
-	ID    uint64 `json:"id"`    // DB primary key
-	Name  string `json:"name"`  // product's name
-	Unit  string `json:"unit"`  // product's unit of measure
-	Price string `json:"price"` // product's price (formatted decimal, precision: 8.2)
+	ID      uint64 `json:"id"`      // DB primary key
+	Name    string `json:"name"`    // product's name
+	Unit    string `json:"unit"`    // product's unit of measure
+	Price   string `json:"price"`   // product's price including VAT (formatted decimal, precision: 8.2)
+	VATRate string `json:"vatRate"` // VAT rate in percent (formatted decimal, precision: 4.2)
 }
 
 // ProductSearchResult is JSON encoded search product result structure
@@ -361,15 +618,78 @@ type ProductSearchResult struct {
 	Products []Product `json:"products"`
 }
 
-// ProductSearchRequest is JSON encoded search product request structure
+// ProductSearchRequest is JSON encoded search product request structure.
+// Sort keys are "name" (default) and "price".
 type ProductSearchRequest struct {
 	ValidTo time.Time `json:"validTo"`
 	Query   string    `json:"query"`
+	Page    `json:"-"`
+}
+
+// ProductData is JSON encoded editable product data
+type ProductData struct {
+	Name     string `json:"name"`
+	UnitID   uint64 `json:"unitId"`
+	PLU      string `json:"plu"`      // price look-up code, numeric
+	Price    string `json:"price"`    // including VAT (formatted decimal, precision: 8.2)
+	VATRate  string `json:"vatRate"`  // percent, 0 if empty (formatted decimal, precision: 4.2)
+	MinStock string `json:"minStock"` // reorder level, empty if not reordered (formatted decimal, precision: 10.4)
+	// Controlled substance, its movements are logged in controlled-drug register
+	Controlled bool `json:"controlled"`
+}
+
+// GetProduct is JSON encoded retrievable product data
+type GetProduct struct {
+	Versioned
+	CreatorModifier
+	ProductData
+	Unit    string    `json:"unit"`
+	ValidTo time.Time `json:"validTo"` // last day product can be used, zero if not retired
+}
+
+// CreateProduct is JSON encoded create product data
+type CreateProduct struct {
+	ProductData
+}
+
+// UpdateProduct is JSON encoded update product data. Changed price or VAT rate
+// is valid from today, previous one is kept in product's price history.
+type UpdateProduct struct {
+	Version uint64 `json:"version"`
+	ProductData
+}
+
+// RetireProduct is JSON encoded request to stop offering product. Retired
+// product is not found by search, but records using it are kept intact.
+type RetireProduct struct {
+	Version uint64    `json:"version"`
+	ValidTo time.Time `json:"validTo"` // last day product can be used, today if empty
+}
+
+// ProductPrice is JSON encoded product's price valid in date interval
+type ProductPrice struct {
+	Price     string    `json:"price"`     // including VAT (formatted decimal, precision: 8.2)
+	VATRate   string    `json:"vatRate"`   // percent (formatted decimal, precision: 4.2)
+	ValidFrom time.Time `json:"validFrom"` // zero for product's first price
+	ValidTo   time.Time `json:"validTo"`   // zero for current price
+	CreatorModifier
+}
+
+// ProductPriceList is JSON encoded product's price timeline, oldest first
+type ProductPriceList struct {
+	Items []ProductPrice `json:"items"`
 }
 
 // ProductService manages products
 type ProductService interface {
 	Search(ctx context.Context, p *ProductSearchRequest) (*ProductSearchResult, error)
+	Get(ctx context.Context, id uint64) (*GetProduct, error)
+	Create(ctx context.Context, p *CreateProduct) (uint64, error)
+	Update(ctx context.Context, id uint64, p *UpdateProduct) error
+	Retire(ctx context.Context, id uint64, r *RetireProduct) error
+	// PriceAt returns product's price valid at date
+	PriceAt(ctx context.Context, id uint64, date time.Time) (*ProductPrice, error)
+	PriceHistory(ctx context.Context, id uint64) (*ProductPriceList, error)
 }
 
 // -----------------------------------------------------------------------------
@@ -383,13 +703,26 @@ type ReportRequest struct {
 
 // IncomeStatistics is JSON encoded income statistics report
 type IncomeStatistics struct {
-	Records         int    `json:"records"`         // count
-	Income          string `json:"income"`          // currency (formatted decimal, precision: 8.2)
-	IncomeBilled    string `json:"incomeBilled"`    // currency
-	IncomeNotBilled string `json:"incomeNotBilled"` // currency
+	Records         int            `json:"records"`         // count
+	Income          string         `json:"income"`          // currency (formatted decimal, precision: 8.2)
+	IncomeBilled    string         `json:"incomeBilled"`    // currency
+	IncomeNotBilled string         `json:"incomeNotBilled"` // currency
+	VAT             []VATBreakdown `json:"vat"`             // income by VAT rate
+}
+
+// VATBreakdown is JSON encoded sum of amounts with the same VAT rate
+type VATBreakdown struct {
+	Rate  string `json:"rate"` // percent
+	Net   string `json:"net"`
+	VAT   string `json:"vat"`
+	Gross string `json:"gross"`
 }
 
 // ReportService generates data for various reports
 type ReportService interface {
 	GetIncomeStatistics(ctx context.Context, r *ReportRequest) (*IncomeStatistics, error)
+	// GetControlledDrugRegister lists movements of controlled products with
+	// running balance and records whose dispensed controlled drugs don't
+	// match their prescriptions
+	GetControlledDrugRegister(ctx context.Context, r *ReportRequest) (*ControlledDrugReport, error)
 }
@@ -0,0 +1,119 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package lara_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jkusniar/lara"
+)
+
+func TestDecodeMicrochip(t *testing.T) {
+	tests := []struct {
+		code    string
+		valid   bool
+		country string
+		maker   string
+	}{
+		{"703000012345678", true, "Slovakia", ""},
+		{"981098100563211", true, "", "Datamars"},
+		{"950000012345678", true, "", ""},
+		{"70300001234567", false, "", ""},
+		{"70300001234567a", false, "", ""},
+		{"000000012345678", false, "", ""},
+		{"999000012345678", false, "", ""},
+		{"703300000000000", false, "", ""}, // national code over 38 bits
+	}
+	for _, tt := range tests {
+		m, err := lara.DecodeMicrochip(tt.code)
+		if (err == nil) != tt.valid {
+			t.Fatalf("%s: unexpected error %v", tt.code, err)
+		}
+		if err == nil && (m.Country != tt.country || m.Manufacturer != tt.maker) {
+			t.Fatalf("%s: unexpected result %+v", tt.code, m)
+		}
+	}
+}
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		tagType lara.TagType
+		value   string
+		valid   bool
+		result  string
+	}{
+		{lara.LyssaVirus, " 2017-sk-0007 ", true, "2017-SK-0007"},
+		{lara.LyssaVirus, "2017 - SK - 0007", true, "2017-SK-0007"},
+		{lara.LyssaVirus, "17-SK-0007", false, ""},
+		{lara.LyssaVirus, "2017-SK-007", false, ""},
+		{lara.Tattoo, "  ab   1234 ", true, "AB 1234"},
+		{lara.Tattoo, "12/a.b-c", true, "12/A.B-C"},
+		{lara.Tattoo, "", false, ""},
+		{lara.Tattoo, "-AB", false, ""},
+		{lara.Tattoo, strings.Repeat("A", 31), false, ""},
+		{lara.PetPassport, "sk 012 345 678", true, "SK012345678"},
+		{lara.PetPassport, "SK12345678", false, ""},
+		{lara.PetPassport, "012345678SK", false, ""},
+		{lara.RFID, "703 000 012 345 678", true, "703000012345678"},
+		{lara.RFID, "999000012345678", false, ""},
+		{lara.RFID, "SK012345678", false, ""},
+		{lara.TagType(100), "  any value ", true, "any value"}, // no validator
+	}
+
+	v := lara.DefaultTagValidators()
+	for _, tt := range tests {
+		n, err := v.Normalize(tt.tagType, tt.value)
+		if (err == nil) != tt.valid {
+			t.Fatalf("%s '%s': unexpected error %v", tt.tagType, tt.value, err)
+		}
+		if err != nil {
+			if ce, ok := err.(interface {
+				Code() int
+			}); !ok || ce.Code() != 400 {
+				t.Fatalf("%s '%s': expected error code 400, but was %+v", tt.tagType, tt.value, err)
+			}
+			continue
+		}
+		if n != tt.result {
+			t.Fatalf("%s '%s': expected '%s', but was '%s'", tt.tagType, tt.value, tt.result, n)
+		}
+	}
+}
+
+func TestTagCandidates(t *testing.T) {
+	tests := []struct {
+		value  string
+		result []string
+	}{
+		{"sk012345678", []string{"sk012345678", "SK012345678"}},
+		{"703000012345678", []string{"703000012345678"}},
+		{"2017-sk-0007", []string{"2017-sk-0007", "2017-SK-0007"}},
+		{"703 000 012 345 678", []string{"703 000 012 345 678", "703000012345678"}},
+	}
+
+	v := lara.DefaultTagValidators()
+	for _, tt := range tests {
+		if c := v.Candidates(tt.value); !reflect.DeepEqual(c, tt.result) {
+			t.Fatalf("%s: expected %v, but was %v", tt.value, tt.result, c)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package lara
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// -----------------------------------------------------------------------------
+// ISO 11784 MICROCHIP CODES
+
+// Microchip is JSON encoded ISO 11784 animal identification code decoded
+// from 15 digit RFID tag value. First 3 digits are either ISO 3166 numeric
+// country code (001-899) or ICAR manufacturer code (900-998), remaining 12
+// digits are national identification code.
+type Microchip struct {
+	Code             string `json:"code"`
+	CountryCode      int    `json:"countryCode"`      // 0 if code is manufacturer's
+	Country          string `json:"country"`          // empty if country is not known
+	ManufacturerCode int    `json:"manufacturerCode"` // 0 if code is country's
+	Manufacturer     string `json:"manufacturer"`     // empty if manufacturer is not known
+	NationalID       string `json:"nationalId"`
+}
+
+// maxNationalID is the largest national code fitting 38 bits of FDX-B frame
+const maxNationalID = 1<<38 - 1
+
+// countries are ISO 3166 numeric codes of countries most often found on
+// chips of patients
+var countries = map[int]string{
+	40:  "Austria",
+	56:  "Belgium",
+	100: "Bulgaria",
+	191: "Croatia",
+	196: "Cyprus",
+	203: "Czech Republic",
+	208: "Denmark",
+	233: "Estonia",
+	246: "Finland",
+	250: "France",
+	276: "Germany",
+	300: "Greece",
+	348: "Hungary",
+	372: "Ireland",
+	380: "Italy",
+	428: "Latvia",
+	440: "Lithuania",
+	442: "Luxembourg",
+	470: "Malta",
+	528: "Netherlands",
+	578: "Norway",
+	616: "Poland",
+	620: "Portugal",
+	642: "Romania",
+	703: "Slovakia",
+	705: "Slovenia",
+	724: "Spain",
+	752: "Sweden",
+	756: "Switzerland",
+	804: "Ukraine",
+	826: "United Kingdom",
+	840: "United States",
+}
+
+// manufacturers are ICAR codes of common chip manufacturers
+var manufacturers = map[int]string{
+	900: "Shared manufacturer code",
+	941: "Felixcan",
+	956: "Trovan",
+	977: "AVID",
+	981: "Datamars",
+	982: "Allflex",
+	985: "Destron Fearing",
+}
+
+// DecodeMicrochip decodes 15 digit ISO 11784 code. Unknown country or
+// manufacturer is not an error, only its name is left empty. Test
+// transponders (code 999) can't identify animals and are rejected.
+func DecodeMicrochip(code string) (*Microchip, error) {
+	if len(code) != 15 {
+		return nil, fmt.Errorf("code has %d digits, expected 15", len(code))
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return nil, fmt.Errorf("code contains non-digit '%c'", c)
+		}
+	}
+
+	prefix, _ := strconv.Atoi(code[:3])
+	national, _ := strconv.ParseUint(code[3:], 10, 64)
+	if national > maxNationalID {
+		return nil, fmt.Errorf("national code %s exceeds %d", code[3:], uint64(maxNationalID))
+	}
+
+	m := Microchip{Code: code, NationalID: code[3:]}
+	switch {
+	case prefix == 0:
+		return nil, fmt.Errorf("country code 000 is not valid")
+	case prefix < 900:
+		m.CountryCode = prefix
+		m.Country = countries[prefix]
+	case prefix < 999:
+		m.ManufacturerCode = prefix
+		m.Manufacturer = manufacturers[prefix]
+	default:
+		return nil, fmt.Errorf("code 999 is reserved for test transponders")
+	}
+
+	return &m, nil
+}
@@ -27,21 +27,21 @@ import (
 
 // AddressService is mock implementation of lara.AddressService
 type AddressService struct {
-	SearchCityFn      func(query string) (*lara.CityStreetList, error)
+	SearchCityFn      func(r *lara.AddressSearchRequest) (*lara.CityStreetList, error)
 	SearchCityInvoked bool
 
-	SearchStreetForCityFn      func(cityID uint64, query string) (*lara.CityStreetList, error)
+	SearchStreetForCityFn      func(cityID uint64, r *lara.AddressSearchRequest) (*lara.CityStreetList, error)
 	SearchStreetForCityInvoked bool
 }
 
 // SearchCity mock implementation
-func (s *AddressService) SearchCity(ctx context.Context, query string) (*lara.CityStreetList, error) {
+func (s *AddressService) SearchCity(ctx context.Context, r *lara.AddressSearchRequest) (*lara.CityStreetList, error) {
 	s.SearchCityInvoked = true
-	return s.SearchCityFn(query)
+	return s.SearchCityFn(r)
 }
 
 // SearchStreetForCity mock implementation
-func (s *AddressService) SearchStreetForCity(ctx context.Context, cityID uint64, query string) (*lara.CityStreetList, error) {
+func (s *AddressService) SearchStreetForCity(ctx context.Context, cityID uint64, r *lara.AddressSearchRequest) (*lara.CityStreetList, error) {
 	s.SearchStreetForCityInvoked = true
-	return s.SearchStreetForCityFn(cityID, query)
+	return s.SearchStreetForCityFn(cityID, r)
 }
@@ -0,0 +1,93 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/jkusniar/lara"
+)
+
+// AppointmentService is mock implementation of lara.AppointmentService
+type AppointmentService struct {
+	GetFn      func(id uint64) (*lara.GetAppointment, error)
+	GetInvoked bool
+
+	CreateFn      func(a *lara.CreateAppointment) (uint64, error)
+	CreateInvoked bool
+
+	RescheduleFn      func(id uint64, a *lara.RescheduleAppointment) error
+	RescheduleInvoked bool
+
+	CancelFn      func(id uint64, c *lara.CancelAppointment) error
+	CancelInvoked bool
+
+	CheckInFn      func(id uint64, c *lara.CheckInAppointment) (uint64, error)
+	CheckInInvoked bool
+
+	ListByDayFn      func(day time.Time) (*lara.AppointmentList, error)
+	ListByDayInvoked bool
+
+	ListByVetFn      func(vet string, from, to time.Time) (*lara.AppointmentList, error)
+	ListByVetInvoked bool
+}
+
+// Get mock implementation
+func (s *AppointmentService) Get(ctx context.Context, id uint64) (*lara.GetAppointment, error) {
+	s.GetInvoked = true
+	return s.GetFn(id)
+}
+
+// Create mock implementation
+func (s *AppointmentService) Create(ctx context.Context, a *lara.CreateAppointment) (uint64, error) {
+	s.CreateInvoked = true
+	return s.CreateFn(a)
+}
+
+// Reschedule mock implementation
+func (s *AppointmentService) Reschedule(ctx context.Context, id uint64, a *lara.RescheduleAppointment) error {
+	s.RescheduleInvoked = true
+	return s.RescheduleFn(id, a)
+}
+
+// Cancel mock implementation
+func (s *AppointmentService) Cancel(ctx context.Context, id uint64, c *lara.CancelAppointment) error {
+	s.CancelInvoked = true
+	return s.CancelFn(id, c)
+}
+
+// CheckIn mock implementation
+func (s *AppointmentService) CheckIn(ctx context.Context, id uint64, c *lara.CheckInAppointment) (uint64, error) {
+	s.CheckInInvoked = true
+	return s.CheckInFn(id, c)
+}
+
+// ListByDay mock implementation
+func (s *AppointmentService) ListByDay(ctx context.Context, day time.Time) (*lara.AppointmentList, error) {
+	s.ListByDayInvoked = true
+	return s.ListByDayFn(day)
+}
+
+// ListByVet mock implementation
+func (s *AppointmentService) ListByVet(ctx context.Context, vet string, from, to time.Time) (*lara.AppointmentList, error) {
+	s.ListByVetInvoked = true
+	return s.ListByVetFn(vet, from, to)
+}
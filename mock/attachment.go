@@ -0,0 +1,66 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mock
+
+import (
+	"context"
+	"io"
+
+	"github.com/jkusniar/lara"
+)
+
+// AttachmentService is mock implementation of lara.AttachmentService
+type AttachmentService struct {
+	CreateFn      func(a *lara.NewAttachment) (uint64, error)
+	CreateInvoked bool
+
+	OpenFn      func(id uint64) (*lara.Attachment, io.ReadCloser, error)
+	OpenInvoked bool
+
+	ListByRecordFn      func(recordID uint64) (*lara.AttachmentList, error)
+	ListByRecordInvoked bool
+
+	ListByPatientFn      func(patientID uint64) (*lara.AttachmentList, error)
+	ListByPatientInvoked bool
+}
+
+// Create mock implementation
+func (s *AttachmentService) Create(ctx context.Context, a *lara.NewAttachment) (uint64, error) {
+	s.CreateInvoked = true
+	return s.CreateFn(a)
+}
+
+// Open mock implementation
+func (s *AttachmentService) Open(ctx context.Context, id uint64) (*lara.Attachment, io.ReadCloser, error) {
+	s.OpenInvoked = true
+	return s.OpenFn(id)
+}
+
+// ListByRecord mock implementation
+func (s *AttachmentService) ListByRecord(ctx context.Context, recordID uint64) (*lara.AttachmentList, error) {
+	s.ListByRecordInvoked = true
+	return s.ListByRecordFn(recordID)
+}
+
+// ListByPatient mock implementation
+func (s *AttachmentService) ListByPatient(ctx context.Context, patientID uint64) (*lara.AttachmentList, error) {
+	s.ListByPatientInvoked = true
+	return s.ListByPatientFn(patientID)
+}
@@ -0,0 +1,70 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mock
+
+import "context"
+
+// DocumentService is mock implementation of lara.DocumentService
+type DocumentService struct {
+	InvoiceFn      func(id uint64) ([]byte, error)
+	InvoiceInvoked bool
+
+	RecordFn      func(id uint64) ([]byte, error)
+	RecordInvoked bool
+
+	PatientHistoryFn      func(patientID uint64) ([]byte, error)
+	PatientHistoryInvoked bool
+
+	VaccinationCertificateFn      func(patientID uint64) ([]byte, error)
+	VaccinationCertificateInvoked bool
+
+	PrescriptionFn      func(id uint64) ([]byte, error)
+	PrescriptionInvoked bool
+}
+
+// Invoice mock implementation
+func (s *DocumentService) Invoice(ctx context.Context, id uint64) ([]byte, error) {
+	s.InvoiceInvoked = true
+	return s.InvoiceFn(id)
+}
+
+// Record mock implementation
+func (s *DocumentService) Record(ctx context.Context, id uint64) ([]byte, error) {
+	s.RecordInvoked = true
+	return s.RecordFn(id)
+}
+
+// PatientHistory mock implementation
+func (s *DocumentService) PatientHistory(ctx context.Context, patientID uint64) ([]byte, error) {
+	s.PatientHistoryInvoked = true
+	return s.PatientHistoryFn(patientID)
+}
+
+// VaccinationCertificate mock implementation
+func (s *DocumentService) VaccinationCertificate(ctx context.Context, patientID uint64) ([]byte, error) {
+	s.VaccinationCertificateInvoked = true
+	return s.VaccinationCertificateFn(patientID)
+}
+
+// Prescription mock implementation
+func (s *DocumentService) Prescription(ctx context.Context, id uint64) ([]byte, error) {
+	s.PrescriptionInvoked = true
+	return s.PrescriptionFn(id)
+}
@@ -0,0 +1,56 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mock
+
+import (
+	"context"
+
+	"github.com/jkusniar/lara"
+)
+
+// InvoiceService is mock implementation of lara.InvoiceService
+type InvoiceService struct {
+	GetFn      func(id uint64) (*lara.GetInvoice, error)
+	GetInvoked bool
+
+	CreateFn      func(i *lara.CreateInvoice) (uint64, error)
+	CreateInvoked bool
+
+	ListByOwnerFn      func(ownerID uint64) (*lara.OwnersInvoiceList, error)
+	ListByOwnerInvoked bool
+}
+
+// Get mock implementation
+func (s *InvoiceService) Get(ctx context.Context, id uint64) (*lara.GetInvoice, error) {
+	s.GetInvoked = true
+	return s.GetFn(id)
+}
+
+// Create mock implementation
+func (s *InvoiceService) Create(ctx context.Context, i *lara.CreateInvoice) (uint64, error) {
+	s.CreateInvoked = true
+	return s.CreateFn(i)
+}
+
+// ListByOwner mock implementation
+func (s *InvoiceService) ListByOwner(ctx context.Context, ownerID uint64) (*lara.OwnersInvoiceList, error) {
+	s.ListByOwnerInvoked = true
+	return s.ListByOwnerFn(ownerID)
+}
@@ -26,7 +26,7 @@ import (
 )
 
 // SimpleLovService is mock implementation of lara.TitleService, lara.UnitService, lara.GenderService,
-// lara.SpeciesService, lara.BreedService
+// lara.SpeciesService, lara.BreedService, lara.DiagnosisService, lara.PhraseService
 type SimpleLovService struct {
 	GetAllTitlesFn      func() (*lara.LOVItemList, error)
 	GetAllTitlesInvoked bool
@@ -42,6 +42,24 @@ type SimpleLovService struct {
 
 	GetAllBreedsFn      func(speciesId uint64) (*lara.LOVItemList, error)
 	GetAllBreedsInvoked bool
+
+	GetAllDiagnosesFn      func() (*lara.LOVItemList, error)
+	GetAllDiagnosesInvoked bool
+
+	SearchPhrasesFn      func(query string) (*lara.PhraseList, error)
+	SearchPhrasesInvoked bool
+
+	GetPhraseFn      func(id uint64) (*lara.GetPhrase, error)
+	GetPhraseInvoked bool
+
+	CreatePhraseFn      func(p *lara.Phrase) (uint64, error)
+	CreatePhraseInvoked bool
+
+	UpdatePhraseFn      func(id uint64, p *lara.UpdatePhrase) error
+	UpdatePhraseInvoked bool
+
+	ExpandPhraseFn      func(id, patientID uint64) (*lara.ExpandedPhrase, error)
+	ExpandPhraseInvoked bool
 }
 
 // GetAllSpecies mock implementation
@@ -73,3 +91,39 @@ func (s *SimpleLovService) GetAllBreedsBySpecies(ctx context.Context, speciesID
 	s.GetAllBreedsInvoked = true
 	return s.GetAllBreedsFn(speciesID)
 }
+
+// GetAllDiagnoses mock implementation
+func (s *SimpleLovService) GetAllDiagnoses(ctx context.Context) (*lara.LOVItemList, error) {
+	s.GetAllDiagnosesInvoked = true
+	return s.GetAllDiagnosesFn()
+}
+
+// SearchPhrases mock implementation
+func (s *SimpleLovService) SearchPhrases(ctx context.Context, query string) (*lara.PhraseList, error) {
+	s.SearchPhrasesInvoked = true
+	return s.SearchPhrasesFn(query)
+}
+
+// GetPhrase mock implementation
+func (s *SimpleLovService) GetPhrase(ctx context.Context, id uint64) (*lara.GetPhrase, error) {
+	s.GetPhraseInvoked = true
+	return s.GetPhraseFn(id)
+}
+
+// CreatePhrase mock implementation
+func (s *SimpleLovService) CreatePhrase(ctx context.Context, p *lara.Phrase) (uint64, error) {
+	s.CreatePhraseInvoked = true
+	return s.CreatePhraseFn(p)
+}
+
+// UpdatePhrase mock implementation
+func (s *SimpleLovService) UpdatePhrase(ctx context.Context, id uint64, p *lara.UpdatePhrase) error {
+	s.UpdatePhraseInvoked = true
+	return s.UpdatePhraseFn(id, p)
+}
+
+// ExpandPhrase mock implementation
+func (s *SimpleLovService) ExpandPhrase(ctx context.Context, id, patientID uint64) (*lara.ExpandedPhrase, error) {
+	s.ExpandPhraseInvoked = true
+	return s.ExpandPhraseFn(id, patientID)
+}
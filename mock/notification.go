@@ -0,0 +1,95 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mock
+
+import (
+	"context"
+
+	"github.com/jkusniar/lara"
+)
+
+// NotificationService is mock implementation of lara.NotificationService
+type NotificationService struct {
+	GetFn      func(id uint64) (*lara.GetNotification, error)
+	GetInvoked bool
+
+	CreateFn      func(n *lara.CreateNotification) (uint64, error)
+	CreateInvoked bool
+}
+
+// Get mock implementation
+func (s *NotificationService) Get(ctx context.Context, id uint64) (*lara.GetNotification, error) {
+	s.GetInvoked = true
+	return s.GetFn(id)
+}
+
+// Create mock implementation
+func (s *NotificationService) Create(ctx context.Context, n *lara.CreateNotification) (uint64, error) {
+	s.CreateInvoked = true
+	return s.CreateFn(n)
+}
+
+// Outbox is mock implementation of lara.Outbox
+type Outbox struct {
+	GetFn      func(id uint64) (*lara.GetNotification, error)
+	GetInvoked bool
+
+	EnqueueFn      func(m *lara.OutboxMessage) (uint64, error)
+	EnqueueInvoked bool
+
+	PendingFn      func(limit int) ([]lara.GetNotification, error)
+	PendingInvoked bool
+
+	MarkSentFn      func(id uint64) error
+	MarkSentInvoked bool
+
+	MarkFailedFn      func(id uint64, reason string, final bool) error
+	MarkFailedInvoked bool
+}
+
+// Get mock implementation
+func (s *Outbox) Get(ctx context.Context, id uint64) (*lara.GetNotification, error) {
+	s.GetInvoked = true
+	return s.GetFn(id)
+}
+
+// Enqueue mock implementation
+func (s *Outbox) Enqueue(ctx context.Context, m *lara.OutboxMessage) (uint64, error) {
+	s.EnqueueInvoked = true
+	return s.EnqueueFn(m)
+}
+
+// Pending mock implementation
+func (s *Outbox) Pending(ctx context.Context, limit int) ([]lara.GetNotification, error) {
+	s.PendingInvoked = true
+	return s.PendingFn(limit)
+}
+
+// MarkSent mock implementation
+func (s *Outbox) MarkSent(ctx context.Context, id uint64) error {
+	s.MarkSentInvoked = true
+	return s.MarkSentFn(id)
+}
+
+// MarkFailed mock implementation
+func (s *Outbox) MarkFailed(ctx context.Context, id uint64, reason string, final bool) error {
+	s.MarkFailedInvoked = true
+	return s.MarkFailedFn(id, reason, final)
+}
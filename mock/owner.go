@@ -35,6 +35,12 @@ type OwnerService struct {
 
 	CreateFn      func(o *lara.CreateOwner) (uint64, error)
 	CreateInvoked bool
+
+	MergeFn      func(id uint64, m *lara.MergeOwners) error
+	MergeInvoked bool
+
+	DuplicatesFn      func(p lara.Page) (*lara.DuplicateOwnersList, error)
+	DuplicatesInvoked bool
 }
 
 // Get mock implementation
@@ -54,3 +60,15 @@ func (s *OwnerService) Create(ctx context.Context, o *lara.CreateOwner) (uint64,
 	s.CreateInvoked = true
 	return s.CreateFn(o)
 }
+
+// Merge mock implementation
+func (s *OwnerService) Merge(ctx context.Context, id uint64, m *lara.MergeOwners) error {
+	s.MergeInvoked = true
+	return s.MergeFn(id, m)
+}
+
+// Duplicates mock implementation
+func (s *OwnerService) Duplicates(ctx context.Context, p lara.Page) (*lara.DuplicateOwnersList, error) {
+	s.DuplicatesInvoked = true
+	return s.DuplicatesFn(p)
+}
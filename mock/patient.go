@@ -35,6 +35,9 @@ type PatientService struct {
 
 	CreateFn      func(r *lara.CreatePatient) (uint64, error)
 	CreateInvoked bool
+
+	TransferFn      func(id uint64, t *lara.TransferPatient) error
+	TransferInvoked bool
 }
 
 // Update mock implementation
@@ -54,3 +57,9 @@ func (s *PatientService) Get(ctx context.Context, id uint64) (*lara.GetPatient,
 	s.GetInvoked = true
 	return s.GetFn(id)
 }
+
+// Transfer mock implementation
+func (s *PatientService) Transfer(ctx context.Context, id uint64, t *lara.TransferPatient) error {
+	s.TransferInvoked = true
+	return s.TransferFn(id, t)
+}
@@ -0,0 +1,74 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mock
+
+import (
+	"context"
+
+	"github.com/jkusniar/lara"
+)
+
+// PrescriptionService is mock implementation of lara.PrescriptionService
+type PrescriptionService struct {
+	GetFn      func(id uint64) (*lara.GetPrescription, error)
+	GetInvoked bool
+
+	CreateFn      func(p *lara.CreatePrescription) (uint64, error)
+	CreateInvoked bool
+
+	UpdateFn      func(id uint64, p *lara.UpdatePrescription) error
+	UpdateInvoked bool
+
+	ListByRecordFn      func(recordID uint64) (*lara.PrescriptionList, error)
+	ListByRecordInvoked bool
+
+	ListByPatientFn      func(patientID uint64) (*lara.PrescriptionList, error)
+	ListByPatientInvoked bool
+}
+
+// Get mock implementation
+func (s *PrescriptionService) Get(ctx context.Context, id uint64) (*lara.GetPrescription, error) {
+	s.GetInvoked = true
+	return s.GetFn(id)
+}
+
+// Create mock implementation
+func (s *PrescriptionService) Create(ctx context.Context, p *lara.CreatePrescription) (uint64, error) {
+	s.CreateInvoked = true
+	return s.CreateFn(p)
+}
+
+// Update mock implementation
+func (s *PrescriptionService) Update(ctx context.Context, id uint64, p *lara.UpdatePrescription) error {
+	s.UpdateInvoked = true
+	return s.UpdateFn(id, p)
+}
+
+// ListByRecord mock implementation
+func (s *PrescriptionService) ListByRecord(ctx context.Context, recordID uint64) (*lara.PrescriptionList, error) {
+	s.ListByRecordInvoked = true
+	return s.ListByRecordFn(recordID)
+}
+
+// ListByPatient mock implementation
+func (s *PrescriptionService) ListByPatient(ctx context.Context, patientID uint64) (*lara.PrescriptionList, error) {
+	s.ListByPatientInvoked = true
+	return s.ListByPatientFn(patientID)
+}
@@ -21,6 +21,7 @@ package mock
 
 import (
 	"context"
+	"time"
 
 	"github.com/jkusniar/lara"
 )
@@ -30,6 +31,24 @@ type ProductService struct {
 	SearchFn func(p *lara.ProductSearchRequest) (*lara.ProductSearchResult,
 		error)
 	SearchInvoked bool
+
+	GetFn      func(id uint64) (*lara.GetProduct, error)
+	GetInvoked bool
+
+	CreateFn      func(p *lara.CreateProduct) (uint64, error)
+	CreateInvoked bool
+
+	UpdateFn      func(id uint64, p *lara.UpdateProduct) error
+	UpdateInvoked bool
+
+	RetireFn      func(id uint64, r *lara.RetireProduct) error
+	RetireInvoked bool
+
+	PriceAtFn      func(id uint64, date time.Time) (*lara.ProductPrice, error)
+	PriceAtInvoked bool
+
+	PriceHistoryFn      func(id uint64) (*lara.ProductPriceList, error)
+	PriceHistoryInvoked bool
 }
 
 // Search mock implementation
@@ -38,3 +57,39 @@ func (s *ProductService) Search(ctx context.Context, p *lara.ProductSearchReques
 	s.SearchInvoked = true
 	return s.SearchFn(p)
 }
+
+// Get mock implementation
+func (s *ProductService) Get(ctx context.Context, id uint64) (*lara.GetProduct, error) {
+	s.GetInvoked = true
+	return s.GetFn(id)
+}
+
+// Create mock implementation
+func (s *ProductService) Create(ctx context.Context, p *lara.CreateProduct) (uint64, error) {
+	s.CreateInvoked = true
+	return s.CreateFn(p)
+}
+
+// Update mock implementation
+func (s *ProductService) Update(ctx context.Context, id uint64, p *lara.UpdateProduct) error {
+	s.UpdateInvoked = true
+	return s.UpdateFn(id, p)
+}
+
+// Retire mock implementation
+func (s *ProductService) Retire(ctx context.Context, id uint64, r *lara.RetireProduct) error {
+	s.RetireInvoked = true
+	return s.RetireFn(id, r)
+}
+
+// PriceAt mock implementation
+func (s *ProductService) PriceAt(ctx context.Context, id uint64, date time.Time) (*lara.ProductPrice, error) {
+	s.PriceAtInvoked = true
+	return s.PriceAtFn(id, date)
+}
+
+// PriceHistory mock implementation
+func (s *ProductService) PriceHistory(ctx context.Context, id uint64) (*lara.ProductPriceList, error) {
+	s.PriceHistoryInvoked = true
+	return s.PriceHistoryFn(id)
+}
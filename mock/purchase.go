@@ -0,0 +1,131 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mock
+
+import (
+	"context"
+
+	"github.com/jkusniar/lara"
+)
+
+// SupplierService mock implementation of lara.SupplierService
+type SupplierService struct {
+	GetFn      func(id uint64) (*lara.GetSupplier, error)
+	GetInvoked bool
+
+	UpdateFn      func(id uint64, s *lara.UpdateSupplier) error
+	UpdateInvoked bool
+
+	CreateFn      func(s *lara.CreateSupplier) (uint64, error)
+	CreateInvoked bool
+
+	ListFn      func() (*lara.SupplierList, error)
+	ListInvoked bool
+}
+
+// Get mock implementation
+func (s *SupplierService) Get(ctx context.Context, id uint64) (*lara.GetSupplier, error) {
+	s.GetInvoked = true
+	return s.GetFn(id)
+}
+
+// Update mock implementation
+func (s *SupplierService) Update(ctx context.Context, id uint64, sup *lara.UpdateSupplier) error {
+	s.UpdateInvoked = true
+	return s.UpdateFn(id, sup)
+}
+
+// Create mock implementation
+func (s *SupplierService) Create(ctx context.Context, sup *lara.CreateSupplier) (uint64, error) {
+	s.CreateInvoked = true
+	return s.CreateFn(sup)
+}
+
+// List mock implementation
+func (s *SupplierService) List(ctx context.Context) (*lara.SupplierList, error) {
+	s.ListInvoked = true
+	return s.ListFn()
+}
+
+// PurchaseOrderService mock implementation of lara.PurchaseOrderService
+type PurchaseOrderService struct {
+	GetFn      func(id uint64) (*lara.GetPurchaseOrder, error)
+	GetInvoked bool
+
+	CreateFn      func(o *lara.CreatePurchaseOrder) (uint64, error)
+	CreateInvoked bool
+
+	UpdateFn      func(id uint64, o *lara.UpdatePurchaseOrder) error
+	UpdateInvoked bool
+
+	ReceiveFn      func(id uint64, r *lara.ReceivePurchaseOrder) error
+	ReceiveInvoked bool
+
+	WithdrawFn      func(id uint64, w *lara.WithdrawPurchaseOrder) error
+	WithdrawInvoked bool
+
+	ListBySupplierFn      func(supplierID uint64) (*lara.SuppliersPurchaseOrderList, error)
+	ListBySupplierInvoked bool
+
+	SuggestedReorderFn      func() (*lara.SuggestedReorderList, error)
+	SuggestedReorderInvoked bool
+}
+
+// Get mock implementation
+func (s *PurchaseOrderService) Get(ctx context.Context, id uint64) (*lara.GetPurchaseOrder, error) {
+	s.GetInvoked = true
+	return s.GetFn(id)
+}
+
+// Create mock implementation
+func (s *PurchaseOrderService) Create(ctx context.Context, o *lara.CreatePurchaseOrder) (uint64, error) {
+	s.CreateInvoked = true
+	return s.CreateFn(o)
+}
+
+// Update mock implementation
+func (s *PurchaseOrderService) Update(ctx context.Context, id uint64, o *lara.UpdatePurchaseOrder) error {
+	s.UpdateInvoked = true
+	return s.UpdateFn(id, o)
+}
+
+// Receive mock implementation
+func (s *PurchaseOrderService) Receive(ctx context.Context, id uint64, r *lara.ReceivePurchaseOrder) error {
+	s.ReceiveInvoked = true
+	return s.ReceiveFn(id, r)
+}
+
+// Withdraw mock implementation
+func (s *PurchaseOrderService) Withdraw(ctx context.Context, id uint64, w *lara.WithdrawPurchaseOrder) error {
+	s.WithdrawInvoked = true
+	return s.WithdrawFn(id, w)
+}
+
+// ListBySupplier mock implementation
+func (s *PurchaseOrderService) ListBySupplier(ctx context.Context, supplierID uint64) (*lara.SuppliersPurchaseOrderList, error) {
+	s.ListBySupplierInvoked = true
+	return s.ListBySupplierFn(supplierID)
+}
+
+// SuggestedReorder mock implementation
+func (s *PurchaseOrderService) SuggestedReorder(ctx context.Context) (*lara.SuggestedReorderList, error) {
+	s.SuggestedReorderInvoked = true
+	return s.SuggestedReorderFn()
+}
@@ -35,6 +35,15 @@ type RecordService struct {
 
 	UpdateFn      func(id uint64, r *lara.UpdateRecord) error
 	UpdateInvoked bool
+
+	ListPatientDiagnosesFn      func(patientID uint64) (*lara.PatientDiagnosisList, error)
+	ListPatientDiagnosesInvoked bool
+
+	ListDiagnosedPatientsFn      func(diagnosisID uint64, p lara.Page) (*lara.DiagnosedPatientList, error)
+	ListDiagnosedPatientsInvoked bool
+
+	GetPatientVitalsFn      func(patientID uint64) (*lara.PatientVitals, error)
+	GetPatientVitalsInvoked bool
 }
 
 // Get mock implementation
@@ -54,3 +63,22 @@ func (s *RecordService) Create(ctx context.Context, r *lara.CreateRecord) (uint6
 	s.CreateInvoked = true
 	return s.CreateFn(r)
 }
+
+// ListPatientDiagnoses mock implementation
+func (s *RecordService) ListPatientDiagnoses(ctx context.Context, patientID uint64) (*lara.PatientDiagnosisList, error) {
+	s.ListPatientDiagnosesInvoked = true
+	return s.ListPatientDiagnosesFn(patientID)
+}
+
+// ListDiagnosedPatients mock implementation
+func (s *RecordService) ListDiagnosedPatients(ctx context.Context, diagnosisID uint64,
+	p lara.Page) (*lara.DiagnosedPatientList, error) {
+	s.ListDiagnosedPatientsInvoked = true
+	return s.ListDiagnosedPatientsFn(diagnosisID, p)
+}
+
+// GetPatientVitals mock implementation
+func (s *RecordService) GetPatientVitals(ctx context.Context, patientID uint64) (*lara.PatientVitals, error) {
+	s.GetPatientVitalsInvoked = true
+	return s.GetPatientVitalsFn(patientID)
+}
@@ -30,6 +30,10 @@ type ReportService struct {
 	GetIncomeStatisticsFn func(
 		r *lara.ReportRequest) (*lara.IncomeStatistics, error)
 	GetIncomeStatisticsInvoked bool
+
+	GetControlledDrugRegisterFn func(
+		r *lara.ReportRequest) (*lara.ControlledDrugReport, error)
+	GetControlledDrugRegisterInvoked bool
 }
 
 // GetIncomeStatistics mock implementation
@@ -38,3 +42,10 @@ func (s *ReportService) GetIncomeStatistics(ctx context.Context,
 	s.GetIncomeStatisticsInvoked = true
 	return s.GetIncomeStatisticsFn(r)
 }
+
+// GetControlledDrugRegister mock implementation
+func (s *ReportService) GetControlledDrugRegister(ctx context.Context,
+	r *lara.ReportRequest) (*lara.ControlledDrugReport, error) {
+	s.GetControlledDrugRegisterInvoked = true
+	return s.GetControlledDrugRegisterFn(r)
+}
@@ -27,12 +27,12 @@ import (
 
 // SearchService is mock implementation of lara.SearchService
 type SearchService struct {
-	SearchFn        func(q string) (*lara.SearchResult, error)
+	SearchFn        func(r *lara.SearchRequest) (*lara.SearchResult, error)
 	SearchFnInvoked bool
 }
 
 // Search mock implementation
-func (s *SearchService) Search(ctx context.Context, q string) (*lara.SearchResult, error) {
+func (s *SearchService) Search(ctx context.Context, r *lara.SearchRequest) (*lara.SearchResult, error) {
 	s.SearchFnInvoked = true
-	return s.SearchFn(q)
+	return s.SearchFn(r)
 }
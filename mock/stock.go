@@ -0,0 +1,93 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/jkusniar/lara"
+)
+
+// StockService mock implementation of lara.StockService
+type StockService struct {
+	GetMovementFn      func(id uint64) (*lara.GetStockMovement, error)
+	GetMovementInvoked bool
+
+	PostFn      func(m *lara.CreateStockMovement) (uint64, error)
+	PostInvoked bool
+
+	ListMovementsFn      func(productID uint64, from, to time.Time) (*lara.StockMovementList, error)
+	ListMovementsInvoked bool
+
+	GetFn      func(productID uint64) (*lara.StockLevel, error)
+	GetInvoked bool
+
+	ListFn      func() (*lara.StockLevelList, error)
+	ListInvoked bool
+
+	BatchesFn      func(productID uint64) (*lara.StockBatchList, error)
+	BatchesInvoked bool
+
+	ExpiringFn      func(days int) (*lara.StockBatchList, error)
+	ExpiringInvoked bool
+}
+
+// GetMovement mock implementation
+func (s *StockService) GetMovement(ctx context.Context, id uint64) (*lara.GetStockMovement, error) {
+	s.GetMovementInvoked = true
+	return s.GetMovementFn(id)
+}
+
+// Post mock implementation
+func (s *StockService) Post(ctx context.Context, m *lara.CreateStockMovement) (uint64, error) {
+	s.PostInvoked = true
+	return s.PostFn(m)
+}
+
+// ListMovements mock implementation
+func (s *StockService) ListMovements(ctx context.Context, productID uint64, from, to time.Time) (*lara.StockMovementList, error) {
+	s.ListMovementsInvoked = true
+	return s.ListMovementsFn(productID, from, to)
+}
+
+// Get mock implementation
+func (s *StockService) Get(ctx context.Context, productID uint64) (*lara.StockLevel, error) {
+	s.GetInvoked = true
+	return s.GetFn(productID)
+}
+
+// List mock implementation
+func (s *StockService) List(ctx context.Context) (*lara.StockLevelList, error) {
+	s.ListInvoked = true
+	return s.ListFn()
+}
+
+// Batches mock implementation
+func (s *StockService) Batches(ctx context.Context, productID uint64) (*lara.StockBatchList, error) {
+	s.BatchesInvoked = true
+	return s.BatchesFn(productID)
+}
+
+// Expiring mock implementation
+func (s *StockService) Expiring(ctx context.Context, days int) (*lara.StockBatchList, error) {
+	s.ExpiringInvoked = true
+	return s.ExpiringFn(days)
+}
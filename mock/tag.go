@@ -21,6 +21,7 @@ package mock
 
 import (
 	"context"
+	"io"
 
 	"github.com/jkusniar/lara"
 )
@@ -36,6 +37,24 @@ type TagService struct {
 	CreateFn      func(t *lara.CreateTag) (uint64, error)
 	CreateInvoked bool
 
+	ReplaceFn      func(id uint64, t *lara.ReplaceTag) (uint64, error)
+	ReplaceInvoked bool
+
+	DeactivateFn      func(id uint64, t *lara.DeactivateTag) error
+	DeactivateInvoked bool
+
+	PutDataFn      func(id uint64, content io.Reader) error
+	PutDataInvoked bool
+
+	GetDataFn      func(id uint64) (*lara.TagData, error)
+	GetDataInvoked bool
+
+	GetThumbnailFn      func(id uint64) (*lara.TagData, error)
+	GetThumbnailInvoked bool
+
+	DeleteDataFn      func(id uint64) error
+	DeleteDataInvoked bool
+
 	GetPatientByTagFn      func(tagValue string) (*lara.PatientByTag, error)
 	GetPatientByTagInvoked bool
 }
@@ -63,3 +82,39 @@ func (s *TagService) Create(ctx context.Context, t *lara.CreateTag) (uint64, err
 	s.CreateInvoked = true
 	return s.CreateFn(t)
 }
+
+// Replace mock implementation
+func (s *TagService) Replace(ctx context.Context, id uint64, t *lara.ReplaceTag) (uint64, error) {
+	s.ReplaceInvoked = true
+	return s.ReplaceFn(id, t)
+}
+
+// Deactivate mock implementation
+func (s *TagService) Deactivate(ctx context.Context, id uint64, t *lara.DeactivateTag) error {
+	s.DeactivateInvoked = true
+	return s.DeactivateFn(id, t)
+}
+
+// PutData mock implementation
+func (s *TagService) PutData(ctx context.Context, id uint64, content io.Reader) error {
+	s.PutDataInvoked = true
+	return s.PutDataFn(id, content)
+}
+
+// GetData mock implementation
+func (s *TagService) GetData(ctx context.Context, id uint64) (*lara.TagData, error) {
+	s.GetDataInvoked = true
+	return s.GetDataFn(id)
+}
+
+// GetThumbnail mock implementation
+func (s *TagService) GetThumbnail(ctx context.Context, id uint64) (*lara.TagData, error) {
+	s.GetThumbnailInvoked = true
+	return s.GetThumbnailFn(id)
+}
+
+// DeleteData mock implementation
+func (s *TagService) DeleteData(ctx context.Context, id uint64) error {
+	s.DeleteDataInvoked = true
+	return s.DeleteDataFn(id)
+}
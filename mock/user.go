@@ -30,8 +30,26 @@ type UserService struct {
 	AuthenticateFn      func(login, password string) (*lara.User, error)
 	AuthenticateInvoked bool
 
+	CheckEnabledFn      func(login string) error
+	CheckEnabledInvoked bool
+
 	RegisterFn     func(login, password string, permissions []lara.PermissionType) error
 	RegiserInvoked bool
+
+	ListFn      func() (*lara.UserList, error)
+	ListInvoked bool
+
+	GetFn      func(login string) (*lara.UserAccount, error)
+	GetInvoked bool
+
+	DisableFn      func(login string) error
+	DisableInvoked bool
+
+	EnableFn      func(login string) error
+	EnableInvoked bool
+
+	ResetPasswordFn      func(login, password string) error
+	ResetPasswordInvoked bool
 }
 
 // Authenticate mock implementation
@@ -40,6 +58,12 @@ func (s *UserService) Authenticate(ctx context.Context, login, password string)
 	return s.AuthenticateFn(login, password)
 }
 
+// CheckEnabled mock implementation
+func (s *UserService) CheckEnabled(ctx context.Context, login string) error {
+	s.CheckEnabledInvoked = true
+	return s.CheckEnabledFn(login)
+}
+
 // Register mock implementation
 func (s *UserService) Register(ctx context.Context, login, password string, permissions []lara.PermissionType) error {
 	s.RegiserInvoked = true
@@ -55,3 +79,33 @@ func (s *UserService) Grant(ctx context.Context, login string, permissions []lar
 func (s *UserService) Revoke(ctx context.Context, login string, permissions []lara.PermissionType) error {
 	return nil
 }
+
+// List mock implementation
+func (s *UserService) List(ctx context.Context) (*lara.UserList, error) {
+	s.ListInvoked = true
+	return s.ListFn()
+}
+
+// Get mock implementation
+func (s *UserService) Get(ctx context.Context, login string) (*lara.UserAccount, error) {
+	s.GetInvoked = true
+	return s.GetFn(login)
+}
+
+// Disable mock implementation
+func (s *UserService) Disable(ctx context.Context, login string) error {
+	s.DisableInvoked = true
+	return s.DisableFn(login)
+}
+
+// Enable mock implementation
+func (s *UserService) Enable(ctx context.Context, login string) error {
+	s.EnableInvoked = true
+	return s.EnableFn(login)
+}
+
+// ResetPassword mock implementation
+func (s *UserService) ResetPassword(ctx context.Context, login, password string) error {
+	s.ResetPasswordInvoked = true
+	return s.ResetPasswordFn(login, password)
+}
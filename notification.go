@@ -0,0 +1,190 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package lara
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// OWNER NOTIFICATION SERVICE
+
+// NotificationChannel defines transport used to deliver notification
+//go:generate stringer -type=NotificationChannel -output notificationchannel_string.go
+//requires golang.org/x/tools/cmd/stringer installed locally
+//if new channel added to enum, run "go generate"
+type NotificationChannel int
+
+// NotificationChannel enum
+const (
+	Email NotificationChannel = iota // recipient is owner's email
+	SMS                              // recipient is owner's primary phone
+)
+
+// MarshalJSON is JSON marshaller implementation for NotificationChannel
+func (i NotificationChannel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.String())
+}
+
+// UnmarshalJSON is JSON unmarshaller implementation for NotificationChannel
+func (i *NotificationChannel) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	switch strings.ToLower(s) {
+	case "email":
+		*i = Email
+	case "sms":
+		*i = SMS
+	default:
+		return fmt.Errorf("bad NotificationChannel: '%s'", s)
+	}
+
+	return nil
+}
+
+// NotificationTemplate defines kind of message sent to owner
+//go:generate stringer -type=NotificationTemplate -output notificationtemplate_string.go
+//requires golang.org/x/tools/cmd/stringer installed locally
+//if new template added to enum, run "go generate"
+type NotificationTemplate int
+
+// NotificationTemplate enum
+const (
+	VaccinationDue          NotificationTemplate = iota // requires VaccinationID
+	AppointmentConfirmation                             // requires AppointmentID
+	RecordSummary                                       // requires RecordID
+)
+
+// MarshalJSON is JSON marshaller implementation for NotificationTemplate
+func (i NotificationTemplate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.String())
+}
+
+// UnmarshalJSON is JSON unmarshaller implementation for NotificationTemplate
+func (i *NotificationTemplate) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	switch strings.ToLower(s) {
+	case "vaccinationdue":
+		*i = VaccinationDue
+	case "appointmentconfirmation":
+		*i = AppointmentConfirmation
+	case "recordsummary":
+		*i = RecordSummary
+	default:
+		return fmt.Errorf("bad NotificationTemplate: '%s'", s)
+	}
+
+	return nil
+}
+
+// NotificationStatus defines state of notification in outbox
+//go:generate stringer -type=NotificationStatus -output notificationstatus_string.go
+//requires golang.org/x/tools/cmd/stringer installed locally
+//if new status added to enum, run "go generate"
+type NotificationStatus int
+
+// NotificationStatus enum
+const (
+	Pending NotificationStatus = iota // waiting for (next) delivery attempt
+	Sent
+	Failed // no more delivery attempts will be made
+)
+
+// MarshalJSON is JSON marshaller implementation for NotificationStatus
+func (i NotificationStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.String())
+}
+
+// Message is rendered notification ready to be delivered
+type Message struct {
+	Channel   NotificationChannel `json:"channel"`
+	Recipient string              `json:"recipient"` // email address or phone number
+	Subject   string              `json:"subject"`   // not used by SMS transports
+	Body      string              `json:"body"`
+}
+
+// Notifier delivers messages to owners using single transport (email, SMS, ...)
+type Notifier interface {
+	Send(ctx context.Context, m *Message) error
+}
+
+// GetNotification is JSON encoded retrievable outbox entry
+type GetNotification struct {
+	ID uint64 `json:"id"`
+	Message
+	Template  NotificationTemplate `json:"template"`
+	OwnerID   uint64               `json:"ownerId"`
+	PatientID uint64               `json:"patientId"`
+	Status    NotificationStatus   `json:"status"`
+	Attempts  uint                 `json:"attempts"`
+	LastError string               `json:"lastError"`
+	Creator   string               `json:"creator"`
+	Created   time.Time            `json:"created"`
+	Sent      time.Time            `json:"sent"` // zero if not sent yet
+}
+
+// CreateNotification is JSON encoded request to notify patient's owner.
+// Only ID of the object required by Template has to be filled.
+type CreateNotification struct {
+	Template      NotificationTemplate `json:"template"`
+	Channel       NotificationChannel  `json:"channel"`
+	OwnerID       uint64               `json:"ownerId"`
+	PatientID     uint64               `json:"patientId"`
+	VaccinationID uint64               `json:"vaccinationId"`
+	AppointmentID uint64               `json:"appointmentId"`
+	RecordID      uint64               `json:"recordId"`
+}
+
+// OutboxMessage is rendered message stored to outbox
+type OutboxMessage struct {
+	Message
+	Template  NotificationTemplate
+	OwnerID   uint64
+	PatientID uint64
+}
+
+// Outbox persists messages until they are delivered
+type Outbox interface {
+	Get(ctx context.Context, id uint64) (*GetNotification, error)
+	Enqueue(ctx context.Context, m *OutboxMessage) (uint64, error)
+	// Pending returns at most limit oldest pending messages
+	Pending(ctx context.Context, limit int) ([]GetNotification, error)
+	MarkSent(ctx context.Context, id uint64) error
+	// MarkFailed records failed delivery attempt. If final is true, message
+	// is not returned by Pending anymore.
+	MarkFailed(ctx context.Context, id uint64, reason string, final bool) error
+}
+
+// NotificationService renders notifications and stores them to outbox
+type NotificationService interface {
+	Get(ctx context.Context, id uint64) (*GetNotification, error)
+	Create(ctx context.Context, n *CreateNotification) (uint64, error)
+}
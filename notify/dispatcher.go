@@ -0,0 +1,106 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package notify
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jkusniar/lara"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultInterval    = time.Minute
+	defaultBatchSize   = 50
+	defaultMaxAttempts = 5
+)
+
+// Dispatcher delivers pending outbox messages using notifier registered for
+// message's channel. Messages stay in outbox until delivered, so delivery
+// continues after restart.
+type Dispatcher struct {
+	Outbox      lara.Outbox
+	Notifiers   map[lara.NotificationChannel]lara.Notifier
+	Interval    time.Duration // outbox polling interval
+	BatchSize   int           // max messages sent in one Dispatch call
+	MaxAttempts uint          // message is marked as failed after MaxAttempts
+}
+
+// NewDispatcher creates new Dispatcher with default settings
+func NewDispatcher(o lara.Outbox, n map[lara.NotificationChannel]lara.Notifier) *Dispatcher {
+	return &Dispatcher{o, n, defaultInterval, defaultBatchSize, defaultMaxAttempts}
+}
+
+// Dispatch sends one batch of pending messages. Number of successfully sent
+// messages is returned. Delivery errors are recorded in outbox, only outbox
+// errors are returned.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	pending, err := d.Outbox.Pending(ctx, d.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range pending {
+		n := &pending[i]
+
+		var sendErr error
+		if notifier, ok := d.Notifiers[n.Channel]; ok {
+			sendErr = notifier.Send(ctx, &n.Message)
+		} else {
+			sendErr = errors.Errorf("no notifier for channel %s", n.Channel)
+		}
+
+		if sendErr == nil {
+			if err := d.Outbox.MarkSent(ctx, n.ID); err != nil {
+				return sent, err
+			}
+			sent++
+			continue
+		}
+
+		final := n.Attempts+1 >= d.MaxAttempts
+		if err := d.Outbox.MarkFailed(ctx, n.ID, sendErr.Error(), final); err != nil {
+			return sent, err
+		}
+	}
+
+	return sent, nil
+}
+
+// Run dispatches pending messages every Interval until ctx is done
+func (d *Dispatcher) Run(ctx context.Context) {
+	t := time.NewTicker(d.Interval)
+	defer t.Stop()
+
+	for {
+		if _, err := d.Dispatch(ctx); err != nil {
+			log.Printf("ERROR: notification dispatch failed: %+v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,87 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jkusniar/lara"
+	"github.com/pkg/errors"
+)
+
+// File is lara.Notifier appending messages of all channels to a file instead
+// of delivering them. Useful for development and testing installations.
+type File struct {
+	mu   sync.Mutex
+	Path string
+}
+
+// Send appends message m to file
+func (f *File) Send(ctx context.Context, m *lara.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fd, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "open notification file failed")
+	}
+
+	_, err = fmt.Fprintf(fd, "Date: %s\nChannel: %s\nTo: %s\nSubject: %s\n\n%s\n---\n",
+		time.Now().Format(time.RFC1123Z), m.Channel, m.Recipient, m.Subject, m.Body)
+	if err != nil {
+		fd.Close()
+		return errors.Wrap(err, "write notification file failed")
+	}
+
+	return errors.Wrap(fd.Close(), "close notification file failed")
+}
+
+// Memory is lara.Notifier keeping messages of all channels in memory
+type Memory struct {
+	mu       sync.Mutex
+	messages []lara.Message
+	// Err, if set, is returned from Send and message is not stored
+	Err error
+}
+
+// Send stores message m in memory
+func (n *Memory) Send(ctx context.Context, m *lara.Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.Err != nil {
+		return n.Err
+	}
+
+	n.messages = append(n.messages, *m)
+	return nil
+}
+
+// Messages returns copy of all sent messages
+func (n *Memory) Messages() []lara.Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return append([]lara.Message(nil), n.messages...)
+}
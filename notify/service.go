@@ -0,0 +1,190 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package notify
+
+import (
+	"context"
+
+	"github.com/jkusniar/lara"
+	"github.com/pkg/errors"
+)
+
+// Service is lara.NotificationService implementation rendering messages from
+// owner's and patient's data and storing them to outbox
+type Service struct {
+	Clinic       string // clinic name used in message signature
+	Outbox       lara.Outbox
+	Owners       lara.OwnerService
+	Patients     lara.PatientService
+	Records      lara.RecordService
+	Appointments lara.AppointmentService
+	Vaccinations lara.VaccinationService
+}
+
+func requiredFieldError(field string) error {
+	return lara.NewCodedError(400, errors.Errorf("%s is required", field))
+}
+
+func patientMismatchError(obj string, id, patientID uint64) error {
+	return lara.NewCodedError(400,
+		errors.Errorf("%s %d does not belong to patient %d", obj, id, patientID))
+}
+
+// Get is implementation of NotificationService.Get
+func (s *Service) Get(ctx context.Context, id uint64) (*lara.GetNotification, error) {
+	return s.Outbox.Get(ctx, id)
+}
+
+// Create is implementation of NotificationService.Create. Message is rendered
+// immediately and it is delivered asynchronously by Dispatcher.
+func (s *Service) Create(ctx context.Context, n *lara.CreateNotification) (uint64, error) {
+	if n.OwnerID == 0 {
+		return 0, requiredFieldError("ownerId")
+	}
+
+	if n.PatientID == 0 {
+		return 0, requiredFieldError("patientId")
+	}
+
+	d := TemplateData{Clinic: s.Clinic}
+	var err error
+	if d.Owner, err = s.Owners.Get(ctx, n.OwnerID); err != nil {
+		return 0, err
+	}
+
+	if !ownsPatient(d.Owner, n.PatientID) {
+		return 0, lara.NewCodedError(400,
+			errors.Errorf("patient %d does not belong to owner %d", n.PatientID, n.OwnerID))
+	}
+
+	if d.Patient, err = s.Patients.Get(ctx, n.PatientID); err != nil {
+		return 0, err
+	}
+
+	if err = s.loadTemplateObject(ctx, n, &d); err != nil {
+		return 0, err
+	}
+
+	recipient, err := recipient(d.Owner, n.Channel)
+	if err != nil {
+		return 0, err
+	}
+
+	subject, body, err := Render(n.Template, &d)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.Outbox.Enqueue(ctx, &lara.OutboxMessage{
+		Message: lara.Message{
+			Channel:   n.Channel,
+			Recipient: recipient,
+			Subject:   subject,
+			Body:      body},
+		Template:  n.Template,
+		OwnerID:   n.OwnerID,
+		PatientID: n.PatientID,
+	})
+}
+
+// loadTemplateObject loads object required by notification's template
+func (s *Service) loadTemplateObject(ctx context.Context, n *lara.CreateNotification, d *TemplateData) (err error) {
+	switch n.Template {
+	case lara.VaccinationDue:
+		if n.VaccinationID == 0 {
+			return requiredFieldError("vaccinationId")
+		}
+		if d.Vaccination, err = s.Vaccinations.Get(ctx, n.VaccinationID); err != nil {
+			return err
+		}
+		if d.Vaccination.PatientID != n.PatientID {
+			return patientMismatchError("vaccination", n.VaccinationID, n.PatientID)
+		}
+		if d.Vaccination.Due.IsZero() {
+			return lara.NewCodedError(400,
+				errors.Errorf("vaccination %d doesn't require revaccination", n.VaccinationID))
+		}
+	case lara.AppointmentConfirmation:
+		if n.AppointmentID == 0 {
+			return requiredFieldError("appointmentId")
+		}
+		if d.Appointment, err = s.Appointments.Get(ctx, n.AppointmentID); err != nil {
+			return err
+		}
+		if d.Appointment.PatientID != n.PatientID {
+			return patientMismatchError("appointment", n.AppointmentID, n.PatientID)
+		}
+		if d.Appointment.Status != lara.Scheduled {
+			return lara.NewCodedError(400,
+				errors.Errorf("appointment %d is not scheduled", n.AppointmentID))
+		}
+	case lara.RecordSummary:
+		if n.RecordID == 0 {
+			return requiredFieldError("recordId")
+		}
+		if !hasRecord(d.Patient, n.RecordID) {
+			return patientMismatchError("record", n.RecordID, n.PatientID)
+		}
+		if d.Record, err = s.Records.Get(ctx, n.RecordID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ownsPatient(o *lara.GetOwner, patientID uint64) bool {
+	for _, p := range o.Patients {
+		if p.ID == patientID {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRecord(p *lara.GetPatient, recordID uint64) bool {
+	for _, r := range p.Records {
+		if r.ID == recordID {
+			return true
+		}
+	}
+	return false
+}
+
+// recipient returns owner's address for channel c
+func recipient(o *lara.GetOwner, c lara.NotificationChannel) (string, error) {
+	var r string
+	switch c {
+	case lara.Email:
+		r = o.Email
+	case lara.SMS:
+		r = o.Phone1
+		if r == "" {
+			r = o.Phone2
+		}
+	}
+
+	if r == "" {
+		return "", lara.NewCodedError(400,
+			errors.Errorf("owner %d has no contact for channel %s", o.ID, c))
+	}
+
+	return r, nil
+}
@@ -0,0 +1,106 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/jkusniar/lara"
+	"github.com/pkg/errors"
+)
+
+// SMTP is lara.Notifier delivering email messages using SMTP server
+type SMTP struct {
+	addr string        // host:port
+	from *mail.Address // sender's address
+	auth smtp.Auth     // nil if server doesn't require authentication
+}
+
+// NewSMTP creates new SMTP notifier. If user is empty, no authentication
+// is used. Server has to support STARTTLS for PLAIN authentication to work.
+func NewSMTP(host string, port uint, user, pass, from string) (*SMTP, error) {
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, errors.Wrapf(err, "bad sender address '%s'", from)
+	}
+
+	var auth smtp.Auth
+	if user != "" {
+		auth = smtp.PlainAuth("", user, pass, host)
+	}
+
+	return &SMTP{net.JoinHostPort(host, strconv.Itoa(int(port))), sender, auth}, nil
+}
+
+// Send delivers message m using SMTP. Only Email channel is supported.
+func (s *SMTP) Send(ctx context.Context, m *lara.Message) error {
+	if m.Channel != lara.Email {
+		return errors.Errorf("channel %s not supported by SMTP notifier", m.Channel)
+	}
+
+	to, err := mail.ParseAddress(m.Recipient)
+	if err != nil {
+		return errors.Wrapf(err, "bad recipient address '%s'", m.Recipient)
+	}
+
+	msg, err := s.compose(to, m)
+	if err != nil {
+		return err
+	}
+
+	// net/smtp can't be cancelled, at least don't start sending
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return errors.Wrap(smtp.SendMail(s.addr, s.auth, s.from.Address, []string{to.Address}, msg),
+		"send mail failed")
+}
+
+// compose creates RFC 5322 message with quoted-printable encoded UTF-8 body
+func (s *SMTP) compose(to *mail.Address, m *lara.Message) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", s.from.String())
+	fmt.Fprintf(&b, "To: %s\r\n", to.String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(&b)
+	if _, err := w.Write(bytes.Replace([]byte(m.Body), []byte("\n"), []byte("\r\n"), -1)); err != nil {
+		return nil, errors.Wrap(err, "encode mail body failed")
+	}
+	if err := w.Close(); err != nil {
+		return nil, errors.Wrap(err, "encode mail body failed")
+	}
+
+	return b.Bytes(), nil
+}
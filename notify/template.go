@@ -0,0 +1,130 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package notify
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jkusniar/lara"
+	"github.com/pkg/errors"
+)
+
+// TemplateData is data available to message templates. Only the object
+// required by rendered template has to be set.
+type TemplateData struct {
+	Clinic      string // clinic name used in message signature
+	Owner       *lara.GetOwner
+	Patient     *lara.GetPatient
+	Vaccination *lara.GetVaccination
+	Appointment *lara.GetAppointment
+	Record      *lara.GetRecord
+}
+
+type messageTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+var funcs = template.FuncMap{
+	"date": func(t time.Time) string {
+		return t.Format("02.01.2006")
+	},
+	"datetime": func(t time.Time) string {
+		return t.Format("02.01.2006 15:04")
+	},
+	"ownerName": func(o *lara.GetOwner) string {
+		s := make([]string, 0, 3)
+		for _, v := range []string{o.Title, o.FirstName, o.LastName} {
+			if v != "" {
+				s = append(s, v)
+			}
+		}
+		return strings.Join(s, " ")
+	},
+}
+
+func newTemplate(t lara.NotificationTemplate, subject, body string) messageTemplate {
+	return messageTemplate{
+		subject: template.Must(template.New(t.String() + "Subject").Funcs(funcs).Parse(subject)),
+		body:    template.Must(template.New(t.String() + "Body").Funcs(funcs).Parse(body)),
+	}
+}
+
+const signature = `
+{{if .Clinic}}{{.Clinic}}{{end}}`
+
+var templates = map[lara.NotificationTemplate]messageTemplate{
+	lara.VaccinationDue: newTemplate(lara.VaccinationDue,
+		`Vaccination of {{.Patient.Name}} is due`,
+		`Dear {{ownerName .Owner}},
+
+vaccination of {{.Patient.Name}} ({{.Vaccination.Vaccine}}, given on {{date .Vaccination.Administered}}) is due on {{date .Vaccination.Due}}.
+Please contact us to schedule an appointment.
+`+signature),
+
+	lara.AppointmentConfirmation: newTemplate(lara.AppointmentConfirmation,
+		`Appointment of {{.Patient.Name}} on {{datetime .Appointment.Start}}`,
+		`Dear {{ownerName .Owner}},
+
+we confirm appointment of {{.Patient.Name}} on {{datetime .Appointment.Start}}.
+{{- if .Appointment.Note}}
+Note: {{.Appointment.Note}}
+{{- end}}
+If you can't come, please let us know.
+`+signature),
+
+	lara.RecordSummary: newTemplate(lara.RecordSummary,
+		`Visit of {{.Patient.Name}} on {{date .Record.Date}}`,
+		`Dear {{ownerName .Owner}},
+
+summary of {{.Patient.Name}}'s visit on {{date .Record.Date}}:
+
+{{.Record.Text}}
+{{if .Record.Items}}
+{{range .Record.Items}}{{.Product}}, {{.Amount}} {{.Unit}}: {{.ItemPrice}}
+{{end}}Total: {{.Record.Total}}
+{{end}}`+signature),
+}
+
+// Render renders subject and body of message t from data d
+func Render(t lara.NotificationTemplate, d *TemplateData) (subject, body string, err error) {
+	tmpl, ok := templates[t]
+	if !ok {
+		return "", "", lara.NewCodedError(400,
+			errors.Errorf("unknown notification template %s", t))
+	}
+
+	var b bytes.Buffer
+	if err = tmpl.subject.Execute(&b, d); err != nil {
+		return "", "", errors.Wrapf(err, "render %s subject failed", t)
+	}
+	subject = b.String()
+
+	b.Reset()
+	if err = tmpl.body.Execute(&b, d); err != nil {
+		return "", "", errors.Wrapf(err, "render %s body failed", t)
+	}
+	body = strings.TrimSpace(b.String()) + "\n"
+
+	return subject, body, nil
+}
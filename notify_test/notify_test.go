@@ -0,0 +1,356 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package notify_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jkusniar/lara"
+	"github.com/jkusniar/lara/mock"
+	"github.com/jkusniar/lara/notify"
+	"github.com/pkg/errors"
+)
+
+func checkErrCode(err error, expCode int) (codeEqual bool, actualCode int) {
+	type codedError interface {
+		Code() int
+	}
+
+	if ce, ok := errors.Cause(err).(codedError); ok {
+		codeEqual = ce.Code() == expCode
+		actualCode = ce.Code()
+	}
+
+	return
+}
+
+func testData() *notify.TemplateData {
+	return &notify.TemplateData{
+		Clinic: "Test Clinic",
+		Owner: &lara.GetOwner{Versioned: lara.Versioned{ID: 1},
+			Owner:    lara.Owner{FirstName: "John", LastName: "Doe", Email: "john@doe.com"},
+			Title:    "Ing.",
+			Patients: []lara.OwnersPatient{{ID: 3, Name: "Rex"}}},
+		Patient: &lara.GetPatient{Versioned: lara.Versioned{ID: 3},
+			Patient: lara.Patient{Name: "Rex"},
+			Records: []lara.PatientsRecord{{ID: 5}}},
+		Vaccination: &lara.GetVaccination{Versioned: lara.Versioned{ID: 2},
+			Vaccination: lara.Vaccination{
+				Administered: time.Date(2016, time.June, 5, 0, 0, 0, 0, time.UTC)},
+			PatientID: 3,
+			Vaccine:   "Rabies",
+			Due:       time.Date(2017, time.June, 5, 0, 0, 0, 0, time.UTC)},
+		Appointment: &lara.GetAppointment{Versioned: lara.Versioned{ID: 4},
+			Appointment: lara.Appointment{
+				Start: time.Date(2017, time.May, 1, 10, 30, 0, 0, time.UTC)},
+			PatientID: 3,
+			Note:      "bring passport"},
+		Record: &lara.GetRecord{Versioned: lara.Versioned{ID: 5},
+			Date: time.Date(2017, time.May, 2, 9, 0, 0, 0, time.UTC),
+			Text: "checkup",
+			Items: []lara.GetRecordItem{{
+				RecordItem: lara.RecordItem{Amount: "1", ItemPrice: "10.00"},
+				Product:    "Examination",
+				Unit:       "pc"}},
+			Total: "10.00"},
+	}
+}
+
+func TestRender(t *testing.T) {
+	var tests = []struct {
+		template lara.NotificationTemplate
+		subject  string
+		body     []string
+	}{
+		{lara.VaccinationDue, "Vaccination of Rex is due",
+			[]string{"Dear Ing. John Doe", "Rabies, given on 05.06.2016", "due on 05.06.2017", "Test Clinic"}},
+		{lara.AppointmentConfirmation, "Appointment of Rex on 01.05.2017 10:30",
+			[]string{"Dear Ing. John Doe", "Note: bring passport", "Test Clinic"}},
+		{lara.RecordSummary, "Visit of Rex on 02.05.2017",
+			[]string{"checkup", "Examination, 1 pc: 10.00", "Total: 10.00", "Test Clinic"}},
+	}
+
+	for _, tt := range tests {
+		subject, body, err := notify.Render(tt.template, testData())
+		if err != nil {
+			t.Fatalf("%s: expected nil error, but was %+v", tt.template, err)
+		}
+
+		if subject != tt.subject {
+			t.Errorf("%s: expected subject '%s', got '%s'", tt.template, tt.subject, subject)
+		}
+
+		for _, b := range tt.body {
+			if !strings.Contains(body, b) {
+				t.Errorf("%s: expected body to contain '%s', got '%s'", tt.template, b, body)
+			}
+		}
+	}
+
+	// unknown template
+	_, _, err := notify.Render(lara.NotificationTemplate(100), testData())
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+}
+
+func newService(enqueued *lara.OutboxMessage) *notify.Service {
+	d := testData()
+	owners := &mock.OwnerService{}
+	owners.GetFn = func(id uint64) (*lara.GetOwner, error) { return d.Owner, nil }
+	patients := &mock.PatientService{}
+	patients.GetFn = func(id uint64) (*lara.GetPatient, error) { return d.Patient, nil }
+	records := &mock.RecordService{}
+	records.GetFn = func(id uint64) (*lara.GetRecord, error) { return d.Record, nil }
+	appointments := &mock.AppointmentService{}
+	appointments.GetFn = func(id uint64) (*lara.GetAppointment, error) { return d.Appointment, nil }
+	vaccinations := &mock.VaccinationService{}
+	vaccinations.GetFn = func(id uint64) (*lara.GetVaccination, error) { return d.Vaccination, nil }
+	outbox := &mock.Outbox{}
+	outbox.EnqueueFn = func(m *lara.OutboxMessage) (uint64, error) {
+		*enqueued = *m
+		return 42, nil
+	}
+
+	return &notify.Service{
+		Clinic:       d.Clinic,
+		Outbox:       outbox,
+		Owners:       owners,
+		Patients:     patients,
+		Records:      records,
+		Appointments: appointments,
+		Vaccinations: vaccinations,
+	}
+}
+
+func TestCreateNotification(t *testing.T) {
+	var m lara.OutboxMessage
+	s := newService(&m)
+	ctx := context.Background()
+
+	var tests = []struct {
+		name    string
+		n       lara.CreateNotification
+		expCode int
+	}{
+		{"OwnerMissing", lara.CreateNotification{PatientID: 3}, 400},
+		{"PatientMissing", lara.CreateNotification{OwnerID: 1}, 400},
+		{"PatientNotOwned", lara.CreateNotification{OwnerID: 1, PatientID: 4}, 400},
+		{"VaccinationMissing", lara.CreateNotification{OwnerID: 1, PatientID: 3}, 400},
+		{"RecordNotPatients", lara.CreateNotification{Template: lara.RecordSummary,
+			OwnerID: 1, PatientID: 3, RecordID: 6}, 400},
+		{"NoPhone", lara.CreateNotification{Template: lara.AppointmentConfirmation,
+			Channel: lara.SMS, OwnerID: 1, PatientID: 3, AppointmentID: 4}, 400},
+	}
+
+	for _, tt := range tests {
+		_, err := s.Create(ctx, &tt.n)
+		if ok, actual := checkErrCode(err, tt.expCode); !ok {
+			t.Fatalf("%s: expected error code %d but was %d, %+v", tt.name, tt.expCode, actual, err)
+		}
+	}
+
+	// OK
+	id, err := s.Create(ctx, &lara.CreateNotification{Template: lara.RecordSummary,
+		OwnerID: 1, PatientID: 3, RecordID: 5})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if id != 42 {
+		t.Fatalf("incorrect ID returned %d", id)
+	}
+
+	if m.Recipient != "john@doe.com" || m.Channel != lara.Email || m.Template != lara.RecordSummary ||
+		m.OwnerID != 1 || m.PatientID != 3 || m.Subject != "Visit of Rex on 02.05.2017" {
+		t.Fatalf("unexpected enqueued message %+v", m)
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	type result struct {
+		sent   bool
+		final  bool
+		reason string
+	}
+	results := make(map[uint64]result)
+
+	outbox := &mock.Outbox{}
+	outbox.PendingFn = func(limit int) ([]lara.GetNotification, error) {
+		return []lara.GetNotification{
+			{ID: 1, Message: lara.Message{Channel: lara.Email, Recipient: "a@b.com", Body: "1"}},
+			{ID: 2, Message: lara.Message{Channel: lara.SMS, Recipient: "0900", Body: "2"}},
+			{ID: 3, Message: lara.Message{Channel: lara.SMS, Recipient: "0900", Body: "3"}, Attempts: 4},
+		}, nil
+	}
+	outbox.MarkSentFn = func(id uint64) error {
+		results[id] = result{sent: true}
+		return nil
+	}
+	outbox.MarkFailedFn = func(id uint64, reason string, final bool) error {
+		results[id] = result{final: final, reason: reason}
+		return nil
+	}
+
+	email := &notify.Memory{}
+	d := notify.NewDispatcher(outbox,
+		map[lara.NotificationChannel]lara.Notifier{lara.Email: email})
+
+	sent, err := d.Dispatch(context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if sent != 1 {
+		t.Fatalf("expected 1 sent message, got %d", sent)
+	}
+
+	if msgs := email.Messages(); len(msgs) != 1 || msgs[0].Body != "1" {
+		t.Fatalf("unexpected sent messages %+v", msgs)
+	}
+
+	if r := results[1]; !r.sent {
+		t.Errorf("message 1 not marked as sent %+v", r)
+	}
+	if r := results[2]; r.sent || r.final || r.reason == "" {
+		t.Errorf("message 2 should be retried %+v", r)
+	}
+	if r := results[3]; r.sent || !r.final {
+		t.Errorf("message 3 should be marked as failed %+v", r)
+	}
+
+	// notifier failure
+	email.Err = errors.New("connection refused")
+	if sent, err = d.Dispatch(context.Background()); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if sent != 0 {
+		t.Fatalf("expected 0 sent messages, got %d", sent)
+	}
+	if r := results[1]; r.sent || r.reason != "connection refused" {
+		t.Errorf("message 1 should be retried %+v", r)
+	}
+}
+
+func TestFileNotifier(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lara-notify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f := &notify.File{Path: filepath.Join(dir, "outbox.txt")}
+	for _, body := range []string{"first", "second"} {
+		if err := f.Send(context.Background(), &lara.Message{Channel: lara.SMS,
+			Recipient: "0900", Body: body}); err != nil {
+			t.Fatalf("expected nil error, but was %+v", err)
+		}
+	}
+
+	b, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := string(b)
+	if !strings.Contains(s, "To: 0900") || !strings.Contains(s, "first") ||
+		!strings.Contains(s, "second") {
+		t.Fatalf("unexpected file content '%s'", s)
+	}
+}
+
+// fakeSMTP accepts single SMTP session on l and records client's commands
+func fakeSMTP(t *testing.T, l net.Listener, cmds chan<- []string) {
+	c, err := l.Accept()
+	if err != nil {
+		t.Errorf("accept failed: %+v", err)
+		close(cmds)
+		return
+	}
+	defer c.Close()
+
+	var recorded []string
+	defer func() { cmds <- recorded }()
+
+	tc := textproto.NewConn(c)
+	tc.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := tc.ReadLine()
+		if err != nil {
+			return
+		}
+		recorded = append(recorded, line)
+
+		switch {
+		case strings.HasPrefix(line, "EHLO"):
+			tc.PrintfLine("250 localhost")
+		case line == "DATA":
+			tc.PrintfLine("354 go ahead")
+			if _, err := tc.ReadDotLines(); err != nil {
+				return
+			}
+			tc.PrintfLine("250 queued")
+		case line == "QUIT":
+			tc.PrintfLine("221 bye")
+			return
+		default:
+			tc.PrintfLine("250 ok")
+		}
+	}
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	if _, err := notify.NewSMTP("localhost", 25, "", "", "not an address"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	cmds := make(chan []string, 1)
+	go fakeSMTP(t, l, cmds)
+
+	addr := l.Addr().(*net.TCPAddr)
+	s, err := notify.NewSMTP("127.0.0.1", uint(addr.Port), "", "",
+		"Clinic <lara@clinic.sk>")
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	if err := s.Send(context.Background(), &lara.Message{Channel: lara.Email,
+		Recipient: "John Doe <john@doe.com>", Subject: "Test", Body: "test"}); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	recorded := strings.Join(<-cmds, "\n")
+	if !strings.Contains(recorded, "MAIL FROM:<lara@clinic.sk>") ||
+		!strings.Contains(recorded, "RCPT TO:<john@doe.com>") {
+		t.Fatalf("unexpected SMTP session '%s'", recorded)
+	}
+}
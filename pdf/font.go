@@ -0,0 +1,165 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package pdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Text is encoded in WinAnsiEncoding (Latin-1 for codes 0xA0-0xFF) with
+// unused codes replaced by central european letters missing in it. All
+// glyphs are part of standard Helvetica character set.
+
+// extra maps letters missing in WinAnsiEncoding to code and glyph name
+var extra = []struct {
+	r     rune
+	code  byte
+	glyph string
+}{
+	{'Č', 0x81, "Ccaron"}, {'č', 0x82, "ccaron"},
+	{'Ď', 0x83, "Dcaron"}, {'ď', 0x84, "dcaron"},
+	{'Ľ', 0x85, "Lcaron"}, {'ľ', 0x86, "lcaron"},
+	{'Ĺ', 0x87, "Lacute"}, {'ĺ', 0x88, "lacute"},
+	{'Ň', 0x89, "Ncaron"}, {'ň', 0x8B, "ncaron"},
+	{'Ŕ', 0x8C, "Racute"}, {'ŕ', 0x8D, "racute"},
+	{'Ť', 0x8F, "Tcaron"}, {'ť', 0x90, "tcaron"},
+	{'Ř', 0x91, "Rcaron"}, {'ř', 0x92, "rcaron"},
+	{'Ě', 0x93, "Ecaron"}, {'ě', 0x94, "ecaron"},
+	{'Ů', 0x95, "Uring"}, {'ů', 0x96, "uring"},
+}
+
+// winAnsi are letters of WinAnsiEncoding outside of Latin-1 range which
+// are kept by encoding
+var winAnsi = map[rune]byte{
+	'€': 0x80, 'Š': 0x8A, 'Ž': 0x8E, 'š': 0x9A, 'ž': 0x9E,
+}
+
+// replacements of typographic characters without code
+var replacements = map[rune]rune{
+	'‘': '\'', '’': '\'', '‚': ',', '“': '"', '”': '"', '„': '"',
+	'–': '-', '—': '-', '…': '.', '\t': ' ',
+}
+
+var runeCodes = func() map[rune]byte {
+	m := make(map[rune]byte, len(extra)+len(winAnsi))
+	for _, e := range extra {
+		m[e.r] = e.code
+	}
+	for r, c := range winAnsi {
+		m[r] = c
+	}
+	return m
+}()
+
+func differences() string {
+	s := make([]string, 0, len(extra))
+	next := -1
+	for _, e := range extra {
+		if int(e.code) != next {
+			s = append(s, fmt.Sprintf("%d", e.code))
+		}
+		s = append(s, "/"+e.glyph)
+		next = int(e.code) + 1
+	}
+	return strings.Join(s, " ")
+}
+
+// code returns byte code of r, '?' if r can't be encoded
+func code(r rune) byte {
+	if rr, ok := replacements[r]; ok {
+		r = rr
+	}
+	switch {
+	case r >= 0x20 && r < 0x7F, r >= 0xA0 && r <= 0xFF:
+		return byte(r)
+	}
+	if c, ok := runeCodes[r]; ok {
+		return c
+	}
+	return '?'
+}
+
+// encode returns s encoded as content of PDF literal string
+func encode(s string) string {
+	var b []byte
+	for _, r := range s {
+		c := code(r)
+		switch {
+		case c == '(', c == ')', c == '\\':
+			b = append(b, '\\', c)
+		case c >= 0x80:
+			b = append(b, []byte(fmt.Sprintf("\\%03o", c))...)
+		default:
+			b = append(b, c)
+		}
+	}
+	return string(b)
+}
+
+// widths of ASCII characters 0x20-0x7E in 1/1000 of font size
+var widths = [...][95]int{
+	regular: {
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584},
+	bold: {
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584},
+}
+
+// accented letters are measured as their base letter
+var baseLetters = func() map[rune]rune {
+	const pairs = "ÀAÁAÂAÃAÄAÅAÇCÈEÉEÊEËEÌIÍIÎIÏIÑNÒOÓOÔOÕOÖOÙUÚUÛUÜUÝY" +
+		"àaáaâaãaäaåaçcèeéeêeëeìiíiîiïiñnòoóoôoõoöoùuúuûuüuýyÿy" +
+		"ŠSšsŽZžzČCčcĎDďdĽLľlĹLĺlŇNňnŔRŕrŤTťtŘRřrĚEěeŮUůu"
+	m := make(map[rune]rune)
+	rs := []rune(pairs)
+	for i := 0; i+1 < len(rs); i += 2 {
+		m[rs[i]] = rs[i+1]
+	}
+	return m
+}()
+
+// textWidth returns width of s in points
+func textWidth(s string, f font, size float64) float64 {
+	w := 0
+	for _, r := range s {
+		if rr, ok := replacements[r]; ok {
+			r = rr
+		}
+		if b, ok := baseLetters[r]; ok {
+			r = b
+		}
+		if r >= 0x20 && r < 0x7F {
+			w += widths[f][r-0x20]
+		} else {
+			w += 556
+		}
+	}
+	return float64(w) * size / 1000
+}
@@ -0,0 +1,322 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package pdf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jkusniar/lara"
+)
+
+const (
+	margin       = 50.0
+	lineHeight   = 1.35 // relative to font size
+	bottom       = pageHeight - margin - 10
+	textWidthMax = pageWidth - 2*margin
+)
+
+// font sizes
+const (
+	small  = 8.0
+	normal = 10.0
+	large  = 12.0
+	title  = 16.0
+)
+
+// layout writes content top to bottom, starting new page when current one
+// is full
+type layout struct {
+	doc *Document
+	y   float64 // top of next line
+}
+
+func newLayout(docTitle string) *layout {
+	l := &layout{doc: &Document{Title: docTitle}}
+	l.newPage()
+	return l
+}
+
+func (l *layout) newPage() {
+	l.doc.AddPage()
+	l.y = margin
+}
+
+// ensure starts new page if h points don't fit on current one
+func (l *layout) ensure(h float64) {
+	if l.y+h > bottom {
+		l.newPage()
+	}
+}
+
+func (l *layout) space(h float64) {
+	l.y += h
+}
+
+// rule draws horizontal line over full width
+func (l *layout) rule() {
+	l.ensure(6)
+	l.doc.Line(margin, l.y, pageWidth-margin, l.y, 0.5)
+	l.y += 6
+}
+
+// paragraph writes text s wrapped to full width
+func (l *layout) paragraph(s string, f font, size float64) {
+	if s == "" {
+		return
+	}
+	for _, line := range wrap(s, f, size, textWidthMax) {
+		l.ensure(size * lineHeight)
+		l.doc.Text(margin, l.y+size, f, size, line)
+		l.y += size * lineHeight
+	}
+}
+
+// heading writes document title, with optional right aligned subtitle
+func (l *layout) heading(s, right string) {
+	l.ensure(title * lineHeight)
+	l.doc.Text(margin, l.y+title, bold, title, s)
+	if right != "" {
+		l.doc.Text(pageWidth-margin-textWidth(right, bold, title), l.y+title, bold, title, right)
+	}
+	l.y += title*lineHeight + 4
+}
+
+// fields writes label: value pairs, empty values are skipped
+func (l *layout) fields(pairs ...string) {
+	const labelWidth = 110
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			continue
+		}
+		lines := wrap(pairs[i+1], regular, normal, textWidthMax-labelWidth)
+		l.ensure(normal * lineHeight)
+		l.doc.Text(margin, l.y+normal, bold, normal, pairs[i]+":")
+		for _, line := range lines {
+			l.ensure(normal * lineHeight)
+			l.doc.Text(margin+labelWidth, l.y+normal, regular, normal, line)
+			l.y += normal * lineHeight
+		}
+	}
+}
+
+// blocks writes blocks of lines side by side, first line of block is bold
+func (l *layout) blocks(blocks ...[]string) {
+	w := textWidthMax / float64(len(blocks))
+	var rows [][]string
+	for _, b := range blocks {
+		var lines []string
+		for _, s := range b {
+			if s == "" {
+				continue
+			}
+			lines = append(lines, wrap(s, regular, normal, w-10)...)
+		}
+		rows = append(rows, lines)
+	}
+
+	h := 0.0
+	for _, r := range rows {
+		if rh := float64(len(r)) * normal * lineHeight; rh > h {
+			h = rh
+		}
+	}
+	l.ensure(h)
+
+	for i, r := range rows {
+		for j, s := range r {
+			f := regular
+			if j == 0 {
+				f = bold
+			}
+			l.doc.Text(margin+float64(i)*w, l.y+normal+float64(j)*normal*lineHeight, f, normal, s)
+		}
+	}
+	l.y += h
+}
+
+type column struct {
+	title string
+	width float64
+	right bool // right aligned
+}
+
+// table writes rows with header repeated on each page. Cells are wrapped
+// to column's width.
+func (l *layout) table(cols []column, rows [][]string) {
+	const size = 9.0
+	lh := size * lineHeight
+
+	header := func() {
+		l.ensure(2 * lh)
+		l.row(cols, bold, size, func(i int) []string { return []string{cols[i].title} })
+		l.doc.Line(margin, l.y, pageWidth-margin, l.y, 0.5)
+		l.y += 3
+	}
+	header()
+
+	for _, r := range rows {
+		cells := make([][]string, len(cols))
+		n := 1
+		for i := range cols {
+			if i < len(r) {
+				cells[i] = wrap(r[i], regular, size, cols[i].width-4)
+			}
+			if len(cells[i]) > n {
+				n = len(cells[i])
+			}
+		}
+		if l.y+float64(n)*lh > bottom {
+			l.newPage()
+			header()
+		}
+		l.row(cols, regular, size, func(i int) []string { return cells[i] })
+	}
+
+	l.doc.Line(margin, l.y, pageWidth-margin, l.y, 0.5)
+	l.y += 3
+}
+
+// row writes one table row, cell returns lines of i-th column
+func (l *layout) row(cols []column, f font, size float64, cell func(i int) []string) {
+	lh := size * lineHeight
+	x, n := margin, 1
+	for i, c := range cols {
+		lines := cell(i)
+		for j, s := range lines {
+			tx := x
+			if c.right {
+				tx = x + c.width - 2 - textWidth(s, f, size)
+			}
+			l.doc.Text(tx, l.y+size+float64(j)*lh, f, size, s)
+		}
+		if len(lines) > n {
+			n = len(lines)
+		}
+		x += c.width
+	}
+	l.y += float64(n) * lh
+}
+
+// totals writes right aligned label and value pairs
+func (l *layout) totals(pairs ...string) {
+	const valueWidth = 80
+	for i := 0; i+1 < len(pairs); i += 2 {
+		f := regular
+		if i+2 >= len(pairs) {
+			f = bold
+		}
+		l.ensure(normal * lineHeight)
+		x := pageWidth - margin - valueWidth
+		l.doc.Text(x-10-textWidth(pairs[i], f, normal), l.y+normal, f, normal, pairs[i])
+		l.doc.Text(pageWidth-margin-textWidth(pairs[i+1], f, normal), l.y+normal, f, normal, pairs[i+1])
+		l.y += normal * lineHeight
+	}
+}
+
+// clinicHeader writes clinic's name and contacts
+func (l *layout) clinicHeader(c *lara.Clinic) {
+	if c == nil || c.Name == "" {
+		return
+	}
+
+	l.paragraph(c.Name, bold, large)
+	l.paragraph(c.Address, regular, small)
+	l.paragraph(join(", ", prefixed("IC: ", c.IC), prefixed("DIC: ", c.DIC),
+		prefixed("IC DPH: ", c.ICDPH)), regular, small)
+	l.paragraph(join(", ", prefixed("Phone: ", c.Phone), prefixed("Email: ", c.Email)),
+		regular, small)
+	l.rule()
+	l.space(6)
+}
+
+// bytes writes footer with page numbers to all pages and returns PDF document
+func (l *layout) bytes(footer string) ([]byte, error) {
+	n := l.doc.PageCount()
+	y := pageHeight - margin/2
+	for i := 0; i < n; i++ {
+		l.doc.SetPage(i)
+		l.doc.Text(margin, y, regular, small, footer)
+		p := fmt.Sprintf("%d / %d", i+1, n)
+		l.doc.Text(pageWidth-margin-textWidth(p, regular, small), y, regular, small, p)
+	}
+	return l.doc.Bytes()
+}
+
+// wrap splits s to lines not wider than w. New lines in s are kept.
+func wrap(s string, f font, size, w float64) []string {
+	var lines []string
+	for _, p := range strings.Split(strings.Replace(s, "\r", "", -1), "\n") {
+		cur := ""
+		for _, word := range strings.Fields(p) {
+			next := word
+			if cur != "" {
+				next = cur + " " + word
+			}
+			if textWidth(next, f, size) <= w {
+				cur = next
+				continue
+			}
+			if cur != "" {
+				lines = append(lines, cur)
+			}
+			// split words longer than line
+			for textWidth(word, f, size) > w {
+				rs := []rune(word)
+				i := len(rs) - 1
+				for i > 1 && textWidth(string(rs[:i]), f, size) > w {
+					i--
+				}
+				lines = append(lines, string(rs[:i]))
+				word = string(rs[i:])
+			}
+			cur = word
+		}
+		lines = append(lines, cur)
+	}
+	return lines
+}
+
+// join joins non empty strings with sep
+func join(sep string, s ...string) string {
+	var r []string
+	for _, v := range s {
+		if v != "" {
+			r = append(r, v)
+		}
+	}
+	return strings.Join(r, sep)
+}
+
+// prefixed returns s with prefix p or empty string if s is empty
+func prefixed(p, s string) string {
+	if s == "" {
+		return ""
+	}
+	return p + s
+}
+
+// suffixed returns s with suffix x or empty string if s is empty
+func suffixed(s, x string) string {
+	if s == "" {
+		return ""
+	}
+	return s + x
+}
@@ -0,0 +1,183 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package pdf renders printable documents (invoices, record summaries,
+// patient's history and vaccination certificates) to PDF. Documents use
+// PDF's built-in Helvetica fonts, so no font files or external binaries
+// are required.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+)
+
+// A4 page size in points
+const (
+	pageWidth  = 595.28
+	pageHeight = 841.89
+)
+
+type font int
+
+const (
+	regular font = iota
+	bold
+)
+
+// resource name and base font of font
+var fonts = [...]struct {
+	name     string
+	baseFont string
+}{
+	regular: {"F1", "Helvetica"},
+	bold:    {"F2", "Helvetica-Bold"},
+}
+
+// Document is minimal PDF writer of A4 pages containing text and lines.
+// Coordinates are in points, origin is top left corner of page.
+type Document struct {
+	Title string
+	pages []*bytes.Buffer
+	cur   int
+}
+
+// AddPage adds new page to document and makes it current
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, new(bytes.Buffer))
+	d.cur = len(d.pages) - 1
+}
+
+// PageCount returns number of pages in document
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// SetPage makes i-th (zero based) page current
+func (d *Document) SetPage(i int) {
+	d.cur = i
+}
+
+func (d *Document) content() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[d.cur]
+}
+
+// Text writes single line of text s with baseline at y
+func (d *Document) Text(x, y float64, f font, size float64, s string) {
+	fmt.Fprintf(d.content(), "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n",
+		fonts[f].name, size, x, pageHeight-y, encode(s))
+}
+
+// Line draws line of width w from x1, y1 to x2, y2
+func (d *Document) Line(x1, y1, x2, y2, w float64) {
+	fmt.Fprintf(d.content(), "%.2f w %.2f %.2f m %.2f %.2f l S\n",
+		w, x1, pageHeight-y1, x2, pageHeight-y2)
+}
+
+// Bytes returns document encoded as PDF
+func (d *Document) Bytes() ([]byte, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	w := &objWriter{}
+	w.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// fixed objects: 1 catalog, 2 pages, 3 info, 4 encoding, 5.. fonts,
+	// then page and page content pairs
+	firstPage := 5 + len(fonts)
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+
+	w.object("<< /Type /Catalog /Pages 2 0 R >>")
+	w.object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>",
+		strings.Join(kids, " "), len(d.pages)))
+	w.object(fmt.Sprintf("<< /Title (%s) /Producer (lara) >>", encode(d.Title)))
+	w.object(fmt.Sprintf("<< /Type /Encoding /BaseEncoding /WinAnsiEncoding /Differences [%s] >>",
+		differences()))
+
+	fontRes := make([]string, len(fonts))
+	for i, f := range fonts {
+		w.object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding 4 0 R >>",
+			f.baseFont))
+		fontRes[i] = fmt.Sprintf("/%s %d 0 R", f.name, 5+i)
+	}
+
+	for i, p := range d.pages {
+		w.object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, strings.Join(fontRes, " "), firstPage+2*i+1))
+
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		if _, err := zw.Write(p.Bytes()); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		w.stream(z.Bytes())
+	}
+
+	w.trailer()
+	return w.buf.Bytes(), nil
+}
+
+// objWriter writes numbered indirect objects and keeps their offsets for
+// cross-reference table
+type objWriter struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+func (w *objWriter) begin() {
+	w.offsets = append(w.offsets, w.buf.Len())
+	fmt.Fprintf(&w.buf, "%d 0 obj\n", len(w.offsets))
+}
+
+func (w *objWriter) object(dict string) {
+	w.begin()
+	w.buf.WriteString(dict)
+	w.buf.WriteString("\nendobj\n")
+}
+
+// stream writes flate compressed stream object
+func (w *objWriter) stream(data []byte) {
+	w.begin()
+	fmt.Fprintf(&w.buf, "<< /Length %d /Filter /FlateDecode >>\nstream\n", len(data))
+	w.buf.Write(data)
+	w.buf.WriteString("\nendstream\nendobj\n")
+}
+
+func (w *objWriter) trailer() {
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, o := range w.offsets {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(w.offsets)+1, xref)
+}
@@ -0,0 +1,280 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package pdf
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jkusniar/lara"
+)
+
+func date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("02.01.2006")
+}
+
+func ownerName(o *lara.GetOwner) string {
+	return join(" ", o.Title, o.FirstName, o.LastName)
+}
+
+func ownerAddress(o *lara.GetOwner) string {
+	return join(", ", join(" ", o.Street, o.HouseNo), o.City)
+}
+
+// patientFields writes patient's and owner's identification
+func (l *layout) patientFields(o *lara.GetOwner, p *lara.GetPatient) {
+	var tags []string
+	for _, t := range p.Tags {
+		tags = append(tags, t.Type+" "+t.Value)
+	}
+
+	l.fields(
+		"Patient", p.Name,
+		"Species", join(", ", p.Species, p.Breed),
+		"Gender", p.Gender,
+		"Birth date", date(p.BirthDate),
+		"Identification", join(", ", tags...),
+		"Owner", ownerName(o),
+		"Address", ownerAddress(o),
+		"Phone", join(", ", o.Phone1, o.Phone2))
+	l.space(8)
+}
+
+var itemColumns = []column{
+	{"Item", 235, false},
+	{"Amount", 60, true},
+	{"Unit", 40, false},
+	{"Unit price", 70, true},
+	{"Price", 90, true},
+}
+
+// recordItems writes record's items followed by total
+func (l *layout) recordItems(r *lara.GetRecord) {
+	if len(r.Items) == 0 {
+		return
+	}
+
+	rows := make([][]string, len(r.Items))
+	for i, itm := range r.Items {
+		name := itm.Product
+		if len(itm.Batch) > 0 {
+			name = fmt.Sprintf("%s (batch %s)", name, itm.Batch)
+		}
+		rows[i] = []string{name, itm.Amount, itm.Unit, itm.ProductPrice, itm.ItemPrice}
+	}
+	l.table(itemColumns, rows)
+	l.totals("Total", r.Total)
+}
+
+// recordClinical writes record's structured SOAP sections, vitals and diagnoses
+func (l *layout) recordClinical(r *lara.GetRecord) {
+	heartRate := ""
+	if r.HeartRate > 0 {
+		heartRate = fmt.Sprintf("%d/min", r.HeartRate)
+	}
+
+	var diagnoses []string
+	for _, d := range r.Diagnoses {
+		diagnoses = append(diagnoses, d.Code+" "+d.Name)
+	}
+
+	l.fields(
+		"Subjective", r.Subjective,
+		"Objective", r.Objective,
+		"Vitals", join(", ",
+			prefixed("weight ", suffixed(r.Weight, " kg")),
+			prefixed("temperature ", suffixed(r.Temperature, " °C")),
+			prefixed("heart rate ", heartRate)),
+		"Assessment", r.Assessment,
+		"Diagnoses", join(", ", diagnoses...),
+		"Plan", r.Plan)
+}
+
+// Record renders summary of patient's record r
+func Record(c *lara.Clinic, o *lara.GetOwner, p *lara.GetPatient, r *lara.GetRecord) ([]byte, error) {
+	l := newLayout(fmt.Sprintf("Record summary - %s %s", p.Name, date(r.Date)))
+	l.clinicHeader(c)
+	l.heading("Record summary", date(r.Date))
+	l.patientFields(o, p)
+	l.paragraph(r.Text, regular, normal)
+	l.recordClinical(r)
+	l.space(8)
+	l.recordItems(r)
+
+	return l.bytes(clinicName(c))
+}
+
+// History renders patient's clinical history from records
+func History(c *lara.Clinic, o *lara.GetOwner, p *lara.GetPatient, records []*lara.GetRecord) ([]byte, error) {
+	l := newLayout(fmt.Sprintf("Clinical history - %s", p.Name))
+	l.clinicHeader(c)
+	l.heading("Clinical history", "")
+	l.patientFields(o, p)
+
+	for _, r := range records {
+		l.ensure(3 * large * lineHeight)
+		l.paragraph(date(r.Date), bold, large)
+		l.paragraph(r.Text, regular, normal)
+		l.recordClinical(r)
+		l.space(4)
+		l.recordItems(r)
+		l.space(10)
+	}
+
+	return l.bytes(join(" - ", clinicName(c), p.Name))
+}
+
+// VaccinationCertificate renders certificate of patient's vaccinations
+func VaccinationCertificate(c *lara.Clinic, o *lara.GetOwner, p *lara.GetPatient,
+	vaccinations []*lara.GetVaccination) ([]byte, error) {
+	l := newLayout(fmt.Sprintf("Vaccination certificate - %s", p.Name))
+	l.clinicHeader(c)
+	l.heading("Vaccination certificate", "")
+	l.patientFields(o, p)
+
+	rows := make([][]string, len(vaccinations))
+	for i, v := range vaccinations {
+		rows[i] = []string{date(v.Administered), v.Vaccine, v.Batch, date(v.Due), v.Vet}
+	}
+	l.table([]column{
+		{"Date", 70, false},
+		{"Vaccine", 175, false},
+		{"Batch", 90, false},
+		{"Valid until", 70, false},
+		{"Veterinarian", 90, false},
+	}, rows)
+
+	l.space(40)
+	l.paragraph(fmt.Sprintf("Issued on %s", date(time.Now())), regular, normal)
+	l.space(30)
+	l.paragraph("Signature and stamp: ..............................", regular, normal)
+
+	return l.bytes(join(" - ", clinicName(c), p.Name))
+}
+
+// Prescription renders prescription issued to patient. Withdrawal period is
+// highlighted, so owners of food-producing animals don't miss it.
+func Prescription(c *lara.Clinic, o *lara.GetOwner, p *lara.GetPatient, rx *lara.GetPrescription) ([]byte, error) {
+	l := newLayout(fmt.Sprintf("Prescription - %s %s", p.Name, date(rx.Date)))
+	l.clinicHeader(c)
+	l.heading("Prescription", date(rx.Date))
+	l.patientFields(o, p)
+
+	rows := make([][]string, len(rx.Items))
+	for i, itm := range rx.Items {
+		duration := ""
+		if itm.DurationDays > 0 {
+			duration = fmt.Sprintf("%d days", itm.DurationDays)
+		}
+		rows[i] = []string{itm.Product, join(" ", itm.Amount, itm.Unit), itm.Dose, itm.Frequency, duration,
+			date(itm.WithdrawalEnd)}
+	}
+	l.table([]column{
+		{"Drug", 140, false},
+		{"Amount", 65, true},
+		{"Dose", 85, false},
+		{"Frequency", 85, false},
+		{"Duration", 50, true},
+		{"Withdrawal", 70, false},
+	}, rows)
+	l.space(8)
+
+	if !rx.WithdrawalEnd.IsZero() {
+		l.paragraph(fmt.Sprintf("Meat, milk, eggs and honey of treated animal must not be used for human "+
+			"consumption before %s.", date(rx.WithdrawalEnd)), bold, normal)
+		l.space(8)
+	}
+	l.paragraph(rx.Note, regular, normal)
+
+	l.space(30)
+	l.paragraph("Signature and stamp: ..............................", regular, normal)
+
+	return l.bytes(join(" - ", clinicName(c), p.Name))
+}
+
+// Invoice renders issued invoice
+func Invoice(i *lara.GetInvoice) ([]byte, error) {
+	l := newLayout("Invoice " + i.Number)
+	l.heading("Invoice", i.Number)
+
+	s, c := i.Supplier, i.Customer
+	l.blocks(
+		[]string{"Supplier", s.Name, s.Address, prefixed("IC: ", s.IC), prefixed("DIC: ", s.DIC),
+			prefixed("IC DPH: ", s.ICDPH), prefixed("Phone: ", s.Phone), prefixed("Email: ", s.Email)},
+		[]string{"Customer", c.Name, c.Address, prefixed("IC: ", c.IC), prefixed("DIC: ", c.DIC),
+			prefixed("IC DPH: ", c.ICDPH)})
+	l.space(10)
+
+	l.fields(
+		"Issue date", date(i.IssueDate),
+		"Delivery date", date(i.DeliveryDate),
+		"Due date", date(i.DueDate),
+		"IBAN", s.IBAN,
+		"Variable symbol", i.Number)
+	l.space(10)
+
+	rows := make([][]string, len(i.Lines))
+	for n, ln := range i.Lines {
+		rows[n] = []string{ln.Product, ln.Amount, ln.Unit, ln.ProductPrice, ln.VATRate,
+			ln.ItemNet, ln.ItemVAT, ln.ItemPrice}
+	}
+	l.table([]column{
+		{"Item", 150, false},
+		{"Amount", 45, true},
+		{"Unit", 35, false},
+		{"Unit price", 55, true},
+		{"VAT %", 40, true},
+		{"Net", 55, true},
+		{"VAT", 50, true},
+		{"Total", 65, true},
+	}, rows)
+	l.space(6)
+
+	if len(i.VAT) > 0 {
+		rows = make([][]string, len(i.VAT))
+		for n, v := range i.VAT {
+			rows[n] = []string{v.Rate, v.Net, v.VAT, v.Gross}
+		}
+		l.table([]column{
+			{"VAT %", 60, true},
+			{"Net", 80, true},
+			{"VAT", 80, true},
+			{"Total", 80, true},
+		}, rows)
+		l.space(6)
+	}
+
+	l.totals(
+		"Total net", i.TotalNet,
+		"Total VAT", i.TotalVAT,
+		"Total to pay", i.Total)
+
+	return l.bytes(join(" - ", s.Name, "Invoice "+i.Number))
+}
+
+func clinicName(c *lara.Clinic) string {
+	if c == nil {
+		return ""
+	}
+	return c.Name
+}
@@ -0,0 +1,135 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package pdf
+
+import (
+	"context"
+
+	"github.com/jkusniar/lara"
+	"github.com/pkg/errors"
+)
+
+// Service is lara.DocumentService implementation loading documents' data
+// from other services
+type Service struct {
+	Clinic        *lara.Clinic // clinic printed in header of documents
+	Owners        lara.OwnerService
+	Patients      lara.PatientService
+	Records       lara.RecordService
+	Vaccinations  lara.VaccinationService
+	Invoices      lara.InvoiceService
+	Prescriptions lara.PrescriptionService
+}
+
+// Invoice is implementation of DocumentService.Invoice. Supplier is taken
+// from invoice, as it was at the time of issue.
+func (s *Service) Invoice(ctx context.Context, id uint64) ([]byte, error) {
+	i, err := s.Invoices.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return Invoice(i)
+}
+
+// Record is implementation of DocumentService.Record
+func (s *Service) Record(ctx context.Context, id uint64) ([]byte, error) {
+	r, err := s.Records.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	o, p, err := s.patient(ctx, r.PatientID)
+	if err != nil {
+		return nil, err
+	}
+
+	return Record(s.Clinic, o, p, r)
+}
+
+// PatientHistory is implementation of DocumentService.PatientHistory
+func (s *Service) PatientHistory(ctx context.Context, patientID uint64) ([]byte, error) {
+	o, p, err := s.patient(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*lara.GetRecord, len(p.Records))
+	for i, pr := range p.Records {
+		if records[i], err = s.Records.Get(ctx, pr.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return History(s.Clinic, o, p, records)
+}
+
+// VaccinationCertificate is implementation of
+// DocumentService.VaccinationCertificate
+func (s *Service) VaccinationCertificate(ctx context.Context, patientID uint64) ([]byte, error) {
+	o, p, err := s.patient(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(p.Vaccinations) == 0 {
+		return nil, lara.NewCodedError(400,
+			errors.Errorf("patient %d has no vaccinations", patientID))
+	}
+
+	vaccinations := make([]*lara.GetVaccination, len(p.Vaccinations))
+	for i, pv := range p.Vaccinations {
+		if vaccinations[i], err = s.Vaccinations.Get(ctx, pv.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return VaccinationCertificate(s.Clinic, o, p, vaccinations)
+}
+
+// Prescription is implementation of DocumentService.Prescription
+func (s *Service) Prescription(ctx context.Context, id uint64) ([]byte, error) {
+	rx, err := s.Prescriptions.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	o, p, err := s.patient(ctx, rx.PatientID)
+	if err != nil {
+		return nil, err
+	}
+
+	return Prescription(s.Clinic, o, p, rx)
+}
+
+// patient loads patient and it's owner
+func (s *Service) patient(ctx context.Context, id uint64) (*lara.GetOwner, *lara.GetPatient, error) {
+	p, err := s.Patients.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	o, err := s.Owners.Get(ctx, p.OwnerID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return o, p, nil
+}
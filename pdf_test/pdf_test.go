@@ -0,0 +1,350 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package pdf_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jkusniar/lara"
+	"github.com/jkusniar/lara/mock"
+	"github.com/jkusniar/lara/pdf"
+	"github.com/pkg/errors"
+)
+
+func checkErrCode(err error, expCode int) (codeEqual bool, actualCode int) {
+	type codedError interface {
+		Code() int
+	}
+
+	if ce, ok := errors.Cause(err).(codedError); ok {
+		codeEqual = ce.Code() == expCode
+		actualCode = ce.Code()
+	}
+
+	return
+}
+
+var (
+	clinic = &lara.Clinic{Name: "Test Clinic", Address: "Main 1, Bratislava", IC: "12345678"}
+	owner  = &lara.GetOwner{Versioned: lara.Versioned{ID: 1},
+		Owner:    lara.Owner{FirstName: "Ján", LastName: "Čierny", HouseNo: "5", Phone1: "0900123456"},
+		Title:    "Ing.",
+		City:     "Košice",
+		Street:   "Hlavná",
+		Patients: []lara.OwnersPatient{{ID: 3, Name: "Rex"}}}
+	patient = &lara.GetPatient{Versioned: lara.Versioned{ID: 3},
+		Patient:      lara.Patient{Name: "Rex"},
+		OwnerID:      1,
+		Species:      "Pes",
+		Records:      []lara.PatientsRecord{{ID: 5}, {ID: 6}},
+		Tags:         []lara.PatientsTag{{ID: 1, Type: "RFID", Value: "900123456789012"}},
+		Vaccinations: []lara.PatientsVaccination{{ID: 2}}}
+	vaccination = &lara.GetVaccination{Versioned: lara.Versioned{ID: 2},
+		Vaccination: lara.Vaccination{Batch: "B-1", Vet: "vet1",
+			Administered: time.Date(2016, time.June, 5, 0, 0, 0, 0, time.UTC)},
+		PatientID: 3,
+		Vaccine:   "Rabies",
+		Due:       time.Date(2017, time.June, 5, 0, 0, 0, 0, time.UTC)}
+	invoice = &lara.GetInvoice{ID: 7, Number: "2017000001", OwnerID: 1,
+		Supplier: *clinic,
+		Customer: lara.InvoiceCustomer{Name: "Ing. Ján Čierny", Address: "Hlavná 5, Košice"},
+		Lines: []lara.InvoiceLine{{RecordID: 5, Product: "Vyšetrenie", Unit: "ks",
+			ProductPrice: "12.00", Amount: "1.0000", ItemPrice: "12.00",
+			VATRate: "20.00", ItemNet: "10.00", ItemVAT: "2.00"}},
+		Total: "12.00", TotalNet: "10.00", TotalVAT: "2.00",
+		VAT: []lara.VATBreakdown{{Rate: "20.00", Net: "10.00", VAT: "2.00", Gross: "12.00"}}}
+	prescription = &lara.GetPrescription{Versioned: lara.Versioned{ID: 4},
+		RecordID:  5,
+		PatientID: 3,
+		Date:      time.Date(2017, time.May, 2, 9, 0, 0, 0, time.UTC),
+		Note:      "keep separated from herd",
+		Items: []lara.GetPrescriptionItem{{
+			PrescriptionItem: lara.PrescriptionItem{Amount: "10.0000", Dose: "2 ml", Frequency: "once a day",
+				DurationDays: 5, WithdrawalDays: 28},
+			Product:       "Penicilín",
+			Unit:          "ml",
+			WithdrawalEnd: time.Date(2017, time.June, 4, 9, 0, 0, 0, time.UTC)}},
+		WithdrawalEnd: time.Date(2017, time.June, 4, 9, 0, 0, 0, time.UTC)}
+)
+
+func record(id uint64, text string) *lara.GetRecord {
+	return &lara.GetRecord{Versioned: lara.Versioned{ID: id},
+		PatientID: 3,
+		Date:      time.Date(2017, time.May, 2, 9, 0, 0, 0, time.UTC),
+		Text:      text,
+		Items: []lara.GetRecordItem{{
+			RecordItem: lara.RecordItem{Amount: "1.0000", ProductPrice: "10.00", ItemPrice: "10.00"},
+			Product:    "Očkovanie (Rabies) \\ špeciál",
+			Unit:       "ks"}},
+		Total: "10.00"}
+}
+
+var objRe = regexp.MustCompile(`(?m)^(\d+) 0 obj`)
+
+// checkPDF validates structure of PDF document b and returns it's
+// decompressed page contents
+func checkPDF(t *testing.T, b []byte) (pages int, content string) {
+	if !bytes.HasPrefix(b, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(b, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+
+	// cross-reference table must point to objects
+	i := bytes.LastIndex(b, []byte("startxref\n"))
+	xref, err := strconv.Atoi(strings.Fields(string(b[i+len("startxref\n"):]))[0])
+	if err != nil || !bytes.HasPrefix(b[xref:], []byte("xref\n")) {
+		t.Fatalf("invalid startxref %d, %v", xref, err)
+	}
+	lines := strings.Split(string(b[xref:]), "\n")
+	count, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+	if n := len(objRe.FindAll(b, -1)); n != count-1 {
+		t.Fatalf("expected %d objects but was %d", count-1, n)
+	}
+	for n := 1; n < count; n++ {
+		off, _ := strconv.Atoi(lines[2+n][:10])
+		if !bytes.HasPrefix(b[off:], []byte(fmt.Sprintf("%d 0 obj", n))) {
+			t.Fatalf("xref entry of object %d points to wrong offset %d", n, off)
+		}
+	}
+
+	// decompress streams
+	var c bytes.Buffer
+	for _, s := range bytes.Split(b, []byte("\nstream\n"))[1:] {
+		r, err := zlib.NewReader(bytes.NewReader(s[:bytes.Index(s, []byte("\nendstream"))]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Write(d)
+		pages++
+	}
+
+	if !bytes.Contains(b, []byte(fmt.Sprintf("/Count %d", pages))) {
+		t.Fatalf("page count %d not found", pages)
+	}
+
+	return pages, c.String()
+}
+
+func TestRecord(t *testing.T) {
+	r := record(5, "checkup (teplota 38,5 °C)")
+	r.Objective = "dental tartar"
+	r.Weight = "12.500"
+	r.HeartRate = 90
+	r.Diagnoses = []lara.Diagnosis{{ID: 1, Code: "K08", Name: "dental disease"}}
+	b, err := pdf.Record(clinic, owner, patient, r)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	pages, c := checkPDF(t, b)
+	if pages != 1 {
+		t.Fatalf("expected 1 page but was %d", pages)
+	}
+
+	for _, s := range []string{
+		"(Test Clinic)",
+		"(Record summary)",
+		"(02.05.2017)",
+		"(Ing. J\\341n \\201ierny)",                  // WinAnsi á, extra Č
+		"(Hlavn\\341 5, Ko\\232ice)",                 // WinAnsi š
+		"(checkup \\(teplota 38,5 \\260C\\))",        // escaped parens
+		"(O\\202kovanie \\(Rabies\\) \\\\ \\232peci", // escaped backslash
+		"(RFID 900123456789012)",
+		"(dental tartar)",
+		"(weight 12.500 kg, heart rate 90/min)",
+		"(K08 dental disease)",
+		"(1 / 1)",
+	} {
+		if !strings.Contains(c, s) {
+			t.Fatalf("expected %s in content:\n%s", s, c)
+		}
+	}
+}
+
+func TestHistoryPageBreak(t *testing.T) {
+	var records []*lara.GetRecord
+	for i := 0; i < 20; i++ {
+		records = append(records, record(uint64(i), strings.Repeat("very long record text ", 40)))
+	}
+
+	b, err := pdf.History(clinic, owner, patient, records)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	pages, c := checkPDF(t, b)
+	if pages < 2 {
+		t.Fatalf("expected more pages but was %d", pages)
+	}
+	if !strings.Contains(c, fmt.Sprintf("(%d / %d)", pages, pages)) {
+		t.Fatal("missing page number")
+	}
+}
+
+func TestVaccinationCertificate(t *testing.T) {
+	b, err := pdf.VaccinationCertificate(clinic, owner, patient, []*lara.GetVaccination{vaccination})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	_, c := checkPDF(t, b)
+	for _, s := range []string{"(Vaccination certificate)", "(Rabies)", "(B-1)", "(05.06.2017)"} {
+		if !strings.Contains(c, s) {
+			t.Fatalf("expected %s in content:\n%s", s, c)
+		}
+	}
+}
+
+func TestInvoice(t *testing.T) {
+	b, err := pdf.Invoice(invoice)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	_, c := checkPDF(t, b)
+	for _, s := range []string{"(2017000001)", "(Vy\\232etrenie)", "(Total to pay)", "(12.00)", "(IC: 12345678)"} {
+		if !strings.Contains(c, s) {
+			t.Fatalf("expected %s in content:\n%s", s, c)
+		}
+	}
+}
+
+func TestPrescription(t *testing.T) {
+	b, err := pdf.Prescription(clinic, owner, patient, prescription)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	_, c := checkPDF(t, b)
+	for _, s := range []string{"(Prescription)", "(Penicil\\355n)", "(10.0000 ml)", "(2 ml)", "(once a day)",
+		"(5 days)", "(04.06.2017)", "(keep separated from herd)"} {
+		if !strings.Contains(c, s) {
+			t.Fatalf("expected %s in content:\n%s", s, c)
+		}
+	}
+
+	// withdrawal warning is printed only when drug has withdrawal period
+	if !strings.Contains(c, "human consumption") {
+		t.Fatalf("expected withdrawal warning in content:\n%s", c)
+	}
+	rx := *prescription
+	rx.WithdrawalEnd = time.Time{}
+	if b, err = pdf.Prescription(clinic, owner, patient, &rx); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if _, c = checkPDF(t, b); strings.Contains(c, "human consumption") {
+		t.Fatalf("unexpected withdrawal warning in content:\n%s", c)
+	}
+}
+
+func newService() *pdf.Service {
+	owners := &mock.OwnerService{}
+	owners.GetFn = func(id uint64) (*lara.GetOwner, error) {
+		return owner, nil
+	}
+	patients := &mock.PatientService{}
+	patients.GetFn = func(id uint64) (*lara.GetPatient, error) {
+		if id != 3 {
+			return nil, lara.NewCodedError(404, errors.New("patient not found"))
+		}
+		return patient, nil
+	}
+	records := &mock.RecordService{}
+	records.GetFn = func(id uint64) (*lara.GetRecord, error) {
+		if id == 404 {
+			return nil, lara.NewCodedError(404, errors.New("record not found"))
+		}
+		return record(id, "checkup"), nil
+	}
+	vaccinations := &mock.VaccinationService{}
+	vaccinations.GetFn = func(id uint64) (*lara.GetVaccination, error) {
+		return vaccination, nil
+	}
+	invoices := &mock.InvoiceService{}
+	invoices.GetFn = func(id uint64) (*lara.GetInvoice, error) {
+		return invoice, nil
+	}
+
+	prescriptions := &mock.PrescriptionService{}
+	prescriptions.GetFn = func(id uint64) (*lara.GetPrescription, error) {
+		return prescription, nil
+	}
+
+	return &pdf.Service{Clinic: clinic, Owners: owners, Patients: patients, Records: records,
+		Vaccinations: vaccinations, Invoices: invoices, Prescriptions: prescriptions}
+}
+
+func TestService(t *testing.T) {
+	s := newService()
+	ctx := context.Background()
+
+	for name, fn := range map[string]func() ([]byte, error){
+		"record":       func() ([]byte, error) { return s.Record(ctx, 5) },
+		"history":      func() ([]byte, error) { return s.PatientHistory(ctx, 3) },
+		"certificate":  func() ([]byte, error) { return s.VaccinationCertificate(ctx, 3) },
+		"invoice":      func() ([]byte, error) { return s.Invoice(ctx, 7) },
+		"prescription": func() ([]byte, error) { return s.Prescription(ctx, 4) },
+	} {
+		b, err := fn()
+		if err != nil {
+			t.Fatalf("%s: expected nil error, but was %+v", name, err)
+		}
+		checkPDF(t, b)
+	}
+
+	// not found
+	if _, err := s.Record(ctx, 404); err == nil {
+		t.Fatal("expected error")
+	} else if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+	if _, err := s.PatientHistory(ctx, 4); err == nil {
+		t.Fatal("expected error")
+	} else if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+}
+
+func TestServiceNoVaccinations(t *testing.T) {
+	s := newService()
+	s.Patients.(*mock.PatientService).GetFn = func(id uint64) (*lara.GetPatient, error) {
+		return &lara.GetPatient{Versioned: lara.Versioned{ID: id}, OwnerID: 1}, nil
+	}
+
+	_, err := s.VaccinationCertificate(context.Background(), 3)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+}
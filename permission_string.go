@@ -4,9 +4,9 @@ package lara
 
 import "fmt"
 
-const _PermissionType_name = "ViewRecordEditRecordViewReportsEditProducts"
+const _PermissionType_name = "ViewRecordEditRecordViewReportsEditProductsAdmin"
 
-var _PermissionType_index = [...]uint8{0, 10, 20, 31, 43}
+var _PermissionType_index = [...]uint8{0, 10, 20, 31, 43, 48}
 
 func (i PermissionType) String() string {
 	if i < 0 || i >= PermissionType(len(_PermissionType_index)-1) {
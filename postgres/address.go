@@ -32,15 +32,25 @@ type AddressService struct {
 	DB *sql.DB
 }
 
-func (s *AddressService) searchCityOrStreet(ctx context.Context,
+// addressSorts are AddressSearchRequest's sort keys
+var addressSorts = map[string][]string{
+	"name": {"name", "id"},
+	"zip":  {"zip", "name", "id"},
+}
+
+func (s *AddressService) searchCityOrStreet(ctx context.Context, p lara.Page,
 	countQuery, dataQuery string, params ...interface{}) (*lara.CityStreetList, error) {
+	page, err := pageSQL(p, addressSorts, "name")
+	if err != nil {
+		return nil, err
+	}
+
 	var result = lara.CityStreetList{Items: []lara.CityStreet{}}
-	// Count query. Ignore last param - search limit
-	if err := s.DB.QueryRowContext(ctx, countQuery, params[:len(params)-1]...).Scan(&result.Total); err != nil {
+	if err := s.DB.QueryRowContext(ctx, countQuery, params...).Scan(&result.Total); err != nil {
 		return nil, errors.Wrap(err, "search city/street count error")
 	}
 
-	rows, err := s.DB.QueryContext(ctx, dataQuery, params...)
+	rows, err := s.DB.QueryContext(ctx, dataQuery+" "+page, params...)
 	if err != nil {
 		return nil, errors.Wrap(err, "search city/street query error")
 	}
@@ -59,31 +69,26 @@ func (s *AddressService) searchCityOrStreet(ctx context.Context,
 }
 
 // SearchCity is implementation of AddressService.SearchCity using postgresql database.
-// TODO make search limit parametric
-func (s *AddressService) SearchCity(ctx context.Context, query string) (*lara.CityStreetList, error) {
+func (s *AddressService) SearchCity(ctx context.Context, r *lara.AddressSearchRequest) (*lara.CityStreetList, error) {
 	const cq = `SELECT count(*) FROM lov_city WHERE  city ILIKE $1`
 	const dq = `SELECT
 			  id,
-			  city,
-			  psc
+			  city AS name,
+			  psc  AS zip
 			FROM lov_city
-			WHERE city ILIKE $1
-			ORDER BY city
-			LIMIT $2`
+			WHERE city ILIKE $1`
 
-	return s.searchCityOrStreet(ctx, cq, dq, "%"+query+"%", 30)
+	return s.searchCityOrStreet(ctx, r.Page, cq, dq, "%"+r.Query+"%")
 }
 
 // SearchStreetForCity is implementation of AddressService.SearchStreetForCity using postgresql database
-func (s *AddressService) SearchStreetForCity(ctx context.Context, cityID uint64, query string) (*lara.CityStreetList, error) {
+func (s *AddressService) SearchStreetForCity(ctx context.Context, cityID uint64, r *lara.AddressSearchRequest) (*lara.CityStreetList, error) {
 	const cq = `SELECT count(*) FROM lov_street WHERE city_id = $1 AND street ILIKE $2`
 	const dq = `SELECT
 			  id,
-			  street,
-			  psc
+			  street AS name,
+			  psc    AS zip
 			FROM lov_street
-			WHERE city_id = $1 AND street ILIKE $2
-			ORDER BY street
-			LIMIT $3`
-	return s.searchCityOrStreet(ctx, cq, dq, cityID, "%"+query+"%", 30)
+			WHERE city_id = $1 AND street ILIKE $2`
+	return s.searchCityOrStreet(ctx, r.Page, cq, dq, cityID, "%"+r.Query+"%")
 }
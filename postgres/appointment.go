@@ -0,0 +1,480 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jkusniar/lara"
+	"github.com/pkg/errors"
+)
+
+// AppointmentService is lara.AppointmentService implementation backed by postgresql
+type AppointmentService struct {
+	DB  *sql.DB
+	Loc *time.Location
+}
+
+type appointmentDTO struct {
+	versionedDTO
+	creatorDTO
+	modifierDTO
+	PatientID uint64
+	Patient   string
+	OwnerID   uint64
+	OwnerNameDTO
+	Vet          string
+	Room         sql.NullString
+	Start        time.Time
+	End          time.Time
+	Note         sql.NullString
+	Status       lara.AppointmentStatus
+	CancelReason sql.NullString
+	RecordID     sql.NullInt64
+}
+
+func (a *appointmentDTO) toGetAppointment() *lara.GetAppointment {
+	return &lara.GetAppointment{
+		Versioned: lara.Versioned{
+			ID:      a.ID,
+			Version: a.Version},
+		CreatorModifier: lara.CreatorModifier{
+			Creator:  a.Creator,
+			Created:  a.Created,
+			Modifier: a.Modifier.String,
+			Modified: a.Modified.Time},
+		Appointment: lara.Appointment{
+			Vet:   a.Vet,
+			Room:  a.Room.String,
+			Start: a.Start,
+			End:   a.End},
+		PatientID:    a.PatientID,
+		Patient:      a.Patient,
+		OwnerID:      a.OwnerID,
+		OwnerName:    a.OwnerNameDTO.String(),
+		Note:         a.Note.String,
+		Status:       a.Status,
+		CancelReason: a.CancelReason.String,
+		RecordID:     uint64(a.RecordID.Int64),
+	}
+}
+
+// all appointment queries share columns and joins, only WHERE clause differs
+const appointmentQuery = `SELECT
+			  a.id,
+			  a.version,
+			  a.creator,
+			  a.created,
+			  a.modifier,
+			  a.modified,
+			  a.patient_id,
+			  p.name,
+			  o.id,
+			  o.first_name,
+			  o.last_name,
+			  t.name AS title,
+			  a.vet,
+			  a.room,
+			  a.start_time,
+			  a.end_time,
+			  a.note,
+			  a.status,
+			  a.cancel_reason,
+			  a.record_id
+			FROM appointment a
+			  JOIN patient p ON p.id = a.patient_id
+			  JOIN owner o ON o.id = p.owner_id
+			  LEFT JOIN lov_title t ON t.id = o.title_id
+			`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAppointment(row rowScanner, a *appointmentDTO) error {
+	return row.Scan(
+		&a.ID,
+		&a.Version,
+		&a.Creator,
+		&a.Created,
+		&a.Modifier,
+		&a.Modified,
+		&a.PatientID,
+		&a.Patient,
+		&a.OwnerID,
+		&a.FirstName,
+		&a.LastName,
+		&a.Title,
+		&a.Vet,
+		&a.Room,
+		&a.Start,
+		&a.End,
+		&a.Note,
+		&a.Status,
+		&a.CancelReason,
+		&a.RecordID)
+}
+
+// Get is implementation of AppointmentService.Get using postgresql database.
+func (s *AppointmentService) Get(ctx context.Context, id uint64) (*lara.GetAppointment, error) {
+	var a appointmentDTO
+	err := scanAppointment(s.DB.QueryRowContext(ctx, appointmentQuery+`WHERE a.id = $1`, id), &a)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, notFoundByIDError(id)
+	case err != nil:
+		return nil, errors.Wrap(err, "get appointment by id failed")
+	}
+
+	return a.toGetAppointment(), nil
+}
+
+func (s *AppointmentService) list(ctx context.Context, where string, params ...interface{}) (*lara.AppointmentList, error) {
+	rows, err := s.DB.QueryContext(ctx, appointmentQuery+where+` ORDER BY a.start_time, a.id`, params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "list appointments query error")
+	}
+	defer rows.Close()
+
+	result := lara.AppointmentList{Items: []lara.GetAppointment{}}
+	for rows.Next() {
+		var a appointmentDTO
+		if err := scanAppointment(rows, &a); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		result.Items = append(result.Items, *a.toGetAppointment())
+	}
+	err = rows.Err()
+
+	return &result, errors.Wrap(err, "rows processing errror")
+}
+
+// startOfDay returns midnight of date's day in location loc
+func startOfDay(date time.Time, loc *time.Location) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+}
+
+// ListByDay is implementation of AppointmentService.ListByDay using postgresql database.
+// Returns all appointments starting on specified day, including cancelled ones.
+func (s *AppointmentService) ListByDay(ctx context.Context, day time.Time) (*lara.AppointmentList, error) {
+	from := startOfDay(day, s.Loc)
+	return s.list(ctx, `WHERE a.start_time >= $1 AND a.start_time < $2`, from, from.AddDate(0, 0, 1))
+}
+
+// ListByVet is implementation of AppointmentService.ListByVet using postgresql database.
+// Returns vet's appointments starting between days from and to (both inclusive).
+func (s *AppointmentService) ListByVet(ctx context.Context, vet string, from, to time.Time) (*lara.AppointmentList, error) {
+	if len(vet) == 0 {
+		return nil, requiredFieldError("vet")
+	}
+
+	from, to = startOfDay(from, s.Loc), startOfDay(to, s.Loc)
+	if to.Before(from) {
+		return nil, lara.NewCodedError(400, errors.New("to can't be before from"))
+	}
+
+	return s.list(ctx, `WHERE a.vet = $1 AND a.start_time >= $2 AND a.start_time < $3`,
+		vet, from, to.AddDate(0, 0, 1))
+}
+
+func validateAppointment(a *lara.Appointment) error {
+	if len(a.Vet) == 0 {
+		return requiredFieldError("vet")
+	}
+
+	if a.Start.IsZero() {
+		return requiredFieldError("start")
+	}
+
+	if a.End.IsZero() {
+		return requiredFieldError("end")
+	}
+
+	if !a.End.After(a.Start) {
+		return lara.NewCodedError(400, errors.New("appointment must end after it starts"))
+	}
+
+	return nil
+}
+
+// lockAppointments serializes concurrent appointment scheduling, so that two
+// overlapping appointments can't be booked at the same time.
+// Readers are not blocked.
+func lockAppointments(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `LOCK TABLE appointment IN SHARE ROW EXCLUSIVE MODE`)
+	return errors.Wrap(err, "lock appointment table failed")
+}
+
+// checkAppointmentConflict returns 409 error if appointment overlaps other
+// not cancelled appointment of the same vet or in the same room.
+// Appointment with ID id is excluded from check.
+func (s *AppointmentService) checkAppointmentConflict(ctx context.Context, tx *sql.Tx, id uint64, a *lara.Appointment) error {
+	const q = `SELECT id, vet, room
+			FROM appointment
+			WHERE status <> $1 AND id <> $2
+			  AND start_time < $3 AND end_time > $4
+			  AND (vet = $5 OR room = $6)
+			ORDER BY start_time
+			LIMIT 1`
+
+	var cid uint64
+	var vet string
+	var room sql.NullString
+	err := tx.QueryRowContext(ctx, q,
+		lara.Cancelled,
+		id,
+		a.End.In(s.Loc),
+		a.Start.In(s.Loc),
+		a.Vet,
+		toNullString(a.Room)).Scan(&cid, &vet, &room)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil
+	case err != nil:
+		return errors.Wrap(err, "check appointment conflict failed")
+	}
+
+	if vet == a.Vet {
+		return lara.NewCodedError(409,
+			errors.Errorf("vet %s already has appointment %d at this time", vet, cid))
+	}
+
+	return lara.NewCodedError(409,
+		errors.Errorf("room %s is already booked by appointment %d at this time", room.String, cid))
+}
+
+// Create is implementation of AppointmentService.Create using postgresql database.
+func (s *AppointmentService) Create(ctx context.Context, a *lara.CreateAppointment) (uint64, error) {
+	if a.PatientID == 0 {
+		return 0, requiredFieldError("patientId")
+	}
+
+	if err := validateAppointment(&a.Appointment); err != nil {
+		return 0, err
+	}
+
+	var id uint64
+	err := execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		const insert = `INSERT INTO appointment (patient_id, vet, room, start_time, end_time, note, status, creator, created)
+					VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
+
+		u, ok := lara.UserFromContext(ctx)
+		if !ok {
+			return errors.New("no user in context")
+		}
+
+		if err := lockAppointments(ctx, tx); err != nil {
+			return err
+		}
+
+		if err := s.checkAppointmentConflict(ctx, tx, 0, &a.Appointment); err != nil {
+			return err
+		}
+
+		err := tx.QueryRowContext(ctx, insert,
+			toNullFK(a.PatientID),
+			toNullString(a.Vet),
+			toNullString(a.Room),
+			a.Start.In(s.Loc),
+			a.End.In(s.Loc),
+			toNullString(a.Note),
+			lara.Scheduled,
+			toNullString(u.Login),
+			now()).Scan(&id)
+
+		return errors.Wrap(err, "create appointment failed")
+	})
+
+	return id, err
+}
+
+// lockScheduledAppointment locks appointment row and checks it's still scheduled.
+// Returns appointment's patient ID.
+func lockScheduledAppointment(ctx context.Context, tx *sql.Tx, id uint64) (uint64, error) {
+	const lck = `SELECT patient_id, status FROM appointment WHERE id = $1 FOR UPDATE`
+
+	var pid uint64
+	var status lara.AppointmentStatus
+	err := tx.QueryRowContext(ctx, lck, id).Scan(&pid, &status)
+	switch {
+	case err == sql.ErrNoRows:
+		return 0, notFoundByIDError(id)
+	case err != nil:
+		return 0, errors.Wrap(err, "error selecting appointment by id")
+	}
+
+	if status != lara.Scheduled {
+		return 0, lara.NewCodedError(409,
+			errors.Errorf("appointment with id %d is %s", id, status))
+	}
+
+	return pid, nil
+}
+
+// checkUpdatedAppointment checks result of versioned appointment update
+func checkUpdatedAppointment(id uint64, r sql.Result, err error) error {
+	if err != nil {
+		return errors.Wrap(err, "update appointment failed")
+	}
+
+	count, err := r.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "update appointment can't check updated rows")
+	}
+
+	if count != 1 {
+		return versionMismatchError(id)
+	}
+
+	return nil
+}
+
+// Reschedule is implementation of AppointmentService.Reschedule using postgresql database.
+// Only scheduled appointments can be rescheduled.
+func (s *AppointmentService) Reschedule(ctx context.Context, id uint64, a *lara.RescheduleAppointment) error {
+	if err := validateAppointment(&a.Appointment); err != nil {
+		return err
+	}
+
+	err := execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		const upd = `UPDATE appointment
+				SET vet      = $1,
+				  room       = $2,
+				  start_time = $3,
+				  end_time   = $4,
+				  note       = $5,
+				  modifier   = $6,
+				  modified   = $7,
+				  version    = version + 1
+				WHERE id = $8 AND version = $9`
+
+		if err := lockAppointments(ctx, tx); err != nil {
+			return err
+		}
+
+		if _, err := lockScheduledAppointment(ctx, tx, id); err != nil {
+			return err
+		}
+
+		if err := s.checkAppointmentConflict(ctx, tx, id, &a.Appointment); err != nil {
+			return err
+		}
+
+		u, ok := lara.UserFromContext(ctx)
+		if !ok {
+			return errors.New("no user in context")
+		}
+
+		r, err := tx.ExecContext(ctx, upd,
+			toNullString(a.Vet),
+			toNullString(a.Room),
+			a.Start.In(s.Loc),
+			a.End.In(s.Loc),
+			toNullString(a.Note),
+			toNullString(u.Login),
+			now(),
+			id,
+			a.Version)
+
+		return checkUpdatedAppointment(id, r, err)
+	})
+
+	return err
+}
+
+// Cancel is implementation of AppointmentService.Cancel using postgresql database.
+// Cancelled appointment is kept in database, but doesn't block its time slot.
+func (s *AppointmentService) Cancel(ctx context.Context, id uint64, c *lara.CancelAppointment) error {
+	err := execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		const upd = `UPDATE appointment
+				SET status      = $1,
+				  cancel_reason = $2,
+				  modifier      = $3,
+				  modified      = $4,
+				  version       = version + 1
+				WHERE id = $5 AND version = $6`
+
+		if _, err := lockScheduledAppointment(ctx, tx, id); err != nil {
+			return err
+		}
+
+		u, ok := lara.UserFromContext(ctx)
+		if !ok {
+			return errors.New("no user in context")
+		}
+
+		r, err := tx.ExecContext(ctx, upd,
+			lara.Cancelled,
+			toNullString(c.Reason),
+			toNullString(u.Login),
+			now(),
+			id,
+			c.Version)
+
+		return checkUpdatedAppointment(id, r, err)
+	})
+
+	return err
+}
+
+// CheckIn is implementation of AppointmentService.CheckIn using postgresql database.
+// New record for appointment's patient is created the same way RecordService.Create
+// does and its ID is returned.
+func (s *AppointmentService) CheckIn(ctx context.Context, id uint64, c *lara.CheckInAppointment) (uint64, error) {
+	var recID uint64
+	err := execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		const upd = `UPDATE appointment
+				SET status = $1,
+				  record_id = $2,
+				  modifier  = $3,
+				  modified  = $4,
+				  version   = version + 1
+				WHERE id = $5 AND version = $6`
+
+		pid, err := lockScheduledAppointment(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		recID, err = createRecordTx(ctx, tx, &lara.CreateRecord{PatientID: pid, NewRecord: c.NewRecord})
+		if err != nil {
+			return err
+		}
+
+		u, ok := lara.UserFromContext(ctx)
+		if !ok {
+			return errors.New("no user in context")
+		}
+
+		r, err := tx.ExecContext(ctx, upd,
+			lara.CheckedIn,
+			recID,
+			toNullString(u.Login),
+			now(),
+			id,
+			c.Version)
+
+		return checkUpdatedAppointment(id, r, err)
+	})
+
+	return recID, err
+}
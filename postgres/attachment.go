@@ -0,0 +1,296 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/jkusniar/lara"
+	"github.com/pkg/errors"
+)
+
+// AttachmentService is implementation of lara.AttachmentService using
+// postgresql database for metadata and Store for content
+type AttachmentService struct {
+	DB    *sql.DB
+	Store lara.BlobStore
+	// MaxSize is attachment's size limit in bytes,
+	// lara.DefaultMaxAttachmentSize if 0
+	MaxSize int64
+}
+
+// sniffLen is number of bytes used for content type detection
+const sniffLen = 512
+
+// attachmentTypes are allowed content types of attachments
+var attachmentTypes = map[string]bool{
+	"application/pdf":           true,
+	"application/dicom":         true,
+	"image/jpeg":                true,
+	"image/png":                 true,
+	"image/gif":                 true,
+	"image/bmp":                 true,
+	"image/webp":                true,
+	"text/plain; charset=utf-8": true,
+}
+
+// sniffContentType detects content type of content starting with head.
+// DICOM files (X-rays) are recognized by "DICM" magic after 128 byte
+// preamble, other types by http.DetectContentType.
+func sniffContentType(head []byte) string {
+	if len(head) >= 132 && bytes.Equal(head[128:132], []byte("DICM")) {
+		return "application/dicom"
+	}
+
+	return http.DetectContentType(head)
+}
+
+// measuringReader computes size and checksum of content read from r and fails
+// when more than max bytes is read
+type measuringReader struct {
+	r    io.Reader
+	hash hash.Hash
+	size int64
+	max  int64
+}
+
+func (m *measuringReader) Read(p []byte) (int, error) {
+	n, err := m.r.Read(p)
+	m.size += int64(n)
+	m.hash.Write(p[:n])
+	if m.size > m.max {
+		return n, m.tooLargeError()
+	}
+	return n, err
+}
+
+func (m *measuringReader) tooLargeError() error {
+	return lara.NewCodedError(http.StatusRequestEntityTooLarge,
+		errors.Errorf("attachment exceeds size limit of %d bytes", m.max))
+}
+
+// newStorageKey returns random key of attachment's content
+func newStorageKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "generate attachment key failed")
+	}
+
+	k := hex.EncodeToString(b)
+	return k[:2] + "/" + k, nil
+}
+
+// attachmentPatient returns ID of patient new attachment a belongs to
+func (s *AttachmentService) attachmentPatient(ctx context.Context, a *lara.NewAttachment) (uint64, error) {
+	var pid uint64
+	var err error
+	if a.RecordID != 0 {
+		err = s.DB.QueryRowContext(ctx, `SELECT patient_id FROM record WHERE id = $1`, a.RecordID).Scan(&pid)
+		if err == sql.ErrNoRows {
+			return 0, notFoundByIDError(a.RecordID)
+		}
+	} else {
+		if a.PatientID == 0 {
+			return 0, requiredFieldError("patientId")
+		}
+		err = s.DB.QueryRowContext(ctx, `SELECT id FROM patient WHERE id = $1`, a.PatientID).Scan(&pid)
+		if err == sql.ErrNoRows {
+			return 0, notFoundByIDError(a.PatientID)
+		}
+	}
+
+	return pid, errors.Wrap(err, "get attachment's patient failed")
+}
+
+// Create is implementation of AttachmentService.Create using postgresql database
+func (s *AttachmentService) Create(ctx context.Context, a *lara.NewAttachment) (uint64, error) {
+	// strip client's directories, both unix and windows
+	name := filepath.Base("/" + strings.Replace(a.FileName, `\`, "/", -1))
+	if name == "/" || name == "." || name == ".." {
+		return 0, requiredFieldError("fileName")
+	}
+
+	u, ok := lara.UserFromContext(ctx)
+	if !ok {
+		return 0, errors.New("no user in context")
+	}
+
+	pid, err := s.attachmentPatient(ctx, a)
+	if err != nil {
+		return 0, err
+	}
+
+	content := bufio.NewReaderSize(a.Content, sniffLen)
+	head, err := content.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		return 0, errors.Wrap(err, "read attachment failed")
+	}
+	if len(head) == 0 {
+		return 0, lara.NewCodedError(400, errors.New("attachment is empty"))
+	}
+
+	ctype := sniffContentType(head)
+	if !attachmentTypes[ctype] {
+		return 0, lara.NewCodedError(http.StatusUnsupportedMediaType,
+			errors.Errorf("attachment's content type %s is not supported", ctype))
+	}
+
+	max := s.MaxSize
+	if max == 0 {
+		max = lara.DefaultMaxAttachmentSize
+	}
+
+	key, err := newStorageKey()
+	if err != nil {
+		return 0, err
+	}
+
+	m := &measuringReader{r: content, hash: sha256.New(), max: max}
+	if err := s.Store.Put(ctx, key, m); err != nil {
+		if m.size > m.max {
+			return 0, m.tooLargeError()
+		}
+		return 0, errors.Wrap(err, "store attachment failed")
+	}
+
+	const insert = `INSERT INTO attachment (patient_id, record_id, file_name, content_type, size, checksum,
+				storage_key, creator, created)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING id`
+
+	var id uint64
+	err = s.DB.QueryRowContext(ctx, insert,
+		pid,
+		toNullFK(a.RecordID),
+		name,
+		ctype,
+		m.size,
+		hex.EncodeToString(m.hash.Sum(nil)),
+		key,
+		u.Login,
+		now()).Scan(&id)
+	if err != nil {
+		s.Store.Delete(ctx, key)
+		return 0, errors.Wrap(err, "create attachment failed")
+	}
+
+	return id, nil
+}
+
+const attachmentColumns = `id,
+			  patient_id,
+			  record_id,
+			  file_name,
+			  content_type,
+			  size,
+			  checksum,
+			  creator,
+			  created`
+
+type attachmentDTO struct {
+	lara.Attachment
+	RecordID sql.NullInt64
+}
+
+func (a *attachmentDTO) scanArgs() []interface{} {
+	return []interface{}{&a.ID,
+		&a.PatientID,
+		&a.RecordID,
+		&a.FileName,
+		&a.ContentType,
+		&a.Size,
+		&a.Checksum,
+		&a.Creator,
+		&a.Created}
+}
+
+func (a *attachmentDTO) toAttachment() lara.Attachment {
+	result := a.Attachment
+	result.RecordID = uint64(a.RecordID.Int64)
+	return result
+}
+
+// Open is implementation of AttachmentService.Open using postgresql database
+func (s *AttachmentService) Open(ctx context.Context, id uint64) (*lara.Attachment, io.ReadCloser, error) {
+	const q = `SELECT ` + attachmentColumns + `, storage_key FROM attachment WHERE id = $1`
+
+	var a attachmentDTO
+	var key string
+	err := s.DB.QueryRowContext(ctx, q, id).Scan(append(a.scanArgs(), &key)...)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil, notFoundByIDError(id)
+	case err != nil:
+		return nil, nil, errors.Wrap(err, "get attachment by id failed")
+	}
+
+	content, err := s.Store.Get(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := a.toAttachment()
+	return &result, content, nil
+}
+
+func (s *AttachmentService) list(ctx context.Context, q string, id uint64) (*lara.AttachmentList, error) {
+	rows, err := s.DB.QueryContext(ctx, q, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "list attachments query error")
+	}
+	defer rows.Close()
+
+	result := lara.AttachmentList{Items: []lara.Attachment{}}
+	for rows.Next() {
+		var a attachmentDTO
+		if err := rows.Scan(a.scanArgs()...); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		result.Items = append(result.Items, a.toAttachment())
+	}
+	err = rows.Err()
+
+	return &result, errors.Wrap(err, "rows processing errror")
+}
+
+// ListByRecord is implementation of AttachmentService.ListByRecord using
+// postgresql database
+func (s *AttachmentService) ListByRecord(ctx context.Context, recordID uint64) (*lara.AttachmentList, error) {
+	const q = `SELECT ` + attachmentColumns + ` FROM attachment WHERE record_id = $1 ORDER BY created DESC, id DESC`
+	return s.list(ctx, q, recordID)
+}
+
+// ListByPatient is implementation of AttachmentService.ListByPatient using
+// postgresql database
+func (s *AttachmentService) ListByPatient(ctx context.Context, patientID uint64) (*lara.AttachmentList, error) {
+	const q = `SELECT ` + attachmentColumns + ` FROM attachment WHERE patient_id = $1 ORDER BY created DESC, id DESC`
+	return s.list(ctx, q, patientID)
+}
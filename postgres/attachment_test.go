@@ -0,0 +1,60 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres
+
+import (
+	"crypto/sha256"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestSniffContentType(t *testing.T) {
+	for _, c := range []struct {
+		head string
+		exp  string
+	}{
+		{"%PDF-1.4 lab", "application/pdf"},
+		{"\x89PNG\r\n\x1a\n", "image/png"},
+		{"\xff\xd8\xff\xe0", "image/jpeg"},
+		{strings.Repeat("\x00", 128) + "DICM", "application/dicom"},
+		{"plain text", "text/plain; charset=utf-8"},
+		{"MZ\x90\x00", "application/octet-stream"},
+	} {
+		if ct := sniffContentType([]byte(c.head)); ct != c.exp {
+			t.Fatalf("expected %s but was %s for %q", c.exp, ct, c.head)
+		}
+	}
+}
+
+func TestMeasuringReader(t *testing.T) {
+	m := &measuringReader{r: strings.NewReader("12345"), hash: sha256.New(), max: 5}
+	if _, err := ioutil.ReadAll(m); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if sum := sha256.Sum256([]byte("12345")); m.size != 5 || string(m.hash.Sum(nil)) != string(sum[:]) {
+		t.Fatalf("unexpected size %d or checksum", m.size)
+	}
+
+	m = &measuringReader{r: strings.NewReader("123456"), hash: sha256.New(), max: 5}
+	if _, err := ioutil.ReadAll(m); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -23,6 +23,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jkusniar/lara"
@@ -78,6 +81,12 @@ func versionMismatchError(id uint64) error {
 		errors.Errorf("object with id %d modified by another user. Reload and edit again.", id))
 }
 
+// isUniqueViolation reports whether err is caused by unique constraint
+func isUniqueViolation(err error) bool {
+	e, ok := errors.Cause(err).(*pq.Error)
+	return ok && e.Code == "23505"
+}
+
 var unauthorizedError = lara.NewCodedError(401,
 	errors.New("username or password invalid"))
 
@@ -113,3 +122,65 @@ func toNullTime(t time.Time) pq.NullTime {
 func now() pq.NullTime {
 	return pq.NullTime{Time: time.Now(), Valid: true}
 }
+
+// parseNumeric parses s as value of numeric(precision, scale) column. Value
+// is rounded to scale decimal places, as column does. Not a number,
+// infinities and values not fitting column are rejected with ok false.
+func parseNumeric(s string, precision, scale int) (v float64, ok bool) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(v) {
+		return 0, false
+	}
+
+	exp := math.Pow10(scale)
+	v = math.Copysign(math.Floor(math.Abs(v)*exp+0.5), v) / exp
+	if math.Abs(v) >= math.Pow10(precision-scale) {
+		return 0, false
+	}
+
+	return v, true
+}
+
+// pageSQL returns ORDER BY, LIMIT and OFFSET clauses selecting page p.
+// sorts maps search's sort keys to columns ordered by, def is sort key used
+// if page has none. Descending sort reverses order of all key's columns.
+func pageSQL(p lara.Page, sorts map[string][]string, def string) (string, error) {
+	if p.Offset < 0 {
+		return "", lara.NewCodedError(400,
+			errors.Errorf("invalid offset %d", p.Offset))
+	}
+
+	limit := p.Limit
+	if limit == 0 {
+		limit = lara.DefaultPageLimit
+	}
+	if limit < 0 || limit > lara.MaxPageLimit {
+		return "", lara.NewCodedError(400,
+			errors.Errorf("invalid limit %d, allowed range is 1-%d",
+				p.Limit, lara.MaxPageLimit))
+	}
+
+	key := p.Sort
+	if key == "" {
+		key = def
+	}
+	dir := " ASC"
+	if strings.HasPrefix(key, "-") {
+		key = key[1:]
+		dir = " DESC"
+	}
+
+	cols, ok := sorts[key]
+	if !ok {
+		return "", lara.NewCodedError(400,
+			errors.Errorf("unknown sort '%s'", p.Sort))
+	}
+
+	order := make([]string, len(cols))
+	for i, c := range cols {
+		order[i] = c + dir
+	}
+
+	return fmt.Sprintf("ORDER BY %s LIMIT %d OFFSET %d",
+		strings.Join(order, ", "), limit, p.Offset), nil
+}
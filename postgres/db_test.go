@@ -0,0 +1,61 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres
+
+import (
+	"testing"
+
+	"github.com/jkusniar/lara"
+)
+
+func TestPageSQL(t *testing.T) {
+	sorts := map[string][]string{"name": {"name", "id"}}
+
+	tests := []struct {
+		page lara.Page
+		exp  string
+		code int
+	}{
+		{lara.Page{}, "ORDER BY name ASC, id ASC LIMIT 30 OFFSET 0", 0},
+		{lara.Page{Offset: 60, Limit: 20, Sort: "-name"},
+			"ORDER BY name DESC, id DESC LIMIT 20 OFFSET 60", 0},
+		{lara.Page{Offset: -1}, "", 400},
+		{lara.Page{Limit: -1}, "", 400},
+		{lara.Page{Limit: lara.MaxPageLimit + 1}, "", 400},
+		{lara.Page{Sort: "id"}, "", 400},
+		{lara.Page{Sort: "-"}, "", 400},
+	}
+
+	for _, test := range tests {
+		s, err := pageSQL(test.page, sorts, "name")
+		if test.code != 0 {
+			if e, ok := err.(lara.CodedError); !ok || e.Code() != test.code {
+				t.Errorf("%+v: expected error code %d, but was %v", test.page,
+					test.code, err)
+			}
+			continue
+		}
+
+		if err != nil || s != test.exp {
+			t.Errorf("%+v: expected '%s', but was '%s', %v", test.page,
+				test.exp, s, err)
+		}
+	}
+}
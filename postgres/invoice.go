@@ -0,0 +1,520 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jkusniar/lara"
+	"github.com/pkg/errors"
+)
+
+const defaultInvoiceDueDays = 14
+
+// InvoiceService is lara.InvoiceService implementation backed by postgresql
+type InvoiceService struct {
+	DB      *sql.DB
+	Loc     *time.Location
+	Clinic  *lara.Clinic // supplier's data copied to every issued invoice
+	DueDays int          // default due period, 14 days if 0
+}
+
+type invoiceDTO struct {
+	creatorDTO
+	ID              uint64
+	Number          string
+	OwnerID         uint64
+	IssueDate       time.Time
+	DeliveryDate    time.Time
+	DueDate         time.Time
+	SupplierName    string
+	SupplierAddress sql.NullString
+	SupplierIC      sql.NullString
+	SupplierDIC     sql.NullString
+	SupplierICDPH   sql.NullString
+	SupplierIBAN    sql.NullString
+	SupplierPhone   sql.NullString
+	SupplierEmail   sql.NullString
+	CustomerName    string
+	CustomerAddress sql.NullString
+	CustomerIC      sql.NullString
+	CustomerDIC     sql.NullString
+	CustomerICDPH   sql.NullString
+	Total           string
+	TotalNet        string
+	TotalVAT        string
+}
+
+func (i *invoiceDTO) toGetInvoice(lines []lara.InvoiceLine, vat []lara.VATBreakdown) *lara.GetInvoice {
+	return &lara.GetInvoice{
+		ID:           i.ID,
+		Number:       i.Number,
+		OwnerID:      i.OwnerID,
+		IssueDate:    i.IssueDate,
+		DeliveryDate: i.DeliveryDate,
+		DueDate:      i.DueDate,
+		Supplier: lara.Clinic{
+			Name:    i.SupplierName,
+			Address: i.SupplierAddress.String,
+			IC:      i.SupplierIC.String,
+			DIC:     i.SupplierDIC.String,
+			ICDPH:   i.SupplierICDPH.String,
+			IBAN:    i.SupplierIBAN.String,
+			Phone:   i.SupplierPhone.String,
+			Email:   i.SupplierEmail.String},
+		Customer: lara.InvoiceCustomer{
+			Name:    i.CustomerName,
+			Address: i.CustomerAddress.String,
+			IC:      i.CustomerIC.String,
+			DIC:     i.CustomerDIC.String,
+			ICDPH:   i.CustomerICDPH.String},
+		Lines:    lines,
+		Total:    i.Total,
+		TotalNet: i.TotalNet,
+		TotalVAT: i.TotalVAT,
+		VAT:      vat,
+		Creator:  i.Creator,
+		Created:  i.Created,
+	}
+}
+
+// Get is implementation of InvoiceService.Get using postgresql database.
+func (s *InvoiceService) Get(ctx context.Context, id uint64) (*lara.GetInvoice, error) {
+	const q = `SELECT
+			  id,
+			  inv_number,
+			  owner_id,
+			  issue_date,
+			  delivery_date,
+			  due_date,
+			  supplier_name,
+			  supplier_address,
+			  supplier_ic,
+			  supplier_dic,
+			  supplier_icdph,
+			  supplier_iban,
+			  supplier_phone,
+			  supplier_email,
+			  customer_name,
+			  customer_address,
+			  customer_ic,
+			  customer_dic,
+			  customer_icdph,
+			  total,
+			  total_net,
+			  total_vat,
+			  creator,
+			  created
+			FROM invoice WHERE id = $1`
+
+	var i invoiceDTO
+	err := s.DB.QueryRowContext(ctx, q, id).Scan(
+		&i.ID,
+		&i.Number,
+		&i.OwnerID,
+		&i.IssueDate,
+		&i.DeliveryDate,
+		&i.DueDate,
+		&i.SupplierName,
+		&i.SupplierAddress,
+		&i.SupplierIC,
+		&i.SupplierDIC,
+		&i.SupplierICDPH,
+		&i.SupplierIBAN,
+		&i.SupplierPhone,
+		&i.SupplierEmail,
+		&i.CustomerName,
+		&i.CustomerAddress,
+		&i.CustomerIC,
+		&i.CustomerDIC,
+		&i.CustomerICDPH,
+		&i.Total,
+		&i.TotalNet,
+		&i.TotalVAT,
+		&i.Creator,
+		&i.Created)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, notFoundByIDError(id)
+	case err != nil:
+		return nil, errors.Wrap(err, "get invoice by id failed")
+	}
+
+	lines, err := s.getInvoiceLines(ctx, i.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	vat, err := getVATBreakdown(ctx, s.DB,
+		`SELECT vat_rate, SUM(item_net), SUM(item_vat), SUM(item_price)
+			FROM invoice_line
+			WHERE invoice_id = $1
+			GROUP BY vat_rate
+			ORDER BY vat_rate`, i.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.toGetInvoice(lines, vat), nil
+}
+
+func (s *InvoiceService) getInvoiceLines(ctx context.Context, id uint64) ([]lara.InvoiceLine, error) {
+	const q = `SELECT
+			  record_id,
+			  prod_id,
+			  product,
+			  unit,
+			  plu,
+			  prod_price,
+			  amount,
+			  item_price,
+			  item_type,
+			  vat_rate,
+			  item_net,
+			  item_vat
+			FROM invoice_line
+			WHERE invoice_id = $1
+			ORDER BY id`
+
+	rows, err := s.DB.QueryContext(ctx, q, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "get invoice's lines query error")
+	}
+	defer rows.Close()
+
+	lines := []lara.InvoiceLine{}
+	for rows.Next() {
+		var l lara.InvoiceLine
+		var plu sql.NullString
+		if err := rows.Scan(&l.RecordID,
+			&l.ProductID,
+			&l.Product,
+			&l.Unit,
+			&plu,
+			&l.ProductPrice,
+			&l.Amount,
+			&l.ItemPrice,
+			&l.ItemType,
+			&l.VATRate,
+			&l.ItemNet,
+			&l.ItemVAT); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		l.PLU = plu.String
+		lines = append(lines, l)
+	}
+	err = rows.Err()
+
+	return lines, errors.Wrap(err, "rows processing errror")
+}
+
+// ListByOwner is implementation of InvoiceService.ListByOwner using postgresql database.
+func (s *InvoiceService) ListByOwner(ctx context.Context, ownerID uint64) (*lara.OwnersInvoiceList, error) {
+	const q = `SELECT
+			  id,
+			  inv_number,
+			  issue_date,
+			  due_date,
+			  total
+			FROM invoice
+			WHERE owner_id = $1
+			ORDER BY inv_year DESC, inv_seq DESC`
+
+	rows, err := s.DB.QueryContext(ctx, q, ownerID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get owner's invoices query error")
+	}
+	defer rows.Close()
+
+	result := lara.OwnersInvoiceList{Items: []lara.OwnersInvoice{}}
+	for rows.Next() {
+		var i lara.OwnersInvoice
+		if err := rows.Scan(&i.ID,
+			&i.Number,
+			&i.IssueDate,
+			&i.DueDate,
+			&i.Total); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		result.Items = append(result.Items, i)
+	}
+	err = rows.Err()
+
+	return &result, errors.Wrap(err, "rows processing errror")
+}
+
+// invoiceNumber formats number of invoice in yearly series
+func invoiceNumber(year, seq int) string {
+	return fmt.Sprintf("%04d%06d", year, seq)
+}
+
+func validateCreateInvoice(i *lara.CreateInvoice) error {
+	if i.OwnerID == 0 {
+		return requiredFieldError("ownerId")
+	}
+
+	if len(i.RecordIDs) == 0 {
+		return requiredFieldError("recordIds")
+	}
+
+	seen := make(map[uint64]bool, len(i.RecordIDs))
+	for _, id := range i.RecordIDs {
+		if seen[id] {
+			return lara.NewCodedError(400,
+				errors.Errorf("record %d listed more than once", id))
+		}
+		seen[id] = true
+	}
+
+	return nil
+}
+
+// Create is implementation of InvoiceService.Create using postgresql database.
+// Invoice table is locked for the time of transaction, so numbers in yearly
+// series are assigned without gaps even if transaction is rolled back.
+func (s *InvoiceService) Create(ctx context.Context, i *lara.CreateInvoice) (uint64, error) {
+	if err := validateCreateInvoice(i); err != nil {
+		return 0, err
+	}
+
+	if s.Clinic == nil || s.Clinic.Name == "" {
+		return 0, errors.New("clinic's invoicing data not configured")
+	}
+
+	u, ok := lara.UserFromContext(ctx)
+	if !ok {
+		return 0, errors.New("no user in context")
+	}
+
+	issue := startOfDay(time.Now().In(s.Loc), s.Loc)
+	dueDays := s.DueDays
+	if dueDays == 0 {
+		dueDays = defaultInvoiceDueDays
+	}
+	due := issue.AddDate(0, 0, dueDays)
+	if !i.DueDate.IsZero() {
+		due = startOfDay(i.DueDate.In(s.Loc), s.Loc)
+	}
+	if due.Before(issue) {
+		return 0, lara.NewCodedError(400,
+			errors.New("due date can't be before issue date"))
+	}
+
+	var id uint64
+	err := execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		const lck = `LOCK TABLE invoice IN SHARE ROW EXCLUSIVE MODE`
+		if _, err := tx.ExecContext(ctx, lck); err != nil {
+			return errors.Wrap(err, "lock invoice table failed")
+		}
+
+		c, err := getInvoiceCustomer(ctx, tx, i.OwnerID)
+		if err != nil {
+			return err
+		}
+
+		delivery, err := lockInvoicedRecords(ctx, tx, i)
+		if err != nil {
+			return err
+		}
+		if !i.DeliveryDate.IsZero() {
+			delivery = i.DeliveryDate.In(s.Loc)
+		}
+		delivery = startOfDay(delivery, s.Loc)
+
+		const sq = `SELECT COALESCE(MAX(inv_seq), 0) + 1 FROM invoice WHERE inv_year = $1`
+		var seq int
+		if err := tx.QueryRowContext(ctx, sq, issue.Year()).Scan(&seq); err != nil {
+			return errors.Wrap(err, "get invoice sequence failed")
+		}
+		number := invoiceNumber(issue.Year(), seq)
+
+		const insert = `INSERT INTO invoice (inv_year, inv_seq, inv_number, owner_id, issue_date,
+				delivery_date, due_date, supplier_name, supplier_address, supplier_ic, supplier_dic,
+				supplier_icdph, supplier_iban, supplier_phone, supplier_email, customer_name,
+				customer_address, customer_ic, customer_dic, customer_icdph, total, total_net, total_vat,
+				creator, created)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18,
+				$19, $20, 0, 0, 0, $21, $22) RETURNING id`
+		err = tx.QueryRowContext(ctx, insert,
+			issue.Year(),
+			seq,
+			number,
+			i.OwnerID,
+			issue,
+			delivery,
+			due,
+			s.Clinic.Name,
+			toNullString(s.Clinic.Address),
+			toNullString(s.Clinic.IC),
+			toNullString(s.Clinic.DIC),
+			toNullString(s.Clinic.ICDPH),
+			toNullString(s.Clinic.IBAN),
+			toNullString(s.Clinic.Phone),
+			toNullString(s.Clinic.Email),
+			c.Name,
+			toNullString(c.Address),
+			toNullString(c.IC),
+			toNullString(c.DIC),
+			toNullString(c.ICDPH),
+			toNullString(u.Login),
+			now()).Scan(&id)
+		if err != nil {
+			return errors.Wrap(err, "create invoice failed")
+		}
+
+		lines := `INSERT INTO invoice_line (invoice_id, record_id, prod_id, product, unit, plu,
+				prod_price, amount, item_price, item_type, vat_rate, item_net, item_vat)
+				SELECT $1, ri.record_id, ri.prod_id, p.name, u.name, p.plu, ri.prod_price, ri.amount,
+				  ri.item_price, ri.item_type, ri.vat_rate,
+				  ` + netSQL("ri.item_price", "ri.vat_rate") + `,
+				  ` + vatSQL("ri.item_price", "ri.vat_rate") + `
+				FROM record_item ri
+				  JOIN lov_product p ON p.id = ri.prod_id
+				  JOIN lov_unit u ON u.id = p.unit_id
+				WHERE ri.record_id = $2
+				ORDER BY ri.id`
+		const bill = `UPDATE record
+				SET billed          = TRUE,
+				  invoice_id        = $1,
+				  inv_create_date   = $2,
+				  inv_delivery_date = $3,
+				  inv_payment_date  = $4,
+				  modifier          = $5,
+				  modified          = $6,
+				  version           = version + 1
+				WHERE id = $7`
+		for _, rid := range i.RecordIDs {
+			if _, err := tx.ExecContext(ctx, lines, id, rid); err != nil {
+				return errors.Wrap(err, "copy record items to invoice failed")
+			}
+
+			if _, err := tx.ExecContext(ctx, bill, number, issue, delivery, due,
+				toNullString(u.Login), now(), rid); err != nil {
+				return errors.Wrap(err, "mark record as billed failed")
+			}
+		}
+
+		const total = `UPDATE invoice
+				SET total   = l.gross,
+				  total_net = l.net,
+				  total_vat = l.vat
+				FROM (SELECT COALESCE(SUM(item_price), 0) AS gross,
+				        COALESCE(SUM(item_net), 0) AS net,
+				        COALESCE(SUM(item_vat), 0) AS vat
+				      FROM invoice_line WHERE invoice_id = $1) l
+				WHERE id = $1`
+		_, err = tx.ExecContext(ctx, total, id)
+
+		return errors.Wrap(err, "update invoice total failed")
+	})
+
+	return id, err
+}
+
+// getInvoiceCustomer loads owner's data printed on invoice
+func getInvoiceCustomer(ctx context.Context, tx *sql.Tx, ownerID uint64) (*lara.InvoiceCustomer, error) {
+	const q = `SELECT
+			  o.first_name,
+			  o.last_name,
+			  t.name AS title,
+			  c.city,
+			  s.street,
+			  o.house_no,
+			  o.ic,
+			  o.dic,
+			  o.icdph
+			FROM owner o
+			  LEFT JOIN lov_title t ON t.id = o.title_id
+			  LEFT JOIN lov_city c ON c.id = o.city_id
+			  LEFT JOIN lov_street s ON s.id = o.street_id
+			WHERE o.id = $1`
+
+	var n OwnerNameDTO
+	var a OwnerAddressDTO
+	var ic, dic, icdph sql.NullString
+	err := tx.QueryRowContext(ctx, q, ownerID).Scan(
+		&n.FirstName,
+		&n.LastName,
+		&n.Title,
+		&a.City,
+		&a.Street,
+		&a.HouseNo,
+		&ic,
+		&dic,
+		&icdph)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, notFoundByIDError(ownerID)
+	case err != nil:
+		return nil, errors.Wrap(err, "get invoice customer failed")
+	}
+
+	return &lara.InvoiceCustomer{
+		Name:    n.String(),
+		Address: a.String(),
+		IC:      ic.String,
+		DIC:     dic.String,
+		ICDPH:   icdph.String,
+	}, nil
+}
+
+// lockInvoicedRecords locks records for update and checks they can be
+// invoiced. Date of the latest record is returned.
+func lockInvoicedRecords(ctx context.Context, tx *sql.Tx, i *lara.CreateInvoice) (time.Time, error) {
+	const lck = `SELECT r.rec_date, r.billed, r.invoice_id, p.owner_id
+			FROM record r
+			  JOIN patient p ON p.id = r.patient_id
+			WHERE r.id = $1
+			FOR UPDATE OF r`
+
+	var latest time.Time
+	for _, rid := range i.RecordIDs {
+		var date time.Time
+		var billed bool
+		var invoice sql.NullString
+		var ownerID uint64
+		err := tx.QueryRowContext(ctx, lck, rid).Scan(&date, &billed, &invoice, &ownerID)
+		switch {
+		case err == sql.ErrNoRows:
+			return latest, notFoundByIDError(rid)
+		case err != nil:
+			return latest, errors.Wrap(err, "error selecting record by id")
+		}
+
+		if ownerID != i.OwnerID {
+			return latest, lara.NewCodedError(400,
+				errors.Errorf("record %d does not belong to owner %d", rid, i.OwnerID))
+		}
+
+		if billed || invoice.Valid {
+			return latest, lara.NewCodedError(409,
+				errors.Errorf("record %d is already billed", rid))
+		}
+
+		if date.After(latest) {
+			latest = date
+		}
+	}
+
+	return latest, nil
+}
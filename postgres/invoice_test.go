@@ -0,0 +1,39 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres
+
+import "testing"
+
+func TestInvoiceNumber(t *testing.T) {
+	var tests = []struct {
+		year, seq int
+		expected  string
+	}{
+		{2017, 1, "2017000001"},
+		{2017, 42, "2017000042"},
+		{2018, 999999, "2018999999"},
+	}
+
+	for _, tt := range tests {
+		if n := invoiceNumber(tt.year, tt.seq); n != tt.expected {
+			t.Fatalf("expected invoice number %s, but was %s", tt.expected, n)
+		}
+	}
+}
@@ -4,9 +4,9 @@ package postgres
 
 import "fmt"
 
-const _listOfValuesType_name = "titleunitgenderspeciesbreed"
+const _listOfValuesType_name = "titleunitgenderspeciesbreeddiagnosis"
 
-var _listOfValuesType_index = [...]uint8{0, 5, 9, 15, 22, 27}
+var _listOfValuesType_index = [...]uint8{0, 5, 9, 15, 22, 27, 36}
 
 func (i listOfValuesType) String() string {
 	if i < 0 || i >= listOfValuesType(len(_listOfValuesType_index)-1) {
@@ -0,0 +1,223 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jkusniar/lara"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// OutboxService is lara.Outbox implementation backed by postgresql
+type OutboxService struct {
+	DB *sql.DB
+}
+
+type notificationDTO struct {
+	creatorDTO
+	ID        uint64
+	Channel   lara.NotificationChannel
+	Recipient string
+	Subject   sql.NullString
+	Body      string
+	Template  lara.NotificationTemplate
+	OwnerID   uint64
+	PatientID sql.NullInt64
+	Status    lara.NotificationStatus
+	Attempts  uint
+	LastError sql.NullString
+	Sent      pq.NullTime
+}
+
+func (n *notificationDTO) toGetNotification() *lara.GetNotification {
+	return &lara.GetNotification{
+		ID: n.ID,
+		Message: lara.Message{
+			Channel:   n.Channel,
+			Recipient: n.Recipient,
+			Subject:   n.Subject.String,
+			Body:      n.Body},
+		Template:  n.Template,
+		OwnerID:   n.OwnerID,
+		PatientID: uint64(n.PatientID.Int64),
+		Status:    n.Status,
+		Attempts:  n.Attempts,
+		LastError: n.LastError.String,
+		Creator:   n.Creator,
+		Created:   n.Created,
+		Sent:      n.Sent.Time,
+	}
+}
+
+const notificationQuery = `SELECT
+			  id,
+			  channel,
+			  recipient,
+			  subject,
+			  body,
+			  template,
+			  owner_id,
+			  patient_id,
+			  status,
+			  attempts,
+			  last_error,
+			  creator,
+			  created,
+			  sent
+			FROM notification
+			`
+
+func scanNotification(row rowScanner, n *notificationDTO) error {
+	return row.Scan(
+		&n.ID,
+		&n.Channel,
+		&n.Recipient,
+		&n.Subject,
+		&n.Body,
+		&n.Template,
+		&n.OwnerID,
+		&n.PatientID,
+		&n.Status,
+		&n.Attempts,
+		&n.LastError,
+		&n.Creator,
+		&n.Created,
+		&n.Sent)
+}
+
+// Get is implementation of Outbox.Get using postgresql database.
+func (s *OutboxService) Get(ctx context.Context, id uint64) (*lara.GetNotification, error) {
+	var n notificationDTO
+	err := scanNotification(s.DB.QueryRowContext(ctx, notificationQuery+`WHERE id = $1`, id), &n)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, notFoundByIDError(id)
+	case err != nil:
+		return nil, errors.Wrap(err, "get notification by id failed")
+	}
+
+	return n.toGetNotification(), nil
+}
+
+// Enqueue is implementation of Outbox.Enqueue using postgresql database.
+func (s *OutboxService) Enqueue(ctx context.Context, m *lara.OutboxMessage) (uint64, error) {
+	if m.OwnerID == 0 {
+		return 0, requiredFieldError("ownerId")
+	}
+
+	if m.Recipient == "" {
+		return 0, requiredFieldError("recipient")
+	}
+
+	const insert = `INSERT INTO notification (channel, recipient, subject, body, template, owner_id, patient_id,
+			status, attempts, creator, created)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 0, $9, $10) RETURNING id`
+
+	u, ok := lara.UserFromContext(ctx)
+	if !ok {
+		return 0, errors.New("no user in context")
+	}
+
+	var id uint64
+	err := s.DB.QueryRowContext(ctx, insert,
+		m.Channel,
+		m.Recipient,
+		toNullString(m.Subject),
+		m.Body,
+		m.Template,
+		m.OwnerID,
+		toNullFK(m.PatientID),
+		lara.Pending,
+		u.Login,
+		now()).Scan(&id)
+
+	return id, errors.Wrap(err, "enqueue notification failed")
+}
+
+// Pending is implementation of Outbox.Pending using postgresql database.
+func (s *OutboxService) Pending(ctx context.Context, limit int) ([]lara.GetNotification, error) {
+	rows, err := s.DB.QueryContext(ctx,
+		notificationQuery+`WHERE status = $1 ORDER BY id LIMIT $2`, lara.Pending, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "get pending notifications query error")
+	}
+	defer rows.Close()
+
+	result := []lara.GetNotification{}
+	for rows.Next() {
+		var n notificationDTO
+		if err := scanNotification(rows, &n); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		result = append(result, *n.toGetNotification())
+	}
+	err = rows.Err()
+
+	return result, errors.Wrap(err, "rows processing errror")
+}
+
+func checkUpdatedNotification(id uint64, r sql.Result, err error) error {
+	if err != nil {
+		return errors.Wrap(err, "update notification failed")
+	}
+
+	count, err := r.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "update notification can't check updated rows")
+	}
+
+	if count != 1 {
+		return notFoundByIDError(id)
+	}
+
+	return nil
+}
+
+// MarkSent is implementation of Outbox.MarkSent using postgresql database.
+func (s *OutboxService) MarkSent(ctx context.Context, id uint64) error {
+	const upd = `UPDATE notification
+			SET status = $1,
+			  attempts = attempts + 1,
+			  sent     = $2
+			WHERE id = $3`
+
+	r, err := s.DB.ExecContext(ctx, upd, lara.Sent, now(), id)
+	return checkUpdatedNotification(id, r, err)
+}
+
+// MarkFailed is implementation of Outbox.MarkFailed using postgresql database.
+func (s *OutboxService) MarkFailed(ctx context.Context, id uint64, reason string, final bool) error {
+	const upd = `UPDATE notification
+			SET status   = $1,
+			  attempts   = attempts + 1,
+			  last_error = $2
+			WHERE id = $3`
+
+	status := lara.Pending
+	if final {
+		status = lara.Failed
+	}
+
+	r, err := s.DB.ExecContext(ctx, upd, status, toNullString(reason), id)
+	return checkUpdatedNotification(id, r, err)
+}
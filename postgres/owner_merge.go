@@ -0,0 +1,514 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/jkusniar/lara"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// present reports whether nullable string has non-empty value
+func present(s sql.NullString) bool {
+	return s.Valid && len(strings.TrimSpace(s.String)) > 0
+}
+
+// phoneDigits returns digits of phone number, used to compare phones
+func phoneDigits(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, phone)
+}
+
+// mergeOwnerDTOs returns survivor's data merged with data of other owners.
+// Owners are ordered newest first. Survivor keeps its name, empty name fields
+// are filled from newest owner. Address, email, phones and billing IDs are
+// taken from newest owner having them. Notes are joined.
+func mergeOwnerDTOs(survivor uint64, owners []ownerDTO) ownerDTO {
+	var m ownerDTO
+	for _, o := range owners {
+		if o.ID == survivor {
+			m = o
+		}
+	}
+
+	var address, email, ic, dic, icdph bool
+	var phones []sql.NullString
+	seenPhones := map[string]bool{}
+	notes := []string{}
+	seenNotes := map[string]bool{}
+	addNote := func(n sql.NullString) {
+		if present(n) && !seenNotes[n.String] {
+			seenNotes[n.String] = true
+			notes = append(notes, n.String)
+		}
+	}
+	addNote(m.Note)
+
+	for _, o := range owners {
+		if !present(m.FirstName) && present(o.FirstName) {
+			m.FirstName = o.FirstName
+		}
+		if !m.TitleID.Valid && o.TitleID.Valid {
+			m.TitleID = o.TitleID
+		}
+		if !address && o.CityID.Valid {
+			m.CityID, m.StreetID, m.HouseNo = o.CityID, o.StreetID, o.HouseNo
+			address = true
+		}
+		if !email && present(o.Email) {
+			m.Email, email = o.Email, true
+		}
+		if !ic && present(o.IC) {
+			m.IC, ic = o.IC, true
+		}
+		if !dic && present(o.DIC) {
+			m.DIC, dic = o.DIC, true
+		}
+		if !icdph && present(o.ICDPH) {
+			m.ICDPH, icdph = o.ICDPH, true
+		}
+		for _, p := range []sql.NullString{o.Phone1, o.Phone2} {
+			if present(p) && !seenPhones[phoneDigits(p.String)] {
+				seenPhones[phoneDigits(p.String)] = true
+				phones = append(phones, p)
+			}
+		}
+		if o.ID != survivor {
+			addNote(o.Note)
+		}
+	}
+
+	m.Phone1, m.Phone2 = sql.NullString{}, sql.NullString{}
+	if len(phones) > 0 {
+		m.Phone1 = phones[0]
+	}
+	if len(phones) > 1 {
+		m.Phone2 = phones[1]
+	}
+	m.Note = toNullString(strings.Join(notes, "\n"))
+
+	return m
+}
+
+// Merge is implementation of OwnerService.Merge using postgresql database.
+// Data of merged owners are stored in owner_merge table.
+func (s *OwnerService) Merge(ctx context.Context, id uint64, m *lara.MergeOwners) error {
+	if len(m.Duplicates) == 0 {
+		return requiredFieldError("duplicates")
+	}
+
+	ids := []int64{int64(id)}
+	versions := map[uint64]uint64{id: m.Version}
+	for _, d := range m.Duplicates {
+		if _, ok := versions[d.ID]; ok {
+			return lara.NewCodedError(400,
+				errors.Errorf("owner %d can't be merged more than once", d.ID))
+		}
+		ids = append(ids, int64(d.ID))
+		versions[d.ID] = d.Version
+	}
+
+	return execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		const lck = `SELECT
+			  id,
+			  first_name,
+			  last_name,
+			  title_id,
+			  city_id,
+			  street_id,
+			  house_no,
+			  phone_1,
+			  phone_2,
+			  email,
+			  note,
+			  ic,
+			  dic,
+			  icdph,
+			  version,
+			  creator,
+			  created,
+			  modifier,
+			  modified
+			FROM owner
+			WHERE id = ANY($1)
+			ORDER BY COALESCE(modified, created) DESC, id DESC
+			FOR UPDATE`
+		const pat = `SELECT id, owner_id, name FROM patient WHERE owner_id = ANY($1) ORDER BY id`
+		const ins = `INSERT INTO owner_merge (owner_id, merged_id, merged_data, creator, created)
+			VALUES ($1, $2, $3, $4, $5)`
+		const upd = `UPDATE owner
+			SET first_name = $1,
+			  title_id     = $2,
+			  city_id      = $3,
+			  street_id    = $4,
+			  house_no     = $5,
+			  phone_1      = $6,
+			  phone_2      = $7,
+			  email        = $8,
+			  note         = $9,
+			  ic           = $10,
+			  dic          = $11,
+			  icdph        = $12,
+			  modifier     = $13,
+			  modified     = $14,
+			  version      = version + 1
+			WHERE id = $15 AND version = $16`
+
+		u, ok := lara.UserFromContext(ctx)
+		if !ok {
+			return errors.New("no user in context")
+		}
+
+		rows, err := tx.QueryContext(ctx, lck, pq.Array(ids))
+		if err != nil {
+			return errors.Wrap(err, "error locking merged owners")
+		}
+		defer rows.Close()
+
+		owners := []ownerDTO{}
+		found := map[uint64]bool{}
+		for rows.Next() {
+			var o ownerDTO
+			if err := rows.Scan(&o.ID,
+				&o.FirstName,
+				&o.LastName,
+				&o.TitleID,
+				&o.CityID,
+				&o.StreetID,
+				&o.HouseNo,
+				&o.Phone1,
+				&o.Phone2,
+				&o.Email,
+				&o.Note,
+				&o.IC,
+				&o.DIC,
+				&o.ICDPH,
+				&o.Version,
+				&o.Creator,
+				&o.Created,
+				&o.Modifier,
+				&o.Modified); err != nil {
+				return errors.Wrap(err, "scan DTO error")
+			}
+			owners = append(owners, o)
+			found[o.ID] = true
+		}
+		if err := rows.Err(); err != nil {
+			return errors.Wrap(err, "rows processing errror")
+		}
+
+		for _, i := range ids {
+			if !found[uint64(i)] {
+				return notFoundByIDError(uint64(i))
+			}
+		}
+		for _, o := range owners {
+			if o.Version != versions[o.ID] {
+				return versionMismatchError(o.ID)
+			}
+		}
+
+		// merged owners' patients are part of merge record
+		patients := map[uint64][]ownersPatientDTO{}
+		prows, err := tx.QueryContext(ctx, pat, pq.Array(ids[1:]))
+		if err != nil {
+			return errors.Wrap(err, "error selecting merged patients")
+		}
+		defer prows.Close()
+		for prows.Next() {
+			var p ownersPatientDTO
+			var oid uint64
+			if err := prows.Scan(&p.ID, &oid, &p.Name); err != nil {
+				return errors.Wrap(err, "scan DTO error")
+			}
+			patients[oid] = append(patients[oid], p)
+		}
+		if err := prows.Err(); err != nil {
+			return errors.Wrap(err, "rows processing errror")
+		}
+
+		for _, o := range owners {
+			if o.ID == id {
+				continue
+			}
+			data, err := json.Marshal(o.toGetOwner(patients[o.ID]))
+			if err != nil {
+				return errors.Wrap(err, "merged owner marshalling error")
+			}
+			if _, err := tx.ExecContext(ctx, ins, id, o.ID, string(data),
+				toNullString(u.Login), now()); err != nil {
+				return errors.Wrap(err, "insert owner merge failed")
+			}
+		}
+
+		for _, t := range []string{"patient", "invoice", "notification", "owner_merge"} {
+			if _, err := tx.ExecContext(ctx,
+				fmt.Sprintf("UPDATE %s SET owner_id = $1 WHERE owner_id = ANY($2)", t),
+				id, pq.Array(ids[1:])); err != nil {
+				return errors.Wrapf(err, "moving %s to merged owner failed", t)
+			}
+		}
+
+		for _, c := range []string{"from_owner_id", "to_owner_id"} {
+			if _, err := tx.ExecContext(ctx,
+				fmt.Sprintf("UPDATE patient_transfer SET %[1]s = $1 WHERE %[1]s = ANY($2)", c),
+				id, pq.Array(ids[1:])); err != nil {
+				return errors.Wrap(err, "moving patient transfers to merged owner failed")
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM owner WHERE id = ANY($1)`,
+			pq.Array(ids[1:])); err != nil {
+			return errors.Wrap(err, "delete merged owners failed")
+		}
+
+		o := mergeOwnerDTOs(id, owners)
+		r, err := tx.ExecContext(ctx, upd,
+			o.FirstName,
+			o.TitleID,
+			o.CityID,
+			o.StreetID,
+			o.HouseNo,
+			o.Phone1,
+			o.Phone2,
+			o.Email,
+			o.Note,
+			o.IC,
+			o.DIC,
+			o.ICDPH,
+			toNullString(u.Login),
+			now(),
+			id,
+			m.Version)
+		if err != nil {
+			return errors.Wrap(err, "update merged owner failed")
+		}
+
+		count, err := r.RowsAffected()
+		if err != nil {
+			return errors.Wrap(err, "update owner can't check updated rows")
+		}
+
+		if count != 1 {
+			return versionMismatchError(id)
+		}
+
+		return nil
+	})
+}
+
+// duplicatesWith selects pairs of owners with same name (unaccented, first
+// name may be missing), same phone digits or same street and house number.
+// Name and phone are scored 2, address 1. Pairs scored at least 2 are
+// duplicate candidates.
+const duplicatesWith = `WITH k AS (
+			  SELECT
+			    id,
+			    lower(f_unaccent(last_name))                 AS last_name,
+			    lower(f_unaccent(COALESCE(first_name, ''))) AS first_name,
+			    street_id,
+			    lower(trim(COALESCE(house_no, '')))          AS house_no
+			  FROM owner
+			), ph AS (
+			  SELECT DISTINCT id, phone FROM (
+			    SELECT id, regexp_replace(COALESCE(phone_1, ''), '[^0-9]', '', 'g') AS phone FROM owner
+			    UNION ALL
+			    SELECT id, regexp_replace(COALESCE(phone_2, ''), '[^0-9]', '', 'g') FROM owner
+			  ) p
+			  WHERE length(phone) >= 6
+			), pairs AS (
+			  SELECT a.id AS id1, b.id AS id2
+			  FROM k a JOIN k b ON a.last_name = b.last_name AND (a.first_name = b.first_name
+			    OR a.first_name = '' OR b.first_name = '') AND a.id < b.id
+			  UNION
+			  SELECT a.id, b.id
+			  FROM ph a JOIN ph b ON a.phone = b.phone AND a.id < b.id
+			  UNION
+			  SELECT a.id, b.id
+			  FROM k a JOIN k b ON a.street_id = b.street_id AND a.house_no = b.house_no
+			    AND a.house_no <> '' AND a.id < b.id
+			), scored AS (
+			  SELECT
+			    p.id1,
+			    p.id2,
+			    a.last_name = b.last_name AND (a.first_name = b.first_name
+			      OR a.first_name = '' OR b.first_name = '')               AS same_name,
+			    EXISTS(SELECT 1 FROM ph x JOIN ph y ON x.phone = y.phone
+			           WHERE x.id = p.id1 AND y.id = p.id2)                   AS same_phone,
+			    COALESCE(a.street_id = b.street_id AND a.house_no = b.house_no
+			      AND a.house_no <> '', FALSE)                               AS same_address
+			  FROM pairs p
+			    JOIN k a ON a.id = p.id1
+			    JOIN k b ON b.id = p.id2
+			), d AS (
+			  SELECT *,
+			    CASE WHEN same_name THEN 2 ELSE 0 END +
+			    CASE WHEN same_phone THEN 2 ELSE 0 END +
+			    CASE WHEN same_address THEN 1 ELSE 0 END AS score
+			  FROM scored
+			)
+			`
+
+// duplicateSorts are Duplicates' sort keys. Score is negated, so ascending
+// order lists most similar pairs first.
+var duplicateSorts = map[string][]string{
+	"score": {"-d.score", "d.id1", "d.id2"},
+}
+
+type duplicateCandidateDTO struct {
+	versionedDTO
+	OwnerNameDTO
+	OwnerAddressDTO
+	Phone1 sql.NullString
+	Phone2 sql.NullString
+}
+
+func (d *duplicateCandidateDTO) toDuplicateCandidate() lara.DuplicateCandidate {
+	phones := []string{}
+	for _, p := range []sql.NullString{d.Phone1, d.Phone2} {
+		if present(p) {
+			phones = append(phones, p.String)
+		}
+	}
+
+	return lara.DuplicateCandidate{
+		Versioned: lara.Versioned{ID: d.ID, Version: d.Version},
+		Name:      d.OwnerNameDTO.String(),
+		Address:   d.OwnerAddressDTO.String(),
+		Phone:     strings.Join(phones, ", "),
+	}
+}
+
+// Duplicates is implementation of OwnerService.Duplicates using postgresql
+// database
+func (s *OwnerService) Duplicates(ctx context.Context, p lara.Page) (*lara.DuplicateOwnersList, error) {
+	page, err := pageSQL(p, duplicateSorts, "score")
+	if err != nil {
+		return nil, err
+	}
+
+	cq := duplicatesWith + `SELECT count(*) FROM d WHERE d.score >= 2`
+	dq := duplicatesWith + `SELECT
+			  d.same_name,
+			  d.same_phone,
+			  d.same_address,
+			  d.score,
+			  o1.id,
+			  o1.version,
+			  o1.first_name,
+			  o1.last_name,
+			  t1.name,
+			  c1.city,
+			  s1.street,
+			  o1.house_no,
+			  o1.phone_1,
+			  o1.phone_2,
+			  o2.id,
+			  o2.version,
+			  o2.first_name,
+			  o2.last_name,
+			  t2.name,
+			  c2.city,
+			  s2.street,
+			  o2.house_no,
+			  o2.phone_1,
+			  o2.phone_2
+			FROM d
+			  JOIN owner o1 ON o1.id = d.id1
+			  LEFT JOIN lov_title t1 ON t1.id = o1.title_id
+			  LEFT JOIN lov_city c1 ON c1.id = o1.city_id
+			  LEFT JOIN lov_street s1 ON s1.id = o1.street_id
+			  JOIN owner o2 ON o2.id = d.id2
+			  LEFT JOIN lov_title t2 ON t2.id = o2.title_id
+			  LEFT JOIN lov_city c2 ON c2.id = o2.city_id
+			  LEFT JOIN lov_street s2 ON s2.id = o2.street_id
+			WHERE d.score >= 2
+			` + page
+
+	r := lara.DuplicateOwnersList{Items: []lara.DuplicateOwners{}}
+	if err := s.DB.QueryRowContext(ctx, cq).Scan(&r.Total); err != nil {
+		return nil, errors.Wrap(err, "duplicate owners count error")
+	}
+
+	rows, err := s.DB.QueryContext(ctx, dq)
+	if err != nil {
+		return nil, errors.Wrap(err, "duplicate owners query error")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, phone, address bool
+		var d lara.DuplicateOwners
+		var o1, o2 duplicateCandidateDTO
+		if err := rows.Scan(&name,
+			&phone,
+			&address,
+			&d.Score,
+			&o1.ID,
+			&o1.Version,
+			&o1.FirstName,
+			&o1.LastName,
+			&o1.Title,
+			&o1.City,
+			&o1.Street,
+			&o1.HouseNo,
+			&o1.Phone1,
+			&o1.Phone2,
+			&o2.ID,
+			&o2.Version,
+			&o2.FirstName,
+			&o2.LastName,
+			&o2.Title,
+			&o2.City,
+			&o2.Street,
+			&o2.HouseNo,
+			&o2.Phone1,
+			&o2.Phone2); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+
+		d.Owner1 = o1.toDuplicateCandidate()
+		d.Owner2 = o2.toDuplicateCandidate()
+		d.Reasons = []string{}
+		for _, reason := range []struct {
+			ok   bool
+			name string
+		}{{name, "name"}, {phone, "phone"}, {address, "address"}} {
+			if reason.ok {
+				d.Reasons = append(d.Reasons, reason.name)
+			}
+		}
+		r.Items = append(r.Items, d)
+	}
+	err = rows.Err()
+
+	return &r, errors.Wrap(err, "rows processing errror")
+}
@@ -98,3 +98,27 @@ func TestOwnersPatientsDTO(t *testing.T) {
 		t.Fatal("expected not dead")
 	}
 }
+
+func TestMergeOwnerDTOs(t *testing.T) {
+	str := func(s string) sql.NullString { return toNullString(s) }
+	owners := []ownerDTO{ // newest first
+		{versionedDTO: versionedDTO{ID: 2}, FirstName: str("Ján"), LastName: "Kováč",
+			Phone1: str("0905 123 456"), Note: str("newer"), IC: str("123")},
+		{versionedDTO: versionedDTO{ID: 1}, LastName: "Kovac", Phone1: str("0905123456"),
+			Phone2: str("02 1234"), Email: str("jan@example.sk"),
+			CityID: sql.NullInt64{Int64: 1, Valid: true}, HouseNo: str("7"),
+			Note: str("older")},
+		{versionedDTO: versionedDTO{ID: 3}, LastName: "Kováč", Phone1: str("0999"),
+			StreetID: sql.NullInt64{Int64: 5, Valid: true}, Note: str("newer")},
+	}
+
+	m := mergeOwnerDTOs(1, owners)
+
+	if m.ID != 1 || m.FirstName.String != "Ján" || m.LastName != "Kovac" ||
+		m.Phone1.String != "0905 123 456" || m.Phone2.String != "02 1234" ||
+		m.Email.String != "jan@example.sk" || m.CityID.Int64 != 1 ||
+		m.StreetID.Valid || m.HouseNo.String != "7" || m.IC.String != "123" ||
+		m.Note.String != "older\nnewer" {
+		t.Fatalf("unexpected merge result %#v", m)
+	}
+}
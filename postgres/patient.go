@@ -38,6 +38,7 @@ type patientDTO struct {
 	versionedDTO
 	creatorDTO
 	modifierDTO
+	OwnerID   uint64
 	Name      string
 	BirthDate pq.NullTime
 	SpeciesID sql.NullInt64
@@ -48,9 +49,12 @@ type patientDTO struct {
 	Species   sql.NullString
 	Breed     sql.NullString
 	Gender    sql.NullString
+	Weight    sql.NullString
+	WeightAt  pq.NullTime
 }
 
-func (p *patientDTO) toGetPatient(records []patientsRecordDTO, tags []lara.PatientsTag) *lara.GetPatient {
+func (p *patientDTO) toGetPatient(records []patientsRecordDTO, tags []lara.PatientsTag,
+	vaccinations []lara.PatientsVaccination, transfers []lara.PatientsTransfer) *lara.GetPatient {
 	result := lara.GetPatient{
 		Versioned: lara.Versioned{
 			ID:      p.ID,
@@ -68,12 +72,17 @@ func (p *patientDTO) toGetPatient(records []patientsRecordDTO, tags []lara.Patie
 			GenderID:  uint64(p.GenderID.Int64),
 			Note:      p.Note.String,
 		},
-		Dead:    p.Dead,
-		Species: p.Species.String,
-		Breed:   p.Breed.String,
-		Gender:  p.Gender.String,
-		Records: []lara.PatientsRecord{},
-		Tags:    tags}
+		OwnerID:      p.OwnerID,
+		Dead:         p.Dead,
+		Species:      p.Species.String,
+		Breed:        p.Breed.String,
+		Gender:       p.Gender.String,
+		Weight:       p.Weight.String,
+		WeightDate:   p.WeightAt.Time,
+		Records:      []lara.PatientsRecord{},
+		Tags:         tags,
+		Vaccinations: vaccinations,
+		Transfers:    transfers}
 
 	for _, r := range records {
 		result.Records = append(result.Records, *r.toPatientsRecord())
@@ -104,6 +113,7 @@ func (p *patientsRecordDTO) toPatientsRecord() *lara.PatientsRecord {
 func (s *PatientService) Get(ctx context.Context, id uint64) (*lara.GetPatient, error) {
 	const q = `SELECT
 			  p.id,
+			  p.owner_id,
 			  p.name,
 			  p.birth_date,
 			  p.species_id,
@@ -118,16 +128,24 @@ func (s *PatientService) Get(ctx context.Context, id uint64) (*lara.GetPatient,
 			  p.modified,
 			  g.name as gender,
 			  s.name as species,
-			  b.name as breed
+			  b.name as breed,
+			  w.weight,
+			  w.rec_date
 			FROM patient p
 			 LEFT JOIN lov_gender g ON g.id = p.gender_id
 			 LEFT JOIN lov_species s ON s.id = p.species_id
 			 LEFT JOIN lov_breed b ON b.id = p.breed_id
+			 LEFT JOIN LATERAL (SELECT r.weight, r.rec_date
+			                    FROM record r
+			                    WHERE r.patient_id = p.id AND r.weight IS NOT NULL
+			                    ORDER BY r.rec_date DESC, r.id DESC
+			                    LIMIT 1) w ON true
 			WHERE p.id = $1`
 
 	var p patientDTO
 	err := s.DB.QueryRowContext(ctx, q, id).Scan(
 		&p.ID,
+		&p.OwnerID,
 		&p.Name,
 		&p.BirthDate,
 		&p.SpeciesID,
@@ -142,7 +160,9 @@ func (s *PatientService) Get(ctx context.Context, id uint64) (*lara.GetPatient,
 		&p.Modified,
 		&p.Gender,
 		&p.Species,
-		&p.Breed)
+		&p.Breed,
+		&p.Weight,
+		&p.WeightAt)
 	switch {
 	case err == sql.ErrNoRows:
 		return nil, notFoundByIDError(id)
@@ -162,7 +182,19 @@ func (s *PatientService) Get(ctx context.Context, id uint64) (*lara.GetPatient,
 		return nil, err
 	}
 
-	return p.toGetPatient(records, tags), nil
+	// load vaccinations
+	vaccinations, err := s.getPatientsVaccinations(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// load ownership history
+	transfers, err := s.getPatientsTransfers(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.toGetPatient(records, tags, vaccinations, transfers), nil
 }
 
 func (s *PatientService) getPatientsRecords(ctx context.Context, id uint64) ([]patientsRecordDTO, error) {
@@ -197,7 +229,8 @@ func (s *PatientService) getPatientsRecords(ctx context.Context, id uint64) ([]p
 func (s *PatientService) getPatientsTags(ctx context.Context, id uint64) ([]lara.PatientsTag, error) {
 	const q = `SELECT t.id,
 			  tt.name,
-			  t.value
+			  t.value,
+			  ` + tagActiveSQL + `
 			FROM tag t
 			JOIN tag_type tt on tt.id = t.tag_type_id
 			WHERE patient_id = $1
@@ -214,7 +247,8 @@ func (s *PatientService) getPatientsTags(ctx context.Context, id uint64) ([]lara
 		var t lara.PatientsTag
 		if err := rows.Scan(&t.ID,
 			&t.Type,
-			&t.Value); err != nil {
+			&t.Value,
+			&t.Active); err != nil {
 			return nil, errors.Wrap(err, "scan DTO error")
 		}
 		tags = append(tags, t)
@@ -224,6 +258,95 @@ func (s *PatientService) getPatientsTags(ctx context.Context, id uint64) ([]lara
 	return tags, errors.Wrap(err, "rows processing errror")
 }
 
+func (s *PatientService) getPatientsVaccinations(ctx context.Context, id uint64) ([]lara.PatientsVaccination, error) {
+	const q = `SELECT v.id,
+			  p.name,
+			  v.administered,
+			  v.due_date
+			FROM vaccination v
+			JOIN lov_product p on p.id = v.prod_id
+			WHERE v.patient_id = $1
+			ORDER BY v.administered DESC, v.id DESC`
+
+	rows, err := s.DB.QueryContext(ctx, q, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "get patient's vaccinations query error")
+	}
+	defer rows.Close()
+
+	vaccinations := []lara.PatientsVaccination{}
+	for rows.Next() {
+		var v lara.PatientsVaccination
+		var due pq.NullTime
+		if err := rows.Scan(&v.ID,
+			&v.Vaccine,
+			&v.Administered,
+			&due); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		v.Due = due.Time
+		vaccinations = append(vaccinations, v)
+	}
+	err = rows.Err()
+
+	return vaccinations, errors.Wrap(err, "rows processing errror")
+}
+
+func (s *PatientService) getPatientsTransfers(ctx context.Context, id uint64) ([]lara.PatientsTransfer, error) {
+	const q = `SELECT
+			  t.from_owner_id,
+			  fo.first_name,
+			  fo.last_name,
+			  fl.name,
+			  t.to_owner_id,
+			  tov.first_name,
+			  tov.last_name,
+			  tl.name,
+			  t.transfer_date,
+			  t.reason,
+			  t.creator
+			FROM patient_transfer t
+			  JOIN owner fo ON fo.id = t.from_owner_id
+			  LEFT JOIN lov_title fl ON fl.id = fo.title_id
+			  JOIN owner tov ON tov.id = t.to_owner_id
+			  LEFT JOIN lov_title tl ON tl.id = tov.title_id
+			WHERE t.patient_id = $1
+			ORDER BY t.transfer_date DESC, t.id DESC`
+
+	rows, err := s.DB.QueryContext(ctx, q, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "get patient's transfers query error")
+	}
+	defer rows.Close()
+
+	transfers := []lara.PatientsTransfer{}
+	for rows.Next() {
+		var t lara.PatientsTransfer
+		var from, to OwnerNameDTO
+		var reason sql.NullString
+		if err := rows.Scan(&t.FromOwnerID,
+			&from.FirstName,
+			&from.LastName,
+			&from.Title,
+			&t.ToOwnerID,
+			&to.FirstName,
+			&to.LastName,
+			&to.Title,
+			&t.Date,
+			&reason,
+			&t.Creator); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		t.FromOwner = from.String()
+		t.ToOwner = to.String()
+		t.Reason = reason.String
+		transfers = append(transfers, t)
+	}
+	err = rows.Err()
+
+	return transfers, errors.Wrap(err, "rows processing errror")
+}
+
 // Create is implementation of PatientService.Create using postgresql database.
 func (s *PatientService) Create(ctx context.Context, p *lara.CreatePatient) (uint64, error) {
 	var pID uint64
@@ -339,3 +462,85 @@ func (s *PatientService) Update(ctx context.Context, id uint64, p *lara.UpdatePa
 
 	return err
 }
+
+// Transfer is implementation of PatientService.Transfer using postgresql
+// database.
+func (s *PatientService) Transfer(ctx context.Context, id uint64, t *lara.TransferPatient) error {
+	if t.OwnerID == 0 {
+		return requiredFieldError("ownerId")
+	}
+
+	date := t.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	if date.After(time.Now()) {
+		return lara.NewCodedError(400,
+			errors.New("transfer date can't be in the future"))
+	}
+
+	return execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		const lck = `SELECT owner_id FROM patient WHERE id = $1 FOR UPDATE`
+		const own = `SELECT id FROM owner WHERE id = $1`
+		const upd = `UPDATE patient
+				SET owner_id = $1,
+				  modifier   = $2,
+				  modified   = $3,
+				  version    = version + 1
+				WHERE id = $4 AND version = $5`
+		const ins = `INSERT INTO patient_transfer (patient_id, from_owner_id, to_owner_id,
+				  transfer_date, reason, creator, created)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+		var from uint64
+		err := tx.QueryRowContext(ctx, lck, id).Scan(&from)
+		switch err {
+		case nil: // continue
+		case sql.ErrNoRows:
+			return notFoundByIDError(id)
+		default:
+			return errors.Wrap(err, "error selecting patient by id")
+		}
+
+		if from == t.OwnerID {
+			return lara.NewCodedError(400,
+				errors.Errorf("patient %d already belongs to owner %d", id, t.OwnerID))
+		}
+
+		var to uint64
+		err = tx.QueryRowContext(ctx, own, t.OwnerID).Scan(&to)
+		switch err {
+		case nil: // continue
+		case sql.ErrNoRows:
+			return lara.NewCodedError(400,
+				errors.Errorf("owner %d not found", t.OwnerID))
+		default:
+			return errors.Wrap(err, "error selecting owner by id")
+		}
+
+		u, ok := lara.UserFromContext(ctx)
+		if !ok {
+			return errors.New("no user in context")
+		}
+
+		r, err := tx.ExecContext(ctx, upd, to, toNullString(u.Login), now(), id,
+			t.Version)
+		if err != nil {
+			return errors.Wrap(err, "transfer patient failed")
+		}
+
+		count, err := r.RowsAffected()
+		if err != nil {
+			return errors.Wrap(err, "transfer patient can't check updated rows")
+		}
+
+		if count != 1 {
+			return versionMismatchError(id)
+		}
+
+		_, err = tx.ExecContext(ctx, ins, id, from, to, date.Local(),
+			toNullString(t.Reason), toNullString(u.Login), now())
+
+		return errors.Wrap(err, "insert patient transfer failed")
+	})
+}
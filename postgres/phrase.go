@@ -0,0 +1,270 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/jkusniar/lara"
+	"github.com/pkg/errors"
+)
+
+type phraseDTO struct {
+	versionedDTO
+	Name  string
+	Text  sql.NullString
+	Login sql.NullString
+}
+
+func (p *phraseDTO) toGetPhrase() lara.GetPhrase {
+	return lara.GetPhrase{
+		Versioned: lara.Versioned{
+			ID:      p.ID,
+			Version: p.Version},
+		Phrase: lara.Phrase{
+			Name:     p.Name,
+			Text:     p.Text.String,
+			Personal: p.Login.Valid,
+		},
+		Author: p.Login.String,
+	}
+}
+
+func phraseExistsError(err error, p *lara.Phrase) error {
+	if isUniqueViolation(err) {
+		return lara.NewCodedError(409,
+			errors.Errorf("phrase %s already exists", p.Name))
+	}
+	return err
+}
+
+// phraseLogin returns user_login column value of phrase p created or updated
+// by user u
+func phraseLogin(u *lara.User, p *lara.Phrase) sql.NullString {
+	if p.Personal {
+		return toNullString(u.Login)
+	}
+	return sql.NullString{}
+}
+
+// SearchPhrases is implementation of PhraseService.SearchPhrases using postgresql database.
+func (s *SimpleLovService) SearchPhrases(ctx context.Context, query string) (*lara.PhraseList, error) {
+	const q = `SELECT id,
+			  version,
+			  name,
+			  phrase_text,
+			  user_login
+			FROM lov_phrase
+			WHERE (user_login IS NULL OR user_login = $1)
+			  AND (name ILIKE $2 OR phrase_text ILIKE $2)
+			ORDER BY name, user_login NULLS FIRST`
+
+	u, ok := lara.UserFromContext(ctx)
+	if !ok {
+		return nil, errors.New("no user in context")
+	}
+
+	rows, err := s.DB.QueryContext(ctx, q, u.Login, "%"+query+"%")
+	if err != nil {
+		return nil, errors.Wrap(err, "search phrases query error")
+	}
+	defer rows.Close()
+
+	result := lara.PhraseList{Items: []lara.GetPhrase{}}
+	for rows.Next() {
+		var p phraseDTO
+		if err := rows.Scan(&p.ID, &p.Version, &p.Name, &p.Text, &p.Login); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		result.Items = append(result.Items, p.toGetPhrase())
+	}
+	err = rows.Err()
+
+	return &result, errors.Wrap(err, "rows processing errror")
+}
+
+// GetPhrase is implementation of PhraseService.GetPhrase using postgresql database.
+func (s *SimpleLovService) GetPhrase(ctx context.Context, id uint64) (*lara.GetPhrase, error) {
+	const q = `SELECT id,
+			  version,
+			  name,
+			  phrase_text,
+			  user_login
+			FROM lov_phrase
+			WHERE id = $1 AND (user_login IS NULL OR user_login = $2)`
+
+	u, ok := lara.UserFromContext(ctx)
+	if !ok {
+		return nil, errors.New("no user in context")
+	}
+
+	var p phraseDTO
+	err := s.DB.QueryRowContext(ctx, q, id, u.Login).Scan(&p.ID, &p.Version, &p.Name, &p.Text, &p.Login)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, notFoundByIDError(id)
+	case err != nil:
+		return nil, errors.Wrap(err, "get phrase by id failed")
+	}
+
+	result := p.toGetPhrase()
+	return &result, nil
+}
+
+// CreatePhrase is implementation of PhraseService.CreatePhrase using postgresql database.
+func (s *SimpleLovService) CreatePhrase(ctx context.Context, p *lara.Phrase) (uint64, error) {
+	if len(p.Name) == 0 {
+		return 0, requiredFieldError("name")
+	}
+
+	const insert = `INSERT INTO lov_phrase (name, phrase_text, user_login) VALUES ($1, $2, $3) RETURNING id`
+
+	u, ok := lara.UserFromContext(ctx)
+	if !ok {
+		return 0, errors.New("no user in context")
+	}
+
+	var id uint64
+	err := s.DB.QueryRowContext(ctx, insert,
+		p.Name,
+		toNullString(p.Text),
+		phraseLogin(u, p)).Scan(&id)
+	if err != nil {
+		return 0, phraseExistsError(errors.Wrap(err, "create phrase failed"), p)
+	}
+
+	return id, nil
+}
+
+// UpdatePhrase is implementation of PhraseService.UpdatePhrase using postgresql database.
+func (s *SimpleLovService) UpdatePhrase(ctx context.Context, id uint64, p *lara.UpdatePhrase) error {
+	if len(p.Name) == 0 {
+		return requiredFieldError("name")
+	}
+
+	u, ok := lara.UserFromContext(ctx)
+	if !ok {
+		return errors.New("no user in context")
+	}
+
+	return execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		const lck = `SELECT user_login FROM lov_phrase
+				WHERE id = $1 AND (user_login IS NULL OR user_login = $2) FOR UPDATE`
+		const upd = `UPDATE lov_phrase
+				SET name      = $1,
+				  phrase_text = $2,
+				  user_login  = $3,
+				  version     = version + 1
+				WHERE id = $4 AND version = $5`
+
+		var login sql.NullString
+		err := tx.QueryRowContext(ctx, lck, id, u.Login).Scan(&login)
+		switch err {
+		case nil: // continue
+		case sql.ErrNoRows:
+			return notFoundByIDError(id)
+		default:
+			return errors.Wrap(err, "error selecting phrase by id")
+		}
+
+		// only author may change ownership of personal phrase, clinic-wide
+		// phrase belongs to nobody
+		if !login.Valid && p.Personal {
+			return lara.NewCodedError(400,
+				errors.Errorf("clinic-wide phrase %d can't be made personal", id))
+		}
+
+		r, err := tx.ExecContext(ctx, upd,
+			p.Name,
+			toNullString(p.Text),
+			phraseLogin(u, &p.Phrase),
+			id,
+			p.Version)
+		if err != nil {
+			return phraseExistsError(errors.Wrap(err, "update phrase failed"), &p.Phrase)
+		}
+
+		count, err := r.RowsAffected()
+		if err != nil {
+			return errors.Wrap(err, "update phrase can't check updated rows")
+		}
+		if count != 1 {
+			return versionMismatchError(id)
+		}
+
+		return nil
+	})
+}
+
+// ExpandPhrase is implementation of PhraseService.ExpandPhrase using postgresql database.
+func (s *SimpleLovService) ExpandPhrase(ctx context.Context, id, patientID uint64) (*lara.ExpandedPhrase, error) {
+	p, err := s.GetPhrase(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []string{"{date}", time.Now().Format("02.01.2006")}
+	if patientID != 0 {
+		const q = `SELECT p.name,
+				  s.name,
+				  b.name,
+				  g.name,
+				  o.first_name,
+				  o.last_name,
+				  t.name
+				FROM patient p
+				  JOIN owner o ON o.id = p.owner_id
+				  LEFT JOIN lov_species s ON s.id = p.species_id
+				  LEFT JOIN lov_breed b ON b.id = p.breed_id
+				  LEFT JOIN lov_gender g ON g.id = p.gender_id
+				  LEFT JOIN lov_title t ON t.id = o.title_id
+				WHERE p.id = $1`
+
+		var name string
+		var species, breed, gender sql.NullString
+		var o OwnerNameDTO
+		err := s.DB.QueryRowContext(ctx, q, patientID).Scan(&name,
+			&species,
+			&breed,
+			&gender,
+			&o.FirstName,
+			&o.LastName,
+			&o.Title)
+		switch {
+		case err == sql.ErrNoRows:
+			return nil, lara.NewCodedError(400,
+				errors.Errorf("patient %d not found", patientID))
+		case err != nil:
+			return nil, errors.Wrap(err, "get phrase's patient failed")
+		}
+
+		values = append(values,
+			"{patient}", name,
+			"{species}", species.String,
+			"{breed}", breed.String,
+			"{gender}", gender.String,
+			"{owner}", o.String())
+	}
+
+	return &lara.ExpandedPhrase{Text: strings.NewReplacer(values...).Replace(p.Text)}, nil
+}
@@ -0,0 +1,350 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jkusniar/lara"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// PrescriptionService is lara.PrescriptionService implementation backed by postgresql
+type PrescriptionService struct {
+	DB *sql.DB
+}
+
+// Get is implementation of PrescriptionService.Get using postgresql database.
+func (s *PrescriptionService) Get(ctx context.Context, id uint64) (*lara.GetPrescription, error) {
+	const q = `SELECT
+			  id,
+			  version,
+			  creator,
+			  created,
+			  modifier,
+			  modified,
+			  record_id,
+			  patient_id,
+			  presc_date,
+			  note
+			FROM prescription
+			WHERE id = $1`
+
+	var p lara.GetPrescription
+	var modifier, note sql.NullString
+	var modified pq.NullTime
+	err := s.DB.QueryRowContext(ctx, q, id).Scan(
+		&p.ID,
+		&p.Version,
+		&p.Creator,
+		&p.Created,
+		&modifier,
+		&modified,
+		&p.RecordID,
+		&p.PatientID,
+		&p.Date,
+		&note)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, notFoundByIDError(id)
+	case err != nil:
+		return nil, errors.Wrap(err, "get prescription by id failed")
+	}
+	p.Modifier = modifier.String
+	p.Modified = modified.Time
+	p.Note = note.String
+
+	if p.Items, err = s.getItems(ctx, p.ID, p.Date); err != nil {
+		return nil, err
+	}
+
+	for _, i := range p.Items {
+		if i.WithdrawalEnd.After(p.WithdrawalEnd) {
+			p.WithdrawalEnd = i.WithdrawalEnd
+		}
+	}
+
+	return &p, nil
+}
+
+func (s *PrescriptionService) getItems(ctx context.Context, id uint64, date time.Time) ([]lara.GetPrescriptionItem, error) {
+	const q = `SELECT i.id,
+			  i.prod_id,
+			  i.amount,
+			  i.dose,
+			  i.frequency,
+			  i.duration_days,
+			  i.withdrawal_days,
+			  p.name,
+			  u.name,
+			  p.controlled
+			FROM prescription_item i
+			JOIN lov_product p ON p.id = i.prod_id
+			JOIN lov_unit u ON u.id = p.unit_id
+			WHERE i.prescription_id = $1
+			ORDER BY i.id`
+
+	rows, err := s.DB.QueryContext(ctx, q, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "get prescription's items query error")
+	}
+	defer rows.Close()
+
+	items := []lara.GetPrescriptionItem{}
+	for rows.Next() {
+		var i lara.GetPrescriptionItem
+		var frequency sql.NullString
+		if err := rows.Scan(&i.ID,
+			&i.ProductID,
+			&i.Amount,
+			&i.Dose,
+			&frequency,
+			&i.DurationDays,
+			&i.WithdrawalDays,
+			&i.Product,
+			&i.Unit,
+			&i.Controlled); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		i.Frequency = frequency.String
+		i.WithdrawalEnd = i.PrescriptionItem.WithdrawalEnd(date)
+		items = append(items, i)
+	}
+	err = rows.Err()
+
+	return items, errors.Wrap(err, "rows processing errror")
+}
+
+func validatePrescription(p *lara.Prescription) error {
+	if len(p.Items) == 0 {
+		return requiredFieldError("items")
+	}
+
+	for n, i := range p.Items {
+		if i.ProductID == 0 {
+			return requiredFieldError(fmt.Sprintf("productId on item %d", n))
+		}
+		if len(i.Amount) == 0 {
+			return requiredFieldError(fmt.Sprintf("amount on item %d", n))
+		}
+		if err := validateMeasurement(i.Amount, fmt.Sprintf("amount on item %d", n), 1000000, 10, 4); err != nil {
+			return err
+		}
+		if len(i.Dose) == 0 {
+			return requiredFieldError(fmt.Sprintf("dose on item %d", n))
+		}
+	}
+
+	return nil
+}
+
+// Create is implementation of PrescriptionService.Create using postgresql
+// database. Prescription is issued to patient of record.
+func (s *PrescriptionService) Create(ctx context.Context, p *lara.CreatePrescription) (uint64, error) {
+	if p.RecordID == 0 {
+		return 0, requiredFieldError("recordId")
+	}
+
+	if err := validatePrescription(&p.Prescription); err != nil {
+		return 0, err
+	}
+
+	var id uint64
+	err := execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		const insert = `INSERT INTO prescription (record_id, patient_id, presc_date, note, creator, created)
+				SELECT r.id, r.patient_id, COALESCE($2::timestamp, $4::timestamp), $3::text, $5::text, $4::timestamp
+				FROM record r
+				WHERE r.id = $1
+				RETURNING id`
+
+		u, ok := lara.UserFromContext(ctx)
+		if !ok {
+			return errors.New("no user in context")
+		}
+
+		err := tx.QueryRowContext(ctx, insert,
+			p.RecordID,
+			toNullTime(p.Date),
+			toNullString(p.Note),
+			now(),
+			u.Login).Scan(&id)
+		switch {
+		case err == sql.ErrNoRows:
+			return lara.NewCodedError(400,
+				errors.Errorf("record %d not found", p.RecordID))
+		case err != nil:
+			return errors.Wrap(err, "create prescription failed")
+		}
+
+		return createPrescriptionItems(ctx, tx, id, p.Items)
+	})
+
+	return id, err
+}
+
+func createPrescriptionItems(ctx context.Context, tx *sql.Tx, id uint64, items []lara.PrescriptionItem) error {
+	const insert = `INSERT INTO prescription_item (prescription_id, prod_id, amount, dose, frequency, duration_days,
+				withdrawal_days)
+				SELECT $1, p.id, $3::numeric, $4::text, $5::text, $6::integer, $7::integer
+				FROM lov_product p
+				WHERE p.id = $2`
+
+	for n, i := range items {
+		res, err := tx.ExecContext(ctx, insert,
+			id,
+			i.ProductID,
+			i.Amount,
+			i.Dose,
+			toNullString(i.Frequency),
+			i.DurationDays,
+			i.WithdrawalDays)
+		if err != nil {
+			return errors.Wrap(err, "insert prescription item failed")
+		}
+
+		count, err := res.RowsAffected()
+		if err != nil {
+			return errors.Wrap(err, "insert prescription item can't check inserted rows")
+		}
+		if count != 1 {
+			return lara.NewCodedError(400,
+				errors.Errorf("product %d on item %d not found", i.ProductID, n))
+		}
+	}
+
+	return nil
+}
+
+// Update is implementation of PrescriptionService.Update using postgresql
+// database. Items are replaced.
+func (s *PrescriptionService) Update(ctx context.Context, id uint64, p *lara.UpdatePrescription) error {
+	if err := validatePrescription(&p.Prescription); err != nil {
+		return err
+	}
+
+	err := execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		const lock = `SELECT id FROM prescription WHERE id = $1 FOR UPDATE`
+		const update = `UPDATE prescription
+				SET presc_date = COALESCE($1::timestamp, presc_date),
+				  note         = $2,
+				  modifier     = $3,
+				  modified     = $4,
+				  version      = version + 1
+				WHERE id = $5 AND version = $6`
+		const del = `DELETE FROM prescription_item WHERE prescription_id = $1`
+
+		var pid uint64
+		err := tx.QueryRowContext(ctx, lock, id).Scan(&pid)
+		switch err {
+		case nil: // continue
+		case sql.ErrNoRows:
+			return notFoundByIDError(id)
+		default:
+			return errors.Wrap(err, "error selecting prescription by id")
+		}
+
+		u, ok := lara.UserFromContext(ctx)
+		if !ok {
+			return errors.New("no user in context")
+		}
+
+		res, err := tx.ExecContext(ctx, update,
+			toNullTime(p.Date),
+			toNullString(p.Note),
+			toNullString(u.Login),
+			now(),
+			pid,
+			p.Version)
+		if err != nil {
+			return errors.Wrap(err, "update prescription failed")
+		}
+
+		count, err := res.RowsAffected()
+		if err != nil {
+			return errors.Wrap(err, "update prescription can't check updated rows")
+		}
+
+		if count != 1 {
+			return versionMismatchError(id)
+		}
+
+		if _, err := tx.ExecContext(ctx, del, pid); err != nil {
+			return errors.Wrap(err, "delete prescription items failed")
+		}
+
+		return createPrescriptionItems(ctx, tx, pid, p.Items)
+	})
+
+	return err
+}
+
+// ListByRecord is implementation of PrescriptionService.ListByRecord using
+// postgresql database.
+func (s *PrescriptionService) ListByRecord(ctx context.Context, recordID uint64) (*lara.PrescriptionList, error) {
+	return s.list(ctx, "WHERE pr.record_id = $1", recordID)
+}
+
+// ListByPatient is implementation of PrescriptionService.ListByPatient using
+// postgresql database.
+func (s *PrescriptionService) ListByPatient(ctx context.Context, patientID uint64) (*lara.PrescriptionList, error) {
+	return s.list(ctx, "WHERE pr.patient_id = $1", patientID)
+}
+
+func (s *PrescriptionService) list(ctx context.Context, where string, id uint64) (*lara.PrescriptionList, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT
+			  pr.id,
+			  pr.record_id,
+			  pr.presc_date,
+			  string_agg(p.name, ', ' ORDER BY i.id),
+			  MAX(CASE WHEN i.withdrawal_days > 0
+			    THEN pr.presc_date + (i.duration_days + i.withdrawal_days) * interval '1 day' END)
+			FROM prescription pr
+			  JOIN prescription_item i ON i.prescription_id = pr.id
+			  JOIN lov_product p ON p.id = i.prod_id
+			`+where+`
+			GROUP BY pr.id
+			ORDER BY pr.presc_date DESC, pr.id DESC`, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "list prescriptions query error")
+	}
+	defer rows.Close()
+
+	result := lara.PrescriptionList{Items: []lara.PrescriptionListItem{}}
+	for rows.Next() {
+		var i lara.PrescriptionListItem
+		var withdrawal pq.NullTime
+		if err := rows.Scan(&i.ID,
+			&i.RecordID,
+			&i.Date,
+			&i.Drugs,
+			&withdrawal); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		i.WithdrawalEnd = withdrawal.Time
+		result.Items = append(result.Items, i)
+	}
+	err = rows.Err()
+
+	return &result, errors.Wrap(err, "rows processing errror")
+}
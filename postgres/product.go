@@ -22,8 +22,12 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/jkusniar/lara"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 )
 
@@ -32,28 +36,85 @@ type ProductService struct {
 	DB *sql.DB
 }
 
-// Search performs DB search according to ProductSearchRequest
-// TODO make search limit parametric
+type productDTO struct {
+	versionedDTO
+	creatorDTO
+	modifierDTO
+	Name       string
+	UnitID     uint64
+	Unit       string
+	PLU        sql.NullString
+	Price      string
+	VATRate    string
+	MinStock   sql.NullString
+	Controlled bool
+	ValidTo    pq.NullTime
+}
+
+func (p *productDTO) toGetProduct() *lara.GetProduct {
+	return &lara.GetProduct{
+		Versioned: lara.Versioned{
+			ID:      p.ID,
+			Version: p.Version},
+		CreatorModifier: lara.CreatorModifier{
+			Creator:  p.Creator,
+			Created:  p.Created,
+			Modifier: p.Modifier.String,
+			Modified: p.Modified.Time},
+		ProductData: lara.ProductData{
+			Name:       p.Name,
+			UnitID:     p.UnitID,
+			PLU:        p.PLU.String,
+			Price:      p.Price,
+			VATRate:    p.VATRate,
+			MinStock:   p.MinStock.String,
+			Controlled: p.Controlled},
+		Unit:    p.Unit,
+		ValidTo: p.ValidTo.Time,
+	}
+}
+
+// priceValidAt returns SQL condition selecting product_price row aliased as
+// alias valid at date expression
+func priceValidAt(alias, date string) string {
+	return fmt.Sprintf("(%[1]s.valid_from IS NULL OR %[1]s.valid_from <= %[2]s) AND "+
+		"(%[1]s.valid_to IS NULL OR %[1]s.valid_to >= %[2]s)", alias, date)
+}
+
+// productSorts are ProductSearchRequest's sort keys
+var productSorts = map[string][]string{
+	"name":  {"p.name", "p.id"},
+	"price": {"price", "p.name", "p.id"},
+}
+
+// Search performs DB search according to ProductSearchRequest. Products'
+// prices valid at p.ValidTo are returned.
 func (s *ProductService) Search(ctx context.Context, p *lara.ProductSearchRequest) (*lara.ProductSearchResult, error) {
+	page, err := pageSQL(p.Page, productSorts, "name")
+	if err != nil {
+		return nil, err
+	}
+
 	r := lara.ProductSearchResult{Total: 0, Products: []lara.Product{}}
 
 	const cq = `SELECT count(*) FROM lov_product WHERE name ILIKE $1 AND (valid_to IS NULL OR valid_to >= $2)`
-	const dq = `SELECT
+	dq := `SELECT
 			  p.id    AS id,
 			  p.name  AS name,
 			  u.name  AS unit,
-			  p.price AS price
+			  COALESCE(pp.price, p.price) AS price,
+			  COALESCE(pp.vat_rate, p.vat_rate) AS vat_rate
 			FROM lov_product p
 			  JOIN lov_unit u ON u.id = p.unit_id
+			  LEFT JOIN product_price pp ON pp.prod_id = p.id AND ` + priceValidAt("pp", "$2::date") + `
 			WHERE p.name ILIKE $1 AND (p.valid_to IS NULL OR p.valid_to >= $2)
-			ORDER BY p.name
-			LIMIT $3`
+			` + page
 
 	if err := s.DB.QueryRowContext(ctx, cq, "%"+p.Query+"%", p.ValidTo.Local()).Scan(&r.Total); err != nil {
 		return nil, errors.Wrap(err, "search product count error")
 	}
 
-	rows, err := s.DB.QueryContext(ctx, dq, "%"+p.Query+"%", p.ValidTo.Local(), 30)
+	rows, err := s.DB.QueryContext(ctx, dq, "%"+p.Query+"%", p.ValidTo.Local())
 	if err != nil {
 		return nil, errors.Wrap(err, "search query error")
 	}
@@ -64,7 +125,8 @@ func (s *ProductService) Search(ctx context.Context, p *lara.ProductSearchReques
 		if err := rows.Scan(&p.ID,
 			&p.Name,
 			&p.Unit,
-			&p.Price); err != nil {
+			&p.Price,
+			&p.VATRate); err != nil {
 			return nil, errors.Wrap(err, "scan DTO error")
 		}
 		r.Products = append(r.Products, p)
@@ -73,3 +135,400 @@ func (s *ProductService) Search(ctx context.Context, p *lara.ProductSearchReques
 
 	return &r, errors.Wrap(err, "rows processing errror")
 }
+
+// Get is implementation of ProductService.Get using postgresql database
+func (s *ProductService) Get(ctx context.Context, id uint64) (*lara.GetProduct, error) {
+	const q = `SELECT
+			  p.id,
+			  p.name,
+			  p.unit_id,
+			  u.name,
+			  p.plu,
+			  p.price,
+			  p.vat_rate,
+			  p.min_stock,
+			  p.controlled,
+			  p.valid_to,
+			  p.version,
+			  p.creator,
+			  p.created,
+			  p.modifier,
+			  p.modified
+			FROM lov_product p
+			  JOIN lov_unit u ON u.id = p.unit_id
+			WHERE p.id = $1`
+
+	var p productDTO
+	err := s.DB.QueryRowContext(ctx, q, id).Scan(
+		&p.ID,
+		&p.Name,
+		&p.UnitID,
+		&p.Unit,
+		&p.PLU,
+		&p.Price,
+		&p.VATRate,
+		&p.MinStock,
+		&p.Controlled,
+		&p.ValidTo,
+		&p.Version,
+		&p.Creator,
+		&p.Created,
+		&p.Modifier,
+		&p.Modified)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, notFoundByIDError(id)
+	case err != nil:
+		return nil, errors.Wrap(err, "get product by id failed")
+	}
+
+	return p.toGetProduct(), nil
+}
+
+func validateProduct(p *lara.ProductData) error {
+	if len(p.Name) == 0 {
+		return requiredFieldError("name")
+	}
+	if p.UnitID == 0 {
+		return requiredFieldError("unitId")
+	}
+	if len(p.Price) == 0 {
+		return requiredFieldError("price")
+	}
+	if v, ok := parseNumeric(p.Price, 8, 2); !ok || v < 0 {
+		return lara.NewCodedError(400,
+			errors.Errorf("price '%s' is not valid", p.Price))
+	}
+	if len(p.PLU) > 0 {
+		if _, err := strconv.ParseUint(p.PLU, 10, 31); err != nil {
+			return lara.NewCodedError(400,
+				errors.Errorf("plu '%s' is not valid", p.PLU))
+		}
+	}
+	if len(p.MinStock) > 0 {
+		if v, ok := parseNumeric(p.MinStock, 10, 4); !ok || v < 0 {
+			return lara.NewCodedError(400,
+				errors.Errorf("minStock '%s' is not valid", p.MinStock))
+		}
+	}
+	return validateVATRate(p.VATRate, "vatRate")
+}
+
+func productExistsError(err error, p *lara.ProductData) error {
+	if isUniqueViolation(err) {
+		return lara.NewCodedError(409,
+			errors.Errorf("product %s with unit %d already exists", p.Name, p.UnitID))
+	}
+	return err
+}
+
+// Create is implementation of ProductService.Create using postgresql database
+func (s *ProductService) Create(ctx context.Context, p *lara.CreateProduct) (uint64, error) {
+	const insert = `INSERT INTO lov_product (name, unit_id, plu, price, vat_rate, min_stock, controlled, creator,
+			  created)
+			VALUES ($1, $2, $3, $4, COALESCE($5::numeric, 0), $6, $7, $8, $9)
+			RETURNING id`
+
+	if err := validateProduct(&p.ProductData); err != nil {
+		return 0, err
+	}
+
+	var id uint64
+	err := execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		u, ok := lara.UserFromContext(ctx)
+		if !ok {
+			return errors.New("no user in context")
+		}
+
+		err := tx.QueryRowContext(ctx, insert,
+			p.Name,
+			p.UnitID,
+			toNullString(p.PLU),
+			p.Price,
+			toNullString(p.VATRate),
+			toNullString(p.MinStock),
+			p.Controlled,
+			u.Login,
+			now()).Scan(&id)
+		if err != nil {
+			return productExistsError(errors.Wrap(err, "create product failed"), &p.ProductData)
+		}
+
+		return insertPrice(ctx, tx, id, &p.ProductData, true, u.Login)
+	})
+
+	return id, err
+}
+
+// Update is implementation of ProductService.Update using postgresql database
+func (s *ProductService) Update(ctx context.Context, id uint64, p *lara.UpdateProduct) error {
+	if err := validateProduct(&p.ProductData); err != nil {
+		return err
+	}
+
+	return execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		const upd = `UPDATE lov_product
+			SET name     = $1,
+			  unit_id    = $2,
+			  plu        = $3,
+			  price      = $4,
+			  vat_rate   = COALESCE($5::numeric, 0),
+			  min_stock  = $6,
+			  controlled = $7,
+			  modifier   = $8,
+			  modified   = $9,
+			  version    = version + 1
+			WHERE id = $10 AND version = $11`
+
+		if err := lockProduct(ctx, tx, id); err != nil {
+			return err
+		}
+
+		u, ok := lara.UserFromContext(ctx)
+		if !ok {
+			return errors.New("no user in context")
+		}
+
+		r, err := tx.ExecContext(ctx, upd,
+			p.Name,
+			p.UnitID,
+			toNullString(p.PLU),
+			p.Price,
+			toNullString(p.VATRate),
+			toNullString(p.MinStock),
+			p.Controlled,
+			u.Login,
+			now(),
+			id,
+			p.Version)
+		if err != nil {
+			return productExistsError(errors.Wrap(err, "update product failed"), &p.ProductData)
+		}
+
+		if err := checkUpdatedProduct(r, id); err != nil {
+			return err
+		}
+
+		return changePrice(ctx, tx, id, &p.ProductData, u.Login)
+	})
+}
+
+// Retire is implementation of ProductService.Retire using postgresql database
+func (s *ProductService) Retire(ctx context.Context, id uint64, rp *lara.RetireProduct) error {
+	return execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		const upd = `UPDATE lov_product
+			SET valid_to = COALESCE($1::date, current_date),
+			  modifier   = $2,
+			  modified   = $3,
+			  version    = version + 1
+			WHERE id = $4 AND version = $5`
+
+		if err := lockProduct(ctx, tx, id); err != nil {
+			return err
+		}
+
+		u, ok := lara.UserFromContext(ctx)
+		if !ok {
+			return errors.New("no user in context")
+		}
+
+		r, err := tx.ExecContext(ctx, upd,
+			toNullTime(rp.ValidTo),
+			u.Login,
+			now(),
+			id,
+			rp.Version)
+		if err != nil {
+			return errors.Wrap(err, "retire product failed")
+		}
+
+		return checkUpdatedProduct(r, id)
+	})
+}
+
+func lockProduct(ctx context.Context, tx *sql.Tx, id uint64) error {
+	const lck = `SELECT id FROM lov_product WHERE id = $1 FOR UPDATE`
+
+	var pid uint64
+	err := tx.QueryRowContext(ctx, lck, id).Scan(&pid)
+	switch err {
+	case nil:
+		return nil
+	case sql.ErrNoRows:
+		return notFoundByIDError(id)
+	default:
+		return errors.Wrap(err, "error selecting product by id")
+	}
+}
+
+func checkUpdatedProduct(r sql.Result, id uint64) error {
+	count, err := r.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "update product can't check updated rows")
+	}
+
+	if count != 1 {
+		return versionMismatchError(id)
+	}
+
+	return nil
+}
+
+// insertPrice inserts product's current price. First price is valid since
+// ever, next ones from today.
+func insertPrice(ctx context.Context, tx *sql.Tx, id uint64, p *lara.ProductData, first bool, user string) error {
+	const insert = `INSERT INTO product_price (prod_id, price, vat_rate, valid_from, creator, created)
+			VALUES ($1, $2, COALESCE($3::numeric, 0), CASE WHEN $4 THEN NULL ELSE current_date END, $5, $6)`
+
+	_, err := tx.ExecContext(ctx, insert,
+		id,
+		p.Price,
+		toNullString(p.VATRate),
+		first,
+		user,
+		now())
+
+	return errors.Wrap(err, "insert product price failed")
+}
+
+// changePrice keeps product's price history after update. Changed price is
+// valid from today, price already changed today is overwritten.
+func changePrice(ctx context.Context, tx *sql.Tx, id uint64, p *lara.ProductData, user string) error {
+	const current = `SELECT id,
+			  price = $2::numeric AND vat_rate = COALESCE($3::numeric, 0),
+			  COALESCE(valid_from = current_date, FALSE)
+			FROM product_price
+			WHERE prod_id = $1 AND valid_to IS NULL
+			FOR UPDATE`
+	const closePrice = `UPDATE product_price
+			SET valid_to = current_date - 1,
+			  modifier   = $2,
+			  modified   = $3
+			WHERE id = $1`
+	const overwrite = `UPDATE product_price
+			SET price  = $2,
+			  vat_rate = COALESCE($3::numeric, 0),
+			  modifier = $4,
+			  modified = $5
+			WHERE id = $1`
+
+	var priceID uint64
+	var same, today bool
+	err := tx.QueryRowContext(ctx, current, id, p.Price, toNullString(p.VATRate)).Scan(&priceID, &same, &today)
+	switch {
+	case err == sql.ErrNoRows:
+		return insertPrice(ctx, tx, id, p, true, user)
+	case err != nil:
+		return errors.Wrap(err, "select current product price failed")
+	case same:
+		return nil
+	case today:
+		_, err = tx.ExecContext(ctx, overwrite, priceID, p.Price, toNullString(p.VATRate), user, now())
+		return errors.Wrap(err, "update product price failed")
+	}
+
+	if _, err = tx.ExecContext(ctx, closePrice, priceID, user, now()); err != nil {
+		return errors.Wrap(err, "close product price failed")
+	}
+
+	return insertPrice(ctx, tx, id, p, false, user)
+}
+
+type productPriceDTO struct {
+	creatorDTO
+	modifierDTO
+	Price     string
+	VATRate   string
+	ValidFrom pq.NullTime
+	ValidTo   pq.NullTime
+}
+
+func (p *productPriceDTO) toProductPrice() *lara.ProductPrice {
+	return &lara.ProductPrice{
+		Price:     p.Price,
+		VATRate:   p.VATRate,
+		ValidFrom: p.ValidFrom.Time,
+		ValidTo:   p.ValidTo.Time,
+		CreatorModifier: lara.CreatorModifier{
+			Creator:  p.Creator,
+			Created:  p.Created,
+			Modifier: p.Modifier.String,
+			Modified: p.Modified.Time},
+	}
+}
+
+const productPriceColumns = `price,
+			  vat_rate,
+			  valid_from,
+			  valid_to,
+			  creator,
+			  created,
+			  modifier,
+			  modified`
+
+func scanProductPrice(row rowScanner) (*productPriceDTO, error) {
+	var p productPriceDTO
+	err := row.Scan(&p.Price,
+		&p.VATRate,
+		&p.ValidFrom,
+		&p.ValidTo,
+		&p.Creator,
+		&p.Created,
+		&p.Modifier,
+		&p.Modified)
+	return &p, err
+}
+
+// PriceAt is implementation of ProductService.PriceAt using postgresql
+// database
+func (s *ProductService) PriceAt(ctx context.Context, id uint64, date time.Time) (*lara.ProductPrice, error) {
+	q := `SELECT ` + productPriceColumns + `
+			FROM product_price pp
+			WHERE pp.prod_id = $1 AND ` + priceValidAt("pp", "$2::date")
+
+	d := date.Format("2006-01-02")
+	p, err := scanProductPrice(s.DB.QueryRowContext(ctx, q, id, d))
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, lara.NewCodedError(404,
+			errors.Errorf("price of product %d valid at %s not found", id, d))
+	case err != nil:
+		return nil, errors.Wrap(err, "get product price failed")
+	}
+
+	return p.toProductPrice(), nil
+}
+
+// PriceHistory is implementation of ProductService.PriceHistory using
+// postgresql database
+func (s *ProductService) PriceHistory(ctx context.Context, id uint64) (*lara.ProductPriceList, error) {
+	const q = `SELECT ` + productPriceColumns + `
+			FROM product_price
+			WHERE prod_id = $1
+			ORDER BY valid_from NULLS FIRST`
+
+	rows, err := s.DB.QueryContext(ctx, q, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "product price history query error")
+	}
+	defer rows.Close()
+
+	result := lara.ProductPriceList{Items: []lara.ProductPrice{}}
+	for rows.Next() {
+		p, err := scanProductPrice(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		result.Items = append(result.Items, *p.toProductPrice())
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "rows processing errror")
+	}
+
+	// every product has price since it's creation
+	if len(result.Items) == 0 {
+		return nil, notFoundByIDError(id)
+	}
+
+	return &result, nil
+}
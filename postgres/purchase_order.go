@@ -0,0 +1,554 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jkusniar/lara"
+	"github.com/pkg/errors"
+)
+
+// PurchaseOrderService is implementation of lara.PurchaseOrderService using postgresql database
+type PurchaseOrderService struct {
+	DB *sql.DB
+}
+
+type purchaseOrderDTO struct {
+	versionedDTO
+	creatorDTO
+	modifierDTO
+	SupplierID uint64
+	Supplier   string
+	Note       sql.NullString
+	Status     lara.PurchaseOrderStatus
+}
+
+func (o *purchaseOrderDTO) toGetPurchaseOrder(lines []lara.GetPurchaseOrderLine) *lara.GetPurchaseOrder {
+	return &lara.GetPurchaseOrder{
+		Versioned: lara.Versioned{
+			ID:      o.ID,
+			Version: o.Version},
+		CreatorModifier: lara.CreatorModifier{
+			Creator:  o.Creator,
+			Created:  o.Created,
+			Modifier: o.Modifier.String,
+			Modified: o.Modified.Time},
+		SupplierID: o.SupplierID,
+		Supplier:   o.Supplier,
+		Note:       o.Note.String,
+		Status:     o.Status,
+		Lines:      lines,
+	}
+}
+
+// Get is implementation of PurchaseOrderService.Get using postgresql database
+func (s *PurchaseOrderService) Get(ctx context.Context, id uint64) (*lara.GetPurchaseOrder, error) {
+	const q = `SELECT
+			  o.id,
+			  o.version,
+			  o.supplier_id,
+			  s.name,
+			  o.note,
+			  o.status,
+			  o.creator,
+			  o.created,
+			  o.modifier,
+			  o.modified
+			FROM purchase_order o
+			  JOIN supplier s ON s.id = o.supplier_id
+			WHERE o.id = $1`
+
+	var o purchaseOrderDTO
+	err := s.DB.QueryRowContext(ctx, q, id).Scan(
+		&o.ID,
+		&o.Version,
+		&o.SupplierID,
+		&o.Supplier,
+		&o.Note,
+		&o.Status,
+		&o.Creator,
+		&o.Created,
+		&o.Modifier,
+		&o.Modified)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, notFoundByIDError(id)
+	case err != nil:
+		return nil, errors.Wrap(err, "get purchase order by id failed")
+	}
+
+	lines, err := s.getLines(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.toGetPurchaseOrder(lines), nil
+}
+
+func (s *PurchaseOrderService) getLines(ctx context.Context, id uint64) ([]lara.GetPurchaseOrderLine, error) {
+	const q = `SELECT
+			  l.id,
+			  l.prod_id,
+			  l.amount,
+			  l.price,
+			  p.name,
+			  u.name,
+			  l.received
+			FROM purchase_order_line l
+			  JOIN lov_product p ON p.id = l.prod_id
+			  JOIN lov_unit u ON u.id = p.unit_id
+			WHERE l.order_id = $1
+			ORDER BY l.id`
+
+	rows, err := s.DB.QueryContext(ctx, q, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "get purchase order's lines query error")
+	}
+	defer rows.Close()
+
+	lines := []lara.GetPurchaseOrderLine{}
+	for rows.Next() {
+		var l lara.GetPurchaseOrderLine
+		var price sql.NullString
+		if err := rows.Scan(&l.ID,
+			&l.ProductID,
+			&l.Amount,
+			&price,
+			&l.Product,
+			&l.Unit,
+			&l.Received); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		l.Price = price.String
+		lines = append(lines, l)
+	}
+	err = rows.Err()
+
+	return lines, errors.Wrap(err, "rows processing errror")
+}
+
+// validPositive reports whether s is numeric(10,4) amount greater than zero
+func validPositive(s string) bool {
+	v, ok := parseNumeric(s, 10, 4)
+	return ok && v > 0
+}
+
+func validatePurchaseOrder(o *lara.PurchaseOrder) error {
+	if o.SupplierID == 0 {
+		return requiredFieldError("supplierId")
+	}
+	if len(o.Lines) == 0 {
+		return requiredFieldError("lines")
+	}
+
+	for i, l := range o.Lines {
+		if l.ProductID == 0 {
+			return requiredFieldError(fmt.Sprintf("productId on line %d", i))
+		}
+		if len(l.Amount) == 0 {
+			return requiredFieldError(fmt.Sprintf("amount on line %d", i))
+		}
+		if !validPositive(l.Amount) {
+			return lara.NewCodedError(400,
+				errors.Errorf("amount '%s' on line %d is not valid", l.Amount, i))
+		}
+		if len(l.Price) > 0 {
+			if v, ok := parseNumeric(l.Price, 8, 2); !ok || v < 0 {
+				return lara.NewCodedError(400,
+					errors.Errorf("price '%s' on line %d is not valid", l.Price, i))
+			}
+		}
+	}
+
+	return nil
+}
+
+func checkSupplierExists(ctx context.Context, tx *sql.Tx, id uint64) error {
+	const q = `SELECT id FROM supplier WHERE id = $1`
+
+	var sid uint64
+	err := tx.QueryRowContext(ctx, q, id).Scan(&sid)
+	switch err {
+	case nil:
+		return nil
+	case sql.ErrNoRows:
+		return lara.NewCodedError(400,
+			errors.Errorf("supplier %d not found", id))
+	default:
+		return errors.Wrap(err, "error selecting supplier by id")
+	}
+}
+
+func createPurchaseOrderLines(ctx context.Context, tx *sql.Tx, orderID uint64, lines []lara.PurchaseOrderLine) error {
+	const insert = `INSERT INTO purchase_order_line (order_id, prod_id, amount, price)
+			SELECT $1, p.id, $3::numeric, $4::numeric
+			FROM lov_product p
+			WHERE p.id = $2`
+
+	for n, l := range lines {
+		res, err := tx.ExecContext(ctx, insert,
+			orderID,
+			l.ProductID,
+			l.Amount,
+			toNullString(l.Price))
+		if err != nil {
+			return errors.Wrap(err, "insert purchase order line failed")
+		}
+
+		count, err := res.RowsAffected()
+		if err != nil {
+			return errors.Wrap(err, "insert purchase order line can't check inserted rows")
+		}
+		if count != 1 {
+			return lara.NewCodedError(400,
+				errors.Errorf("product %d on line %d not found", l.ProductID, n))
+		}
+	}
+
+	return nil
+}
+
+// Create is implementation of PurchaseOrderService.Create using postgresql database
+func (s *PurchaseOrderService) Create(ctx context.Context, o *lara.CreatePurchaseOrder) (uint64, error) {
+	if err := validatePurchaseOrder(&o.PurchaseOrder); err != nil {
+		return 0, err
+	}
+
+	const insert = `INSERT INTO purchase_order (supplier_id, note, status, creator, created)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id`
+
+	var id uint64
+	err := execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		if err := checkSupplierExists(ctx, tx, o.SupplierID); err != nil {
+			return err
+		}
+
+		u, ok := lara.UserFromContext(ctx)
+		if !ok {
+			return errors.New("no user in context")
+		}
+
+		err := tx.QueryRowContext(ctx, insert,
+			o.SupplierID,
+			toNullString(o.Note),
+			lara.Ordered,
+			u.Login,
+			now()).Scan(&id)
+		if err != nil {
+			return errors.Wrap(err, "create purchase order failed")
+		}
+
+		return createPurchaseOrderLines(ctx, tx, id, o.Lines)
+	})
+
+	return id, err
+}
+
+// lockPurchaseOrder locks purchase order for update and checks it's in one
+// of allowed statuses
+func lockPurchaseOrder(ctx context.Context, tx *sql.Tx, id uint64, allowed ...lara.PurchaseOrderStatus) error {
+	const lck = `SELECT status FROM purchase_order WHERE id = $1 FOR UPDATE`
+
+	var status lara.PurchaseOrderStatus
+	err := tx.QueryRowContext(ctx, lck, id).Scan(&status)
+	switch err {
+	case nil: // continue
+	case sql.ErrNoRows:
+		return notFoundByIDError(id)
+	default:
+		return errors.Wrap(err, "error selecting purchase order by id")
+	}
+
+	for _, a := range allowed {
+		if status == a {
+			return nil
+		}
+	}
+
+	return lara.NewCodedError(409,
+		errors.Errorf("purchase order %d is %s", id, status))
+}
+
+// updatePurchaseOrderStatus changes order's status and increments version
+func updatePurchaseOrderStatus(ctx context.Context, tx *sql.Tx, id, version uint64,
+	status lara.PurchaseOrderStatus) error {
+	const upd = `UPDATE purchase_order
+			SET status = $1,
+			  modifier = $2,
+			  modified = $3,
+			  version  = version + 1
+			WHERE id = $4 AND version = $5`
+
+	u, ok := lara.UserFromContext(ctx)
+	if !ok {
+		return errors.New("no user in context")
+	}
+
+	r, err := tx.ExecContext(ctx, upd, status, u.Login, now(), id, version)
+	if err != nil {
+		return errors.Wrap(err, "update purchase order status failed")
+	}
+
+	return checkUpdatedPurchaseOrder(r, id)
+}
+
+func checkUpdatedPurchaseOrder(r sql.Result, id uint64) error {
+	count, err := r.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "update purchase order can't check updated rows")
+	}
+
+	if count != 1 {
+		return versionMismatchError(id)
+	}
+
+	return nil
+}
+
+// Update is implementation of PurchaseOrderService.Update using postgresql database
+func (s *PurchaseOrderService) Update(ctx context.Context, id uint64, o *lara.UpdatePurchaseOrder) error {
+	if err := validatePurchaseOrder(&o.PurchaseOrder); err != nil {
+		return err
+	}
+
+	return execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		const upd = `UPDATE purchase_order
+			SET supplier_id = $1,
+			  note          = $2,
+			  modifier      = $3,
+			  modified      = $4,
+			  version       = version + 1
+			WHERE id = $5 AND version = $6`
+		const del = `DELETE FROM purchase_order_line WHERE order_id = $1`
+
+		if err := lockPurchaseOrder(ctx, tx, id, lara.Ordered); err != nil {
+			return err
+		}
+
+		if err := checkSupplierExists(ctx, tx, o.SupplierID); err != nil {
+			return err
+		}
+
+		u, ok := lara.UserFromContext(ctx)
+		if !ok {
+			return errors.New("no user in context")
+		}
+
+		r, err := tx.ExecContext(ctx, upd,
+			o.SupplierID,
+			toNullString(o.Note),
+			u.Login,
+			now(),
+			id,
+			o.Version)
+		if err != nil {
+			return errors.Wrap(err, "update purchase order failed")
+		}
+
+		if err := checkUpdatedPurchaseOrder(r, id); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, del, id); err != nil {
+			return errors.Wrap(err, "delete purchase order lines failed")
+		}
+
+		return createPurchaseOrderLines(ctx, tx, id, o.Lines)
+	})
+}
+
+// Receive is implementation of PurchaseOrderService.Receive using postgresql
+// database. Order is Received when all lines are received in full.
+func (s *PurchaseOrderService) Receive(ctx context.Context, id uint64, r *lara.ReceivePurchaseOrder) error {
+	if len(r.Lines) == 0 {
+		return requiredFieldError("lines")
+	}
+	for i, l := range r.Lines {
+		if l.LineID == 0 {
+			return requiredFieldError(fmt.Sprintf("lineId on line %d", i))
+		}
+		if !validPositive(l.Amount) {
+			return lara.NewCodedError(400,
+				errors.Errorf("amount '%s' on line %d is not valid", l.Amount, i))
+		}
+	}
+
+	return execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		const line = `SELECT prod_id, amount - received >= $3::numeric
+			FROM purchase_order_line
+			WHERE id = $1 AND order_id = $2
+			FOR UPDATE`
+		const receive = `UPDATE purchase_order_line SET received = received + $2::numeric WHERE id = $1`
+		const complete = `SELECT NOT EXISTS (
+			  SELECT 1 FROM purchase_order_line WHERE order_id = $1 AND received < amount)`
+
+		if err := lockPurchaseOrder(ctx, tx, id, lara.Ordered, lara.PartiallyReceived); err != nil {
+			return err
+		}
+
+		// version is checked before any stock is received
+		if err := updatePurchaseOrderStatus(ctx, tx, id, r.Version, lara.PartiallyReceived); err != nil {
+			return err
+		}
+
+		u, ok := lara.UserFromContext(ctx)
+		if !ok {
+			return errors.New("no user in context")
+		}
+
+		for n, l := range r.Lines {
+			var prodID uint64
+			var fits bool
+			err := tx.QueryRowContext(ctx, line, l.LineID, id, l.Amount).Scan(&prodID, &fits)
+			switch {
+			case err == sql.ErrNoRows:
+				return lara.NewCodedError(400,
+					errors.Errorf("line %d of purchase order %d not found", l.LineID, id))
+			case err != nil:
+				return errors.Wrap(err, "select purchase order line failed")
+			case !fits:
+				return lara.NewCodedError(400,
+					errors.Errorf("amount on line %d exceeds amount not received yet", n))
+			}
+
+			if _, err := tx.ExecContext(ctx, receive, l.LineID, l.Amount); err != nil {
+				return errors.Wrap(err, "update purchase order line failed")
+			}
+
+			_, err = postMovement(ctx, tx, &lara.CreateStockMovement{
+				StockMovement: lara.StockMovement{
+					ProductID: prodID,
+					Type:      lara.Receipt,
+					Amount:    l.Amount,
+					Date:      r.Date,
+					Note:      fmt.Sprintf("purchase order %d", id)},
+				Batch:  l.Batch,
+				Expiry: l.Expiry}, 1, u.Login)
+			if err != nil {
+				return err
+			}
+		}
+
+		var done bool
+		if err := tx.QueryRowContext(ctx, complete, id).Scan(&done); err != nil {
+			return errors.Wrap(err, "check purchase order received failed")
+		}
+		if done {
+			return updatePurchaseOrderStatus(ctx, tx, id, r.Version+1, lara.Received)
+		}
+
+		return nil
+	})
+}
+
+// Withdraw is implementation of PurchaseOrderService.Withdraw using postgresql database
+func (s *PurchaseOrderService) Withdraw(ctx context.Context, id uint64, w *lara.WithdrawPurchaseOrder) error {
+	return execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		if err := lockPurchaseOrder(ctx, tx, id, lara.Ordered, lara.PartiallyReceived); err != nil {
+			return err
+		}
+
+		return updatePurchaseOrderStatus(ctx, tx, id, w.Version, lara.Withdrawn)
+	})
+}
+
+// ListBySupplier is implementation of PurchaseOrderService.ListBySupplier using postgresql database
+func (s *PurchaseOrderService) ListBySupplier(ctx context.Context, supplierID uint64) (*lara.SuppliersPurchaseOrderList, error) {
+	const q = `SELECT id, created, status, note
+			FROM purchase_order
+			WHERE supplier_id = $1
+			ORDER BY created DESC, id DESC`
+
+	rows, err := s.DB.QueryContext(ctx, q, supplierID)
+	if err != nil {
+		return nil, errors.Wrap(err, "list supplier's purchase orders query error")
+	}
+	defer rows.Close()
+
+	result := lara.SuppliersPurchaseOrderList{Items: []lara.SuppliersPurchaseOrder{}}
+	for rows.Next() {
+		var o lara.SuppliersPurchaseOrder
+		var note sql.NullString
+		if err := rows.Scan(&o.ID, &o.Created, &o.Status, &note); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		o.Note = note.String
+		result.Items = append(result.Items, o)
+	}
+	err = rows.Err()
+
+	return &result, errors.Wrap(err, "rows processing errror")
+}
+
+// SuggestedReorder is implementation of PurchaseOrderService.SuggestedReorder
+// using postgresql database. Amounts not received yet on Ordered and
+// PartiallyReceived orders count as stock. Retired products are skipped.
+func (s *PurchaseOrderService) SuggestedReorder(ctx context.Context) (*lara.SuggestedReorderList, error) {
+	const q = `SELECT
+			  p.id,
+			  p.name,
+			  u.name,
+			  p.min_stock,
+			  COALESCE(st.quantity, 0.0000),
+			  COALESCE(ord.amount, 0.0000),
+			  p.min_stock - COALESCE(st.quantity, 0) - COALESCE(ord.amount, 0)
+			FROM lov_product p
+			  JOIN lov_unit u ON u.id = p.unit_id
+			  LEFT JOIN (SELECT prod_id, SUM(quantity) AS quantity
+			    FROM stock_movement
+			    GROUP BY prod_id) st ON st.prod_id = p.id
+			  LEFT JOIN (SELECT l.prod_id, SUM(l.amount - l.received) AS amount
+			    FROM purchase_order_line l
+			      JOIN purchase_order o ON o.id = l.order_id
+			    WHERE o.status IN ($1, $2)
+			    GROUP BY l.prod_id) ord ON ord.prod_id = p.id
+			WHERE p.min_stock IS NOT NULL
+			  AND (p.valid_to IS NULL OR p.valid_to >= current_date)
+			  AND COALESCE(st.quantity, 0) + COALESCE(ord.amount, 0) < p.min_stock
+			ORDER BY p.name, p.id`
+
+	rows, err := s.DB.QueryContext(ctx, q, lara.Ordered, lara.PartiallyReceived)
+	if err != nil {
+		return nil, errors.Wrap(err, "suggested reorder query error")
+	}
+	defer rows.Close()
+
+	result := lara.SuggestedReorderList{Items: []lara.SuggestedReorder{}}
+	for rows.Next() {
+		var r lara.SuggestedReorder
+		if err := rows.Scan(&r.ProductID,
+			&r.Product,
+			&r.Unit,
+			&r.MinStock,
+			&r.Stock,
+			&r.Ordered,
+			&r.Suggested); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		result.Items = append(result.Items, r)
+	}
+	err = rows.Err()
+
+	return &result, errors.Wrap(err, "rows processing errror")
+}
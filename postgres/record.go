@@ -23,9 +23,11 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/jkusniar/lara"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 )
 
@@ -38,13 +40,53 @@ type recordDTO struct {
 	versionedDTO
 	creatorDTO
 	modifierDTO
-	Date   time.Time
-	Text   sql.NullString
-	Billed bool
-	Total  string
+	PatientID uint64
+	Date      time.Time
+	Text      sql.NullString
+	Billed    bool
+	Total     string
+	clinicalDTO
 }
 
-func (r *recordDTO) toGetRecord(total string, items []lara.GetRecordItem) *lara.GetRecord {
+// clinicalDTO is structured part of record
+type clinicalDTO struct {
+	Subjective  sql.NullString
+	Objective   sql.NullString
+	Assessment  sql.NullString
+	Plan        sql.NullString
+	Weight      sql.NullString
+	Temperature sql.NullString
+	HeartRate   sql.NullInt64
+}
+
+func (c *clinicalDTO) toClinicalData(diagnoses []lara.Diagnosis) lara.ClinicalData {
+	result := lara.ClinicalData{
+		Subjective:   c.Subjective.String,
+		Objective:    c.Objective.String,
+		Assessment:   c.Assessment.String,
+		Plan:         c.Plan.String,
+		Weight:       c.Weight.String,
+		Temperature:  c.Temperature.String,
+		HeartRate:    uint64(c.HeartRate.Int64),
+		DiagnosisIDs: []uint64{},
+	}
+
+	for _, d := range diagnoses {
+		result.DiagnosisIDs = append(result.DiagnosisIDs, d.ID)
+	}
+
+	return result
+}
+
+// recordTotal is sum of record's items
+type recordTotal struct {
+	Gross string
+	Net   string
+	VAT   string
+}
+
+func (r *recordDTO) toGetRecord(total recordTotal, items []lara.GetRecordItem,
+	diagnoses []lara.Diagnosis) *lara.GetRecord {
 	return &lara.GetRecord{
 		Versioned: lara.Versioned{
 			ID:      r.ID,
@@ -54,11 +96,16 @@ func (r *recordDTO) toGetRecord(total string, items []lara.GetRecordItem) *lara.
 			Created:  r.Created,
 			Modifier: r.Modifier.String,
 			Modified: r.Modified.Time},
-		Date:   r.Date,
-		Text:   r.Text.String,
-		Billed: r.Billed,
-		Total:  total,
-		Items:  items,
+		PatientID:    r.PatientID,
+		Date:         r.Date,
+		Text:         r.Text.String,
+		Billed:       r.Billed,
+		Total:        total.Gross,
+		TotalNet:     total.Net,
+		TotalVAT:     total.VAT,
+		Items:        items,
+		ClinicalData: r.toClinicalData(diagnoses),
+		Diagnoses:    diagnoses,
 	}
 }
 
@@ -66,9 +113,17 @@ func (r *recordDTO) toGetRecord(total string, items []lara.GetRecordItem) *lara.
 func (s *RecordService) Get(ctx context.Context, id uint64) (*lara.GetRecord, error) {
 	const q = `SELECT
 			  id,
+			  patient_id,
 			  rec_date,
 			  data,
 			  billed,
+			  subjective,
+			  objective,
+			  assessment,
+			  plan,
+			  weight,
+			  temperature,
+			  heart_rate,
 			  version,
 			  creator,
 			  created,
@@ -79,9 +134,17 @@ func (s *RecordService) Get(ctx context.Context, id uint64) (*lara.GetRecord, er
 	var r recordDTO
 	err := s.DB.QueryRowContext(ctx, q, id).Scan(
 		&r.ID,
+		&r.PatientID,
 		&r.Date,
 		&r.Text,
 		&r.Billed,
+		&r.Subjective,
+		&r.Objective,
+		&r.Assessment,
+		&r.Plan,
+		&r.Weight,
+		&r.Temperature,
+		&r.HeartRate,
 		&r.Version,
 		&r.Creator,
 		&r.Created,
@@ -99,27 +162,67 @@ func (s *RecordService) Get(ctx context.Context, id uint64) (*lara.GetRecord, er
 		return nil, err
 	}
 
-	sum, err := s.sumItemsForRecord(ctx, r.ID)
+	total, err := s.sumItemsForRecord(ctx, r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	diagnoses, err := s.getRecordDiagnoses(ctx, r.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.toGetRecord(sum, items), nil
+	return r.toGetRecord(total, items, diagnoses), nil
+}
+
+func (s *RecordService) getRecordDiagnoses(ctx context.Context, id uint64) ([]lara.Diagnosis, error) {
+	const q = `SELECT d.id,
+			  d.code,
+			  d.name
+			FROM record_diagnosis rd
+			JOIN lov_diagnosis d ON d.id = rd.diagnosis_id
+			WHERE rd.record_id = $1
+			ORDER BY d.code`
+
+	rows, err := s.DB.QueryContext(ctx, q, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "get record's diagnoses query error")
+	}
+	defer rows.Close()
+
+	diagnoses := []lara.Diagnosis{}
+	for rows.Next() {
+		var d lara.Diagnosis
+		if err := rows.Scan(&d.ID, &d.Code, &d.Name); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		diagnoses = append(diagnoses, d)
+	}
+	err = rows.Err()
+
+	return diagnoses, errors.Wrap(err, "rows processing errror")
 }
 
 func (s *RecordService) getRecordItems(ctx context.Context, id uint64) ([]lara.GetRecordItem, error) {
-	const q = `SELECT ri.id,
+	q := `SELECT ri.id,
 			  ri.prod_id,
 			  ri.prod_price,
 			  ri.amount,
 			  ri.item_price,
 			  ri.item_type,
+			  ri.vat_rate,
+			  ` + netSQL("ri.item_price", "ri.vat_rate") + ` as item_net,
+			  ` + vatSQL("ri.item_price", "ri.vat_rate") + ` as item_vat,
 			  p.name as product,
 			  u.name as unit,
-			  p.plu as plu
+			  p.plu as plu,
+			  ri.batch_id,
+			  b.batch_no,
+			  b.expiry
 			FROM record_item ri
 			JOIN lov_product p ON p.id = ri.prod_id
 			JOIN lov_unit u ON u.id = p.unit_id
+			LEFT JOIN stock_batch b ON b.id = ri.batch_id
 			WHERE ri.record_id = $1
 			ORDER BY ri.id`
 
@@ -132,19 +235,30 @@ func (s *RecordService) getRecordItems(ctx context.Context, id uint64) ([]lara.G
 	items := []lara.GetRecordItem{}
 	for rows.Next() {
 		var i lara.GetRecordItem
-		var plu sql.NullString
+		var plu, batch sql.NullString
+		var batchID sql.NullInt64
+		var expiry pq.NullTime
 		if err := rows.Scan(&i.ID,
 			&i.ProductID,
 			&i.ProductPrice,
 			&i.Amount,
 			&i.ItemPrice,
 			&i.ItemType,
+			&i.VATRate,
+			&i.ItemNet,
+			&i.ItemVAT,
 			&i.Product,
 			&i.Unit,
-			&plu); err != nil {
+			&plu,
+			&batchID,
+			&batch,
+			&expiry); err != nil {
 			return nil, errors.Wrap(err, "scan DTO error")
 		}
 		i.PLU = plu.String
+		i.BatchID = uint64(batchID.Int64)
+		i.Batch = batch.String
+		i.Expiry = expiry.Time
 		items = append(items, i)
 	}
 	err = rows.Err()
@@ -152,25 +266,27 @@ func (s *RecordService) getRecordItems(ctx context.Context, id uint64) ([]lara.G
 	return items, errors.Wrap(err, "rows processing errror")
 }
 
-func (s *RecordService) sumItemsForRecord(ctx context.Context, id uint64) (string, error) {
-	const sq = `SELECT SUM(ri.item_price)
+func (s *RecordService) sumItemsForRecord(ctx context.Context, id uint64) (recordTotal, error) {
+	sq := `SELECT SUM(ri.item_price),
+		  SUM(` + netSQL("ri.item_price", "ri.vat_rate") + `),
+		  SUM(` + vatSQL("ri.item_price", "ri.vat_rate") + `)
 		FROM record r INNER JOIN record_item ri ON ri.record_id = r.id
 		WHERE r.id = $1`
 
-	var sum sql.NullString
-	err := s.DB.QueryRowContext(ctx, sq, id).Scan(&sum)
+	var gross, net, vat sql.NullString
+	err := s.DB.QueryRowContext(ctx, sq, id).Scan(&gross, &net, &vat)
 	switch {
 	case err == sql.ErrNoRows:
-		return "", notFoundByIDError(id)
+		return recordTotal{}, notFoundByIDError(id)
 	case err != nil:
-		return "", errors.Wrap(err, "get record items sum failed")
+		return recordTotal{}, errors.Wrap(err, "get record items sum failed")
 	}
 
-	if !sum.Valid {
-		return "0.00", nil
+	if !gross.Valid {
+		return recordTotal{"0.00", "0.00", "0.00"}, nil
 	}
 
-	return sum.String, nil
+	return recordTotal{gross.String, net.String, vat.String}, nil
 }
 
 // Create is implementation of RecordService.Create using postgresql database.
@@ -193,8 +309,13 @@ func createRecordTx(ctx context.Context, tx *sql.Tx, r *lara.CreateRecord) (uint
 		return 0, err
 	}
 
-	const insertRecord = `INSERT INTO record (patient_id, rec_date, data, billed, creator, created)
-					VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	if err := validateClinicalData(&r.ClinicalData); err != nil {
+		return 0, err
+	}
+
+	const insertRecord = `INSERT INTO record (patient_id, rec_date, data, billed, subjective, objective,
+					  assessment, plan, weight, temperature, heart_rate, creator, created)
+					VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id`
 
 	var recID uint64
 
@@ -208,36 +329,106 @@ func createRecordTx(ctx context.Context, tx *sql.Tx, r *lara.CreateRecord) (uint
 		now(),
 		toNullString(r.Text),
 		r.Billed,
+		toNullString(r.Subjective),
+		toNullString(r.Objective),
+		toNullString(r.Assessment),
+		toNullString(r.Plan),
+		toNullString(r.Weight),
+		toNullString(r.Temperature),
+		toNullFK(r.HeartRate),
 		toNullString(u.Login),
 		now()).Scan(&recID)
 	if err != nil {
 		return 0, errors.Wrap(err, "create record failed")
 	}
 
+	if err = createRecordDiagnoses(ctx, tx, recID, r.DiagnosisIDs); err != nil {
+		return 0, err
+	}
+
 	err = createRecordItems(ctx, tx, recID, r.Items)
 
 	return recID, err
 }
 
+func createRecordDiagnoses(ctx context.Context, tx *sql.Tx, recID uint64, ids []uint64) error {
+	const insert = `INSERT INTO record_diagnosis (record_id, diagnosis_id)
+					SELECT $1, id FROM lov_diagnosis WHERE id = $2`
+
+	seen := map[uint64]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		res, err := tx.ExecContext(ctx, insert, recID, id)
+		if err != nil {
+			return errors.Wrap(err, "insert record diagnosis failed")
+		}
+
+		count, err := res.RowsAffected()
+		if err != nil {
+			return errors.Wrap(err, "insert record diagnosis can't check inserted rows")
+		}
+		if count != 1 {
+			return lara.NewCodedError(400,
+				errors.Errorf("diagnosis %d not found", id))
+		}
+	}
+
+	return nil
+}
+
 func createRecordItems(ctx context.Context, tx *sql.Tx, recID uint64, items []lara.RecordItem) error {
-	const insert = `INSERT INTO record_item (record_id, prod_id, amount, item_price, prod_price, item_type)
-					VALUES ($1, $2, $3, $4, $5, $6)`
+	// Missing price and VAT rate are taken from product's price valid at
+	// record's date and copied to item, so later changes don't affect
+	// existing records. Material items are dispensed from stock.
+	insert := `INSERT INTO record_item (record_id, prod_id, amount, item_price, prod_price, item_type,
+					vat_rate, batch_id)
+					SELECT r.id, p.id, $3::numeric,
+					  COALESCE($4::numeric, round(COALESCE($5::numeric, pp.price, p.price) * $3::numeric, 2)),
+					  COALESCE($5::numeric, pp.price, p.price),
+					  $6::integer,
+					  COALESCE($7::numeric, pp.vat_rate, p.vat_rate),
+					  $8::integer
+					FROM record r
+					  JOIN lov_product p ON p.id = $2
+					  LEFT JOIN product_price pp ON pp.prod_id = p.id AND ` + priceValidAt("pp", "r.rec_date::date") + `
+					WHERE r.id = $1`
+
+	for n, i := range items {
+		if i.BatchID != 0 {
+			if err := checkDispensedBatch(ctx, tx, recID, n, &i); err != nil {
+				return err
+			}
+		}
 
-	for _, i := range items {
-		_, err := tx.ExecContext(ctx, insert,
+		res, err := tx.ExecContext(ctx, insert,
 			recID,
 			toNullFK(i.ProductID),
 			toNullString(i.Amount),
 			toNullString(i.ItemPrice),
 			toNullString(i.ProductPrice),
 			i.ItemType,
+			toNullString(i.VATRate),
+			toNullFK(i.BatchID),
 		)
 		if err != nil {
 			return errors.Wrap(err, "insert record item failed")
 		}
+
+		count, err := res.RowsAffected()
+		if err != nil {
+			return errors.Wrap(err, "insert record item can't check inserted rows")
+		}
+		if count != 1 {
+			return lara.NewCodedError(400,
+				errors.Errorf("product %d on item %d not found", i.ProductID, n))
+		}
 	}
 
-	return nil
+	return dispenseRecordItems(ctx, tx, recID)
 }
 
 // Update is implementation of RecordService.Update using postgresql database.
@@ -246,18 +437,31 @@ func (s *RecordService) Update(ctx context.Context, id uint64, r *lara.UpdateRec
 		return err
 	}
 
+	if err := validateClinicalData(&r.ClinicalData); err != nil {
+		return err
+	}
+
 	err := execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
-		const lock = `SELECT id FROM record WHERE id = $1 FOR UPDATE`
+		const lock = `SELECT id, invoice_id FROM record WHERE id = $1 FOR UPDATE`
 		const update = `UPDATE record
-				SET data   = $1,
-				  modifier = $2,
-				  modified = $3,
-				  version  = version + 1
-				WHERE id = $4 AND version = $5`
+				SET data      = $1,
+				  subjective  = $2,
+				  objective   = $3,
+				  assessment  = $4,
+				  plan        = $5,
+				  weight      = $6,
+				  temperature = $7,
+				  heart_rate  = $8,
+				  modifier    = $9,
+				  modified    = $10,
+				  version     = version + 1
+				WHERE id = $11 AND version = $12`
 		const del = `DELETE FROM record_item WHERE record_id = $1`
+		const delDiagnoses = `DELETE FROM record_diagnosis WHERE record_id = $1`
 
 		var rid uint64
-		err := tx.QueryRowContext(ctx, lock, id).Scan(&rid)
+		var invoice sql.NullString
+		err := tx.QueryRowContext(ctx, lock, id).Scan(&rid, &invoice)
 		switch err {
 		case nil: // continue
 		case sql.ErrNoRows:
@@ -266,6 +470,12 @@ func (s *RecordService) Update(ctx context.Context, id uint64, r *lara.UpdateRec
 			return errors.Wrap(err, "error selecting record by id")
 		}
 
+		// invoice's lines are copied from record, invoiced record can't change
+		if invoice.Valid {
+			return lara.NewCodedError(409,
+				errors.Errorf("record %d is invoiced by %s", id, invoice.String))
+		}
+
 		u, ok := lara.UserFromContext(ctx)
 		if !ok {
 			return errors.New("no user in context")
@@ -273,6 +483,13 @@ func (s *RecordService) Update(ctx context.Context, id uint64, r *lara.UpdateRec
 
 		res, err := tx.ExecContext(ctx, update,
 			toNullString(r.Text),
+			toNullString(r.Subjective),
+			toNullString(r.Objective),
+			toNullString(r.Assessment),
+			toNullString(r.Plan),
+			toNullString(r.Weight),
+			toNullString(r.Temperature),
+			toNullFK(r.HeartRate),
 			toNullString(u.Login),
 			now(),
 			rid,
@@ -290,7 +507,15 @@ func (s *RecordService) Update(ctx context.Context, id uint64, r *lara.UpdateRec
 			return versionMismatchError(id)
 		}
 
-		// del & create items
+		// del & create diagnoses and items
+		if _, err := tx.ExecContext(ctx, delDiagnoses, rid); err != nil {
+			return errors.Wrap(err, "delete record diagnoses failed")
+		}
+
+		if err := createRecordDiagnoses(ctx, tx, rid, r.DiagnosisIDs); err != nil {
+			return err
+		}
+
 		if _, err := tx.ExecContext(ctx, del, rid); err != nil {
 			return err
 		}
@@ -306,15 +531,208 @@ func validateRecordItems(items []lara.RecordItem) error {
 		if itm.ProductID == 0 {
 			return requiredFieldError(fmt.Sprintf("productId on item %d", i))
 		}
-		if len(itm.ProductPrice) == 0 {
-			return requiredFieldError(fmt.Sprintf("productPrice on item %d", i))
-		}
 		if len(itm.Amount) == 0 {
 			return requiredFieldError(fmt.Sprintf("amount on item %d", i))
 		}
-		if len(itm.ItemPrice) == 0 {
-			return requiredFieldError(fmt.Sprintf("itemPrice on item %d", i))
+		// material amounts are dispensed from stock, both are numeric(10,4)
+		if _, ok := parseNumeric(itm.Amount, 10, 4); !ok {
+			return lara.NewCodedError(400,
+				errors.Errorf("amount '%s' on item %d is not valid", itm.Amount, i))
 		}
+		if err := validateVATRate(itm.VATRate, fmt.Sprintf("vatRate on item %d", i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateMeasurement checks optional positive value of numeric(precision,
+// scale) column lower than max
+func validateMeasurement(value, field string, max float64, precision, scale int) error {
+	if value == "" {
+		return nil
+	}
+
+	if v, ok := parseNumeric(value, precision, scale); !ok || v <= 0 || v >= max {
+		return lara.NewCodedError(400,
+			errors.Errorf("%s '%s' is not valid, expected number in range (0, %g)",
+				field, value, max))
+	}
+
+	return nil
+}
+
+func validateClinicalData(c *lara.ClinicalData) error {
+	if err := validateMeasurement(c.Weight, "weight", 10000, 7, 3); err != nil {
+		return err
+	}
+	if err := validateMeasurement(c.Temperature, "temperature", 100, 4, 1); err != nil {
+		return err
+	}
+	if c.HeartRate > 1000 {
+		return lara.NewCodedError(400,
+			errors.Errorf("heartRate %d is not valid", c.HeartRate))
 	}
 	return nil
 }
+
+// ListPatientDiagnoses is implementation of RecordService.ListPatientDiagnoses
+// using postgresql database.
+func (s *RecordService) ListPatientDiagnoses(ctx context.Context, patientID uint64) (*lara.PatientDiagnosisList, error) {
+	const q = `SELECT d.id,
+			  d.code,
+			  d.name,
+			  r.id,
+			  r.rec_date
+			FROM record r
+			JOIN record_diagnosis rd ON rd.record_id = r.id
+			JOIN lov_diagnosis d ON d.id = rd.diagnosis_id
+			WHERE r.patient_id = $1
+			ORDER BY r.rec_date DESC, r.id DESC, d.code`
+
+	rows, err := s.DB.QueryContext(ctx, q, patientID)
+	if err != nil {
+		return nil, errors.Wrap(err, "list patient's diagnoses query error")
+	}
+	defer rows.Close()
+
+	result := lara.PatientDiagnosisList{Items: []lara.PatientDiagnosis{}}
+	for rows.Next() {
+		var d lara.PatientDiagnosis
+		if err := rows.Scan(&d.ID,
+			&d.Code,
+			&d.Name,
+			&d.RecordID,
+			&d.Date); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		result.Items = append(result.Items, d)
+	}
+	err = rows.Err()
+
+	return &result, errors.Wrap(err, "rows processing errror")
+}
+
+// diagnosedSorts are ListDiagnosedPatients' sort keys
+var diagnosedSorts = map[string][]string{
+	"date":    {"r.rec_date", "r.id"},
+	"patient": {"p.name", "p.id", "r.rec_date"},
+}
+
+// ListDiagnosedPatients is implementation of
+// RecordService.ListDiagnosedPatients using postgresql database.
+func (s *RecordService) ListDiagnosedPatients(ctx context.Context, diagnosisID uint64,
+	p lara.Page) (*lara.DiagnosedPatientList, error) {
+	page, err := pageSQL(p, diagnosedSorts, "-date")
+	if err != nil {
+		return nil, err
+	}
+
+	const cq = `SELECT count(*) FROM record_diagnosis WHERE diagnosis_id = $1`
+	dq := `SELECT p.id,
+			  p.name,
+			  o.id,
+			  o.first_name,
+			  o.last_name,
+			  t.name,
+			  r.id,
+			  r.rec_date
+			FROM record_diagnosis rd
+			JOIN record r ON r.id = rd.record_id
+			JOIN patient p ON p.id = r.patient_id
+			JOIN owner o ON o.id = p.owner_id
+			LEFT JOIN lov_title t ON t.id = o.title_id
+			WHERE rd.diagnosis_id = $1
+			` + page
+
+	result := lara.DiagnosedPatientList{Items: []lara.DiagnosedPatient{}}
+	if err := s.DB.QueryRowContext(ctx, cq, diagnosisID).Scan(&result.Total); err != nil {
+		return nil, errors.Wrap(err, "diagnosed patients count error")
+	}
+
+	rows, err := s.DB.QueryContext(ctx, dq, diagnosisID)
+	if err != nil {
+		return nil, errors.Wrap(err, "diagnosed patients query error")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d lara.DiagnosedPatient
+		var o OwnerNameDTO
+		if err := rows.Scan(&d.PatientID,
+			&d.Patient,
+			&d.OwnerID,
+			&o.FirstName,
+			&o.LastName,
+			&o.Title,
+			&d.RecordID,
+			&d.Date); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		d.Owner = o.String()
+		result.Items = append(result.Items, d)
+	}
+	err = rows.Err()
+
+	return &result, errors.Wrap(err, "rows processing errror")
+}
+
+// GetPatientVitals is implementation of RecordService.GetPatientVitals using
+// postgresql database.
+func (s *RecordService) GetPatientVitals(ctx context.Context, patientID uint64) (*lara.PatientVitals, error) {
+	const q = `SELECT id,
+			  rec_date,
+			  weight,
+			  temperature,
+			  heart_rate
+			FROM record
+			WHERE patient_id = $1
+			  AND (weight IS NOT NULL OR temperature IS NOT NULL OR heart_rate IS NOT NULL)
+			ORDER BY rec_date, id`
+
+	var pid uint64
+	err := s.DB.QueryRowContext(ctx, `SELECT id FROM patient WHERE id = $1`, patientID).Scan(&pid)
+	switch err {
+	case nil: // continue
+	case sql.ErrNoRows:
+		return nil, notFoundByIDError(patientID)
+	default:
+		return nil, errors.Wrap(err, "error selecting patient by id")
+	}
+
+	rows, err := s.DB.QueryContext(ctx, q, patientID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get patient's vitals query error")
+	}
+	defer rows.Close()
+
+	result := lara.PatientVitals{
+		Weight:      lara.VitalsSeries{Unit: "kg", Points: []lara.VitalsPoint{}},
+		Temperature: lara.VitalsSeries{Unit: "°C", Points: []lara.VitalsPoint{}},
+		HeartRate:   lara.VitalsSeries{Unit: "bpm", Points: []lara.VitalsPoint{}},
+	}
+	for rows.Next() {
+		var id uint64
+		var date time.Time
+		var c clinicalDTO
+		if err := rows.Scan(&id, &date, &c.Weight, &c.Temperature, &c.HeartRate); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+
+		if c.Weight.Valid {
+			result.Weight.Points = append(result.Weight.Points,
+				lara.VitalsPoint{RecordID: id, Date: date, Value: c.Weight.String})
+		}
+		if c.Temperature.Valid {
+			result.Temperature.Points = append(result.Temperature.Points,
+				lara.VitalsPoint{RecordID: id, Date: date, Value: c.Temperature.String})
+		}
+		if c.HeartRate.Valid {
+			result.HeartRate.Points = append(result.HeartRate.Points,
+				lara.VitalsPoint{RecordID: id, Date: date, Value: strconv.FormatInt(c.HeartRate.Int64, 10)})
+		}
+	}
+	err = rows.Err()
+
+	return &result, errors.Wrap(err, "rows processing errror")
+}
@@ -48,7 +48,8 @@ type ReportService struct {
 //
 func (s *ReportService) GetIncomeStatistics(ctx context.Context,
 	r *lara.ReportRequest) (*lara.IncomeStatistics, error) {
-	resp := lara.IncomeStatistics{Income: "0.00", IncomeBilled: "0.00", IncomeNotBilled: "0.00"}
+	resp := lara.IncomeStatistics{Income: "0.00", IncomeBilled: "0.00", IncomeNotBilled: "0.00",
+		VAT: []lara.VATBreakdown{}}
 
 	from := r.ValidFrom.In(s.Loc)
 	to := r.ValidTo.In(s.Loc)
@@ -85,6 +86,22 @@ func (s *ReportService) GetIncomeStatistics(ctx context.Context,
 		return nil, err
 	}
 
+	var err error
+	resp.VAT, err = getVATBreakdown(ctx, s.DB,
+		`SELECT ri.vat_rate,
+			  SUM(`+netSQL("ri.item_price", "ri.vat_rate")+`),
+			  SUM(`+vatSQL("ri.item_price", "ri.vat_rate")+`),
+			  SUM(ri.item_price)
+			FROM record_item ri
+			INNER JOIN record r ON r.id = ri.record_id
+			WHERE r.rec_date >= $1 AND r.rec_date <= $2
+			GROUP BY ri.vat_rate
+			ORDER BY ri.vat_rate`,
+		from, to)
+	if err != nil {
+		return nil, err
+	}
+
 	return &resp, nil
 }
 
@@ -100,3 +117,202 @@ func (s *ReportService) scanIncome(ctx context.Context, income *string, query st
 
 	return nil
 }
+
+// GetControlledDrugRegister lists movements of controlled products in period
+// with stock balance after each movement. Dispense movements are linked with
+// record's patient and prescription of the drug. Records of period whose
+// Material items of controlled product don't sum to prescribed amount are
+// reported as discrepancies.
+func (s *ReportService) GetControlledDrugRegister(ctx context.Context,
+	r *lara.ReportRequest) (*lara.ControlledDrugReport, error) {
+	from := r.ValidFrom.In(s.Loc)
+	to := r.ValidTo.In(s.Loc)
+
+	products, err := s.controlledDrugs(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.controlledDrugEntries(ctx, products, from, to); err != nil {
+		return nil, err
+	}
+
+	discrepancies, err := s.controlledDrugDiscrepancies(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lara.ControlledDrugReport{Products: products, Discrepancies: discrepancies}, nil
+}
+
+func (s *ReportService) controlledDrugs(ctx context.Context, from, to time.Time) ([]lara.ControlledDrugRegister, error) {
+	const q = `SELECT p.id,
+			  p.name,
+			  u.name,
+			  COALESCE(SUM(CASE WHEN m.mov_date < $1 THEN m.quantity END), 0.0000),
+			  COALESCE(SUM(CASE WHEN m.mov_date <= $2 THEN m.quantity END), 0.0000)
+			FROM lov_product p
+			  JOIN lov_unit u ON u.id = p.unit_id
+			  LEFT JOIN stock_movement m ON m.prod_id = p.id
+			WHERE p.controlled
+			GROUP BY p.id, u.name
+			ORDER BY p.name, p.id`
+
+	rows, err := s.DB.QueryContext(ctx, q, from, to)
+	if err != nil {
+		return nil, errors.Wrap(err, "controlled drugs query error")
+	}
+	defer rows.Close()
+
+	products := []lara.ControlledDrugRegister{}
+	for rows.Next() {
+		p := lara.ControlledDrugRegister{Entries: []lara.ControlledDrugEntry{}}
+		if err := rows.Scan(&p.ProductID, &p.Product, &p.Unit, &p.Opening, &p.Closing); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		products = append(products, p)
+	}
+	err = rows.Err()
+
+	return products, errors.Wrap(err, "rows processing errror")
+}
+
+// controlledDrugEntries appends movements dated from - to to products' registers
+func (s *ReportService) controlledDrugEntries(ctx context.Context, products []lara.ControlledDrugRegister,
+	from, to time.Time) error {
+	const q = `SELECT m.id,
+			  m.prod_id,
+			  m.mov_date,
+			  m.mov_type,
+			  m.quantity,
+			  m.balance,
+			  b.batch_no,
+			  m.record_id,
+			  pa.id,
+			  pa.name,
+			  pr.id,
+			  m.note,
+			  m.creator
+			FROM (
+			  SELECT sm.*, SUM(sm.quantity) OVER (PARTITION BY sm.prod_id ORDER BY sm.mov_date, sm.id) AS balance
+			  FROM stock_movement sm
+			    JOIN lov_product p ON p.id = sm.prod_id
+			  WHERE p.controlled AND sm.mov_date <= $2
+			) m
+			  LEFT JOIN stock_batch b ON b.id = m.batch_id
+			  LEFT JOIN record r ON r.id = m.record_id
+			  LEFT JOIN patient pa ON pa.id = r.patient_id
+			  LEFT JOIN LATERAL (
+			    SELECT p.id FROM prescription p
+			      JOIN prescription_item i ON i.prescription_id = p.id
+			    WHERE p.record_id = m.record_id AND i.prod_id = m.prod_id
+			    ORDER BY p.id
+			    LIMIT 1
+			  ) pr ON true
+			WHERE m.mov_date >= $1
+			ORDER BY m.prod_id, m.mov_date, m.id`
+
+	index := make(map[uint64]int, len(products))
+	for i, p := range products {
+		index[p.ProductID] = i
+	}
+
+	rows, err := s.DB.QueryContext(ctx, q, from, to)
+	if err != nil {
+		return errors.Wrap(err, "controlled drug entries query error")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e lara.ControlledDrugEntry
+		var productID uint64
+		var batch, patient, note sql.NullString
+		var recordID, patientID, prescriptionID sql.NullInt64
+		if err := rows.Scan(&e.MovementID,
+			&productID,
+			&e.Date,
+			&e.Type,
+			&e.Amount,
+			&e.Balance,
+			&batch,
+			&recordID,
+			&patientID,
+			&patient,
+			&prescriptionID,
+			&note,
+			&e.Creator); err != nil {
+			return errors.Wrap(err, "scan DTO error")
+		}
+		e.Batch = batch.String
+		e.RecordID = uint64(recordID.Int64)
+		e.PatientID = uint64(patientID.Int64)
+		e.Patient = patient.String
+		e.PrescriptionID = uint64(prescriptionID.Int64)
+		e.Note = note.String
+
+		if i, ok := index[productID]; ok {
+			products[i].Entries = append(products[i].Entries, e)
+		}
+	}
+	err = rows.Err()
+
+	return errors.Wrap(err, "rows processing errror")
+}
+
+func (s *ReportService) controlledDrugDiscrepancies(ctx context.Context,
+	from, to time.Time) ([]lara.ControlledDrugDiscrepancy, error) {
+	const q = `SELECT r.id,
+			  r.rec_date,
+			  pa.id,
+			  pa.name,
+			  p.id,
+			  p.name,
+			  u.name,
+			  COALESCE(d.amount, 0.0000),
+			  COALESCE(s.amount, 0.0000)
+			FROM (
+			  SELECT record_id, prod_id, SUM(amount) AS amount
+			  FROM record_item
+			  WHERE item_type = $3
+			  GROUP BY record_id, prod_id
+			) d
+			  FULL JOIN (
+			    SELECT pr.record_id, i.prod_id, SUM(i.amount) AS amount
+			    FROM prescription pr
+			      JOIN prescription_item i ON i.prescription_id = pr.id
+			    GROUP BY pr.record_id, i.prod_id
+			  ) s ON s.record_id = d.record_id AND s.prod_id = d.prod_id
+			  JOIN record r ON r.id = COALESCE(d.record_id, s.record_id)
+			  JOIN patient pa ON pa.id = r.patient_id
+			  JOIN lov_product p ON p.id = COALESCE(d.prod_id, s.prod_id)
+			  JOIN lov_unit u ON u.id = p.unit_id
+			WHERE p.controlled AND r.rec_date >= $1 AND r.rec_date <= $2
+			  AND COALESCE(d.amount, 0) <> COALESCE(s.amount, 0)
+			ORDER BY r.rec_date, r.id, p.name`
+
+	rows, err := s.DB.QueryContext(ctx, q, from, to, lara.Material)
+	if err != nil {
+		return nil, errors.Wrap(err, "controlled drug discrepancies query error")
+	}
+	defer rows.Close()
+
+	discrepancies := []lara.ControlledDrugDiscrepancy{}
+	for rows.Next() {
+		var d lara.ControlledDrugDiscrepancy
+		if err := rows.Scan(&d.RecordID,
+			&d.Date,
+			&d.PatientID,
+			&d.Patient,
+			&d.ProductID,
+			&d.Product,
+			&d.Unit,
+			&d.Dispensed,
+			&d.Prescribed); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		discrepancies = append(discrepancies, d)
+	}
+	err = rows.Err()
+
+	return discrepancies, errors.Wrap(err, "rows processing errror")
+}
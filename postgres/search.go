@@ -22,6 +22,8 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"strings"
+	"unicode"
 
 	"github.com/jkusniar/lara"
 	"github.com/pkg/errors"
@@ -36,40 +38,171 @@ type searchDTO struct {
 	ID uint64
 	OwnerNameDTO
 	OwnerAddressDTO
+	Field     lara.SearchField
+	Match     string
+	PatientID sql.NullInt64
+	Patient   sql.NullString
 }
 
 func (s *searchDTO) toRecord() *lara.SearchRecord {
-	return &lara.SearchRecord{ID: s.ID, Name: s.OwnerNameDTO.String(), Address: s.OwnerAddressDTO.String()}
+	return &lara.SearchRecord{
+		ID:        s.ID,
+		Name:      s.OwnerNameDTO.String(),
+		Address:   s.OwnerAddressDTO.String(),
+		Field:     s.Field,
+		Match:     s.Match,
+		PatientID: uint64(s.PatientID.Int64),
+		Patient:   s.Patient.String,
+	}
+}
+
+// compactSQL returns SQL expression of unaccented lowercase expr without
+// non-alphanumeric characters. Phone numbers, emails, ICs and tags are
+// matched in compact form, so "0905 123 456" is found by "0905123".
+func compactSQL(expr string) string {
+	return `regexp_replace(lower(f_unaccent(COALESCE(` + expr + `, ''))), '[^0-9a-z]', '', 'g')`
+}
+
+// Full-text vectors of searched fields. Every vector has GIN expression index,
+// changed expression must be changed in DB index as well.
+var (
+	ownerNameVector  = `to_tsvector('simple', f_unaccent(COALESCE(o.first_name, '') || ' ' || o.last_name))`
+	ownerPhoneVector = `to_tsvector('simple', ` + compactSQL("o.phone_1") + ` || ' ' || ` + compactSQL("o.phone_2") + `)`
+	ownerEmailVector = `to_tsvector('simple', ` + compactSQL("o.email") + `)`
+	ownerICVector    = `to_tsvector('simple', ` + compactSQL("o.ic") + `)`
+	patientVector    = `to_tsvector('simple', f_unaccent(p.name))`
+	tagVector        = `to_tsvector('simple', ` + compactSQL("t.value") + `)`
+)
+
+// ownerLastNameInfix is expression of owner's last name matched by LIKE
+// pattern anywhere in the name. It has trigram GIN expression index.
+const ownerLastNameInfix = `lower(f_unaccent(o.last_name))`
+
+// searchHits selects all matching fields. $1 is query matching words, $2
+// is query matching compact fields, $3 is LIKE pattern matching part of
+// owner's last name. Last names matched inside a word rank below all
+// full-text matches.
+var searchHits = `WITH q AS (
+			  SELECT to_tsquery('simple', f_unaccent($1)) AS words,
+			    to_tsquery('simple', f_unaccent($2)) AS compact
+			), hits AS (
+			  SELECT o.id AS owner_id, NULL::integer AS patient_id, 'name' AS field, 1 AS priority,
+			    concat_ws(' ', o.first_name, o.last_name) AS match,
+			    ts_rank(` + ownerNameVector + `, q.words) AS rank
+			  FROM owner o, q WHERE ` + ownerNameVector + ` @@ q.words
+			  UNION ALL
+			  SELECT o.id, NULL, 'name', 1, concat_ws(' ', o.first_name, o.last_name), 0::real
+			  FROM owner o WHERE ` + ownerLastNameInfix + ` LIKE f_unaccent($3)
+			  UNION ALL
+			  SELECT p.owner_id, p.id, 'patient', 2, p.name, ts_rank(` + patientVector + `, q.words)
+			  FROM patient p, q WHERE ` + patientVector + ` @@ q.words
+			  UNION ALL
+			  SELECT p.owner_id, p.id, 'tag', 3, t.value, ts_rank(` + tagVector + `, q.compact)
+			  FROM tag t JOIN patient p ON p.id = t.patient_id, q WHERE ` + tagVector + ` @@ q.compact
+			  UNION ALL
+			  SELECT o.id, NULL, 'phone', 4, concat_ws(', ', o.phone_1, o.phone_2),
+			    ts_rank(` + ownerPhoneVector + `, q.compact)
+			  FROM owner o, q WHERE ` + ownerPhoneVector + ` @@ q.compact
+			  UNION ALL
+			  SELECT o.id, NULL, 'email', 5, o.email, ts_rank(` + ownerEmailVector + `, q.compact)
+			  FROM owner o, q WHERE ` + ownerEmailVector + ` @@ q.compact
+			  UNION ALL
+			  SELECT o.id, NULL, 'IC', 6, o.ic, ts_rank(` + ownerICVector + `, q.compact)
+			  FROM owner o, q WHERE ` + ownerICVector + ` @@ q.compact
+			)
+			`
+
+// searchSorts are SearchRequest's sort keys. Rank is negated, so ascending
+// relevance lists best match first.
+var searchSorts = map[string][]string{
+	"relevance": {"-b.rank", "o.last_name", "o.id"},
+	"name":      {"o.last_name", "o.first_name", "o.id"},
+}
+
+// toWordsQuery returns tsquery text matching all words of q as prefixes
+func toWordsQuery(q string) string {
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i := range words {
+		words[i] += ":*"
+	}
+	return strings.Join(words, " & ")
 }
 
-// Search performs DB search of "q" string in owner/pet records
-// TODO make search limit parametric
-func (s *SearchService) Search(ctx context.Context, q string) (*lara.SearchResult, error) {
+// toCompactQuery returns tsquery text matching q's letters and digits as
+// prefix of compact field
+func toCompactQuery(q string) string {
+	compact := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, strings.ToLower(q))
+	if len(compact) == 0 {
+		return ""
+	}
+	return compact + ":*"
+}
+
+// toInfixPattern returns LIKE pattern matching lowercase q anywhere in text
+func toInfixPattern(q string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + r.Replace(strings.ToLower(strings.TrimSpace(q))) + "%"
+}
+
+// Search is implementation of SearchService.Search using postgresql full-text
+// search. Owner's last name is also matched anywhere inside, e.g. "nly" finds
+// "Only". Owner is returned once, ranked by its best matching field. Reported
+// field is the most significant one matched (name before patient, tag,
+// phone, email and IC).
+func (s *SearchService) Search(ctx context.Context, req *lara.SearchRequest) (*lara.SearchResult, error) {
+	page, err := pageSQL(req.Page, searchSorts, "relevance")
+	if err != nil {
+		return nil, err
+	}
+
 	r := lara.SearchResult{Total: 0, Records: []lara.SearchRecord{}}
 
-	const cq = `SELECT count(*) FROM owner WHERE last_name ILIKE $1`
-	const dq = `SELECT
+	words := toWordsQuery(req.Query)
+	if len(words) == 0 {
+		return &r, nil
+	}
+	compact := toCompactQuery(req.Query)
+	infix := toInfixPattern(req.Query)
+
+	cq := searchHits + `SELECT count(DISTINCT owner_id) FROM hits`
+	dq := searchHits + `, best AS (
+			  SELECT DISTINCT ON (owner_id) owner_id, patient_id, field, match,
+			    max(rank) OVER (PARTITION BY owner_id) AS rank
+			  FROM hits
+			  ORDER BY owner_id, priority, rank DESC
+			)
+			SELECT
 			  o.id,
 			  o.first_name,
 			  o.last_name,
 			  t.name   AS title,
 			  c.city   AS city,
 			  s.street AS street,
-			  o.house_no
-			FROM owner o
+			  o.house_no,
+			  b.field,
+			  b.match,
+			  b.patient_id,
+			  p.name
+			FROM best b
+			  JOIN owner o ON o.id = b.owner_id
+			  LEFT JOIN patient p ON p.id = b.patient_id
 			  LEFT JOIN lov_title t ON t.id = o.title_id
 			  LEFT JOIN lov_city c ON c.id = o.city_id
 			  LEFT JOIN lov_street s ON s.id = o.street_id
-			WHERE o.last_name
-			      ILIKE $1
-			ORDER BY o.last_name
-			LIMIT $2`
+			` + page
 
-	if err := s.DB.QueryRowContext(ctx, cq, "%"+q+"%").Scan(&r.Total); err != nil {
+	if err := s.DB.QueryRowContext(ctx, cq, words, compact, infix).Scan(&r.Total); err != nil {
 		return nil, errors.Wrap(err, "search count error")
 	}
 
-	rows, err := s.DB.QueryContext(ctx, dq, "%"+q+"%", 30)
+	rows, err := s.DB.QueryContext(ctx, dq, words, compact, infix)
 	if err != nil {
 		return nil, errors.Wrap(err, "search query error")
 	}
@@ -83,7 +216,11 @@ func (s *SearchService) Search(ctx context.Context, q string) (*lara.SearchResul
 			&dto.Title,
 			&dto.City,
 			&dto.Street,
-			&dto.HouseNo); err != nil {
+			&dto.HouseNo,
+			&dto.Field,
+			&dto.Match,
+			&dto.PatientID,
+			&dto.Patient); err != nil {
 			return nil, errors.Wrap(err, "scan DTO error")
 		}
 		r.Records = append(r.Records, *dto.toRecord())
@@ -29,7 +29,8 @@ import (
 )
 
 // SimpleLovService is implementation of lara.TitleService, lara.UnitService, lara.GenderService,
-// lara.SpeciesService, lara.BreedService using postgresql database.
+// lara.SpeciesService, lara.BreedService, lara.DiagnosisService, lara.PhraseService using postgresql
+// database.
 type SimpleLovService struct {
 	DB *sql.DB
 }
@@ -45,6 +46,7 @@ const (
 	gender
 	species
 	breed
+	diagnosis
 )
 
 type lovTable struct {
@@ -58,6 +60,8 @@ var lovTableNames = map[listOfValuesType]lovTable{
 	gender:  {Name: "lov_gender"},
 	species: {Name: "lov_species"},
 	breed:   {Name: "lov_breed", GetAllQuery: `SELECT id, name FROM lov_breed WHERE lov_species_id = $1`},
+	diagnosis: {Name: "lov_diagnosis",
+		GetAllQuery: `SELECT id, code || ' ' || name FROM lov_diagnosis ORDER BY code`},
 }
 
 func lovGetAll(ctx context.Context, db *sql.DB, lov listOfValuesType, params ...interface{}) (*lara.LOVItemList, error) {
@@ -109,3 +113,8 @@ func (s *SimpleLovService) GetAllGenders(ctx context.Context) (*lara.LOVItemList
 func (s *SimpleLovService) GetAllBreedsBySpecies(ctx context.Context, speciesID uint64) (*lara.LOVItemList, error) {
 	return lovGetAll(ctx, s.DB, breed, speciesID)
 }
+
+// GetAllDiagnoses is implementation of DiagnosisService.GetAllDiagnoses using postgresql database.
+func (s *SimpleLovService) GetAllDiagnoses(ctx context.Context) (*lara.LOVItemList, error) {
+	return lovGetAll(ctx, s.DB, diagnosis)
+}
@@ -0,0 +1,505 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jkusniar/lara"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// StockService is lara.StockService implementation backed by postgresql
+type StockService struct {
+	DB  *sql.DB
+	Loc *time.Location
+}
+
+type stockMovementDTO struct {
+	ID        uint64
+	ProductID uint64
+	Type      lara.StockMovementType
+	Quantity  string
+	Date      time.Time
+	Note      sql.NullString
+	Product   string
+	Unit      string
+	RecordID  sql.NullInt64
+	BatchID   sql.NullInt64
+	Batch     sql.NullString
+	Expiry    pq.NullTime
+	creatorDTO
+}
+
+func (m *stockMovementDTO) toGetStockMovement() *lara.GetStockMovement {
+	return &lara.GetStockMovement{
+		ID: m.ID,
+		StockMovement: lara.StockMovement{
+			ProductID: m.ProductID,
+			Type:      m.Type,
+			Amount:    m.Quantity,
+			Date:      m.Date,
+			Note:      m.Note.String,
+			BatchID:   uint64(m.BatchID.Int64)},
+		Product:  m.Product,
+		Unit:     m.Unit,
+		RecordID: uint64(m.RecordID.Int64),
+		Batch:    m.Batch.String,
+		Expiry:   m.Expiry.Time,
+		Creator:  m.Creator,
+		Created:  m.Created,
+	}
+}
+
+const stockMovementQuery = `SELECT
+			  m.id,
+			  m.prod_id,
+			  m.mov_type,
+			  m.quantity,
+			  m.mov_date,
+			  m.note,
+			  p.name,
+			  u.name,
+			  m.record_id,
+			  m.batch_id,
+			  b.batch_no,
+			  b.expiry,
+			  m.creator,
+			  m.created
+			FROM stock_movement m
+			  JOIN lov_product p ON p.id = m.prod_id
+			  JOIN lov_unit u ON u.id = p.unit_id
+			  LEFT JOIN stock_batch b ON b.id = m.batch_id
+			`
+
+func scanStockMovement(row rowScanner, m *stockMovementDTO) error {
+	return row.Scan(
+		&m.ID,
+		&m.ProductID,
+		&m.Type,
+		&m.Quantity,
+		&m.Date,
+		&m.Note,
+		&m.Product,
+		&m.Unit,
+		&m.RecordID,
+		&m.BatchID,
+		&m.Batch,
+		&m.Expiry,
+		&m.Creator,
+		&m.Created)
+}
+
+// GetMovement is implementation of StockService.GetMovement using postgresql database
+func (s *StockService) GetMovement(ctx context.Context, id uint64) (*lara.GetStockMovement, error) {
+	var m stockMovementDTO
+	err := scanStockMovement(s.DB.QueryRowContext(ctx, stockMovementQuery+`WHERE m.id = $1`, id), &m)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, notFoundByIDError(id)
+	case err != nil:
+		return nil, errors.Wrap(err, "get stock movement by id failed")
+	}
+
+	return m.toGetStockMovement(), nil
+}
+
+// validateStockMovement checks manually posted movement and returns sign of
+// stored quantity
+func validateStockMovement(m *lara.StockMovement) (int, error) {
+	if m.ProductID == 0 {
+		return 0, requiredFieldError("productId")
+	}
+	if len(m.Amount) == 0 {
+		return 0, requiredFieldError("amount")
+	}
+
+	// quantity is numeric(10,4)
+	a, ok := parseNumeric(m.Amount, 10, 4)
+	if !ok {
+		return 0, lara.NewCodedError(400,
+			errors.Errorf("amount '%s' is not valid", m.Amount))
+	}
+
+	switch m.Type {
+	case lara.Receipt, lara.WriteOff:
+		if a <= 0 {
+			return 0, lara.NewCodedError(400,
+				errors.Errorf("amount of %s must be positive", m.Type))
+		}
+		if m.Type == lara.WriteOff {
+			return -1, nil
+		}
+	case lara.Correction:
+		if a == 0 {
+			return 0, lara.NewCodedError(400,
+				errors.New("amount of Correction must not be zero"))
+		}
+	case lara.Dispense:
+		return 0, lara.NewCodedError(400,
+			errors.New("Dispense movements are posted from records only"))
+	default:
+		return 0, lara.NewCodedError(400,
+			errors.Errorf("stock movement type %d is not valid", m.Type))
+	}
+
+	return 1, nil
+}
+
+// Post is implementation of StockService.Post using postgresql database
+func (s *StockService) Post(ctx context.Context, m *lara.CreateStockMovement) (uint64, error) {
+	sign, err := validateStockMovement(&m.StockMovement)
+	if err != nil {
+		return 0, err
+	}
+
+	u, ok := lara.UserFromContext(ctx)
+	if !ok {
+		return 0, errors.New("no user in context")
+	}
+
+	var id uint64
+	err = execInTransaction(ctx, s.DB, func(tx *sql.Tx) (err error) {
+		id, err = postMovement(ctx, tx, m, sign, u.Login)
+		return
+	})
+
+	return id, err
+}
+
+// postMovement inserts validated movement, amount is multiplied by sign
+func postMovement(ctx context.Context, tx *sql.Tx, m *lara.CreateStockMovement, sign int, user string) (uint64, error) {
+	const insert = `INSERT INTO stock_movement (prod_id, mov_type, quantity, mov_date, note, batch_id, creator, created)
+			SELECT p.id, $2::integer, $3::numeric * $4::integer, COALESCE($5::timestamp, $7::timestamp), $6::text,
+			  $9::integer, $8::text, $7::timestamp
+			FROM lov_product p
+			WHERE p.id = $1
+			RETURNING id`
+
+	batchID, err := movementBatch(ctx, tx, m, user)
+	if err != nil {
+		return 0, err
+	}
+
+	var id uint64
+	err = tx.QueryRowContext(ctx, insert,
+		m.ProductID,
+		m.Type,
+		m.Amount,
+		sign,
+		toNullTime(m.Date),
+		toNullString(m.Note),
+		now(),
+		user,
+		toNullFK(batchID)).Scan(&id)
+	switch {
+	case err == sql.ErrNoRows:
+		return 0, lara.NewCodedError(400,
+			errors.Errorf("product %d not found", m.ProductID))
+	case err != nil:
+		return 0, errors.Wrap(err, "post stock movement failed")
+	}
+
+	return id, nil
+}
+
+// movementBatch returns ID of batch referenced by movement. Batch received
+// for the first time is created.
+func movementBatch(ctx context.Context, tx *sql.Tx, m *lara.CreateStockMovement, user string) (uint64, error) {
+	const byID = `SELECT prod_id FROM stock_batch WHERE id = $1`
+	const byNumber = `SELECT id, expiry FROM stock_batch WHERE prod_id = $1 AND batch_no = $2 FOR UPDATE`
+	const insert = `INSERT INTO stock_batch (prod_id, batch_no, expiry, creator, created)
+			SELECT p.id, $2::text, $3::date, $4::text, $5::timestamp
+			FROM lov_product p
+			WHERE p.id = $1
+			RETURNING id`
+
+	if len(m.Batch) == 0 {
+		if m.BatchID == 0 {
+			return 0, nil
+		}
+
+		var prodID uint64
+		err := tx.QueryRowContext(ctx, byID, m.BatchID).Scan(&prodID)
+		switch {
+		case err == sql.ErrNoRows || (err == nil && prodID != m.ProductID):
+			return 0, lara.NewCodedError(400,
+				errors.Errorf("batch %d of product %d not found", m.BatchID, m.ProductID))
+		case err != nil:
+			return 0, errors.Wrap(err, "select stock batch failed")
+		}
+
+		return m.BatchID, nil
+	}
+
+	if m.Type != lara.Receipt {
+		return 0, lara.NewCodedError(400,
+			errors.New("new batch can be specified on Receipt only"))
+	}
+
+	var id uint64
+	var expiry pq.NullTime
+	err := tx.QueryRowContext(ctx, byNumber, m.ProductID, m.Batch).Scan(&id, &expiry)
+	switch {
+	case err == sql.ErrNoRows:
+		// new batch
+	case err != nil:
+		return 0, errors.Wrap(err, "select stock batch failed")
+	case !m.Expiry.IsZero() && !sameDay(expiry.Time, m.Expiry):
+		return 0, lara.NewCodedError(400,
+			errors.Errorf("batch %s was received with different expiry date", m.Batch))
+	default:
+		return id, nil
+	}
+
+	err = tx.QueryRowContext(ctx, insert,
+		m.ProductID,
+		m.Batch,
+		toNullTime(m.Expiry),
+		user,
+		now()).Scan(&id)
+	switch {
+	case err == sql.ErrNoRows:
+		return 0, lara.NewCodedError(400,
+			errors.Errorf("product %d not found", m.ProductID))
+	case err != nil:
+		return 0, errors.Wrap(err, "create stock batch failed")
+	}
+
+	return id, nil
+}
+
+// sameDay compares dates ignoring time of day
+func sameDay(a, b time.Time) bool {
+	return a.Format("2006-01-02") == b.Format("2006-01-02")
+}
+
+// ListMovements is implementation of StockService.ListMovements using postgresql database.
+// Returns product's movements dated between days from and to (both inclusive).
+func (s *StockService) ListMovements(ctx context.Context, productID uint64, from, to time.Time) (*lara.StockMovementList, error) {
+	rows, err := s.DB.QueryContext(ctx, stockMovementQuery+
+		`WHERE m.prod_id = $1 AND m.mov_date >= $2 AND m.mov_date < $3
+			ORDER BY m.mov_date, m.id`,
+		productID,
+		startOfDay(from.In(s.Loc), s.Loc),
+		startOfDay(to.In(s.Loc), s.Loc).AddDate(0, 0, 1))
+	if err != nil {
+		return nil, errors.Wrap(err, "list stock movements query error")
+	}
+	defer rows.Close()
+
+	result := lara.StockMovementList{Items: []lara.GetStockMovement{}}
+	for rows.Next() {
+		var m stockMovementDTO
+		if err := scanStockMovement(rows, &m); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		result.Items = append(result.Items, *m.toGetStockMovement())
+	}
+	err = rows.Err()
+
+	return &result, errors.Wrap(err, "rows processing errror")
+}
+
+const stockLevelQuery = `SELECT
+			  p.id,
+			  p.name,
+			  u.name,
+			  COALESCE(SUM(m.quantity), 0.0000)
+			FROM lov_product p
+			  JOIN lov_unit u ON u.id = p.unit_id
+			`
+
+// Get is implementation of StockService.Get using postgresql database.
+// Product without movements has zero stock.
+func (s *StockService) Get(ctx context.Context, productID uint64) (*lara.StockLevel, error) {
+	const q = stockLevelQuery + `LEFT JOIN stock_movement m ON m.prod_id = p.id
+			WHERE p.id = $1
+			GROUP BY p.id, p.name, u.name`
+
+	var l lara.StockLevel
+	err := s.DB.QueryRowContext(ctx, q, productID).Scan(
+		&l.ProductID,
+		&l.Product,
+		&l.Unit,
+		&l.Quantity)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, notFoundByIDError(productID)
+	case err != nil:
+		return nil, errors.Wrap(err, "get stock level failed")
+	}
+
+	return &l, nil
+}
+
+// List is implementation of StockService.List using postgresql database
+func (s *StockService) List(ctx context.Context) (*lara.StockLevelList, error) {
+	const q = stockLevelQuery + `JOIN stock_movement m ON m.prod_id = p.id
+			GROUP BY p.id, p.name, u.name
+			ORDER BY p.name, p.id`
+
+	rows, err := s.DB.QueryContext(ctx, q)
+	if err != nil {
+		return nil, errors.Wrap(err, "list stock levels query error")
+	}
+	defer rows.Close()
+
+	result := lara.StockLevelList{Items: []lara.StockLevel{}}
+	for rows.Next() {
+		var l lara.StockLevel
+		if err := rows.Scan(&l.ProductID, &l.Product, &l.Unit, &l.Quantity); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		result.Items = append(result.Items, l)
+	}
+	err = rows.Err()
+
+	return &result, errors.Wrap(err, "rows processing errror")
+}
+
+const stockBatchQuery = `SELECT
+			  b.id,
+			  b.prod_id,
+			  p.name,
+			  u.name,
+			  b.batch_no,
+			  b.expiry,
+			  SUM(m.quantity)
+			FROM stock_batch b
+			  JOIN lov_product p ON p.id = b.prod_id
+			  JOIN lov_unit u ON u.id = p.unit_id
+			  JOIN stock_movement m ON m.batch_id = b.id
+			`
+
+// Batches is implementation of StockService.Batches using postgresql database.
+// Batches used up are not returned.
+func (s *StockService) Batches(ctx context.Context, productID uint64) (*lara.StockBatchList, error) {
+	return s.batches(ctx, `WHERE b.prod_id = $1`, productID)
+}
+
+// Expiring is implementation of StockService.Expiring using postgresql database
+func (s *StockService) Expiring(ctx context.Context, days int) (*lara.StockBatchList, error) {
+	if days < 0 {
+		return nil, lara.NewCodedError(400,
+			errors.Errorf("days %d must not be negative", days))
+	}
+
+	return s.batches(ctx, `WHERE b.expiry <= current_date + $1::integer`, days)
+}
+
+func (s *StockService) batches(ctx context.Context, where string, params ...interface{}) (*lara.StockBatchList, error) {
+	rows, err := s.DB.QueryContext(ctx, stockBatchQuery+where+`
+			GROUP BY b.id, b.prod_id, p.name, u.name, b.batch_no, b.expiry
+			HAVING SUM(m.quantity) > 0
+			ORDER BY b.expiry NULLS LAST, b.id`, params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "list stock batches query error")
+	}
+	defer rows.Close()
+
+	result := lara.StockBatchList{Items: []lara.StockBatch{}}
+	for rows.Next() {
+		var b lara.StockBatch
+		var expiry pq.NullTime
+		if err := rows.Scan(&b.ID,
+			&b.ProductID,
+			&b.Product,
+			&b.Unit,
+			&b.Number,
+			&expiry,
+			&b.Quantity); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		b.Expiry = expiry.Time
+		result.Items = append(result.Items, b)
+	}
+	err = rows.Err()
+
+	return &result, errors.Wrap(err, "rows processing errror")
+}
+
+// checkDispensedBatch validates batch referenced by n-th record item. Batch
+// must belong to item's product and must not be expired at record's date.
+func checkDispensedBatch(ctx context.Context, tx *sql.Tx, recID uint64, n int, i *lara.RecordItem) error {
+	const q = `SELECT b.prod_id, b.batch_no, b.expiry, COALESCE(b.expiry < r.rec_date::date, FALSE)
+			FROM stock_batch b, record r
+			WHERE b.id = $1 AND r.id = $2`
+
+	if i.ItemType != lara.Material {
+		return lara.NewCodedError(400,
+			errors.Errorf("batch on item %d requires %s item", n, lara.Material))
+	}
+
+	var prodID uint64
+	var number string
+	var expiry pq.NullTime
+	var expired bool
+	err := tx.QueryRowContext(ctx, q, i.BatchID, recID).Scan(&prodID, &number, &expiry, &expired)
+	switch {
+	case err == sql.ErrNoRows || (err == nil && prodID != i.ProductID):
+		return lara.NewCodedError(400,
+			errors.Errorf("batch %d of product %d on item %d not found", i.BatchID, i.ProductID, n))
+	case err != nil:
+		return errors.Wrap(err, "select stock batch failed")
+	case expired:
+		return lara.NewCodedError(400,
+			errors.Errorf("batch %s on item %d expired on %s", number, n, expiry.Time.Format("2006-01-02")))
+	}
+
+	return nil
+}
+
+// dispenseRecordItems brings record's Dispense movements in line with its
+// current Material items. Only difference to already dispensed amount of
+// each product's batch is posted, so updated record reverses dispensing of
+// removed items. Movements are dated at record's date.
+func dispenseRecordItems(ctx context.Context, tx *sql.Tx, recID uint64) error {
+	const dispense = `INSERT INTO stock_movement (prod_id, mov_type, quantity, mov_date, record_id, batch_id,
+			  creator, created)
+			SELECT d.prod_id, $2::integer, -SUM(d.quantity), (SELECT rec_date FROM record WHERE id = $1),
+			  $1, d.batch_id, $4::text, $3::timestamp
+			FROM (
+			  SELECT prod_id, batch_id, amount AS quantity
+			  FROM record_item
+			  WHERE record_id = $1 AND item_type = $5
+			  UNION ALL
+			  SELECT prod_id, batch_id, quantity FROM stock_movement WHERE record_id = $1
+			) d
+			GROUP BY d.prod_id, d.batch_id
+			HAVING SUM(d.quantity) <> 0`
+
+	u, ok := lara.UserFromContext(ctx)
+	if !ok {
+		return errors.New("no user in context")
+	}
+
+	_, err := tx.ExecContext(ctx, dispense,
+		recID,
+		lara.Dispense,
+		now(),
+		u.Login,
+		lara.Material)
+
+	return errors.Wrap(err, "dispense record items failed")
+}
@@ -0,0 +1,238 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jkusniar/lara"
+	"github.com/pkg/errors"
+)
+
+// SupplierService is implementation of lara.SupplierService using postgresql database
+type SupplierService struct {
+	DB *sql.DB
+}
+
+type supplierDTO struct {
+	versionedDTO
+	creatorDTO
+	modifierDTO
+	Name    string
+	IC      sql.NullString
+	DIC     sql.NullString
+	Address sql.NullString
+	Contact sql.NullString
+	Phone   sql.NullString
+	Email   sql.NullString
+	Note    sql.NullString
+}
+
+func (s *supplierDTO) toGetSupplier() *lara.GetSupplier {
+	return &lara.GetSupplier{
+		Versioned: lara.Versioned{
+			ID:      s.ID,
+			Version: s.Version},
+		Supplier: lara.Supplier{
+			Name:    s.Name,
+			IC:      s.IC.String,
+			DIC:     s.DIC.String,
+			Address: s.Address.String,
+			Contact: s.Contact.String,
+			Phone:   s.Phone.String,
+			Email:   s.Email.String,
+			Note:    s.Note.String},
+		CreatorModifier: lara.CreatorModifier{
+			Creator:  s.Creator,
+			Created:  s.Created,
+			Modifier: s.Modifier.String,
+			Modified: s.Modified.Time},
+	}
+}
+
+const supplierQuery = `SELECT
+			  id,
+			  version,
+			  name,
+			  ic,
+			  dic,
+			  address,
+			  contact,
+			  phone,
+			  email,
+			  note,
+			  creator,
+			  created,
+			  modifier,
+			  modified
+			FROM supplier
+			`
+
+func scanSupplier(row rowScanner, s *supplierDTO) error {
+	return row.Scan(
+		&s.ID,
+		&s.Version,
+		&s.Name,
+		&s.IC,
+		&s.DIC,
+		&s.Address,
+		&s.Contact,
+		&s.Phone,
+		&s.Email,
+		&s.Note,
+		&s.Creator,
+		&s.Created,
+		&s.Modifier,
+		&s.Modified)
+}
+
+// Get is implementation of SupplierService.Get using postgresql database
+func (s *SupplierService) Get(ctx context.Context, id uint64) (*lara.GetSupplier, error) {
+	var sup supplierDTO
+	err := scanSupplier(s.DB.QueryRowContext(ctx, supplierQuery+`WHERE id = $1`, id), &sup)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, notFoundByIDError(id)
+	case err != nil:
+		return nil, errors.Wrap(err, "get supplier by id failed")
+	}
+
+	return sup.toGetSupplier(), nil
+}
+
+// List is implementation of SupplierService.List using postgresql database
+func (s *SupplierService) List(ctx context.Context) (*lara.SupplierList, error) {
+	rows, err := s.DB.QueryContext(ctx, supplierQuery+`ORDER BY name, id`)
+	if err != nil {
+		return nil, errors.Wrap(err, "list suppliers query error")
+	}
+	defer rows.Close()
+
+	result := lara.SupplierList{Items: []lara.GetSupplier{}}
+	for rows.Next() {
+		var sup supplierDTO
+		if err := scanSupplier(rows, &sup); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		result.Items = append(result.Items, *sup.toGetSupplier())
+	}
+	err = rows.Err()
+
+	return &result, errors.Wrap(err, "rows processing errror")
+}
+
+// Create is implementation of SupplierService.Create using postgresql database
+func (s *SupplierService) Create(ctx context.Context, sup *lara.CreateSupplier) (uint64, error) {
+	if len(sup.Name) == 0 {
+		return 0, requiredFieldError("name")
+	}
+
+	const insert = `INSERT INTO supplier (name, ic, dic, address, contact, phone, email, note, creator, created)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			RETURNING id`
+
+	u, ok := lara.UserFromContext(ctx)
+	if !ok {
+		return 0, errors.New("no user in context")
+	}
+
+	var id uint64
+	err := s.DB.QueryRowContext(ctx, insert,
+		sup.Name,
+		toNullString(sup.IC),
+		toNullString(sup.DIC),
+		toNullString(sup.Address),
+		toNullString(sup.Contact),
+		toNullString(sup.Phone),
+		toNullString(sup.Email),
+		toNullString(sup.Note),
+		u.Login,
+		now()).Scan(&id)
+
+	return id, errors.Wrap(err, "create supplier failed")
+}
+
+// Update is implementation of SupplierService.Update using postgresql database
+func (s *SupplierService) Update(ctx context.Context, id uint64, sup *lara.UpdateSupplier) error {
+	if len(sup.Name) == 0 {
+		return requiredFieldError("name")
+	}
+
+	return execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		const lck = `SELECT id FROM supplier WHERE id = $1 FOR UPDATE`
+		const upd = `UPDATE supplier
+			SET name   = $1,
+			  ic       = $2,
+			  dic      = $3,
+			  address  = $4,
+			  contact  = $5,
+			  phone    = $6,
+			  email    = $7,
+			  note     = $8,
+			  modifier = $9,
+			  modified = $10,
+			  version  = version + 1
+			WHERE id = $11 AND version = $12`
+
+		var sid uint64
+		err := tx.QueryRowContext(ctx, lck, id).Scan(&sid)
+		switch err {
+		case nil: // continue
+		case sql.ErrNoRows:
+			return notFoundByIDError(id)
+		default:
+			return errors.Wrap(err, "error selecting supplier by id")
+		}
+
+		u, ok := lara.UserFromContext(ctx)
+		if !ok {
+			return errors.New("no user in context")
+		}
+
+		r, err := tx.ExecContext(ctx, upd,
+			sup.Name,
+			toNullString(sup.IC),
+			toNullString(sup.DIC),
+			toNullString(sup.Address),
+			toNullString(sup.Contact),
+			toNullString(sup.Phone),
+			toNullString(sup.Email),
+			toNullString(sup.Note),
+			u.Login,
+			now(),
+			id,
+			sup.Version)
+		if err != nil {
+			return errors.Wrap(err, "update supplier failed")
+		}
+
+		count, err := r.RowsAffected()
+		if err != nil {
+			return errors.Wrap(err, "update supplier can't check updated rows")
+		}
+
+		if count != 1 {
+			return versionMismatchError(id)
+		}
+
+		return nil
+	})
+}
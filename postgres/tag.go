@@ -22,26 +22,69 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
 
 	"github.com/jkusniar/lara"
+	"github.com/jkusniar/lara/thumbnail"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 )
 
 // TagService is lara.TagService implementation backed by postgresql
 type TagService struct {
-	DB *sql.DB
+	DB         *sql.DB
+	Validators lara.TagValidators // lara.DefaultTagValidators() if nil
+	// MaxDataSize is tag data's size limit in bytes,
+	// lara.DefaultMaxTagDataSize if 0
+	MaxDataSize int64
 }
 
+// tagDataTypes are allowed content types of tag's data
+var tagDataTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+}
+
+func (s *TagService) validators() lara.TagValidators {
+	if s.Validators == nil {
+		return lara.DefaultTagValidators()
+	}
+	return s.Validators
+}
+
+// tagActiveSQL is true for tag t which was not deactivated and is valid today
+const tagActiveSQL = `(t.active
+			  AND coalesce(t.valid_from, current_date) <= current_date
+			  AND coalesce(t.valid_to, current_date) >= current_date)`
+
 type tagDTO struct {
 	versionedDTO
 	creatorDTO
 	modifierDTO
-	Type  string
-	Value string
-	Data  []byte
+	Type       string
+	Value      string
+	DataType   sql.NullString
+	DataSize   sql.NullInt64
+	Thumbnail  bool
+	ValidFrom  pq.NullTime
+	ValidTo    pq.NullTime
+	Active     bool
+	Replaces   sql.NullInt64
+	ReplacedBy sql.NullInt64
 }
 
 func (t *tagDTO) toGetTag() *lara.GetTag {
+	var chip *lara.Microchip
+	if t.Type == lara.RFID.String() {
+		// values stored before validation was introduced may not decode
+		chip, _ = lara.DecodeMicrochip(t.Value)
+	}
+
 	return &lara.GetTag{
 		Versioned: lara.Versioned{
 			ID:      t.ID,
@@ -52,9 +95,17 @@ func (t *tagDTO) toGetTag() *lara.GetTag {
 			Modifier: t.Modifier.String,
 			Modified: t.Modified.Time,
 		},
-		Type:  t.Type,
-		Value: t.Value,
-		Data:  t.Data,
+		Type:       t.Type,
+		Value:      t.Value,
+		DataType:   t.DataType.String,
+		DataSize:   t.DataSize.Int64,
+		Thumbnail:  t.Thumbnail,
+		Microchip:  chip,
+		ValidFrom:  t.ValidFrom.Time,
+		ValidTo:    t.ValidTo.Time,
+		Active:     t.Active,
+		Replaces:   uint64(t.Replaces.Int64),
+		ReplacedBy: uint64(t.ReplacedBy.Int64),
 	}
 }
 
@@ -69,9 +120,17 @@ func (s *TagService) Get(ctx context.Context, id uint64) (*lara.GetTag, error) {
 			  t.modified,
 			  tt.name as type,
 			  t.value,
-			  t.data
+			  t.data_type,
+			  octet_length(t.data),
+			  t.thumbnail IS NOT NULL,
+			  t.valid_from,
+			  t.valid_to,
+			  ` + tagActiveSQL + `,
+			  t.replaces,
+			  r.id
 			FROM tag t
 			 JOIN tag_type tt ON tt.id = t.tag_type_id
+			 LEFT JOIN tag r ON r.replaces = t.id
 			WHERE t.id = $1`
 
 	var t tagDTO
@@ -84,7 +143,14 @@ func (s *TagService) Get(ctx context.Context, id uint64) (*lara.GetTag, error) {
 		&t.Modified,
 		&t.Type,
 		&t.Value,
-		&t.Data)
+		&t.DataType,
+		&t.DataSize,
+		&t.Thumbnail,
+		&t.ValidFrom,
+		&t.ValidTo,
+		&t.Active,
+		&t.Replaces,
+		&t.ReplacedBy)
 	switch {
 	case err == sql.ErrNoRows:
 		return nil, notFoundByIDError(id)
@@ -96,29 +162,35 @@ func (s *TagService) Get(ctx context.Context, id uint64) (*lara.GetTag, error) {
 }
 
 // Update is implementation of TagService.Update using postgresql database.
+// Value is validated by validator of tag's type. Inactive tags can't be
+// updated.
 func (s *TagService) Update(ctx context.Context, id uint64, t *lara.UpdateTag) error {
 	if len(t.Value) == 0 {
 		return requiredFieldError("value")
 	}
 
+	if err := checkTagValidity(t.ValidFrom, t.ValidTo); err != nil {
+		return err
+	}
+
 	err := execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
-		const lck = `SELECT id FROM tag WHERE id = $1 FOR UPDATE`
 		const upd = `UPDATE tag
-				SET value  = $1,
-				  data     = $2,
-				  modifier = $3,
-				  modified = $4,
-				  version  = version + 1
-				WHERE id = $5 AND version = $6`
-
-		var tid uint64
-		err := tx.QueryRowContext(ctx, lck, id).Scan(&tid)
-		switch err {
-		case nil: // continue
-		case sql.ErrNoRows:
-			return notFoundByIDError(id)
-		default:
-			return errors.Wrap(err, "error selecting tag by id")
+				SET value    = $1,
+				  valid_from = $2,
+				  valid_to   = $3,
+				  modifier   = $4,
+				  modified   = $5,
+				  version    = version + 1
+				WHERE id = $6 AND version = $7`
+
+		tt, err := lockTag(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		value, err := s.validators().Normalize(tt, t.Value)
+		if err != nil {
+			return err
 		}
 
 		u, ok := lara.UserFromContext(ctx)
@@ -127,32 +199,179 @@ func (s *TagService) Update(ctx context.Context, id uint64, t *lara.UpdateTag) e
 		}
 
 		r, err := tx.ExecContext(ctx, upd,
-			toNullString(t.Value),
-			t.Data,
+			toNullString(value),
+			toNullTime(t.ValidFrom),
+			toNullTime(t.ValidTo),
 			toNullString(u.Login),
 			now(),
 			id,
 			t.Version)
+		if isUniqueViolation(err) {
+			return tagExistsError(value)
+		}
 		if err != nil {
 			return errors.Wrap(err, "update tag failed")
 		}
 
-		count, err := r.RowsAffected()
+		return checkUpdatedTag(r, id)
+	})
+
+	return err
+}
+
+// Replace is implementation of TagService.Replace using postgresql database.
+// Replaced tag is deactivated before new tag is created, so reissued tag may
+// keep replaced tag's value.
+func (s *TagService) Replace(ctx context.Context, id uint64, t *lara.ReplaceTag) (uint64, error) {
+	if len(t.Value) == 0 {
+		return 0, requiredFieldError("value")
+	}
+
+	validFrom := t.ValidFrom
+	if validFrom.IsZero() {
+		validFrom = startOfDay(time.Now(), time.Local)
+	}
+
+	if err := checkTagValidity(validFrom, t.ValidTo); err != nil {
+		return 0, err
+	}
+
+	var newID uint64
+	err := execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		const insert = `INSERT INTO tag (patient_id, tag_type_id, value,
+				  valid_from, valid_to, replaces, creator, created)
+				SELECT patient_id, tag_type_id, $1, $2, $3, id, $4, $5
+				FROM tag
+				WHERE id = $6
+				RETURNING id`
+
+		tt, err := lockTag(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		value, err := s.validators().Normalize(tt, t.Value)
 		if err != nil {
-			return errors.Wrap(err, "update tag can't check updated rows")
+			return err
 		}
 
-		if count != 1 {
-			return versionMismatchError(id)
+		u, ok := lara.UserFromContext(ctx)
+		if !ok {
+			return errors.New("no user in context")
 		}
 
-		return nil
+		if err := deactivateTag(ctx, tx, id, t.Version, toNullTime(validFrom), u.Login); err != nil {
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx, insert,
+			toNullString(value),
+			toNullTime(validFrom),
+			toNullTime(t.ValidTo),
+			toNullString(u.Login),
+			now(),
+			id).Scan(&newID)
+		if isUniqueViolation(err) {
+			return tagExistsError(value)
+		}
+
+		return errors.Wrap(err, "replace tag failed")
 	})
 
-	return err
+	return newID, err
+}
+
+// Deactivate is implementation of TagService.Deactivate using postgresql database.
+func (s *TagService) Deactivate(ctx context.Context, id uint64, t *lara.DeactivateTag) error {
+	return execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		if _, err := lockTag(ctx, tx, id); err != nil {
+			return err
+		}
+
+		u, ok := lara.UserFromContext(ctx)
+		if !ok {
+			return errors.New("no user in context")
+		}
+
+		return deactivateTag(ctx, tx, id, t.Version, toNullTime(t.ValidTo), u.Login)
+	})
+}
+
+// lockTag locks active tag for update and returns its type
+func lockTag(ctx context.Context, tx *sql.Tx, id uint64) (lara.TagType, error) {
+	const lck = `SELECT tt.name, t.active
+			FROM tag t
+			  JOIN tag_type tt ON tt.id = t.tag_type_id
+			WHERE t.id = $1
+			FOR UPDATE OF t`
+
+	var tt lara.TagType
+	var typeName string
+	var active bool
+	err := tx.QueryRowContext(ctx, lck, id).Scan(&typeName, &active)
+	switch err {
+	case nil: // continue
+	case sql.ErrNoRows:
+		return tt, notFoundByIDError(id)
+	default:
+		return tt, errors.Wrap(err, "error selecting tag by id")
+	}
+
+	if !active {
+		return tt, lara.NewCodedError(409, errors.Errorf("tag %d is inactive", id))
+	}
+
+	if err := tt.FromString(typeName); err != nil {
+		return tt, errors.Wrapf(err, "tag %d has unknown type", id)
+	}
+
+	return tt, nil
+}
+
+// deactivateTag deactivates tag, its validity ends at validTo (today if null)
+// unless it ends earlier already
+func deactivateTag(ctx context.Context, tx *sql.Tx, id, version uint64,
+	validTo pq.NullTime, login string) error {
+	const upd = `UPDATE tag
+			SET active = false,
+			  valid_to = LEAST(valid_to, GREATEST(valid_from, COALESCE($1::date, current_date))),
+			  modifier = $2,
+			  modified = $3,
+			  version  = version + 1
+			WHERE id = $4 AND version = $5`
+
+	r, err := tx.ExecContext(ctx, upd, validTo, login, now(), id, version)
+	if err != nil {
+		return errors.Wrap(err, "deactivate tag failed")
+	}
+
+	return checkUpdatedTag(r, id)
+}
+
+func checkUpdatedTag(r sql.Result, id uint64) error {
+	count, err := r.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "update tag can't check updated rows")
+	}
+
+	if count != 1 {
+		return versionMismatchError(id)
+	}
+
+	return nil
+}
+
+// checkTagValidity checks tag's validity period, empty dates are unbounded
+func checkTagValidity(from, to time.Time) error {
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return lara.NewCodedError(400, errors.New("validTo can't be before validFrom"))
+	}
+
+	return nil
 }
 
 // Create is implementation of TagService.Create using postgresql database.
+// Value is validated by validator of tag's type.
 func (s *TagService) Create(ctx context.Context, t *lara.CreateTag) (uint64, error) {
 	if len(t.Value) == 0 {
 		return 0, requiredFieldError("value")
@@ -162,21 +381,36 @@ func (s *TagService) Create(ctx context.Context, t *lara.CreateTag) (uint64, err
 		return 0, requiredFieldError("type")
 	}
 
+	if err := checkTagValidity(t.ValidFrom, t.ValidTo); err != nil {
+		return 0, err
+	}
+
+	var tt lara.TagType
+	if err := tt.FromString(t.Type); err != nil {
+		return 0, lara.NewCodedError(400, errors.Errorf("invalid TagType %s", t.Type))
+	}
+
+	value, err := s.validators().Normalize(tt, t.Value)
+	if err != nil {
+		return 0, err
+	}
+
 	if t.PatientID == 0 {
 		return 0, requiredFieldError("patientId")
 	}
 
 	var id uint64
-	err := execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+	err = execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
 		u, ok := lara.UserFromContext(ctx)
 		if !ok {
 			return errors.New("no user in context")
 		}
 
-		const insert = `INSERT INTO tag (patient_id, tag_type_id, value, data, creator, created)
-					VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+		const insert = `INSERT INTO tag (patient_id, tag_type_id, value,
+					  valid_from, valid_to, creator, created)
+					VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
 
-		ttID, err := getOrCreateTagType(ctx, tx, t.Type)
+		ttID, err := getOrCreateTagType(ctx, tx, tt)
 		if err != nil {
 			return err
 		}
@@ -184,10 +418,14 @@ func (s *TagService) Create(ctx context.Context, t *lara.CreateTag) (uint64, err
 		err = tx.QueryRowContext(ctx, insert,
 			toNullFK(t.PatientID),
 			ttID,
-			toNullString(t.Value),
-			t.Data,
+			toNullString(value),
+			toNullTime(t.ValidFrom),
+			toNullTime(t.ValidTo),
 			toNullString(u.Login),
 			now()).Scan(&id)
+		if isUniqueViolation(err) {
+			return tagExistsError(value)
+		}
 
 		return errors.Wrap(err, "create tag failed")
 	})
@@ -195,14 +433,116 @@ func (s *TagService) Create(ctx context.Context, t *lara.CreateTag) (uint64, err
 	return id, err
 }
 
-func getOrCreateTagType(ctx context.Context, tx *sql.Tx, tagType string) (uint64, error) {
-	var ttID uint64
+// PutData is implementation of TagService.PutData using postgresql database.
+// Data of inactive tags can't be changed.
+func (s *TagService) PutData(ctx context.Context, id uint64, content io.Reader) error {
+	max := s.MaxDataSize
+	if max == 0 {
+		max = lara.DefaultMaxTagDataSize
+	}
 
-	var tt lara.TagType
-	if err := tt.FromString(tagType); err != nil {
-		return ttID, lara.NewCodedError(400, errors.Errorf("invalid TagType %s", tagType))
+	data, err := ioutil.ReadAll(io.LimitReader(content, max+1))
+	if err != nil {
+		return errors.Wrap(err, "read tag data failed")
+	}
+	if len(data) == 0 {
+		return lara.NewCodedError(400, errors.New("tag data is empty"))
+	}
+	if int64(len(data)) > max {
+		return lara.NewCodedError(http.StatusRequestEntityTooLarge,
+			errors.Errorf("tag data exceeds size limit of %d bytes", max))
+	}
+
+	ctype := sniffContentType(data)
+	if !tagDataTypes[ctype] {
+		return lara.NewCodedError(http.StatusUnsupportedMediaType,
+			errors.Errorf("tag data's content type %s is not supported", ctype))
 	}
 
+	var thumb []byte
+	if thumbnail.Supported(ctype) {
+		if thumb, err = thumbnail.Make(data, thumbnail.DefaultSize); err != nil {
+			return lara.NewCodedError(400, errors.Wrap(err, "invalid image"))
+		}
+	}
+
+	return execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		if _, err := lockTag(ctx, tx, id); err != nil {
+			return err
+		}
+
+		return updateTagData(ctx, tx, id, data, toNullString(ctype), thumb)
+	})
+}
+
+// DeleteData is implementation of TagService.DeleteData using postgresql database.
+// Data of inactive tags can't be deleted.
+func (s *TagService) DeleteData(ctx context.Context, id uint64) error {
+	return execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		if _, err := lockTag(ctx, tx, id); err != nil {
+			return err
+		}
+
+		return updateTagData(ctx, tx, id, nil, sql.NullString{}, nil)
+	})
+}
+
+// updateTagData sets data of locked tag
+func updateTagData(ctx context.Context, tx *sql.Tx, id uint64, data []byte,
+	ctype sql.NullString, thumb []byte) error {
+	const upd = `UPDATE tag
+			SET data    = $1,
+			  data_type = $2,
+			  thumbnail = $3,
+			  modifier  = $4,
+			  modified  = $5,
+			  version   = version + 1
+			WHERE id = $6`
+
+	u, ok := lara.UserFromContext(ctx)
+	if !ok {
+		return errors.New("no user in context")
+	}
+
+	_, err := tx.ExecContext(ctx, upd, data, ctype, thumb, u.Login, now(), id)
+	return errors.Wrap(err, "update tag data failed")
+}
+
+// GetData is implementation of TagService.GetData using postgresql database.
+func (s *TagService) GetData(ctx context.Context, id uint64) (*lara.TagData, error) {
+	return s.tagData(ctx, `SELECT data_type, data FROM tag WHERE id = $1`, id, "data")
+}
+
+// GetThumbnail is implementation of TagService.GetThumbnail using postgresql database.
+func (s *TagService) GetThumbnail(ctx context.Context, id uint64) (*lara.TagData, error) {
+	return s.tagData(ctx, `SELECT '`+thumbnail.ContentType+`', thumbnail FROM tag WHERE id = $1`,
+		id, "thumbnail")
+}
+
+// tagData returns content type and content of tag id selected by query q
+func (s *TagService) tagData(ctx context.Context, q string, id uint64, what string) (*lara.TagData, error) {
+	var ctype sql.NullString
+	var d lara.TagData
+	err := s.DB.QueryRowContext(ctx, q, id).Scan(&ctype, &d.Content)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, notFoundByIDError(id)
+	case err != nil:
+		return nil, errors.Wrapf(err, "get tag %s failed", what)
+	case d.Content == nil:
+		return nil, lara.NewCodedError(404, errors.Errorf("tag %d has no %s", id, what))
+	}
+
+	d.ContentType = ctype.String
+	return &d, nil
+}
+
+func tagExistsError(value string) error {
+	return lara.NewCodedError(409, errors.Errorf("tag %s already exists", value))
+}
+
+func getOrCreateTagType(ctx context.Context, tx *sql.Tx, tt lara.TagType) (uint64, error) {
+	var ttID uint64
 	err := tx.QueryRowContext(ctx, `SELECT id FROM tag_type WHERE name = $1`, tt.String()).Scan(&ttID)
 	if err == sql.ErrNoRows {
 		err = tx.QueryRowContext(ctx, `INSERT INTO tag_type (name) VALUES($1) RETURNING id`,
@@ -213,26 +553,38 @@ func getOrCreateTagType(ctx context.Context, tx *sql.Tx, tagType string) (uint64
 }
 
 type patientByTagDTO struct {
-	TagType string
-	Name    string
-	Species sql.NullString
-	Breed   sql.NullString
-	Gender  sql.NullString
-	OwnerID uint64
+	TagID      uint64
+	TagType    string
+	Active     bool
+	ReplacedBy sql.NullString
+	PatientID  uint64
+	Name       string
+	Species    sql.NullString
+	Breed      sql.NullString
+	Gender     sql.NullString
+	OwnerID    uint64
 	OwnerNameDTO
 	OwnerAddressDTO
 }
 
 func (p *patientByTagDTO) toPatientByTag() *lara.PatientByTag {
-	return &lara.PatientByTag{TagType: p.TagType,
+	return &lara.PatientByTag{TagID: p.TagID, TagType: p.TagType, Active: p.Active,
+		ReplacedBy: p.ReplacedBy.String, PatientID: p.PatientID,
 		Name: p.Name, Species: p.Species.String, Breed: p.Breed.String, Gender: p.Gender.String,
 		OwnerID: p.OwnerID, OwnerName: p.OwnerNameDTO.String(), OwnerAddress: p.OwnerAddressDTO.String()}
 }
 
 // GetPatientByTag is implementation of TagService.GetPatientByTag using postgresql database.
+// Tag value is looked up as entered and in canonical forms of all tag types,
+// so differences in white space or case don't matter. Active tags win over
+// inactive ones, then exact match and newer tag wins.
 func (s *TagService) GetPatientByTag(ctx context.Context, tagValue string) (*lara.PatientByTag, error) {
 	const q = `SELECT
+			  t.id     AS tagId,
 			  tt.name  AS tagType,
+			  ` + tagActiveSQL + ` AS active,
+			  r.value  AS replacedBy,
+			  p.id     AS patientId,
 			  p.name   AS name,
 			  sp.name  AS species,
 			  b.name   AS breed,
@@ -246,6 +598,7 @@ func (s *TagService) GetPatientByTag(ctx context.Context, tagValue string) (*lar
 			  o.house_no
 			FROM tag t
 			  JOIN tag_type tt ON tt.id = t.tag_type_id
+			  LEFT JOIN tag r ON r.replaces = t.id
 			  JOIN patient p ON p.id = t.patient_id
 			  JOIN owner o ON o.id = p.owner_id
 			  LEFT JOIN lov_title l ON l.id = o.title_id
@@ -254,11 +607,14 @@ func (s *TagService) GetPatientByTag(ctx context.Context, tagValue string) (*lar
 			  LEFT JOIN lov_gender g ON g.id = p.gender_id
 			  LEFT JOIN lov_species sp ON sp.id = p.species_id
 			  LEFT JOIN lov_breed b ON b.id = p.breed_id
-			WHERE t.value = $1`
+			WHERE t.value = ANY($1)
+			ORDER BY ` + tagActiveSQL + ` DESC, t.value <> $2, t.id DESC
+			LIMIT 1`
 
 	var p patientByTagDTO
-	err := s.DB.QueryRowContext(ctx, q, tagValue).Scan(
-		&p.TagType, &p.Name, &p.Species, &p.Breed, &p.Gender, &p.OwnerID,
+	err := s.DB.QueryRowContext(ctx, q, pq.Array(s.validators().Candidates(tagValue)), tagValue).Scan(
+		&p.TagID, &p.TagType, &p.Active, &p.ReplacedBy, &p.PatientID,
+		&p.Name, &p.Species, &p.Breed, &p.Gender, &p.OwnerID,
 		&p.FirstName, &p.LastName, &p.Title, &p.City,
 		&p.Street, &p.HouseNo)
 	switch {
@@ -25,6 +25,7 @@ import (
 	"log"
 
 	"github.com/jkusniar/lara"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 )
 
@@ -45,14 +46,20 @@ type UserService struct {
 // whether password was incorrect, or login didn't exist.
 // This method logs *all* errors and transforms them to unauthorizedError for http layer
 func (s *UserService) Authenticate(ctx context.Context, login, password string) (*lara.User, error) {
-	const q = `SELECT pass_salt, pass_hash FROM "user" WHERE login = $1`
+	const q = `SELECT pass_salt, pass_hash, disabled FROM "user" WHERE login = $1`
 	var hash, salt []byte
+	var disabled bool
 
-	if err := s.DB.QueryRowContext(ctx, q, login).Scan(&salt, &hash); err != nil {
+	if err := s.DB.QueryRowContext(ctx, q, login).Scan(&salt, &hash, &disabled); err != nil {
 		log.Printf("ERROR: Authenticate: %+v\n", err)
 		return nil, unauthorizedError
 	}
 
+	if disabled {
+		log.Printf("ERROR: Authenticate: user %s is disabled\n", login)
+		return nil, unauthorizedError
+	}
+
 	if err := s.Pass.Check(password, salt, hash); err != nil {
 		log.Printf("ERROR: Authenticate: Pass.Check: %+v\n", err)
 		return nil, unauthorizedError
@@ -93,6 +100,27 @@ func (s *UserService) Authenticate(ctx context.Context, login, password string)
 	return u, nil
 }
 
+// CheckEnabled is implementation of UserService.CheckEnabled using
+// postgresql database
+func (s *UserService) CheckEnabled(ctx context.Context, login string) error {
+	const q = `SELECT disabled FROM "user" WHERE login = $1`
+	var disabled bool
+
+	err := s.DB.QueryRowContext(ctx, q, login).Scan(&disabled)
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("ERROR: CheckEnabled: user %s not found\n", login)
+		return unauthorizedError
+	case err != nil:
+		return errors.Wrap(err, "check user enabled failed")
+	case disabled:
+		log.Printf("ERROR: CheckEnabled: user %s is disabled\n", login)
+		return unauthorizedError
+	}
+
+	return nil
+}
+
 // Register creates new user in database. If user already exists, error is
 // returned.
 func (s *UserService) Register(ctx context.Context, login, password string, permissions []lara.PermissionType) error {
@@ -215,3 +243,122 @@ func (s *UserService) Revoke(ctx context.Context, login string, permissions []la
 
 	return err
 }
+
+// userAccountsSQL selects user accounts with their permissions
+const userAccountsSQL = `SELECT u.login,
+			  u.disabled,
+			  coalesce(array_agg(p.name ORDER BY p.name) FILTER (WHERE p.name IS NOT NULL), '{}')
+			FROM "user" u
+			  LEFT JOIN user_permission up ON up.user_id = u.id
+			  LEFT JOIN permission p ON p.id = up.permission_id`
+
+func scanUserAccount(row interface {
+	Scan(...interface{}) error
+}) (*lara.UserAccount, error) {
+	var a lara.UserAccount
+	err := row.Scan(&a.Login, &a.Disabled, pq.Array(&a.Permissions))
+	return &a, err
+}
+
+// List returns all users sorted by login
+func (s *UserService) List(ctx context.Context) (*lara.UserList, error) {
+	const q = userAccountsSQL + `
+			GROUP BY u.id
+			ORDER BY u.login`
+
+	rows, err := s.DB.QueryContext(ctx, q)
+	if err != nil {
+		return nil, errors.Wrap(err, "list users query error")
+	}
+	defer rows.Close()
+
+	result := &lara.UserList{Items: []lara.UserAccount{}}
+	for rows.Next() {
+		a, err := scanUserAccount(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		result.Items = append(result.Items, *a)
+	}
+	err = rows.Err()
+
+	return result, errors.Wrap(err, "rows processing errror")
+}
+
+// Get returns user's account by login
+func (s *UserService) Get(ctx context.Context, login string) (*lara.UserAccount, error) {
+	const q = userAccountsSQL + `
+			WHERE u.login = $1
+			GROUP BY u.id`
+
+	a, err := scanUserAccount(s.DB.QueryRowContext(ctx, q, login))
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, userNotFoundError(login)
+	case err != nil:
+		return nil, errors.Wrap(err, "get user by login failed")
+	}
+
+	return a, nil
+}
+
+// Disable prevents user from logging in
+func (s *UserService) Disable(ctx context.Context, login string) error {
+	if u, ok := lara.UserFromContext(ctx); ok && u.Login == login {
+		return lara.NewCodedError(400, errors.New("users can't disable themselves"))
+	}
+
+	return s.setDisabled(ctx, login, true)
+}
+
+// Enable allows disabled user to log in again
+func (s *UserService) Enable(ctx context.Context, login string) error {
+	return s.setDisabled(ctx, login, false)
+}
+
+func (s *UserService) setDisabled(ctx context.Context, login string, disabled bool) error {
+	r, err := s.DB.ExecContext(ctx, `UPDATE "user" SET disabled = $1 WHERE login = $2`,
+		disabled, login)
+	if err != nil {
+		return errors.Wrap(err, "update user failed")
+	}
+
+	return checkUpdatedUser(r, login)
+}
+
+// ResetPassword sets user's new password
+func (s *UserService) ResetPassword(ctx context.Context, login, password string) error {
+	if len(password) == 0 {
+		return requiredFieldError("password")
+	}
+
+	hash, salt, err := s.Pass.Create(password)
+	if err != nil {
+		return err
+	}
+
+	r, err := s.DB.ExecContext(ctx, `UPDATE "user" SET pass_salt = $1, pass_hash = $2 WHERE login = $3`,
+		salt, hash, login)
+	if err != nil {
+		return errors.Wrap(err, "update user's password failed")
+	}
+
+	return checkUpdatedUser(r, login)
+}
+
+func checkUpdatedUser(r sql.Result, login string) error {
+	count, err := r.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "update user can't check updated rows")
+	}
+
+	if count != 1 {
+		return userNotFoundError(login)
+	}
+
+	return nil
+}
+
+func userNotFoundError(login string) error {
+	return lara.NewCodedError(404, errors.Errorf("user %s not found", login))
+}
@@ -0,0 +1,357 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jkusniar/lara"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// VaccinationService is lara.VaccinationService implementation backed by postgresql
+type VaccinationService struct {
+	DB  *sql.DB
+	Loc *time.Location
+}
+
+type vaccinationDTO struct {
+	versionedDTO
+	creatorDTO
+	modifierDTO
+	PatientID    uint64
+	ProductID    uint64
+	Vaccine      string
+	Batch        sql.NullString
+	Administered time.Time
+	ValidMonths  uint
+	Due          pq.NullTime
+	Vet          sql.NullString
+	Note         sql.NullString
+}
+
+func (v *vaccinationDTO) toGetVaccination() *lara.GetVaccination {
+	return &lara.GetVaccination{
+		Versioned: lara.Versioned{
+			ID:      v.ID,
+			Version: v.Version},
+		CreatorModifier: lara.CreatorModifier{
+			Creator:  v.Creator,
+			Created:  v.Created,
+			Modifier: v.Modifier.String,
+			Modified: v.Modified.Time},
+		Vaccination: lara.Vaccination{
+			ProductID:    v.ProductID,
+			Batch:        v.Batch.String,
+			Administered: v.Administered,
+			ValidMonths:  v.ValidMonths,
+			Vet:          v.Vet.String,
+			Note:         v.Note.String},
+		PatientID: v.PatientID,
+		Vaccine:   v.Vaccine,
+		Due:       v.Due.Time,
+	}
+}
+
+// dueDate computes next vaccination date. Vaccination without validity period
+// is never due.
+func dueDate(administered time.Time, validMonths uint) pq.NullTime {
+	if validMonths == 0 {
+		return pq.NullTime{}
+	}
+
+	return pq.NullTime{Time: administered.AddDate(0, int(validMonths), 0), Valid: true}
+}
+
+// Get is implementation of VaccinationService.Get using postgresql database.
+func (s *VaccinationService) Get(ctx context.Context, id uint64) (*lara.GetVaccination, error) {
+	const q = `SELECT
+			  v.id,
+			  v.version,
+			  v.creator,
+			  v.created,
+			  v.modifier,
+			  v.modified,
+			  v.patient_id,
+			  v.prod_id,
+			  p.name AS vaccine,
+			  v.batch,
+			  v.administered,
+			  v.valid_months,
+			  v.due_date,
+			  v.vet,
+			  v.note
+			FROM vaccination v
+			  JOIN lov_product p ON p.id = v.prod_id
+			WHERE v.id = $1`
+
+	var v vaccinationDTO
+	err := s.DB.QueryRowContext(ctx, q, id).Scan(
+		&v.ID,
+		&v.Version,
+		&v.Creator,
+		&v.Created,
+		&v.Modifier,
+		&v.Modified,
+		&v.PatientID,
+		&v.ProductID,
+		&v.Vaccine,
+		&v.Batch,
+		&v.Administered,
+		&v.ValidMonths,
+		&v.Due,
+		&v.Vet,
+		&v.Note)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, notFoundByIDError(id)
+	case err != nil:
+		return nil, errors.Wrap(err, "get vaccination by id failed")
+	}
+
+	return v.toGetVaccination(), nil
+}
+
+func validateVaccination(v *lara.Vaccination) error {
+	if v.ProductID == 0 {
+		return requiredFieldError("productId")
+	}
+
+	if v.Administered.IsZero() {
+		return requiredFieldError("administered")
+	}
+
+	return nil
+}
+
+// Create is implementation of VaccinationService.Create using postgresql database.
+func (s *VaccinationService) Create(ctx context.Context, v *lara.CreateVaccination) (uint64, error) {
+	if v.PatientID == 0 {
+		return 0, requiredFieldError("patientId")
+	}
+
+	if err := validateVaccination(&v.Vaccination); err != nil {
+		return 0, err
+	}
+
+	var id uint64
+	err := execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		const insert = `INSERT INTO vaccination (patient_id, prod_id, batch, administered, valid_months, due_date,
+				vet, note, creator, created)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`
+
+		u, ok := lara.UserFromContext(ctx)
+		if !ok {
+			return errors.New("no user in context")
+		}
+
+		administered := v.Administered.In(s.Loc)
+		err := tx.QueryRowContext(ctx, insert,
+			toNullFK(v.PatientID),
+			toNullFK(v.ProductID),
+			toNullString(v.Batch),
+			administered,
+			v.ValidMonths,
+			dueDate(administered, v.ValidMonths),
+			toNullString(v.Vet),
+			toNullString(v.Note),
+			toNullString(u.Login),
+			now()).Scan(&id)
+
+		return errors.Wrap(err, "create vaccination failed")
+	})
+
+	return id, err
+}
+
+// Update is implementation of VaccinationService.Update using postgresql database.
+func (s *VaccinationService) Update(ctx context.Context, id uint64, v *lara.UpdateVaccination) error {
+	if err := validateVaccination(&v.Vaccination); err != nil {
+		return err
+	}
+
+	err := execInTransaction(ctx, s.DB, func(tx *sql.Tx) error {
+		const lck = `SELECT id FROM vaccination WHERE id = $1 FOR UPDATE`
+		const upd = `UPDATE vaccination
+				SET prod_id    = $1,
+				  batch        = $2,
+				  administered = $3,
+				  valid_months = $4,
+				  due_date     = $5,
+				  vet          = $6,
+				  note         = $7,
+				  modifier     = $8,
+				  modified     = $9,
+				  version      = version + 1
+				WHERE id = $10 AND version = $11`
+
+		var vid uint64
+		err := tx.QueryRowContext(ctx, lck, id).Scan(&vid)
+		switch err {
+		case nil: // continue
+		case sql.ErrNoRows:
+			return notFoundByIDError(id)
+		default:
+			return errors.Wrap(err, "error selecting vaccination by id")
+		}
+
+		u, ok := lara.UserFromContext(ctx)
+		if !ok {
+			return errors.New("no user in context")
+		}
+
+		administered := v.Administered.In(s.Loc)
+		r, err := tx.ExecContext(ctx, upd,
+			toNullFK(v.ProductID),
+			toNullString(v.Batch),
+			administered,
+			v.ValidMonths,
+			dueDate(administered, v.ValidMonths),
+			toNullString(v.Vet),
+			toNullString(v.Note),
+			toNullString(u.Login),
+			now(),
+			id,
+			v.Version)
+		if err != nil {
+			return errors.Wrap(err, "update vaccination failed")
+		}
+
+		count, err := r.RowsAffected()
+		if err != nil {
+			return errors.Wrap(err, "update vaccination can't check updated rows")
+		}
+
+		if count != 1 {
+			return versionMismatchError(id)
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+type dueVaccinationDTO struct {
+	VaccinationID uint64
+	Vaccine       string
+	Administered  time.Time
+	Due           time.Time
+	PatientID     uint64
+	PatientName   string
+	Species       sql.NullString
+	OwnerID       uint64
+	OwnerNameDTO
+	OwnerAddressDTO
+	Phone1 sql.NullString
+	Phone2 sql.NullString
+	Email  sql.NullString
+}
+
+func (d *dueVaccinationDTO) toDueVaccination() *lara.DueVaccination {
+	return &lara.DueVaccination{
+		VaccinationID: d.VaccinationID,
+		Vaccine:       d.Vaccine,
+		Administered:  d.Administered,
+		Due:           d.Due,
+		PatientID:     d.PatientID,
+		PatientName:   d.PatientName,
+		Species:       d.Species.String,
+		OwnerID:       d.OwnerID,
+		OwnerName:     d.OwnerNameDTO.String(),
+		OwnerAddress:  d.OwnerAddressDTO.String(),
+		Phone1:        d.Phone1.String,
+		Phone2:        d.Phone2.String,
+		Email:         d.Email.String,
+	}
+}
+
+// GetDue is implementation of VaccinationService.GetDue using postgresql database.
+// Only the latest vaccination by the same vaccine is considered for each patient,
+// so patients already revaccinated are not listed.
+func (s *VaccinationService) GetDue(ctx context.Context, r *lara.ReportRequest) (*lara.DueVaccinationList, error) {
+	const q = `SELECT
+			  v.id,
+			  pr.name  AS vaccine,
+			  v.administered,
+			  v.due_date,
+			  p.id,
+			  p.name,
+			  sp.name  AS species,
+			  o.id     AS ownerId,
+			  o.first_name,
+			  o.last_name,
+			  l.name   AS title,
+			  c.city   AS city,
+			  s.street AS street,
+			  o.house_no,
+			  o.phone_1,
+			  o.phone_2,
+			  o.email
+			FROM (SELECT DISTINCT ON (patient_id, prod_id) *
+			      FROM vaccination
+			      ORDER BY patient_id, prod_id, administered DESC, id DESC) v
+			  JOIN lov_product pr ON pr.id = v.prod_id
+			  JOIN patient p ON p.id = v.patient_id
+			  JOIN owner o ON o.id = p.owner_id
+			  LEFT JOIN lov_title l ON l.id = o.title_id
+			  LEFT JOIN lov_city c ON c.id = o.city_id
+			  LEFT JOIN lov_street s ON s.id = o.street_id
+			  LEFT JOIN lov_species sp ON sp.id = p.species_id
+			WHERE v.due_date >= $1 AND v.due_date <= $2 AND NOT p.dead
+			ORDER BY v.due_date, o.last_name, p.name`
+
+	rows, err := s.DB.QueryContext(ctx, q, r.ValidFrom.In(s.Loc), r.ValidTo.In(s.Loc))
+	if err != nil {
+		return nil, errors.Wrap(err, "get due vaccinations query error")
+	}
+	defer rows.Close()
+
+	result := lara.DueVaccinationList{Items: []lara.DueVaccination{}}
+	for rows.Next() {
+		var d dueVaccinationDTO
+		if err := rows.Scan(&d.VaccinationID,
+			&d.Vaccine,
+			&d.Administered,
+			&d.Due,
+			&d.PatientID,
+			&d.PatientName,
+			&d.Species,
+			&d.OwnerID,
+			&d.FirstName,
+			&d.LastName,
+			&d.Title,
+			&d.City,
+			&d.Street,
+			&d.HouseNo,
+			&d.Phone1,
+			&d.Phone2,
+			&d.Email); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		result.Items = append(result.Items, *d.toDueVaccination())
+	}
+	err = rows.Err()
+
+	return &result, errors.Wrap(err, "rows processing errror")
+}
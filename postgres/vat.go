@@ -0,0 +1,87 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jkusniar/lara"
+	"github.com/pkg/errors"
+)
+
+// Prices are stored including VAT. Net amount is rounded for each item,
+// VAT is the remainder, so net + VAT always equals the stored gross price.
+
+// netSQL returns SQL expression computing net amount from gross price
+// and VAT rate (percent) columns
+func netSQL(price, rate string) string {
+	return fmt.Sprintf("round(%s / (1 + %s / 100), 2)", price, rate)
+}
+
+// vatSQL returns SQL expression computing VAT from gross price and
+// VAT rate (percent) columns
+func vatSQL(price, rate string) string {
+	return fmt.Sprintf("(%s - %s)", price, netSQL(price, rate))
+}
+
+func validateVATRate(rate, field string) error {
+	if rate == "" {
+		return nil
+	}
+
+	// vat_rate is numeric(4,2), rates over 99.99 don't fit
+	if r, ok := parseNumeric(rate, 4, 2); !ok || r < 0 {
+		return lara.NewCodedError(400,
+			errors.Errorf("%s '%s' is not valid VAT rate", field, rate))
+	}
+
+	return nil
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// getVATBreakdown executes query returning rate, net, VAT and gross
+// columns, one row per rate
+func getVATBreakdown(ctx context.Context, db queryer, query string, args ...interface{}) ([]lara.VATBreakdown, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "VAT breakdown query error")
+	}
+	defer rows.Close()
+
+	result := []lara.VATBreakdown{}
+	for rows.Next() {
+		var b lara.VATBreakdown
+		if err := rows.Scan(&b.Rate,
+			&b.Net,
+			&b.VAT,
+			&b.Gross); err != nil {
+			return nil, errors.Wrap(err, "scan DTO error")
+		}
+		result = append(result, b)
+	}
+	err = rows.Err()
+
+	return result, errors.Wrap(err, "rows processing errror")
+}
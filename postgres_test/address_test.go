@@ -19,10 +19,14 @@
 
 package postgres_test
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/jkusniar/lara"
+)
 
 func TestSearchCityFound(t *testing.T) {
-	c, err := addressService.SearchCity(testCtx, "ci")
+	c, err := addressService.SearchCity(testCtx, &lara.AddressSearchRequest{Query: "ci"})
 
 	if err != nil {
 		t.Fatalf("expected nil error, but was %+v", err)
@@ -50,7 +54,7 @@ func TestSearchCityFound(t *testing.T) {
 }
 
 func TestSearchCityNotFound(t *testing.T) {
-	c, err := addressService.SearchCity(testCtx, "xxxxxxx")
+	c, err := addressService.SearchCity(testCtx, &lara.AddressSearchRequest{Query: "xxxxxxx"})
 
 	if err != nil {
 		t.Fatalf("expected nil error, but was %+v", err)
@@ -74,7 +78,7 @@ func TestSearchCityNotFound(t *testing.T) {
 }
 
 func TestSearchStreetFound(t *testing.T) {
-	s, err := addressService.SearchStreetForCity(testCtx, 1, "ee")
+	s, err := addressService.SearchStreetForCity(testCtx, 1, &lara.AddressSearchRequest{Query: "ee"})
 
 	if err != nil {
 		t.Fatalf("expected nil error, but was %+v", err)
@@ -102,7 +106,7 @@ func TestSearchStreetFound(t *testing.T) {
 }
 
 func TestSearchStreetNotFound(t *testing.T) {
-	s, err := addressService.SearchStreetForCity(testCtx, 1, "xxx")
+	s, err := addressService.SearchStreetForCity(testCtx, 1, &lara.AddressSearchRequest{Query: "xxx"})
 
 	if err != nil {
 		t.Fatalf("expected nil error, but was %+v", err)
@@ -124,3 +128,38 @@ func TestSearchStreetNotFound(t *testing.T) {
 		t.Fatalf("expected Items length 0 but was %d", len(s.Items))
 	}
 }
+
+func TestSearchCitySortedByZIP(t *testing.T) {
+	c, err := addressService.SearchCity(testCtx, &lara.AddressSearchRequest{Query: "test city",
+		Page: lara.Page{Sort: "zip"}})
+
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	if c.Total != 2 || len(c.Items) != 2 || c.Items[0].ZIP != "11100" {
+		t.Fatalf("expected 2 cities, lowest ZIP first but was %+v", c)
+	}
+}
+
+func TestSearchStreetPaged(t *testing.T) {
+	s, err := addressService.SearchStreetForCity(testCtx, 1, &lara.AddressSearchRequest{Query: "street",
+		Page: lara.Page{Offset: 1, Limit: 1, Sort: "-name"}})
+
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	if s.Total != 2 || len(s.Items) != 1 || s.Items[0].Name != "another street" {
+		t.Fatalf("expected second street by name descending but was %+v", s)
+	}
+}
+
+func TestSearchCityBadLimit(t *testing.T) {
+	_, err := addressService.SearchCity(testCtx, &lara.AddressSearchRequest{Query: "test",
+		Page: lara.Page{Limit: lara.MaxPageLimit + 1}})
+
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d", actual)
+	}
+}
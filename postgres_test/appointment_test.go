@@ -0,0 +1,295 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jkusniar/lara"
+)
+
+func appointmentTime(day, hour, min int) time.Time {
+	loc, _ := time.LoadLocation("Europe/Bratislava")
+	return time.Date(2017, time.May, day, hour, min, 0, 0, loc)
+}
+
+func TestGetAppointment(t *testing.T) {
+	// get not existing
+	_, err := appointmentService.Get(testCtx, 100)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+
+	// get OK
+	a, err := appointmentService.Get(testCtx, 1)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if a == nil {
+		t.Fatal("expected not nil result")
+	}
+
+	if a.ID != 1 || a.Vet != "vet1" || a.Room != "A" || a.PatientID != 4 ||
+		a.Patient != "scheduled-pet" || a.OwnerID != 7 || a.OwnerName != "Test Scheduling" ||
+		a.Status != lara.Scheduled || a.RecordID != 0 {
+		t.Fatalf("unexpected result %+v", a)
+	}
+}
+
+func TestCreateAppointment(t *testing.T) {
+	x := &lara.CreateAppointment{}
+	var err error
+
+	// patient missing
+	if _, err = appointmentService.Create(testCtx, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// vet missing
+	x.PatientID = 4
+	if _, err = appointmentService.Create(testCtx, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// ends before start
+	x.Vet = "vet1"
+	x.Start = appointmentTime(1, 10, 15)
+	x.End = appointmentTime(1, 10, 0)
+	if _, err = appointmentService.Create(testCtx, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// vet conflict
+	x.End = appointmentTime(1, 10, 45)
+	if _, err = appointmentService.Create(testCtx, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	// room conflict
+	x.Vet = "vet3"
+	x.Room = "A"
+	if _, err = appointmentService.Create(testCtx, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	// OK - cancelled appointment doesn't block time slot
+	x.Vet = "vet2"
+	x.Room = ""
+	x.Start = appointmentTime(2, 9, 0)
+	x.End = appointmentTime(2, 9, 30)
+	id, err := appointmentService.Create(testCtx, x)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if id == 0 {
+		t.Fatal("incorrect ID returned")
+	}
+
+	// OK - adjacent appointment
+	x.Vet = "vet1"
+	x.Start = appointmentTime(1, 10, 30)
+	x.End = appointmentTime(1, 11, 0)
+	if _, err = appointmentService.Create(testCtx, x); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+}
+
+func TestRescheduleAppointment(t *testing.T) {
+	x := &lara.RescheduleAppointment{Version: 1,
+		Appointment: lara.Appointment{Vet: "vet2",
+			Start: appointmentTime(3, 9, 15),
+			End:   appointmentTime(3, 9, 45)}}
+	var err error
+
+	// bad ID
+	if err = appointmentService.Reschedule(testCtx, 100, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+
+	// cancelled
+	if err = appointmentService.Reschedule(testCtx, 3, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	// conflict with appointment 4
+	if err = appointmentService.Reschedule(testCtx, 5, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	// version mismatch
+	x.Start = appointmentTime(4, 10, 0)
+	x.End = appointmentTime(4, 10, 30)
+	if err = appointmentService.Reschedule(testCtx, 5, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	// OK, overlapping itself
+	x.Version = 0
+	x.Start = appointmentTime(4, 9, 15)
+	x.End = appointmentTime(4, 9, 45)
+	if err = appointmentService.Reschedule(testCtx, 5, x); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+}
+
+func TestCancelAppointment(t *testing.T) {
+	x := &lara.CancelAppointment{Version: 1, Reason: "owner called"}
+	var err error
+
+	// version mismatch
+	if err = appointmentService.Cancel(testCtx, 2, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	// OK
+	x.Version = 0
+	if err = appointmentService.Cancel(testCtx, 2, x); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	// already cancelled
+	x.Version = 1
+	if err = appointmentService.Cancel(testCtx, 2, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+}
+
+func TestCheckInAppointment(t *testing.T) {
+	x := &lara.CheckInAppointment{Version: 0,
+		NewRecord: lara.NewRecord{Text: "checked in"}}
+
+	recID, err := appointmentService.CheckIn(testCtx, 4, x)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if recID == 0 {
+		t.Fatal("incorrect record ID returned")
+	}
+
+	a, err := appointmentService.Get(testCtx, 4)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if a.Status != lara.CheckedIn || a.RecordID != recID {
+		t.Fatalf("unexpected result %+v", a)
+	}
+
+	r, err := recordService.Get(testCtx, recID)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if r.Text != "checked in" {
+		t.Fatalf("unexpected record %+v", r)
+	}
+
+	// already checked in
+	x.Version = 1
+	if _, err = appointmentService.CheckIn(testCtx, 4, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+}
+
+func TestListAppointments(t *testing.T) {
+	l, err := appointmentService.ListByDay(testCtx, appointmentTime(1, 0, 0))
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if l == nil || l.Items == nil {
+		t.Fatal("expected not nil result")
+	}
+	if len(l.Items) < 2 || l.Items[0].ID != 1 {
+		t.Fatalf("unexpected result %+v", l)
+	}
+
+	l, err = appointmentService.ListByDay(testCtx, appointmentTime(20, 0, 0))
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(l.Items) != 0 {
+		t.Fatalf("expected empty result, but was %+v", l)
+	}
+
+	// vet missing
+	if _, err = appointmentService.ListByVet(testCtx, "", appointmentTime(1, 0, 0),
+		appointmentTime(5, 0, 0)); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// to before from
+	if _, err = appointmentService.ListByVet(testCtx, "vet2", appointmentTime(4, 0, 0),
+		appointmentTime(3, 0, 0)); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	l, err = appointmentService.ListByVet(testCtx, "vet2", appointmentTime(3, 0, 0),
+		appointmentTime(4, 0, 0))
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(l.Items) != 2 {
+		t.Fatalf("expected 2 appointments, but was %+v", l)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/jkusniar/lara"
+)
+
+func TestCreateAttachment(t *testing.T) {
+	content := "%PDF-1.4 lab results"
+	sum := sha256.Sum256([]byte(content))
+
+	// record's attachment belongs to record's patient
+	id, err := attachmentService.Create(testCtx, &lara.NewAttachment{RecordID: 1,
+		FileName: `C:\lab\results.pdf`, Content: strings.NewReader(content)})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	a, r, err := attachmentService.Open(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != content {
+		t.Fatalf("unexpected content %s", b)
+	}
+	if a.PatientID != 1 || a.RecordID != 1 || a.FileName != "results.pdf" ||
+		a.ContentType != "application/pdf" || a.Size != int64(len(content)) ||
+		a.Checksum != hex.EncodeToString(sum[:]) || a.Creator != "testuser" {
+		t.Fatalf("unexpected attachment %+v", a)
+	}
+
+	// patient's attachment, DICOM image
+	dicom := append(make([]byte, 128), []byte("DICM xray")...)
+	_, err = attachmentService.Create(testCtx, &lara.NewAttachment{PatientID: 1,
+		FileName: "xray.dcm", Content: bytes.NewReader(dicom)})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	l, err := attachmentService.ListByRecord(testCtx, 1)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(l.Items) != 1 || l.Items[0].ID != id {
+		t.Fatalf("unexpected result %+v", l)
+	}
+
+	l, err = attachmentService.ListByPatient(testCtx, 1)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(l.Items) != 2 || l.Items[0].ContentType != "application/dicom" || l.Items[0].RecordID != 0 {
+		t.Fatalf("unexpected result %+v", l)
+	}
+
+	stored := attachmentStore.Len()
+	for _, c := range []struct {
+		a    lara.NewAttachment
+		code int
+	}{
+		{lara.NewAttachment{RecordID: 10000, FileName: "a.pdf", Content: strings.NewReader(content)}, 404},
+		{lara.NewAttachment{PatientID: 10000, FileName: "a.pdf", Content: strings.NewReader(content)}, 404},
+		{lara.NewAttachment{FileName: "a.pdf", Content: strings.NewReader(content)}, 400},
+		{lara.NewAttachment{PatientID: 1, Content: strings.NewReader(content)}, 400},
+		{lara.NewAttachment{PatientID: 1, FileName: "empty.pdf", Content: strings.NewReader("")}, 400},
+		{lara.NewAttachment{PatientID: 1, FileName: "a.exe", Content: strings.NewReader("MZ\x90\x00\x03\x00\x00\x00")}, 415},
+		{lara.NewAttachment{PatientID: 1, FileName: "big.pdf",
+			Content: strings.NewReader("%PDF-1.4 " + strings.Repeat("x", 2048))}, 413},
+	} {
+		_, err = attachmentService.Create(testCtx, &c.a)
+		if ok, actual := checkErrCode(err, c.code); !ok {
+			t.Fatalf("expected error code %d for %s but was %d, %+v", c.code, c.a.FileName, actual, err)
+		}
+	}
+
+	// rejected content is not kept in store
+	if attachmentStore.Len() != stored {
+		t.Fatalf("expected %d stored blobs but was %d", stored, attachmentStore.Len())
+	}
+
+	_, _, err = attachmentService.Open(testCtx, 10000)
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+}
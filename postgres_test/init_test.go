@@ -31,6 +31,7 @@ import (
 	"time"
 
 	"github.com/jkusniar/lara"
+	"github.com/jkusniar/lara/blob"
 	"github.com/jkusniar/lara/postgres"
 	_ "github.com/lib/pq"
 	"github.com/pkg/errors"
@@ -69,21 +70,33 @@ func runSql(db *sql.DB, filename string) error {
 }
 
 var (
-	ownerService   lara.OwnerService
-	patientService lara.PatientService
-	recordService  lara.RecordService
-	searchService  lara.SearchService
-	userService    lara.UserService
-	productService lara.ProductService
-	reportService  lara.ReportService
-	titleService   lara.TitleService
-	unitService    lara.UnitService
-	genderService  lara.GenderService
-	speciesService lara.SpeciesService
-	breedService   lara.BreedService
-	addressService lara.AddressService
-	tagService     lara.TagService
-	testCtx        context.Context
+	ownerService         lara.OwnerService
+	patientService       lara.PatientService
+	recordService        lara.RecordService
+	searchService        lara.SearchService
+	userService          lara.UserService
+	productService       lara.ProductService
+	reportService        lara.ReportService
+	titleService         lara.TitleService
+	unitService          lara.UnitService
+	genderService        lara.GenderService
+	speciesService       lara.SpeciesService
+	diagnosisService     lara.DiagnosisService
+	phraseService        lara.PhraseService
+	attachmentService    lara.AttachmentService
+	attachmentStore      *blob.Memory
+	breedService         lara.BreedService
+	addressService       lara.AddressService
+	tagService           lara.TagService
+	appointmentService   lara.AppointmentService
+	vaccinationService   lara.VaccinationService
+	outboxService        lara.Outbox
+	invoiceService       lara.InvoiceService
+	stockService         lara.StockService
+	supplierService      lara.SupplierService
+	purchaseOrderService lara.PurchaseOrderService
+	prescriptionService  lara.PrescriptionService
+	testCtx              context.Context
 )
 
 func TestMain(m *testing.M) {
@@ -126,9 +139,22 @@ func TestMain(m *testing.M) {
 	genderService = &sls
 	speciesService = &sls
 	breedService = &sls
+	diagnosisService = &sls
+	phraseService = &sls
+	attachmentStore = &blob.Memory{}
+	attachmentService = &postgres.AttachmentService{DB: db, Store: attachmentStore, MaxSize: 1024}
 	loc, _ := time.LoadLocation("Europe/Bratislava") // time.Location for unit tests
 	reportService = &postgres.ReportService{DB: db, Loc: loc}
-	tagService = &postgres.TagService{DB: db}
+	tagService = &postgres.TagService{DB: db, MaxDataSize: 16 << 10}
+	appointmentService = &postgres.AppointmentService{DB: db, Loc: loc}
+	vaccinationService = &postgres.VaccinationService{DB: db, Loc: loc}
+	outboxService = &postgres.OutboxService{DB: db}
+	invoiceService = &postgres.InvoiceService{DB: db, Loc: loc,
+		Clinic: &lara.Clinic{Name: "Test Clinic", IC: "12345678", IBAN: "SK0000000000000000000000"}}
+	stockService = &postgres.StockService{DB: db, Loc: loc}
+	supplierService = &postgres.SupplierService{DB: db}
+	purchaseOrderService = &postgres.PurchaseOrderService{DB: db}
+	prescriptionService = &postgres.PrescriptionService{DB: db}
 
 	// test user in context
 	u, _ := lara.MakeUser("testuser",
@@ -0,0 +1,153 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jkusniar/lara"
+)
+
+func TestGetInvoice(t *testing.T) {
+	// get not existing
+	_, err := invoiceService.Get(testCtx, 100)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+}
+
+func TestCreateInvoice(t *testing.T) {
+	var tests = []struct {
+		name    string
+		i       lara.CreateInvoice
+		expCode int
+	}{
+		{"OwnerMissing", lara.CreateInvoice{RecordIDs: []uint64{8}}, 400},
+		{"RecordsMissing", lara.CreateInvoice{OwnerID: 7}, 400},
+		{"DuplicateRecord", lara.CreateInvoice{OwnerID: 7, RecordIDs: []uint64{8, 8}}, 400},
+		{"OwnerNotFound", lara.CreateInvoice{OwnerID: 100, RecordIDs: []uint64{8}}, 404},
+		{"RecordNotFound", lara.CreateInvoice{OwnerID: 7, RecordIDs: []uint64{8, 1000}}, 404},
+		{"OtherOwnersRecord", lara.CreateInvoice{OwnerID: 7, RecordIDs: []uint64{8, 7}}, 400},
+		{"RecordBilled", lara.CreateInvoice{OwnerID: 7, RecordIDs: []uint64{8, 10}}, 409},
+		{"DueBeforeIssue", lara.CreateInvoice{OwnerID: 7, RecordIDs: []uint64{8},
+			DueDate: time.Now().AddDate(0, 0, -2)}, 400},
+	}
+
+	for _, tt := range tests {
+		_, err := invoiceService.Create(testCtx, &tt.i)
+		if err == nil {
+			t.Fatalf("%s: expected error", tt.name)
+		}
+		if ok, actual := checkErrCode(err, tt.expCode); !ok {
+			t.Fatalf("%s: expected error code %d but was %d, %+v", tt.name, tt.expCode, actual, err)
+		}
+	}
+
+	// OK
+	id, err := invoiceService.Create(testCtx, &lara.CreateInvoice{OwnerID: 7, RecordIDs: []uint64{8, 9}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if id == 0 {
+		t.Fatal("incorrect ID returned")
+	}
+
+	i, err := invoiceService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	if i.Number != fmt.Sprintf("%d000001", time.Now().Year()) || i.OwnerID != 7 ||
+		i.Total != "18.00" || len(i.Lines) != 3 || i.Creator != "testuser" {
+		t.Fatalf("unexpected result %+v", i)
+	}
+
+	if i.Supplier.Name != "Test Clinic" || i.Supplier.IC != "12345678" ||
+		i.Customer.Name != "Test Scheduling" || i.Customer.Address != "test street 2, test city" {
+		t.Fatalf("unexpected supplier/customer %+v, %+v", i.Supplier, i.Customer)
+	}
+
+	if l := i.Lines[0]; l.RecordID != 8 || l.ProductID != 3 || l.PLU != "42" || l.Unit != "tbl." ||
+		l.ItemPrice != "4.00" || l.ItemType != lara.Labor {
+		t.Fatalf("unexpected line %+v", l)
+	}
+
+	if i.TotalNet != "15.75" || i.TotalVAT != "2.25" {
+		t.Fatalf("unexpected net/VAT totals %s/%s", i.TotalNet, i.TotalVAT)
+	}
+
+	if len(i.VAT) != 2 ||
+		i.VAT[0].Rate != "10.00" || i.VAT[0].Net != "9.09" || i.VAT[0].VAT != "0.91" || i.VAT[0].Gross != "10.00" ||
+		i.VAT[1].Rate != "20.00" || i.VAT[1].Net != "6.66" || i.VAT[1].VAT != "1.34" || i.VAT[1].Gross != "8.00" {
+		t.Fatalf("unexpected VAT breakdown %+v", i.VAT)
+	}
+
+	if y, m, d := i.DeliveryDate.Date(); y != 2017 || m != time.May || d != 15 {
+		t.Fatalf("unexpected delivery date %v", i.DeliveryDate)
+	}
+
+	if !i.DueDate.After(i.IssueDate) {
+		t.Fatalf("unexpected due date %v", i.DueDate)
+	}
+
+	// records are billed and can't be changed
+	r, err := recordService.Get(testCtx, 8)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if !r.Billed {
+		t.Fatal("expected billed record")
+	}
+
+	err = recordService.Update(testCtx, 8, &lara.UpdateRecord{Version: r.Version, Text: "changed"})
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	// can't be invoiced twice
+	_, err = invoiceService.Create(testCtx, &lara.CreateInvoice{OwnerID: 7, RecordIDs: []uint64{9}})
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+}
+
+func TestListInvoicesByOwner(t *testing.T) {
+	l, err := invoiceService.ListByOwner(testCtx, 7)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	if len(l.Items) != 1 || l.Items[0].Total != "18.00" {
+		t.Fatalf("unexpected result %+v", l)
+	}
+
+	// no invoices
+	if l, err = invoiceService.ListByOwner(testCtx, 1); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(l.Items) != 0 {
+		t.Fatalf("expected 0 items, got %d", len(l.Items))
+	}
+}
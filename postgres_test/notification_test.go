@@ -0,0 +1,122 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/jkusniar/lara"
+)
+
+func TestOutbox(t *testing.T) {
+	m := &lara.OutboxMessage{
+		Message:  lara.Message{Channel: lara.Email, Subject: "s", Body: "b"},
+		Template: lara.VaccinationDue,
+		OwnerID:  7,
+	}
+	var err error
+
+	// recipient missing
+	if _, err = outboxService.Enqueue(testCtx, m); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// enqueue OK
+	m.Recipient = "scheduling@test.com"
+	m.PatientID = 4
+	id1, err := outboxService.Enqueue(testCtx, m)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	m.Channel = lara.SMS
+	m.Recipient = "0900111222"
+	id2, err := outboxService.Enqueue(testCtx, m)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	// get not existing
+	if _, err = outboxService.Get(testCtx, 100); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+
+	// get OK
+	n, err := outboxService.Get(testCtx, id1)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if n.Channel != lara.Email || n.Recipient != "scheduling@test.com" || n.OwnerID != 7 ||
+		n.PatientID != 4 || n.Status != lara.Pending || n.Creator != "testuser" {
+		t.Fatalf("unexpected result %+v", n)
+	}
+
+	// both pending, oldest first
+	p, err := outboxService.Pending(testCtx, 10)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(p) != 2 || p[0].ID != id1 || p[1].ID != id2 {
+		t.Fatalf("unexpected pending messages %+v", p)
+	}
+
+	// sent, retried and failed messages
+	if err = outboxService.MarkSent(testCtx, id1); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if err = outboxService.MarkFailed(testCtx, id2, "timeout", false); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if err = outboxService.MarkSent(testCtx, 100); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+
+	if p, err = outboxService.Pending(testCtx, 10); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(p) != 1 || p[0].ID != id2 || p[0].Attempts != 1 || p[0].LastError != "timeout" {
+		t.Fatalf("unexpected pending messages %+v", p)
+	}
+
+	if err = outboxService.MarkFailed(testCtx, id2, "timeout", true); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if p, err = outboxService.Pending(testCtx, 10); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(p) != 0 {
+		t.Fatalf("unexpected pending messages %+v", p)
+	}
+
+	if n, err = outboxService.Get(testCtx, id1); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if n.Status != lara.Sent || n.Sent.IsZero() {
+		t.Fatalf("unexpected result %+v", n)
+	}
+}
@@ -0,0 +1,149 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/jkusniar/lara"
+)
+
+func TestMergeOwners(t *testing.T) {
+	survivor, err := ownerService.Create(testCtx, &lara.CreateOwner{
+		Owner: lara.Owner{LastName: "Merger", Phone1: "0911 000 001",
+			Note: "survivor note"},
+		Patient: lara.NewPatient{Patient: lara.Patient{Name: "merge-pet-1"}}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	dup, err := ownerService.Create(testCtx, &lara.CreateOwner{
+		Owner: lara.Owner{FirstName: "Mária", LastName: "Merger", Phone1: "0911000001",
+			Phone2: "0911 000 002", Email: "merger@example.sk", CityID: 1,
+			StreetID: 1, HouseNo: "7", Note: "duplicate note"},
+		Patient: lara.NewPatient{Patient: lara.Patient{Name: "merge-pet-2"}}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	// duplicate's version mismatch
+	err = ownerService.Merge(testCtx, survivor, &lara.MergeOwners{
+		Duplicates: []lara.Versioned{{ID: dup, Version: 5}}})
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	// duplicate not found
+	err = ownerService.Merge(testCtx, survivor, &lara.MergeOwners{
+		Duplicates: []lara.Versioned{{ID: 100000}}})
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+
+	// survivor merged into itself
+	err = ownerService.Merge(testCtx, survivor, &lara.MergeOwners{
+		Duplicates: []lara.Versioned{{ID: survivor}}})
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// nothing to merge
+	err = ownerService.Merge(testCtx, survivor, &lara.MergeOwners{})
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	err = ownerService.Merge(testCtx, survivor, &lara.MergeOwners{
+		Duplicates: []lara.Versioned{{ID: dup}}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	o, err := ownerService.Get(testCtx, survivor)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	if o.FirstName != "Mária" || o.LastName != "Merger" || o.Phone1 != "0911000001" ||
+		o.Phone2 != "0911 000 002" || o.Email != "merger@example.sk" ||
+		o.CityID != 1 || o.StreetID != 1 || o.HouseNo != "7" ||
+		o.Note != "survivor note\nduplicate note" || o.Version != 1 {
+		t.Fatalf("unexpected merged owner %+v", o)
+	}
+
+	if len(o.Patients) != 2 {
+		t.Fatalf("expected 2 patients, but was %+v", o.Patients)
+	}
+
+	if _, err = ownerService.Get(testCtx, dup); err == nil {
+		t.Fatal("expected merged owner deleted")
+	}
+
+	// survivor of previous merge is merged into another owner
+	next, err := ownerService.Create(testCtx, &lara.CreateOwner{
+		Owner:   lara.Owner{LastName: "Merger"},
+		Patient: lara.NewPatient{Patient: lara.Patient{Name: "merge-pet-3"}}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	err = ownerService.Merge(testCtx, next, &lara.MergeOwners{
+		Duplicates: []lara.Versioned{{ID: survivor, Version: o.Version}}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	o, err = ownerService.Get(testCtx, next)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(o.Patients) != 3 {
+		t.Fatalf("expected 3 patients, but was %+v", o.Patients)
+	}
+}
+
+func TestDuplicateOwners(t *testing.T) {
+	d, err := ownerService.Duplicates(testCtx, lara.Page{Limit: lara.MaxPageLimit})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	if d.Total == 0 || len(d.Items) == 0 {
+		t.Fatalf("expected duplicates, but was %+v", d)
+	}
+
+	for i := 1; i < len(d.Items); i++ {
+		if d.Items[i-1].Score < d.Items[i].Score {
+			t.Fatalf("expected most similar first, but was %+v", d.Items)
+		}
+	}
+
+	// GetOwner and CreateTag owners share phones and address
+	for _, i := range d.Items {
+		if i.Owner1.ID == 5 && i.Owner2.ID == 6 {
+			if i.Score != 3 || len(i.Reasons) != 2 || i.Reasons[0] != "phone" ||
+				i.Reasons[1] != "address" {
+				t.Fatalf("unexpected duplicate %+v", i)
+			}
+			return
+		}
+	}
+	t.Fatalf("expected owners 5 and 6 as duplicates, but was %+v", d.Items)
+}
@@ -47,7 +47,8 @@ func TestGetPatient(t *testing.T) {
 	if p == nil {
 		t.Fatal("expected not nil result")
 	}
-	if p.Name != "test-pet" || len(p.Records) != 7 || len(p.Tags) != 1 {
+	if p.Name != "test-pet" || p.OwnerID != 1 || len(p.Records) != 7 || len(p.Tags) != 1 ||
+		!p.Tags[0].Active {
 		t.Fatalf("unexpected result %+v", p)
 	}
 }
@@ -111,3 +112,94 @@ func TestUpdatePatient(t *testing.T) {
 		t.Fatalf("expected nil error, but was %+v", err)
 	}
 }
+
+func TestTransferPatient(t *testing.T) {
+	from, err := ownerService.Create(testCtx, &lara.CreateOwner{
+		Owner:   lara.Owner{LastName: "Seller"},
+		Patient: lara.NewPatient{Patient: lara.Patient{Name: "sold-pet"}}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	o, err := ownerService.Get(testCtx, from)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	id := o.Patients[0].ID
+
+	to, err := ownerService.Create(testCtx, &lara.CreateOwner{
+		Owner:   lara.Owner{FirstName: "New", LastName: "Buyer"},
+		Patient: lara.NewPatient{Patient: lara.Patient{Name: "buyers-pet"}}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	if _, err = tagService.Create(testCtx, &lara.CreateTag{PatientID: id,
+		Type: "Tattoo", Value: "TRANSFER-0001"}); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	// owner missing
+	err = patientService.Transfer(testCtx, id, &lara.TransferPatient{})
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// same owner
+	err = patientService.Transfer(testCtx, id, &lara.TransferPatient{OwnerID: from})
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// not existing owner
+	err = patientService.Transfer(testCtx, id, &lara.TransferPatient{OwnerID: 100000})
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// future date
+	err = patientService.Transfer(testCtx, id, &lara.TransferPatient{OwnerID: to,
+		Date: time.Now().AddDate(0, 0, 2)})
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// not existing patient
+	err = patientService.Transfer(testCtx, 100000, &lara.TransferPatient{OwnerID: to})
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+
+	// version mismatch
+	err = patientService.Transfer(testCtx, id, &lara.TransferPatient{Version: 3, OwnerID: to})
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	err = patientService.Transfer(testCtx, id, &lara.TransferPatient{OwnerID: to,
+		Reason: "sold"})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	p, err := patientService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if p.OwnerID != to || p.Version != 1 || len(p.Transfers) != 1 {
+		t.Fatalf("unexpected result %+v", p)
+	}
+	if tr := p.Transfers[0]; tr.FromOwnerID != from || tr.FromOwner != "Seller" ||
+		tr.ToOwnerID != to || tr.ToOwner != "New Buyer" || tr.Reason != "sold" ||
+		tr.Creator != "testuser" {
+		t.Fatalf("unexpected transfer %+v", tr)
+	}
+
+	// tag resolves to current owner
+	pt, err := tagService.GetPatientByTag(testCtx, "TRANSFER-0001")
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if pt.OwnerID != to {
+		t.Fatalf("expected patient of owner %d, but was %+v", to, pt)
+	}
+}
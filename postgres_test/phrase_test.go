@@ -0,0 +1,148 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jkusniar/lara"
+)
+
+func TestSearchPhrases(t *testing.T) {
+	// other user's personal phrase is not listed
+	l, err := phraseService.SearchPhrases(testCtx, "")
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	for _, p := range l.Items {
+		if p.ID == 2 {
+			t.Fatalf("unexpected other user's phrase %+v", p)
+		}
+	}
+
+	// search by text
+	l, err = phraseService.SearchPhrases(testCtx, "MY OWN")
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(l.Items) != 1 || l.Items[0].ID != 3 || !l.Items[0].Personal || l.Items[0].Author != "testuser" {
+		t.Fatalf("unexpected result %+v", l)
+	}
+}
+
+func TestGetPhrase(t *testing.T) {
+	p, err := phraseService.GetPhrase(testCtx, 1)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if p.Name != "exam" || p.Personal || p.Author != "" {
+		t.Fatalf("unexpected result %+v", p)
+	}
+
+	// other user's personal phrase
+	_, err = phraseService.GetPhrase(testCtx, 2)
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+}
+
+func TestCreateUpdatePhrase(t *testing.T) {
+	// personal phrase may share name with other user's phrase
+	id, err := phraseService.CreatePhrase(testCtx, &lara.Phrase{Name: "exam", Text: "quick exam", Personal: true})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	// clinic-wide name taken
+	_, err = phraseService.CreatePhrase(testCtx, &lara.Phrase{Name: "exam", Text: "duplicate"})
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	// name required
+	_, err = phraseService.CreatePhrase(testCtx, &lara.Phrase{Text: "no name"})
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// update OK, made clinic-wide
+	err = phraseService.UpdatePhrase(testCtx, id, &lara.UpdatePhrase{Version: 0,
+		Phrase: lara.Phrase{Name: "quick exam", Text: "quick exam of {patient}"}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	p, err := phraseService.GetPhrase(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if p.Name != "quick exam" || p.Personal || p.Version != 1 {
+		t.Fatalf("unexpected result %+v", p)
+	}
+
+	// clinic-wide phrase can't be taken over
+	err = phraseService.UpdatePhrase(testCtx, id, &lara.UpdatePhrase{Version: 1,
+		Phrase: lara.Phrase{Name: "quick exam", Personal: true}})
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// update without version upgrade
+	err = phraseService.UpdatePhrase(testCtx, id, &lara.UpdatePhrase{Version: 0,
+		Phrase: lara.Phrase{Name: "quick exam"}})
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	// other user's personal phrase
+	err = phraseService.UpdatePhrase(testCtx, 2, &lara.UpdatePhrase{Version: 0,
+		Phrase: lara.Phrase{Name: "hijacked"}})
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+}
+
+func TestExpandPhrase(t *testing.T) {
+	e, err := phraseService.ExpandPhrase(testCtx, 1, 2)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	today := time.Now().Format("02.01.2006")
+	if !strings.HasPrefix(e.Text, "get-owner-pet (dog, german shepard) examined on "+today+", owner ") ||
+		!strings.Contains(e.Text, "GetOwner") || !strings.HasSuffix(e.Text, "{unknown}") {
+		t.Fatalf("unexpected result %s", e.Text)
+	}
+
+	// without patient only date is expanded
+	e, err = phraseService.ExpandPhrase(testCtx, 1, 0)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if !strings.HasPrefix(e.Text, "{patient} ({species}, {breed}) examined on "+today) {
+		t.Fatalf("unexpected result %s", e.Text)
+	}
+
+	// unknown patient
+	_, err = phraseService.ExpandPhrase(testCtx, 1, 100000)
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+}
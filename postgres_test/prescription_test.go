@@ -0,0 +1,176 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jkusniar/lara"
+)
+
+func TestGetPrescription(t *testing.T) {
+	p, err := prescriptionService.Get(testCtx, 1)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if p.RecordID != 11 || p.PatientID != 5 || p.Note != "sedation" || p.Creator != "testuser" ||
+		len(p.Items) != 1 {
+		t.Fatalf("unexpected result %+v", p)
+	}
+	if i := p.Items[0]; i.ProductID != 7 || i.Product != "Ketamín 10%" || i.Unit != "ml." ||
+		!i.Controlled || i.Amount != "2.0000" || i.Dose != "1 ml" || i.Frequency != "once" ||
+		i.WithdrawalDays != 28 {
+		t.Fatalf("unexpected item %+v", i)
+	}
+	if y, m, d := p.WithdrawalEnd.Date(); y != 2017 || m != time.April || d != 7 {
+		t.Fatalf("unexpected withdrawal end %s", p.WithdrawalEnd)
+	}
+
+	// no withdrawal period
+	if p, err = prescriptionService.Get(testCtx, 2); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if !p.WithdrawalEnd.IsZero() || !p.Items[0].WithdrawalEnd.IsZero() {
+		t.Fatalf("unexpected withdrawal end %+v", p)
+	}
+
+	_, err = prescriptionService.Get(testCtx, 10000)
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+}
+
+func TestCreateUpdatePrescription(t *testing.T) {
+	item := lara.PrescriptionItem{ProductID: 4, Amount: "10", Dose: "1 tbl.", Frequency: "twice a day",
+		DurationDays: 5, WithdrawalDays: 3}
+
+	// validation
+	tests := []struct {
+		name    string
+		p       lara.CreatePrescription
+		expCode int
+	}{
+		{"no record", lara.CreatePrescription{Prescription: lara.Prescription{
+			Items: []lara.PrescriptionItem{item}}}, 400},
+		{"no items", lara.CreatePrescription{RecordID: 1}, 400},
+		{"no product", lara.CreatePrescription{RecordID: 1, Prescription: lara.Prescription{
+			Items: []lara.PrescriptionItem{{Amount: "1", Dose: "1 tbl."}}}}, 400},
+		{"bad amount", lara.CreatePrescription{RecordID: 1, Prescription: lara.Prescription{
+			Items: []lara.PrescriptionItem{{ProductID: 4, Amount: "-1", Dose: "1 tbl."}}}}, 400},
+		{"no dose", lara.CreatePrescription{RecordID: 1, Prescription: lara.Prescription{
+			Items: []lara.PrescriptionItem{{ProductID: 4, Amount: "1"}}}}, 400},
+		{"unknown record", lara.CreatePrescription{RecordID: 10000, Prescription: lara.Prescription{
+			Items: []lara.PrescriptionItem{item}}}, 400},
+		{"unknown product", lara.CreatePrescription{RecordID: 1, Prescription: lara.Prescription{
+			Items: []lara.PrescriptionItem{{ProductID: 10000, Amount: "1", Dose: "1 tbl."}}}}, 400},
+	}
+	for _, tt := range tests {
+		_, err := prescriptionService.Create(testCtx, &tt.p)
+		if ok, actual := checkErrCode(err, tt.expCode); !ok {
+			t.Fatalf("%s: expected error code %d but was %d, %+v", tt.name, tt.expCode, actual, err)
+		}
+	}
+
+	// create, patient is taken from record
+	date := time.Date(2017, time.May, 2, 9, 0, 0, 0, time.UTC)
+	id, err := prescriptionService.Create(testCtx, &lara.CreatePrescription{RecordID: 1,
+		Prescription: lara.Prescription{Date: date, Items: []lara.PrescriptionItem{item}}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	p, err := prescriptionService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if p.RecordID != 1 || p.PatientID != 1 || p.Version != 0 || len(p.Items) != 1 ||
+		p.Items[0].Amount != "10.0000" || p.Items[0].Controlled {
+		t.Fatalf("unexpected result %+v", p)
+	}
+	if y, m, d := p.WithdrawalEnd.Date(); y != 2017 || m != time.May || d != 10 {
+		t.Fatalf("unexpected withdrawal end %s", p.WithdrawalEnd)
+	}
+
+	// update replaces items
+	err = prescriptionService.Update(testCtx, id, &lara.UpdatePrescription{Version: 0,
+		Prescription: lara.Prescription{Note: "updated", Items: []lara.PrescriptionItem{
+			{ProductID: 5, Amount: "1", Dose: "1 pc"}, item}}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	p, err = prescriptionService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if p.Note != "updated" || p.Version != 1 || p.Modifier != "testuser" || len(p.Items) != 2 ||
+		p.Items[0].ProductID != 5 || !p.Date.Equal(date) {
+		t.Fatalf("unexpected result %+v", p)
+	}
+
+	// update without version upgrade
+	err = prescriptionService.Update(testCtx, id, &lara.UpdatePrescription{Version: 0,
+		Prescription: lara.Prescription{Items: []lara.PrescriptionItem{item}}})
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	// update not existing
+	err = prescriptionService.Update(testCtx, 10000, &lara.UpdatePrescription{
+		Prescription: lara.Prescription{Items: []lara.PrescriptionItem{item}}})
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+
+	// listed on record
+	l, err := prescriptionService.ListByRecord(testCtx, 1)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(l.Items) != 1 || l.Items[0].ID != id || l.Items[0].Drugs != "Obväz elastický, Ampicilín tbl." {
+		t.Fatalf("unexpected result %+v", l)
+	}
+}
+
+func TestListPatientPrescriptions(t *testing.T) {
+	l, err := prescriptionService.ListByPatient(testCtx, 5)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	// newest first
+	if len(l.Items) != 2 || l.Items[0].ID != 2 || l.Items[1].ID != 1 {
+		t.Fatalf("unexpected result %+v", l)
+	}
+	if i := l.Items[1]; i.RecordID != 11 || i.Drugs != "Ketamín 10%" || i.WithdrawalEnd.IsZero() {
+		t.Fatalf("unexpected item %+v", i)
+	}
+	if !l.Items[0].WithdrawalEnd.IsZero() {
+		t.Fatalf("unexpected item %+v", l.Items[0])
+	}
+
+	if l, err = prescriptionService.ListByPatient(testCtx, 10000); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(l.Items) != 0 {
+		t.Fatalf("expected no prescriptions, got %d", len(l.Items))
+	}
+}
@@ -49,6 +49,28 @@ func TestProductSearchFound(t *testing.T) {
 	}
 }
 
+func TestProductSearchSortedByPrice(t *testing.T) {
+	s, err := productService.Search(testCtx, &lara.ProductSearchRequest{
+		Query:   "Nie",
+		ValidTo: time.Now(),
+		Page:    lara.Page{Limit: 1, Sort: "-price"}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if s.Total != 2 || len(s.Products) != 1 || s.Products[0].Name != "Vyšetrenie 2" {
+		t.Fatalf("expected most expensive product first, but was %+v", s)
+	}
+}
+
+func TestProductSearchBadOffset(t *testing.T) {
+	_, err := productService.Search(testCtx, &lara.ProductSearchRequest{
+		Query: "Nie",
+		Page:  lara.Page{Offset: -1}})
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+}
+
 func TestProductSearchNotFound(t *testing.T) {
 	s, err := productService.Search(testCtx, &lara.ProductSearchRequest{
 		Query:   "kkkkkk",
@@ -71,3 +93,233 @@ func TestProductSearchNotFound(t *testing.T) {
 		t.Fatalf("expected products length 0 but was %d", len(s.Products))
 	}
 }
+
+func TestGetProduct(t *testing.T) {
+	p, err := productService.Get(testCtx, 1)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if p.Name != "Vyšetrenie uší a čistenie" || p.UnitID != 1 || p.Unit != "ml." ||
+		p.PLU != "10" || p.Price != "7.00" || p.VATRate != "0.00" || p.ValidTo.IsZero() ||
+		p.Creator != "testuser" {
+		t.Fatalf("unexpected result %+v", p)
+	}
+
+	_, err = productService.Get(testCtx, 10000)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+}
+
+func TestCreateUpdateRetireProduct(t *testing.T) {
+	// validation
+	tests := []struct {
+		name    string
+		p       lara.ProductData
+		expCode int
+	}{
+		{"no name", lara.ProductData{UnitID: 1, Price: "1.00"}, 400},
+		{"no unit", lara.ProductData{Name: "Test product", Price: "1.00"}, 400},
+		{"no price", lara.ProductData{Name: "Test product", UnitID: 1}, 400},
+		{"bad price", lara.ProductData{Name: "Test product", UnitID: 1, Price: "-1"}, 400},
+		{"NaN price", lara.ProductData{Name: "Test product", UnitID: 1, Price: "NaN"}, 400},
+		{"price overflow", lara.ProductData{Name: "Test product", UnitID: 1, Price: "1e9"}, 400},
+		{"bad plu", lara.ProductData{Name: "Test product", UnitID: 1, Price: "1.00", PLU: "x1"}, 400},
+		{"bad VAT", lara.ProductData{Name: "Test product", UnitID: 1, Price: "1.00", VATRate: "120"}, 400},
+		{"NaN VAT", lara.ProductData{Name: "Test product", UnitID: 1, Price: "1.00", VATRate: "NaN"}, 400},
+		{"VAT rounded to 100", lara.ProductData{Name: "Test product", UnitID: 1, Price: "1.00", VATRate: "99.999"}, 400},
+		{"bad min stock", lara.ProductData{Name: "Test product", UnitID: 1, Price: "1.00", MinStock: "-5"}, 400},
+		{"NaN min stock", lara.ProductData{Name: "Test product", UnitID: 1, Price: "1.00", MinStock: "NaN"}, 400},
+		{"min stock overflow", lara.ProductData{Name: "Test product", UnitID: 1, Price: "1.00", MinStock: "1e12"}, 400},
+		{"duplicate", lara.ProductData{Name: "Vyšetrenie 2", UnitID: 1, Price: "1.00"}, 409},
+	}
+	for _, tt := range tests {
+		_, err := productService.Create(testCtx, &lara.CreateProduct{ProductData: tt.p})
+		if err == nil {
+			t.Fatalf("%s: expected error", tt.name)
+		}
+		if ok, actual := checkErrCode(err, tt.expCode); !ok {
+			t.Fatalf("%s: expected error code %d but was %d, %+v", tt.name, tt.expCode, actual, err)
+		}
+	}
+
+	// create
+	id, err := productService.Create(testCtx, &lara.CreateProduct{ProductData: lara.ProductData{
+		Name: "Test product", UnitID: 2, PLU: "77", Price: "3.50", VATRate: "20", MinStock: "12.5",
+		Controlled: true}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	p, err := productService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if p.Name != "Test product" || p.Unit != "tbl." || p.PLU != "77" || p.Price != "3.50" ||
+		p.VATRate != "20.00" || p.MinStock != "12.5000" || !p.Controlled || !p.ValidTo.IsZero() ||
+		p.Version != 0 {
+		t.Fatalf("unexpected result %+v", p)
+	}
+
+	// update
+	err = productService.Update(testCtx, id, &lara.UpdateProduct{Version: 0, ProductData: lara.ProductData{
+		Name: "Test product renamed", UnitID: 2, Price: "4.00"}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	p, err = productService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if p.Name != "Test product renamed" || p.PLU != "" || p.Price != "4.00" || p.VATRate != "0.00" ||
+		p.MinStock != "" || p.Controlled || p.Version != 1 || p.Modifier != "testuser" {
+		t.Fatalf("unexpected result %+v", p)
+	}
+
+	// update without version upgrade
+	err = productService.Update(testCtx, id, &lara.UpdateProduct{Version: 0, ProductData: p.ProductData})
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	// update not existing
+	err = productService.Update(testCtx, 10000, &lara.UpdateProduct{ProductData: p.ProductData})
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+
+	// retire
+	validTo := time.Date(2017, time.May, 31, 0, 0, 0, 0, time.Local)
+	err = productService.Retire(testCtx, id, &lara.RetireProduct{Version: 1, ValidTo: validTo})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	p, err = productService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if y, m, d := p.ValidTo.Date(); y != 2017 || m != time.May || d != 31 || p.Version != 2 {
+		t.Fatalf("unexpected result %+v", p)
+	}
+
+	s, err := productService.Search(testCtx, &lara.ProductSearchRequest{
+		Query:   "Test product",
+		ValidTo: time.Now()})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if s.Total != 0 {
+		t.Fatalf("expected retired product not found, but was %+v", s)
+	}
+
+	// retire without version upgrade
+	err = productService.Retire(testCtx, id, &lara.RetireProduct{Version: 1})
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+}
+
+func TestProductPrice(t *testing.T) {
+	tests := []struct {
+		date    time.Time
+		price   string
+		vatRate string
+	}{
+		{time.Date(2016, time.June, 1, 0, 0, 0, 0, time.UTC), "4.50", "10.00"},
+		{time.Date(2016, time.December, 31, 0, 0, 0, 0, time.UTC), "4.50", "10.00"},
+		{time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC), "5.00", "0.00"},
+		{time.Now(), "5.00", "0.00"},
+	}
+	for _, tt := range tests {
+		p, err := productService.PriceAt(testCtx, 2, tt.date)
+		if err != nil {
+			t.Fatalf("expected nil error, but was %+v", err)
+		}
+		if p.Price != tt.price || p.VATRate != tt.vatRate {
+			t.Fatalf("%v: unexpected price %+v", tt.date, p)
+		}
+	}
+
+	_, err := productService.PriceAt(testCtx, 10000, time.Now())
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+
+	h, err := productService.PriceHistory(testCtx, 2)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(h.Items) != 2 || !h.Items[0].ValidFrom.IsZero() || h.Items[0].ValidTo.IsZero() ||
+		h.Items[1].ValidFrom.IsZero() || !h.Items[1].ValidTo.IsZero() {
+		t.Fatalf("unexpected history %+v", h)
+	}
+
+	_, err = productService.PriceHistory(testCtx, 10000)
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+}
+
+func TestProductPriceChange(t *testing.T) {
+	id, err := productService.Create(testCtx, &lara.CreateProduct{ProductData: lara.ProductData{
+		Name: "Priced product", UnitID: 1, Price: "10.00"}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	// unchanged price doesn't create history
+	err = productService.Update(testCtx, id, &lara.UpdateProduct{Version: 0, ProductData: lara.ProductData{
+		Name: "Priced product", UnitID: 1, PLU: "5", Price: "10.00"}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	h, err := productService.PriceHistory(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(h.Items) != 1 {
+		t.Fatalf("unexpected history %+v", h)
+	}
+
+	// changed price is valid from today, first price until yesterday
+	err = productService.Update(testCtx, id, &lara.UpdateProduct{Version: 1, ProductData: lara.ProductData{
+		Name: "Priced product", UnitID: 1, Price: "12.00"}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	// second change on the same day overwrites price
+	err = productService.Update(testCtx, id, &lara.UpdateProduct{Version: 2, ProductData: lara.ProductData{
+		Name: "Priced product", UnitID: 1, Price: "12.50", VATRate: "20"}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	h, err = productService.PriceHistory(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(h.Items) != 2 || h.Items[0].Price != "10.00" || h.Items[1].Price != "12.50" ||
+		h.Items[1].VATRate != "20.00" || h.Items[1].Modifier != "testuser" {
+		t.Fatalf("unexpected history %+v", h)
+	}
+
+	today := time.Now()
+	if y, m, d := h.Items[1].ValidFrom.Date(); y != today.Year() || m != today.Month() || d != today.Day() {
+		t.Fatalf("unexpected validFrom %v", h.Items[1].ValidFrom)
+	}
+
+	p, err := productService.PriceAt(testCtx, id, today.AddDate(0, 0, -1))
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if p.Price != "10.00" {
+		t.Fatalf("unexpected price %+v", p)
+	}
+}
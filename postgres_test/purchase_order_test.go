@@ -0,0 +1,230 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/jkusniar/lara"
+)
+
+func suggestedFor(t *testing.T, productID uint64) *lara.SuggestedReorder {
+	l, err := purchaseOrderService.SuggestedReorder(testCtx)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	for _, r := range l.Items {
+		if r.ProductID == productID {
+			return &r
+		}
+	}
+	return nil
+}
+
+func TestCreatePurchaseOrderInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		o    lara.PurchaseOrder
+	}{
+		{"no supplier", lara.PurchaseOrder{Lines: []lara.PurchaseOrderLine{{ProductID: 6, Amount: "1"}}}},
+		{"no lines", lara.PurchaseOrder{SupplierID: 1}},
+		{"no product", lara.PurchaseOrder{SupplierID: 1, Lines: []lara.PurchaseOrderLine{{Amount: "1"}}}},
+		{"bad amount", lara.PurchaseOrder{SupplierID: 1, Lines: []lara.PurchaseOrderLine{{ProductID: 6, Amount: "0"}}}},
+		{"bad price", lara.PurchaseOrder{SupplierID: 1,
+			Lines: []lara.PurchaseOrderLine{{ProductID: 6, Amount: "1", Price: "-1"}}}},
+		{"NaN amount", lara.PurchaseOrder{SupplierID: 1, Lines: []lara.PurchaseOrderLine{{ProductID: 6, Amount: "NaN"}}}},
+		{"Inf amount", lara.PurchaseOrder{SupplierID: 1, Lines: []lara.PurchaseOrderLine{{ProductID: 6, Amount: "Inf"}}}},
+		{"amount overflow", lara.PurchaseOrder{SupplierID: 1, Lines: []lara.PurchaseOrderLine{{ProductID: 6, Amount: "1e12"}}}},
+		{"NaN price", lara.PurchaseOrder{SupplierID: 1,
+			Lines: []lara.PurchaseOrderLine{{ProductID: 6, Amount: "1", Price: "NaN"}}}},
+		{"price overflow", lara.PurchaseOrder{SupplierID: 1,
+			Lines: []lara.PurchaseOrderLine{{ProductID: 6, Amount: "1", Price: "1e9"}}}},
+		{"unknown supplier", lara.PurchaseOrder{SupplierID: 999,
+			Lines: []lara.PurchaseOrderLine{{ProductID: 6, Amount: "1"}}}},
+		{"unknown product", lara.PurchaseOrder{SupplierID: 1,
+			Lines: []lara.PurchaseOrderLine{{ProductID: 999, Amount: "1"}}}},
+	}
+	for _, tt := range tests {
+		_, err := purchaseOrderService.Create(testCtx, &lara.CreatePurchaseOrder{PurchaseOrder: tt.o})
+		if ok, actual := checkErrCode(err, 400); !ok {
+			t.Fatalf("%s: expected error code 400 but was %d, %+v", tt.name, actual, err)
+		}
+	}
+}
+
+func TestGetPurchaseOrderNotFound(t *testing.T) {
+	_, err := purchaseOrderService.Get(testCtx, 999)
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+}
+
+func TestPurchaseOrderLifecycle(t *testing.T) {
+	// nothing in stock, nothing ordered
+	if r := suggestedFor(t, 6); r == nil || r.Suggested != "50.0000" || r.Stock != "0.0000" {
+		t.Fatalf("unexpected suggested reorder %+v", r)
+	}
+
+	id, err := purchaseOrderService.Create(testCtx, &lara.CreatePurchaseOrder{PurchaseOrder: lara.PurchaseOrder{
+		SupplierID: 2,
+		Note:       "by phone",
+		Lines: []lara.PurchaseOrderLine{
+			{ProductID: 6, Amount: "10", Price: "0.10"},
+			{ProductID: 5, Amount: "2"},
+		}}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	// replace lines while nothing is received
+	err = purchaseOrderService.Update(testCtx, id, &lara.UpdatePurchaseOrder{Version: 0,
+		PurchaseOrder: lara.PurchaseOrder{
+			SupplierID: 1,
+			Lines: []lara.PurchaseOrderLine{
+				{ProductID: 6, Amount: "20", Price: "0.10"},
+			}}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	o, err := purchaseOrderService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if o.Status != lara.Ordered || o.Supplier != "Veterinárna lekáreň" || o.Note != "" || o.Version != 1 ||
+		len(o.Lines) != 1 || o.Lines[0].Amount != "20.0000" || o.Lines[0].Received != "0.0000" {
+		t.Fatalf("unexpected purchase order %+v", o)
+	}
+	if r := suggestedFor(t, 6); r == nil || r.Suggested != "30.0000" || r.Ordered != "20.0000" {
+		t.Fatalf("unexpected suggested reorder %+v", r)
+	}
+
+	stock := stockOf(t, 6)
+	lineID := o.Lines[0].ID
+
+	// invalid received amounts
+	for _, a := range []string{"NaN", "Inf", "0.00001"} {
+		err = purchaseOrderService.Receive(testCtx, id, &lara.ReceivePurchaseOrder{Version: 1,
+			Lines: []lara.ReceivedLine{{LineID: lineID, Amount: a}}})
+		if ok, actual := checkErrCode(err, 400); !ok {
+			t.Fatalf("amount %s: expected error code 400 but was %d, %+v", a, actual, err)
+		}
+	}
+
+	// receiving more than ordered
+	err = purchaseOrderService.Receive(testCtx, id, &lara.ReceivePurchaseOrder{Version: 1,
+		Lines: []lara.ReceivedLine{{LineID: lineID, Amount: "21"}}})
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// partial receipt
+	err = purchaseOrderService.Receive(testCtx, id, &lara.ReceivePurchaseOrder{Version: 1,
+		Lines: []lara.ReceivedLine{{LineID: lineID, Amount: "5", Batch: "S1"}}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	o, err = purchaseOrderService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if o.Status != lara.PartiallyReceived || o.Lines[0].Received != "5.0000" || o.Version != 2 {
+		t.Fatalf("unexpected purchase order %+v", o)
+	}
+	if s := stockOf(t, 6); s != stock+5 {
+		t.Fatalf("expected stock %f, but was %f", stock+5, s)
+	}
+	if r := suggestedFor(t, 6); r == nil || r.Suggested != "30.0000" || r.Ordered != "15.0000" {
+		t.Fatalf("unexpected suggested reorder %+v", r)
+	}
+
+	// partially received order can't be updated
+	err = purchaseOrderService.Update(testCtx, id, &lara.UpdatePurchaseOrder{Version: 2,
+		PurchaseOrder: lara.PurchaseOrder{SupplierID: 1, Lines: []lara.PurchaseOrderLine{{ProductID: 6, Amount: "1"}}}})
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	// receive without version upgrade
+	err = purchaseOrderService.Receive(testCtx, id, &lara.ReceivePurchaseOrder{Version: 1,
+		Lines: []lara.ReceivedLine{{LineID: lineID, Amount: "15"}}})
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	// receipt of the rest completes order
+	err = purchaseOrderService.Receive(testCtx, id, &lara.ReceivePurchaseOrder{Version: 2,
+		Lines: []lara.ReceivedLine{{LineID: lineID, Amount: "15"}}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	o, err = purchaseOrderService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if o.Status != lara.Received || o.Version != 4 {
+		t.Fatalf("unexpected purchase order %+v", o)
+	}
+	if r := suggestedFor(t, 6); r == nil || r.Suggested != "30.0000" || r.Ordered != "0.0000" {
+		t.Fatalf("unexpected suggested reorder %+v", r)
+	}
+
+	// received order can't be withdrawn
+	err = purchaseOrderService.Withdraw(testCtx, id, &lara.WithdrawPurchaseOrder{Version: 4})
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	l, err := purchaseOrderService.ListBySupplier(testCtx, 1)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(l.Items) == 0 || l.Items[0].ID != id {
+		t.Fatalf("unexpected supplier's orders %+v", l.Items)
+	}
+}
+
+func TestWithdrawPurchaseOrder(t *testing.T) {
+	id, err := purchaseOrderService.Create(testCtx, &lara.CreatePurchaseOrder{PurchaseOrder: lara.PurchaseOrder{
+		SupplierID: 2,
+		Lines:      []lara.PurchaseOrderLine{{ProductID: 5, Amount: "3"}}}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	if err = purchaseOrderService.Withdraw(testCtx, id, &lara.WithdrawPurchaseOrder{Version: 0}); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	o, err := purchaseOrderService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if o.Status != lara.Withdrawn {
+		t.Fatalf("expected Withdrawn, but was %s", o.Status)
+	}
+
+	// withdrawn order can't be received
+	err = purchaseOrderService.Receive(testCtx, id, &lara.ReceivePurchaseOrder{Version: 1,
+		Lines: []lara.ReceivedLine{{LineID: o.Lines[0].ID, Amount: "3"}}})
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+}
@@ -46,13 +46,24 @@ func TestGetRecord(t *testing.T) {
 	if r == nil {
 		t.Fatal("expected not nil result")
 	}
-	if r.Text != "RECORD" ||
+	if r.Text != "RECORD" || r.PatientID != 1 ||
 		r.Total != "6.15" ||
 		len(r.Items) != 2 ||
 		r.Items[0].ItemType != lara.Material || r.Items[0].PLU != "10" {
 		t.Fatalf("unexpected result %+v", r)
 	}
 
+	// structured clinical data
+	r, err = recordService.Get(testCtx, 4)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if r.Subjective != "not eating" || r.Weight != "12.500" || r.Temperature != "38.9" ||
+		r.HeartRate != 110 || len(r.Diagnoses) != 2 || r.Diagnoses[0].Code != "D50" ||
+		len(r.DiagnosisIDs) != 2 || r.DiagnosisIDs[0] != 2 {
+		t.Fatalf("unexpected clinical data %+v", r)
+	}
+
 	// Total == 0.00 (no items on record)
 	r, err = recordService.Get(testCtx, 7)
 	if err != nil {
@@ -87,6 +98,71 @@ func TestCreateRecord(t *testing.T) {
 	if id == 0 {
 		t.Fatal("incorrect ID returned")
 	}
+
+	// explicit VAT rate is kept instead of product's current rate
+	r.Items = []lara.RecordItem{
+		{ProductID: 3, Amount: "1.0000", ItemPrice: "2.20", ProductPrice: "2.20", ItemType: lara.Labor, VATRate: "10.00"},
+	}
+	id, err = recordService.Create(testCtx, r)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	chk, err := recordService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if itm := chk.Items[0]; itm.VATRate != "10.00" || itm.ItemNet != "2.00" || itm.ItemVAT != "0.20" {
+		t.Fatalf("unexpected item VAT %+v", itm)
+	}
+
+	// structured clinical data, duplicate diagnosis ignored
+	r.Items = nil
+	r.ClinicalData = lara.ClinicalData{Objective: "ok", Weight: "4.2", Temperature: "38.5", HeartRate: 120,
+		DiagnosisIDs: []uint64{3, 3}}
+	id, err = recordService.Create(testCtx, r)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	chk, err = recordService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if chk.Objective != "ok" || chk.Weight != "4.200" || chk.Temperature != "38.5" ||
+		chk.HeartRate != 120 || len(chk.Diagnoses) != 1 || chk.Diagnoses[0].Code != "Z00" {
+		t.Fatalf("unexpected clinical data %+v", chk)
+	}
+
+	// invalid vitals and unknown diagnosis
+	for _, c := range []lara.ClinicalData{
+		{Weight: "heavy"},
+		{Weight: "-1"},
+		{Weight: "NaN"},
+		{Weight: "Infinity"},
+		{Weight: "9999.9999"},
+		{Weight: "0.0001"},
+		{Temperature: "380"},
+		{Temperature: "99.96"},
+		{HeartRate: 5000},
+		{DiagnosisIDs: []uint64{10000}},
+	} {
+		r.ClinicalData = c
+		_, err = recordService.Create(testCtx, r)
+		if ok, actual := checkErrCode(err, 400); !ok {
+			t.Fatalf("expected error code 400 for %+v but was %d, %+v", c, actual, err)
+		}
+	}
+	r.ClinicalData = lara.ClinicalData{}
+
+	// invalid VAT rate
+	for _, rate := range []string{"100", "99.999", "NaN", "-1"} {
+		r.Items = []lara.RecordItem{
+			{ProductID: 3, Amount: "1.0000", ItemPrice: "2.20", ProductPrice: "2.20", ItemType: lara.Labor, VATRate: rate},
+		}
+		_, err = recordService.Create(testCtx, r)
+		if ok, actual := checkErrCode(err, 400); !ok {
+			t.Fatalf("expected error code 400 for VAT rate %s but was %d, %+v", rate, actual, err)
+		}
+	}
 }
 
 func TestUpdateRecord(t *testing.T) {
@@ -125,6 +201,14 @@ func TestUpdateRecord(t *testing.T) {
 		t.Fatalf("expected zero items, but was %d", len(chk.Items))
 	}
 
+	// VAT rate taken from product's price at record's date
+	if itm := chk.Items[0]; itm.VATRate != "20.00" || itm.ItemNet != "1.67" || itm.ItemVAT != "0.33" {
+		t.Fatalf("unexpected item VAT %+v", itm)
+	}
+	if chk.Total != "2.00" || chk.TotalNet != "1.67" || chk.TotalVAT != "0.33" {
+		t.Fatalf("unexpected record totals %+v", chk)
+	}
+
 	// update not existing
 	err = recordService.Update(testCtx, 1000, u)
 	if err == nil {
@@ -152,4 +236,181 @@ func TestUpdateRecord(t *testing.T) {
 	if ok, actual := checkErrCode(err, 400); !ok {
 		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
 	}
+
+	// update - unknown product
+	err = recordService.Update(testCtx, 2, &lara.UpdateRecord{Version: 2, Text: "updated-text2",
+		Items: []lara.RecordItem{{ProductID: 10000, Amount: "1.0000"}}})
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// update - prices taken from product's price at record's date (2003)
+	err = recordService.Update(testCtx, 2, &lara.UpdateRecord{Version: 2, Text: "updated-text3",
+		Items: []lara.RecordItem{{ProductID: 2, Amount: "2.0000", ItemType: lara.Labor}}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	chk, err = recordService.Get(testCtx, 2)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if itm := chk.Items[0]; itm.ProductPrice != "4.50" || itm.ItemPrice != "9.00" || itm.VATRate != "10.00" {
+		t.Fatalf("unexpected item %+v", itm)
+	}
+}
+
+func TestUpdateRecordClinicalData(t *testing.T) {
+	r := &lara.CreateRecord{PatientID: 1,
+		NewRecord: lara.NewRecord{Text: "clinical",
+			ClinicalData: lara.ClinicalData{Plan: "recheck", DiagnosisIDs: []uint64{3}}}}
+	id, err := recordService.Create(testCtx, r)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	// plain text update keeps working, clears structured data
+	u := &lara.UpdateRecord{Version: 0, Text: "plain"}
+	if err = recordService.Update(testCtx, id, u); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	chk, err := recordService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if chk.Text != "plain" || chk.Plan != "" || len(chk.Diagnoses) != 0 {
+		t.Fatalf("unexpected result %+v", chk)
+	}
+
+	// diagnoses replaced
+	u = &lara.UpdateRecord{Version: 1, Text: "plain",
+		ClinicalData: lara.ClinicalData{Assessment: "stable", Weight: "3.1", DiagnosisIDs: []uint64{3}}}
+	if err = recordService.Update(testCtx, id, u); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	chk, err = recordService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if chk.Assessment != "stable" || chk.Weight != "3.100" || len(chk.DiagnosisIDs) != 1 || chk.DiagnosisIDs[0] != 3 {
+		t.Fatalf("unexpected result %+v", chk)
+	}
+
+	// unknown diagnosis rolls back whole update
+	u = &lara.UpdateRecord{Version: 2, Text: "rollback", ClinicalData: lara.ClinicalData{DiagnosisIDs: []uint64{10000}}}
+	err = recordService.Update(testCtx, id, u)
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+	chk, err = recordService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if chk.Text != "plain" || len(chk.Diagnoses) != 1 {
+		t.Fatalf("unexpected result %+v", chk)
+	}
+}
+
+func TestListPatientDiagnoses(t *testing.T) {
+	l, err := recordService.ListPatientDiagnoses(testCtx, 1)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	var fixture []lara.PatientDiagnosis
+	for _, d := range l.Items {
+		if d.RecordID == 4 {
+			fixture = append(fixture, d)
+		}
+	}
+	if len(fixture) != 2 || fixture[0].Code != "D50" || fixture[1].Code != "K08" {
+		t.Fatalf("unexpected result %+v", l)
+	}
+
+	// patient without diagnoses
+	l, err = recordService.ListPatientDiagnoses(testCtx, 10000)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(l.Items) != 0 {
+		t.Fatalf("unexpected result %+v", l)
+	}
+}
+
+func TestListDiagnosedPatients(t *testing.T) {
+	l, err := recordService.ListDiagnosedPatients(testCtx, 1, lara.Page{Sort: "patient"})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if l.Total != 1 || len(l.Items) != 1 || l.Items[0].PatientID != 1 ||
+		l.Items[0].Owner != "Get Owner" || l.Items[0].RecordID != 4 {
+		t.Fatalf("unexpected result %+v", l)
+	}
+
+	// unknown sort key
+	_, err = recordService.ListDiagnosedPatients(testCtx, 1, lara.Page{Sort: "unknown"})
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+}
+
+func TestGetPatientVitals(t *testing.T) {
+	pid, err := patientService.Create(testCtx, &lara.CreatePatient{OwnerID: 1,
+		NewPatient: lara.NewPatient{Patient: lara.Patient{Name: "vitals-pet"},
+			Record: lara.NewRecord{Text: "first", ClinicalData: lara.ClinicalData{Weight: "5.1"}}}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	for _, c := range []lara.ClinicalData{
+		{Weight: "5.4", Temperature: "38.7", HeartRate: 95},
+		{Objective: "no vitals measured"},
+	} {
+		_, err = recordService.Create(testCtx, &lara.CreateRecord{PatientID: pid,
+			NewRecord: lara.NewRecord{ClinicalData: c}})
+		if err != nil {
+			t.Fatalf("expected nil error, but was %+v", err)
+		}
+	}
+
+	v, err := recordService.GetPatientVitals(testCtx, pid)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if v.Weight.Unit != "kg" || len(v.Weight.Points) != 2 ||
+		v.Weight.Points[0].Value != "5.100" || v.Weight.Points[1].Value != "5.400" ||
+		len(v.Temperature.Points) != 1 || v.Temperature.Points[0].Value != "38.7" ||
+		len(v.HeartRate.Points) != 1 || v.HeartRate.Points[0].Value != "95" {
+		t.Fatalf("unexpected result %+v", v)
+	}
+
+	// latest weight shown on patient
+	p, err := patientService.Get(testCtx, pid)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if p.Weight != "5.400" || p.WeightDate != v.Weight.Points[1].Date {
+		t.Fatalf("unexpected latest weight %s at %s", p.Weight, p.WeightDate)
+	}
+
+	// unknown patient
+	_, err = recordService.GetPatientVitals(testCtx, 10000)
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+
+	// patient without measurements
+	pid, err = patientService.Create(testCtx, &lara.CreatePatient{OwnerID: 1,
+		NewPatient: lara.NewPatient{Patient: lara.Patient{Name: "no-vitals-pet"},
+			Record: lara.NewRecord{Text: "first"}}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	v, err = recordService.GetPatientVitals(testCtx, pid)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(v.Weight.Points) != 0 || len(v.Temperature.Points) != 0 || len(v.HeartRate.Points) != 0 {
+		t.Fatalf("unexpected result %+v", v)
+	}
 }
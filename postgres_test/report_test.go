@@ -47,6 +47,10 @@ func TestGetIncomeStatisticsEmpty(t *testing.T) {
 	if report.IncomeNotBilled != "0.00" {
 		t.Fatalf("expected IncomeNotBilled 0 but was %s", report.IncomeNotBilled)
 	}
+
+	if len(report.VAT) != 0 {
+		t.Fatalf("expected empty VAT breakdown but was %+v", report.VAT)
+	}
 }
 
 func TestGetIncomeStatistics(t *testing.T) {
@@ -78,4 +82,70 @@ func TestGetIncomeStatistics(t *testing.T) {
 	if report.IncomeNotBilled != "3.14" {
 		t.Fatalf("expected IncomeNotBilled 3.14 but was %s", report.IncomeNotBilled)
 	}
+
+	if len(report.VAT) != 1 || report.VAT[0].Rate != "0.00" ||
+		report.VAT[0].Net != "9.42" || report.VAT[0].VAT != "0.00" || report.VAT[0].Gross != "9.42" {
+		t.Fatalf("unexpected VAT breakdown %+v", report.VAT)
+	}
+}
+
+func TestGetControlledDrugRegister(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Bratislava")
+	report, err := reportService.GetControlledDrugRegister(testCtx, &lara.ReportRequest{
+		ValidFrom: time.Date(2017, time.March, 5, 0, 0, 0, 0, loc),
+		ValidTo:   time.Date(2017, time.March, 31, 0, 0, 0, 0, loc),
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	var drug *lara.ControlledDrugRegister
+	for i, p := range report.Products {
+		if p.ProductID == 7 {
+			drug = &report.Products[i]
+		}
+	}
+	if drug == nil {
+		t.Fatalf("expected controlled product 7 in %+v", report.Products)
+	}
+
+	// receipt of 1st March is in opening balance
+	if drug.Product != "Ketamín 10%" || drug.Unit != "ml." || drug.Opening != "20.0000" ||
+		drug.Closing != "16.5000" || len(drug.Entries) != 2 {
+		t.Fatalf("unexpected register %+v", drug)
+	}
+	if e := drug.Entries[0]; e.Type != lara.Dispense || e.Amount != "-2.0000" || e.Balance != "18.0000" ||
+		e.RecordID != 11 || e.PatientID != 5 || e.Patient != "Žofka" || e.PrescriptionID != 1 {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+	if e := drug.Entries[1]; e.Amount != "-1.5000" || e.Balance != "16.5000" || e.RecordID != 12 ||
+		e.PrescriptionID != 0 {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+
+	// record 12 dispensed controlled drug without prescription
+	if len(report.Discrepancies) != 1 {
+		t.Fatalf("expected 1 discrepancy, got %+v", report.Discrepancies)
+	}
+	if d := report.Discrepancies[0]; d.RecordID != 12 || d.PatientID != 5 || d.ProductID != 7 ||
+		d.Dispensed != "1.5000" || d.Prescribed != "0.0000" {
+		t.Fatalf("unexpected discrepancy %+v", d)
+	}
+
+	// nothing in period, balances are kept
+	report, err = reportService.GetControlledDrugRegister(testCtx, &lara.ReportRequest{
+		ValidFrom: time.Date(2017, time.April, 1, 0, 0, 0, 0, loc),
+		ValidTo:   time.Date(2017, time.April, 30, 0, 0, 0, 0, loc),
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	for _, p := range report.Products {
+		if p.ProductID == 7 && (p.Opening != "16.5000" || p.Closing != "16.5000" || len(p.Entries) != 0) {
+			t.Fatalf("unexpected register %+v", p)
+		}
+	}
+	if len(report.Discrepancies) != 0 {
+		t.Fatalf("expected no discrepancies, got %+v", report.Discrepancies)
+	}
 }
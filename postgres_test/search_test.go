@@ -19,10 +19,14 @@
 
 package postgres_test
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/jkusniar/lara"
+)
 
 func TestSearchFound(t *testing.T) {
-	s, err := searchService.Search(testCtx, "NLY")
+	s, err := searchService.Search(testCtx, &lara.SearchRequest{Query: "NLY"})
 
 	if err != nil {
 		t.Fatalf("expected nil error, but was %+v", err)
@@ -46,7 +50,7 @@ func TestSearchFound(t *testing.T) {
 }
 
 func TestSearchNotFound(t *testing.T) {
-	s, err := searchService.Search(testCtx, "kkkkk")
+	s, err := searchService.Search(testCtx, &lara.SearchRequest{Query: "kkkkk"})
 
 	if err != nil {
 		t.Fatalf("expected nil error, but was %+v", err)
@@ -68,3 +72,163 @@ func TestSearchNotFound(t *testing.T) {
 		t.Fatalf("expected Records length 0 but was %d", len(s.Records))
 	}
 }
+
+func TestSearchEmptyQuery(t *testing.T) {
+	s, err := searchService.Search(testCtx, &lara.SearchRequest{Query: " - "})
+
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	if s.Total != 0 || len(s.Records) != 0 {
+		t.Fatalf("expected empty result but was %+v", s)
+	}
+}
+
+// searchOne expects exactly one owner matching q
+func searchOne(t *testing.T, q string) lara.SearchRecord {
+	s, err := searchService.Search(testCtx, &lara.SearchRequest{Query: q})
+
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	if s.Total != 1 || len(s.Records) != 1 {
+		t.Fatalf("expected one record for '%s' but was %+v", q, s)
+	}
+
+	return s.Records[0]
+}
+
+func TestSearchUnaccentedName(t *testing.T) {
+	r := searchOne(t, "jan KOVAC")
+
+	if r.ID != 8 {
+		t.Errorf("expected owner 8 but was %d", r.ID)
+	}
+
+	if r.Field != lara.OwnerNameField {
+		t.Errorf("expected field %s but was %s", lara.OwnerNameField, r.Field)
+	}
+
+	if r.Match != "Ján Kováč" {
+		t.Errorf("expected match 'Ján Kováč' but was '%s'", r.Match)
+	}
+
+	if r.PatientID != 0 {
+		t.Errorf("expected no patient but was %d", r.PatientID)
+	}
+}
+
+func TestSearchLastNameInfix(t *testing.T) {
+	r := searchOne(t, "OVÁČ")
+
+	if r.ID != 8 || r.Field != lara.OwnerNameField {
+		t.Errorf("expected owner 8 by %s but was %+v", lara.OwnerNameField, r)
+	}
+
+	// LIKE wildcards are matched literally
+	s, err := searchService.Search(testCtx, &lara.SearchRequest{Query: "ov%c"})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if s.Total != 0 {
+		t.Fatalf("expected no owners but was %+v", s)
+	}
+}
+
+func TestSearchPatientName(t *testing.T) {
+	r := searchOne(t, "zofk")
+
+	if r.ID != 8 {
+		t.Errorf("expected owner 8 but was %d", r.ID)
+	}
+
+	if r.Field != lara.PatientField {
+		t.Errorf("expected field %s but was %s", lara.PatientField, r.Field)
+	}
+
+	if r.PatientID != 5 || r.Patient != "Žofka" {
+		t.Errorf("expected patient 5 'Žofka' but was %d '%s'", r.PatientID,
+			r.Patient)
+	}
+}
+
+func TestSearchTag(t *testing.T) {
+	r := searchOne(t, "sk 9988")
+
+	if r.Field != lara.TagField {
+		t.Errorf("expected field %s but was %s", lara.TagField, r.Field)
+	}
+
+	if r.Match != "SK-998877-01" {
+		t.Errorf("expected match 'SK-998877-01' but was '%s'", r.Match)
+	}
+
+	if r.PatientID != 5 {
+		t.Errorf("expected patient 5 but was %d", r.PatientID)
+	}
+}
+
+func TestSearchPhone(t *testing.T) {
+	r := searchOne(t, "0905123")
+
+	if r.Field != lara.OwnerPhoneField {
+		t.Errorf("expected field %s but was %s", lara.OwnerPhoneField, r.Field)
+	}
+}
+
+func TestSearchEmail(t *testing.T) {
+	r := searchOne(t, "ordinacia.kv")
+
+	if r.Field != lara.OwnerEmailField {
+		t.Errorf("expected field %s but was %s", lara.OwnerEmailField, r.Field)
+	}
+}
+
+func TestSearchIC(t *testing.T) {
+	r := searchOne(t, "87654321")
+
+	if r.Field != lara.OwnerICField {
+		t.Errorf("expected field %s but was %s", lara.OwnerICField, r.Field)
+	}
+}
+
+func TestSearchPaged(t *testing.T) {
+	s, err := searchService.Search(testCtx, &lara.SearchRequest{Query: "onl",
+		Page: lara.Page{Offset: 1, Limit: 1, Sort: "name"}})
+
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	if s.Total != 2 {
+		t.Fatalf("expected total count 2 but was %d", s.Total)
+	}
+
+	if len(s.Records) != 1 || s.Records[0].ID != 3 {
+		t.Fatalf("expected second owner by name (3) but was %+v", s.Records)
+	}
+}
+
+func TestSearchSortedDescending(t *testing.T) {
+	s, err := searchService.Search(testCtx, &lara.SearchRequest{Query: "onl",
+		Page: lara.Page{Sort: "-name"}})
+
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	if len(s.Records) != 2 || s.Records[0].ID != 3 || s.Records[1].ID != 4 {
+		t.Fatalf("expected owners 3, 4 but was %+v", s.Records)
+	}
+}
+
+func TestSearchBadSort(t *testing.T) {
+	_, err := searchService.Search(testCtx, &lara.SearchRequest{Query: "onl",
+		Page: lara.Page{Sort: "street"}})
+
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d", actual)
+	}
+}
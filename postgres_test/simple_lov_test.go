@@ -104,3 +104,17 @@ func TestGetAllBreeds(t *testing.T) {
 		t.Fatalf("unexpected result %+v", lovs)
 	}
 }
+
+func TestGetAllDiagnoses(t *testing.T) {
+	// get OK
+	lovs, err := diagnosisService.GetAllDiagnoses(testCtx)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if lovs == nil {
+		t.Fatal("expected not nil result")
+	}
+	if len(lovs.Items) != 3 || lovs.Items[0].Name != "D50 anaemia" {
+		t.Fatalf("unexpected result %+v", lovs)
+	}
+}
@@ -0,0 +1,414 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jkusniar/lara"
+)
+
+func stockOf(t *testing.T, productID uint64) float64 {
+	l, err := stockService.Get(testCtx, productID)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	q, err := strconv.ParseFloat(l.Quantity, 64)
+	if err != nil {
+		t.Fatalf("invalid quantity %s", l.Quantity)
+	}
+	return q
+}
+
+func TestStockGet(t *testing.T) {
+	l, err := stockService.Get(testCtx, 4)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if l.Quantity != "19.0000" || l.Product != "Ampicilín tbl." {
+		t.Fatalf("unexpected stock level %+v", l)
+	}
+
+	// product without movements
+	l, err = stockService.Get(testCtx, 1)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if l.Quantity != "0.0000" {
+		t.Fatalf("expected zero stock, but was %s", l.Quantity)
+	}
+}
+
+func TestStockGetNotFound(t *testing.T) {
+	_, err := stockService.Get(testCtx, 999)
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+}
+
+func TestStockList(t *testing.T) {
+	l, err := stockService.List(testCtx)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	for _, i := range l.Items {
+		if i.ProductID == 4 {
+			if i.Quantity != "19.0000" {
+				t.Fatalf("unexpected stock level %+v", i)
+			}
+			return
+		}
+	}
+	t.Fatal("product 4 not listed")
+}
+
+func TestStockListMovements(t *testing.T) {
+	from := time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2017, time.January, 20, 0, 0, 0, 0, time.UTC)
+	l, err := stockService.ListMovements(testCtx, 4, from, to)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(l.Items) != 2 {
+		t.Fatalf("expected 2 movements, but got %d", len(l.Items))
+	}
+	if m := l.Items[1]; m.Type != lara.WriteOff || m.Amount != "-1.0000" || m.Note != "broken" {
+		t.Fatalf("unexpected movement %+v", m)
+	}
+
+	l, err = stockService.ListMovements(testCtx, 4, from, from)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(l.Items) != 0 {
+		t.Fatalf("expected no movements, but got %d", len(l.Items))
+	}
+}
+
+func TestStockPost(t *testing.T) {
+	before := stockOf(t, 5)
+
+	id, err := stockService.Post(testCtx, &lara.CreateStockMovement{
+		StockMovement: lara.StockMovement{ProductID: 5, Type: lara.Receipt, Amount: "20"}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	m, err := stockService.GetMovement(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if m.Amount != "20.0000" || m.Creator != "testuser" || timeEmpty(m.Date) || m.RecordID != 0 {
+		t.Fatalf("unexpected movement %+v", m)
+	}
+
+	// write-off decreases stock
+	id, err = stockService.Post(testCtx, &lara.CreateStockMovement{
+		StockMovement: lara.StockMovement{ProductID: 5, Type: lara.WriteOff, Amount: "2.5"}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	m, err = stockService.GetMovement(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if m.Amount != "-2.5000" {
+		t.Fatalf("expected negative amount, but was %s", m.Amount)
+	}
+
+	// negative correction
+	if _, err = stockService.Post(testCtx, &lara.CreateStockMovement{
+		StockMovement: lara.StockMovement{ProductID: 5, Type: lara.Correction, Amount: "-0.5"}}); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	if after := stockOf(t, 5); after != before+17 {
+		t.Fatalf("expected stock %f, but was %f", before+17, after)
+	}
+}
+
+func TestStockPostInvalid(t *testing.T) {
+	for _, m := range []lara.StockMovement{
+		{Type: lara.Receipt, Amount: "1"},
+		{ProductID: 5, Type: lara.Receipt},
+		{ProductID: 5, Type: lara.Receipt, Amount: "x"},
+		{ProductID: 5, Type: lara.Receipt, Amount: "NaN"},
+		{ProductID: 5, Type: lara.Receipt, Amount: "Inf"},
+		{ProductID: 5, Type: lara.Receipt, Amount: "1e12"},
+		{ProductID: 5, Type: lara.Correction, Amount: "NaN"},
+		{ProductID: 5, Type: lara.Correction, Amount: "0.00001"},
+		{ProductID: 5, Type: lara.Receipt, Amount: "-1"},
+		{ProductID: 5, Type: lara.WriteOff, Amount: "0"},
+		{ProductID: 5, Type: lara.Correction, Amount: "0"},
+		{ProductID: 5, Type: lara.Dispense, Amount: "1"},
+		{ProductID: 999, Type: lara.Receipt, Amount: "1"},
+	} {
+		_, err := stockService.Post(testCtx, &lara.CreateStockMovement{StockMovement: m})
+		if ok, actual := checkErrCode(err, 400); !ok {
+			t.Fatalf("movement %+v: expected error code 400 but was %d, %+v", m, actual, err)
+		}
+	}
+}
+
+func TestStockGetMovementNotFound(t *testing.T) {
+	_, err := stockService.GetMovement(testCtx, 99999)
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+}
+
+func TestStockDispensedByRecord(t *testing.T) {
+	before := stockOf(t, 5)
+
+	id, err := recordService.Create(testCtx, &lara.CreateRecord{
+		PatientID: 1,
+		NewRecord: lara.NewRecord{
+			Items: []lara.RecordItem{
+				{ProductID: 5, Amount: "2", ItemType: lara.Material},
+				{ProductID: 5, Amount: "1", ItemType: lara.Material},
+				{ProductID: 5, Amount: "4", ItemType: lara.Labor},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if after := stockOf(t, 5); after != before-3 {
+		t.Fatalf("expected stock %f, but was %f", before-3, after)
+	}
+
+	// invalid amount isn't dispensed
+	for _, a := range []string{"NaN", "1e12"} {
+		_, err := recordService.Create(testCtx, &lara.CreateRecord{
+			PatientID: 1,
+			NewRecord: lara.NewRecord{
+				Items: []lara.RecordItem{{ProductID: 5, Amount: a, ItemType: lara.Material}},
+			},
+		})
+		if ok, actual := checkErrCode(err, 400); !ok {
+			t.Fatalf("amount %s: expected error code 400 but was %d, %+v", a, actual, err)
+		}
+	}
+
+	// dispensed at record's date
+	r, err := recordService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	l, err := stockService.ListMovements(testCtx, 5, r.Date, r.Date)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	found := false
+	for _, m := range l.Items {
+		if m.RecordID == id {
+			found = m.Date.Equal(r.Date) && m.Amount == "-3.0000"
+		}
+	}
+	if !found {
+		t.Fatalf("expected movement of record %d at %s, but was %+v", id, r.Date, l.Items)
+	}
+
+	// unchanged record posts nothing
+	items := []lara.RecordItem{
+		{ProductID: 5, Amount: "3", ItemType: lara.Material},
+	}
+	if err := recordService.Update(testCtx, id, &lara.UpdateRecord{Version: r.Version,
+		Items: items}); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if after := stockOf(t, 5); after != before-3 {
+		t.Fatalf("expected stock %f, but was %f", before-3, after)
+	}
+
+	// removed material is returned to stock
+	if err := recordService.Update(testCtx, id, &lara.UpdateRecord{Version: r.Version + 1,
+		Items: items[:0]}); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if after := stockOf(t, 5); after != before {
+		t.Fatalf("expected stock %f, but was %f", before, after)
+	}
+}
+
+func TestStockRecordBeforeLedger(t *testing.T) {
+	before := stockOf(t, 1)
+
+	// record's material was dispensed by migration, edit posts nothing
+	r, err := recordService.Get(testCtx, 1)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if err := recordService.Update(testCtx, 1, &lara.UpdateRecord{Version: r.Version,
+		Text: "RECORD, typo fixed",
+		Items: []lara.RecordItem{
+			{ProductID: 1, Amount: "1", ProductPrice: "3.14", ItemPrice: "3.14", ItemType: lara.Material},
+			{ProductID: 2, Amount: "1", ProductPrice: "3.01", ItemPrice: "3.01", ItemType: lara.Material},
+		}}); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if after := stockOf(t, 1); after != before {
+		t.Fatalf("expected stock %f, but was %f", before, after)
+	}
+}
+
+func TestStockBatches(t *testing.T) {
+	l, err := stockService.Batches(testCtx, 4)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(l.Items) != 3 {
+		t.Fatalf("expected 3 batches, but got %d", len(l.Items))
+	}
+	if b := l.Items[0]; b.Number != "A1" || b.Quantity != "5.0000" ||
+		b.Expiry.Format("2006-01-02") != "2017-03-31" {
+		t.Fatalf("unexpected batch %+v", b)
+	}
+}
+
+func TestStockExpiring(t *testing.T) {
+	l, err := stockService.Expiring(testCtx, 30)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	found := map[string]bool{}
+	for _, b := range l.Items {
+		if b.ProductID == 4 {
+			found[b.Number] = true
+		}
+	}
+	if !found["A1"] || !found["B2"] || found["C3"] {
+		t.Fatalf("unexpected expiring batches %+v", l.Items)
+	}
+
+	_, err = stockService.Expiring(testCtx, -1)
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+}
+
+func TestStockPostBatch(t *testing.T) {
+	expiry := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	receipt := &lara.CreateStockMovement{
+		StockMovement: lara.StockMovement{ProductID: 5, Type: lara.Receipt, Amount: "5"},
+		Batch:         "X1",
+		Expiry:        expiry}
+
+	id, err := stockService.Post(testCtx, receipt)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	m, err := stockService.GetMovement(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if m.BatchID == 0 || m.Batch != "X1" || m.Expiry.Format("2006-01-02") != "2030-01-01" {
+		t.Fatalf("unexpected movement %+v", m)
+	}
+
+	// next receipt of the same batch
+	receipt.Expiry = time.Time{}
+	id, err = stockService.Post(testCtx, receipt)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	m2, err := stockService.GetMovement(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if m2.BatchID != m.BatchID {
+		t.Fatalf("expected batch %d, but was %d", m.BatchID, m2.BatchID)
+	}
+
+	// write-off by batch ID
+	if _, err = stockService.Post(testCtx, &lara.CreateStockMovement{
+		StockMovement: lara.StockMovement{ProductID: 5, Type: lara.WriteOff, Amount: "1",
+			BatchID: m.BatchID}}); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	for _, c := range []*lara.CreateStockMovement{
+		// different expiry
+		{StockMovement: lara.StockMovement{ProductID: 5, Type: lara.Receipt, Amount: "1"},
+			Batch: "X1", Expiry: expiry.AddDate(0, 0, 1)},
+		// new batch on write-off
+		{StockMovement: lara.StockMovement{ProductID: 5, Type: lara.WriteOff, Amount: "1"},
+			Batch: "X2"},
+		// other product's batch
+		{StockMovement: lara.StockMovement{ProductID: 5, Type: lara.WriteOff, Amount: "1",
+			BatchID: 1}},
+	} {
+		_, err = stockService.Post(testCtx, c)
+		if ok, actual := checkErrCode(err, 400); !ok {
+			t.Fatalf("movement %+v: expected error code 400 but was %d, %+v", c, actual, err)
+		}
+	}
+}
+
+func TestStockDispenseBatch(t *testing.T) {
+	id, err := recordService.Create(testCtx, &lara.CreateRecord{
+		PatientID: 1,
+		NewRecord: lara.NewRecord{
+			Items: []lara.RecordItem{
+				{ProductID: 4, Amount: "1", ItemType: lara.Material, BatchID: 3},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	r, err := recordService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if itm := r.Items[0]; itm.BatchID != 3 || itm.Batch != "C3" || timeEmpty(itm.Expiry) {
+		t.Fatalf("unexpected record item %+v", itm)
+	}
+
+	l, err := stockService.Batches(testCtx, 4)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	for _, b := range l.Items {
+		if b.ID == 3 && b.Quantity != "1.0000" {
+			t.Fatalf("expected batch quantity 1.0000, but was %s", b.Quantity)
+		}
+	}
+
+	for _, i := range []lara.RecordItem{
+		// expired batch
+		{ProductID: 4, Amount: "1", ItemType: lara.Material, BatchID: 1},
+		// other product's batch
+		{ProductID: 5, Amount: "1", ItemType: lara.Material, BatchID: 3},
+		// labor can't have batch
+		{ProductID: 4, Amount: "1", ItemType: lara.Labor, BatchID: 3},
+	} {
+		_, err = recordService.Create(testCtx, &lara.CreateRecord{
+			PatientID: 1,
+			NewRecord: lara.NewRecord{Items: []lara.RecordItem{i}},
+		})
+		if ok, actual := checkErrCode(err, 400); !ok {
+			t.Fatalf("item %+v: expected error code 400 but was %d, %+v", i, actual, err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/jkusniar/lara"
+)
+
+func TestGetSupplier(t *testing.T) {
+	s, err := supplierService.Get(testCtx, 1)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if s.Name != "Veterinárna lekáreň" || s.IC != "12345678" || s.Phone != "0900123456" ||
+		s.Email != "objednavky@lekaren.sk" || s.Creator != "testuser" {
+		t.Fatalf("unexpected supplier %+v", s)
+	}
+}
+
+func TestGetSupplierNotFound(t *testing.T) {
+	_, err := supplierService.Get(testCtx, 999)
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+}
+
+func TestCreateUpdateSupplier(t *testing.T) {
+	_, err := supplierService.Create(testCtx, &lara.CreateSupplier{})
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	id, err := supplierService.Create(testCtx, &lara.CreateSupplier{Supplier: lara.Supplier{
+		Name: "Test supplier", Contact: "Ján Novák"}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	err = supplierService.Update(testCtx, id, &lara.UpdateSupplier{Version: 0, Supplier: lara.Supplier{
+		Name: "Test supplier", Address: "Hlavná 1, Bratislava"}})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	s, err := supplierService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if s.Contact != "" || s.Address != "Hlavná 1, Bratislava" || s.Version != 1 || s.Modifier != "testuser" {
+		t.Fatalf("unexpected supplier %+v", s)
+	}
+
+	// update without version upgrade
+	err = supplierService.Update(testCtx, id, &lara.UpdateSupplier{Version: 0, Supplier: s.Supplier})
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	// update not existing
+	err = supplierService.Update(testCtx, 999, &lara.UpdateSupplier{Supplier: s.Supplier})
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+}
+
+func TestListSuppliers(t *testing.T) {
+	l, err := supplierService.List(testCtx)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(l.Items) < 2 {
+		t.Fatalf("expected at least 2 suppliers, but got %d", len(l.Items))
+	}
+	for i := 1; i < len(l.Items); i++ {
+		if l.Items[i-1].Name > l.Items[i].Name {
+			t.Fatalf("suppliers not ordered by name %+v", l.Items)
+		}
+	}
+}
@@ -20,7 +20,14 @@
 package postgres_test
 
 import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/jkusniar/lara"
 )
@@ -64,7 +71,19 @@ func TestCreateTag(t *testing.T) {
 		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
 	}
 
+	// invalid value
+	x.Type = "RFID"
+	x.Value = "703-0000-1234"
+	if _, err = tagService.Create(testCtx, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
 	// OK
+	x.Type = "Tattoo"
+	x.Value = "1982-SK-0728"
 	x.PatientID = 3
 	id, err := tagService.Create(testCtx, x)
 	if err != nil {
@@ -74,10 +93,35 @@ func TestCreateTag(t *testing.T) {
 	if id == 0 {
 		t.Fatal("incorrect ID returned")
 	}
+
+	// value is normalised and RFID is decoded
+	x.Type = "RFID"
+	x.Value = " 941 000 012 345 678 "
+	if id, err = tagService.Create(testCtx, x); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	tag, err := tagService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if tag.Type != "RFID" || tag.Value != "941000012345678" || tag.Microchip == nil ||
+		tag.Microchip.ManufacturerCode != 941 || tag.Microchip.Manufacturer != "Felixcan" ||
+		tag.Microchip.NationalID != "000012345678" {
+		t.Fatalf("unexpected result %+v", tag)
+	}
+
+	// duplicate after normalisation
+	x.Value = "941000012345678"
+	if _, err = tagService.Create(testCtx, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
 }
 
 func TestUpdateTag(t *testing.T) {
-	x := &lara.UpdateTag{Version: 1, Data: []byte{11, 11, 11, 11}}
+	x := &lara.UpdateTag{Version: 1}
 	var err error
 
 	// value missing
@@ -89,7 +133,7 @@ func TestUpdateTag(t *testing.T) {
 	}
 
 	// bad ID
-	x.Value = "updated-tag-value"
+	x.Value = "703 000 012 345 678"
 	if err = tagService.Update(testCtx, 100, x); err == nil {
 		t.Fatal("expected error")
 	}
@@ -105,12 +149,31 @@ func TestUpdateTag(t *testing.T) {
 		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
 	}
 
-	// OK
+	// invalid value of tag's type (RFID)
 	x.Version = 2
+	x.Value = "updated-tag-value"
+	if err = tagService.Update(testCtx, 2, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// OK
+	x.Value = "703 000 012 345 678"
 	err = tagService.Update(testCtx, 2, x)
 	if err != nil {
 		t.Fatalf("expected nil error, but was %+v", err)
 	}
+
+	tag, err := tagService.Get(testCtx, 2)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if tag.Value != "703000012345678" || tag.Version != 3 || tag.Microchip == nil ||
+		tag.Microchip.CountryCode != 703 || tag.Microchip.Country != "Slovakia" {
+		t.Fatalf("unexpected result %+v", tag)
+	}
 }
 
 func TestGetTag(t *testing.T) {
@@ -135,7 +198,8 @@ func TestGetTag(t *testing.T) {
 		t.Fatal("expected not nil result")
 	}
 
-	if x.ID != 1 || x.Value != "2017-SK-0007" || len(x.Data) != 0 || x.Type != "LyssaVirus" {
+	if x.ID != 1 || x.Value != "2017-SK-0007" || x.DataType != "" || x.Type != "LyssaVirus" ||
+		x.Microchip != nil || !x.Active || x.Replaces != 0 || x.ReplacedBy != 0 {
 		t.Fatalf("unexpected result %+v", x)
 	}
 }
@@ -170,7 +234,315 @@ func TestGetPatientByTag(t *testing.T) {
 	if x == nil {
 		t.Fatal("expected not nil result")
 	}
-	if x.OwnerID != 1 || x.TagType != "LyssaVirus" {
+	if x.OwnerID != 1 || x.PatientID != 1 || x.TagType != "LyssaVirus" {
+		t.Fatalf("unexpected result %+v", x)
+	}
+
+	// found by normalised value
+	x, err = tagService.GetPatientByTag(testCtx, " 2017-sk-0007")
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if x.OwnerID != 1 {
 		t.Fatalf("unexpected result %+v", x)
 	}
 }
+
+func TestReplaceTag(t *testing.T) {
+	x := &lara.ReplaceTag{Version: 1,
+		ValidFrom: time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC),
+		ValidTo:   time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	var err error
+
+	// value missing
+	if _, err = tagService.Replace(testCtx, 4, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// validity ends before it starts
+	x.Value = " 2019-sk-0100"
+	if _, err = tagService.Replace(testCtx, 4, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// bad ID
+	x.ValidTo = time.Time{}
+	if _, err = tagService.Replace(testCtx, 100, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+
+	// version mismatch
+	if _, err = tagService.Replace(testCtx, 4, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	// invalid value of tag's type (LyssaVirus)
+	x.Version = 0
+	x.Value = "SK123456789"
+	if _, err = tagService.Replace(testCtx, 4, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// OK
+	x.Value = " 2019-sk-0100"
+	id, err := tagService.Replace(testCtx, 4, x)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	old, err := tagService.Get(testCtx, 4)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if old.Active || old.ReplacedBy != id || old.Version != 1 ||
+		!old.ValidTo.Equal(time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected replaced tag %+v", old)
+	}
+
+	tag, err := tagService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if !tag.Active || tag.Replaces != 4 || tag.Value != "2019-SK-0100" || tag.Type != "LyssaVirus" {
+		t.Fatalf("unexpected new tag %+v", tag)
+	}
+
+	// replaced tag can't be replaced or updated again
+	x.Version = 1
+	if _, err = tagService.Replace(testCtx, 4, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+	err = tagService.Update(testCtx, 4, &lara.UpdateTag{Version: 1, Value: "2018-SK-0101"})
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	// historical value is found flagged inactive
+	p, err := tagService.GetPatientByTag(testCtx, "2018-SK-0100")
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if p.TagID != 4 || p.Active || p.ReplacedBy != "2019-SK-0100" || p.PatientID != 5 {
+		t.Fatalf("unexpected result %+v", p)
+	}
+
+	p, err = tagService.GetPatientByTag(testCtx, "2019-SK-0100")
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if p.TagID != id || !p.Active || p.ReplacedBy != "" || p.PatientID != 5 {
+		t.Fatalf("unexpected result %+v", p)
+	}
+}
+
+func TestDeactivateTag(t *testing.T) {
+	x := &lara.DeactivateTag{Version: 1}
+	var err error
+
+	// bad ID
+	if err = tagService.Deactivate(testCtx, 100, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+
+	// version mismatch
+	if err = tagService.Deactivate(testCtx, 5, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	// OK
+	x.Version = 0
+	if err = tagService.Deactivate(testCtx, 5, x); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	tag, err := tagService.Get(testCtx, 5)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if tag.Active || tag.ValidTo.IsZero() || tag.ReplacedBy != 0 {
+		t.Fatalf("unexpected result %+v", tag)
+	}
+
+	// already inactive
+	x.Version = 1
+	if err = tagService.Deactivate(testCtx, 5, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	// value of inactive tag can be reused, active tag wins lookup
+	id, err := tagService.Create(testCtx, &lara.CreateTag{PatientID: 3,
+		Type: "LyssaVirus", Value: "2018-SK-0200"})
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	p, err := tagService.GetPatientByTag(testCtx, "2018-SK-0200")
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if p.TagID != id || !p.Active || p.PatientID != 3 {
+		t.Fatalf("unexpected result %+v", p)
+	}
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{B: 0xff, A: 0xff})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode test image failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestTagData(t *testing.T) {
+	scan := testPNG(t, 400, 300)
+	var err error
+
+	// bad ID
+	if err = tagService.PutData(testCtx, 100, bytes.NewReader(scan)); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+
+	// empty
+	if err = tagService.PutData(testCtx, 1, strings.NewReader("")); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// unsupported content type
+	if err = tagService.PutData(testCtx, 1, strings.NewReader("plain text")); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 415); !ok {
+		t.Fatalf("expected error code 415 but was %d, %+v", actual, err)
+	}
+
+	// too large
+	large := "%PDF-1.4 " + strings.Repeat("x", 16<<10)
+	if err = tagService.PutData(testCtx, 1, strings.NewReader(large)); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 413); !ok {
+		t.Fatalf("expected error code 413 but was %d, %+v", actual, err)
+	}
+
+	// corrupted image
+	if err = tagService.PutData(testCtx, 1, bytes.NewReader(scan[:64])); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// inactive tag (replaced by TestReplaceTag)
+	if err = tagService.PutData(testCtx, 4, bytes.NewReader(scan)); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	// OK image with thumbnail
+	before, err := tagService.Get(testCtx, 1)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if err = tagService.PutData(testCtx, 1, bytes.NewReader(scan)); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	tag, err := tagService.Get(testCtx, 1)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if tag.DataType != "image/png" || tag.DataSize != int64(len(scan)) || !tag.Thumbnail ||
+		tag.Version != before.Version+1 || tag.Modifier != "testuser" {
+		t.Fatalf("unexpected result %+v", tag)
+	}
+
+	d, err := tagService.GetData(testCtx, 1)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if d.ContentType != "image/png" || !bytes.Equal(d.Content, scan) {
+		t.Fatalf("unexpected data %s, %d bytes", d.ContentType, len(d.Content))
+	}
+
+	d, err = tagService.GetThumbnail(testCtx, 1)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	th, err := jpeg.Decode(bytes.NewReader(d.Content))
+	if err != nil {
+		t.Fatalf("thumbnail is not JPEG: %v", err)
+	}
+	if b := th.Bounds(); d.ContentType != "image/jpeg" || b.Dx() != 200 || b.Dy() != 150 {
+		t.Fatalf("unexpected thumbnail %s %v", d.ContentType, b)
+	}
+
+	// OK document without thumbnail
+	if err = tagService.PutData(testCtx, 1, strings.NewReader("%PDF-1.4 certificate")); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if _, err = tagService.GetThumbnail(testCtx, 1); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+
+	// delete
+	if err = tagService.DeleteData(testCtx, 1); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if _, err = tagService.GetData(testCtx, 1); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+	if tag, err = tagService.Get(testCtx, 1); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if tag.DataType != "" || tag.DataSize != 0 || tag.Thumbnail {
+		t.Fatalf("unexpected result %+v", tag)
+	}
+}
@@ -20,14 +20,23 @@ INSERT INTO lov_unit (name) VALUES ('ml.');
 INSERT INTO lov_unit (name) VALUES ('tbl.');
 
 --id=1
-INSERT INTO lov_product (NAME, UNIT_ID, PRICE, VALID_TO, PLU)
-VALUES ('Vyšetrenie uší a čistenie', 1, 7.00, to_date('31 Mar 2015', 'DD Mon YYYY'), '10');
+INSERT INTO lov_product (NAME, UNIT_ID, PRICE, VALID_TO, PLU, CREATOR, CREATED)
+VALUES ('Vyšetrenie uší a čistenie', 1, 7.00, to_date('31 Mar 2015', 'DD Mon YYYY'), '10', 'testuser', current_timestamp);
 --id=2
-INSERT INTO lov_product (NAME, UNIT_ID, PRICE, VALID_TO)
-VALUES ('Vyšetrenie 2', 1, 5.00, current_date);
+INSERT INTO lov_product (NAME, UNIT_ID, PRICE, VALID_TO, CREATOR, CREATED)
+VALUES ('Vyšetrenie 2', 1, 5.00, current_date, 'testuser', current_timestamp);
 --id=3
-INSERT INTO lov_product (NAME, UNIT_ID, PRICE, PLU)
-VALUES ('Vystavenie potvrdenia o zdravotnom stave psa', 2, 4.00, 42);
+INSERT INTO lov_product (NAME, UNIT_ID, PRICE, PLU, VAT_RATE, CREATOR, CREATED)
+VALUES ('Vystavenie potvrdenia o zdravotnom stave psa', 2, 4.00, 42, 20.00, 'testuser', current_timestamp);
+
+INSERT INTO product_price (prod_id, price, vat_rate, creator, created)
+VALUES (1, 7.00, 0, 'testuser', current_timestamp);
+INSERT INTO product_price (prod_id, price, vat_rate, valid_to, creator, created)
+VALUES (2, 4.50, 10.00, to_date('31 Dec 2016', 'DD Mon YYYY'), 'testuser', current_timestamp);
+INSERT INTO product_price (prod_id, price, vat_rate, valid_from, creator, created)
+VALUES (2, 5.00, 0, to_date('01 Jan 2017', 'DD Mon YYYY'), 'testuser', current_timestamp);
+INSERT INTO product_price (prod_id, price, vat_rate, creator, created)
+VALUES (3, 4.00, 20.00, 'testuser', current_timestamp);
 
 -- id=1
 INSERT INTO lov_city (city,
@@ -92,6 +101,21 @@ INSERT INTO tag_type (name) VALUES ('LyssaVirus');
 -- id=2
 INSERT INTO tag_type (name) VALUES ('RFID');
 
+-- id=1
+INSERT INTO lov_diagnosis (code, name) VALUES ('K08', 'dental disease');
+-- id=2
+INSERT INTO lov_diagnosis (code, name) VALUES ('D50', 'anaemia');
+-- id=3
+INSERT INTO lov_diagnosis (code, name) VALUES ('Z00', 'general examination');
+
+-- id=1
+INSERT INTO lov_phrase (name, phrase_text)
+VALUES ('exam', '{patient} ({species}, {breed}) examined on {date}, owner {owner}. {unknown}');
+-- id=2
+INSERT INTO lov_phrase (name, phrase_text, user_login) VALUES ('exam', 'someone else''s exam', 'otheruser');
+-- id=3
+INSERT INTO lov_phrase (name, phrase_text, user_login) VALUES ('mine', 'my own exam', 'testuser');
+
 -- GENERIC DATA
 -----------------------------------------------------------------------------
 
@@ -132,10 +156,13 @@ VALUES (1, to_timestamp('22 Apr 2003 00:01:00', 'DD Mon YYYY HH24:MI:SS'), true,
 INSERT INTO record_item (record_id, prod_id, amount, item_price, prod_price, item_type)
 VALUES (3, 1, 1.0, 3.14, 3.14, 0);
 -- id=4
-INSERT INTO record (patient_id, rec_date, billed, creator, created)
-VALUES (1, to_timestamp('05 Jun 2010 12:00:00', 'DD Mon YYYY HH24:MI:SS'), true, 'testuser', current_timestamp);
+INSERT INTO record (patient_id, rec_date, billed, subjective, weight, temperature, heart_rate, creator, created)
+VALUES (1, to_timestamp('05 Jun 2010 12:00:00', 'DD Mon YYYY HH24:MI:SS'), true, 'not eating', 12.5, 38.9, 110,
+        'testuser', current_timestamp);
 INSERT INTO record_item (record_id, prod_id, amount, item_price, prod_price, item_type)
 VALUES (4, 1, 1.0, 3.14, 3.14, 0);
+INSERT INTO record_diagnosis (record_id, diagnosis_id) VALUES (4, 1);
+INSERT INTO record_diagnosis (record_id, diagnosis_id) VALUES (4, 2);
 -- id=5
 INSERT INTO record (patient_id, rec_date, billed, creator, created)
 VALUES (1, to_timestamp('04 Feb 2017 23:58:00', 'DD Mon YYYY HH24:MI:SS'), false, 'testuser', current_timestamp);
@@ -231,4 +258,181 @@ INSERT INTO patient (owner_id,
    current_timestamp, 5);
 
 -- id=2
-INSERT INTO tag (value, patient_id, tag_type_id, creator, created, version) VALUES ('tag-id', 3,2,'testuser',current_timestamp, 2);
\ No newline at end of file
+INSERT INTO tag (value, patient_id, tag_type_id, creator, created, version) VALUES ('tag-id', 3,2,'testuser',current_timestamp, 2);
+
+-- Scheduling
+-- id=7
+INSERT INTO owner (first_name, last_name, phone_1, email, city_id, street_id, house_no, creator, created)
+VALUES ('Test', 'Scheduling', '0900111222', 'scheduling@test.com', 1, 1, '2', 'testuser', current_timestamp);
+-- id=4
+INSERT INTO patient (owner_id, name, species_id, creator, created) VALUES (7, 'scheduled-pet', 1, 'testuser', current_timestamp);
+
+-- Appointments
+-- id=1
+INSERT INTO appointment (patient_id, vet, room, start_time, end_time, status, creator, created)
+VALUES (4, 'vet1', 'A', to_timestamp('01 May 2017 10:00:00', 'DD Mon YYYY HH24:MI:SS'),
+        to_timestamp('01 May 2017 10:30:00', 'DD Mon YYYY HH24:MI:SS'), 0, 'testuser', current_timestamp);
+-- id=2
+INSERT INTO appointment (patient_id, vet, start_time, end_time, status, creator, created)
+VALUES (4, 'vet1', to_timestamp('01 May 2017 11:00:00', 'DD Mon YYYY HH24:MI:SS'),
+        to_timestamp('01 May 2017 11:30:00', 'DD Mon YYYY HH24:MI:SS'), 0, 'testuser', current_timestamp);
+-- id=3
+INSERT INTO appointment (patient_id, vet, start_time, end_time, status, cancel_reason, creator, created)
+VALUES (4, 'vet2', to_timestamp('02 May 2017 09:00:00', 'DD Mon YYYY HH24:MI:SS'),
+        to_timestamp('02 May 2017 09:30:00', 'DD Mon YYYY HH24:MI:SS'), 1, 'sick', 'testuser', current_timestamp);
+-- id=4
+INSERT INTO appointment (patient_id, vet, start_time, end_time, status, creator, created)
+VALUES (4, 'vet2', to_timestamp('03 May 2017 09:00:00', 'DD Mon YYYY HH24:MI:SS'),
+        to_timestamp('03 May 2017 09:30:00', 'DD Mon YYYY HH24:MI:SS'), 0, 'testuser', current_timestamp);
+-- id=5
+INSERT INTO appointment (patient_id, vet, start_time, end_time, status, creator, created)
+VALUES (4, 'vet2', to_timestamp('04 May 2017 09:00:00', 'DD Mon YYYY HH24:MI:SS'),
+        to_timestamp('04 May 2017 09:30:00', 'DD Mon YYYY HH24:MI:SS'), 0, 'testuser', current_timestamp);
+
+-- Vaccinations
+-- id=1, revaccinated by id=2
+INSERT INTO vaccination (patient_id, prod_id, batch, administered, valid_months, due_date, vet, creator, created)
+VALUES (4, 3, 'B-2015', to_date('10 Jun 2015', 'DD Mon YYYY'), 12, to_date('10 Jun 2016', 'DD Mon YYYY'), 'vet1',
+        'testuser', current_timestamp);
+-- id=2
+INSERT INTO vaccination (patient_id, prod_id, batch, administered, valid_months, due_date, vet, creator, created)
+VALUES (4, 3, 'B-2016', to_date('05 Jun 2016', 'DD Mon YYYY'), 12, to_date('05 Jun 2017', 'DD Mon YYYY'), 'vet1',
+        'testuser', current_timestamp);
+-- id=3
+INSERT INTO vaccination (patient_id, prod_id, batch, administered, valid_months, due_date, vet, creator, created)
+VALUES (4, 2, 'B-2016', to_date('20 Jun 2016', 'DD Mon YYYY'), 12, to_date('20 Jun 2017', 'DD Mon YYYY'), 'vet1',
+        'testuser', current_timestamp);
+
+-- Invoices
+-- id=8
+INSERT INTO record (patient_id, rec_date, billed, creator, created)
+VALUES (4, to_timestamp('10 May 2017 10:00:00', 'DD Mon YYYY HH24:MI:SS'), false, 'testuser', current_timestamp);
+INSERT INTO record_item (record_id, prod_id, amount, item_price, prod_price, item_type, vat_rate)
+VALUES (8, 3, 1.0, 4.00, 4.00, 0, 20.00);
+INSERT INTO record_item (record_id, prod_id, amount, item_price, prod_price, item_type, vat_rate)
+VALUES (8, 2, 2.0, 10.00, 5.00, 1, 10.00);
+-- id=9
+INSERT INTO record (patient_id, rec_date, billed, creator, created)
+VALUES (4, to_timestamp('15 May 2017 10:00:00', 'DD Mon YYYY HH24:MI:SS'), false, 'testuser', current_timestamp);
+INSERT INTO record_item (record_id, prod_id, amount, item_price, prod_price, item_type, vat_rate)
+VALUES (9, 3, 1.0, 4.00, 4.00, 0, 20.00);
+-- id=10
+INSERT INTO record (patient_id, rec_date, billed, creator, created)
+VALUES (4, to_timestamp('16 May 2017 10:00:00', 'DD Mon YYYY HH24:MI:SS'), true, 'testuser', current_timestamp);
+INSERT INTO record_item (record_id, prod_id, amount, item_price, prod_price, item_type)
+VALUES (10, 3, 1.0, 4.00, 4.00, 0);
+
+-- Stock
+-- id=4
+INSERT INTO lov_product (NAME, UNIT_ID, PRICE, CREATOR, CREATED)
+VALUES ('Ampicilín tbl.', 2, 0.50, 'testuser', current_timestamp);
+-- id=5
+INSERT INTO lov_product (NAME, UNIT_ID, PRICE, CREATOR, CREATED)
+VALUES ('Obväz elastický', 2, 1.20, 'testuser', current_timestamp);
+INSERT INTO product_price (prod_id, price, vat_rate, creator, created)
+VALUES (4, 0.50, 0, 'testuser', current_timestamp);
+INSERT INTO product_price (prod_id, price, vat_rate, creator, created)
+VALUES (5, 1.20, 0, 'testuser', current_timestamp);
+-- id=1
+INSERT INTO stock_movement (prod_id, mov_type, quantity, mov_date, creator, created)
+VALUES (4, 0, 10.0, to_timestamp('10 Jan 2017 10:00:00', 'DD Mon YYYY HH24:MI:SS'), 'testuser', current_timestamp);
+-- id=2
+INSERT INTO stock_movement (prod_id, mov_type, quantity, mov_date, note, creator, created)
+VALUES (4, 3, -1.0, to_timestamp('20 Jan 2017 10:00:00', 'DD Mon YYYY HH24:MI:SS'), 'broken', 'testuser', current_timestamp);
+-- id=1
+INSERT INTO stock_batch (prod_id, batch_no, expiry, creator, created)
+VALUES (4, 'A1', to_date('31 Mar 2017', 'DD Mon YYYY'), 'testuser', current_timestamp);
+-- id=2
+INSERT INTO stock_batch (prod_id, batch_no, expiry, creator, created)
+VALUES (4, 'B2', current_date + 10, 'testuser', current_timestamp);
+-- id=3
+INSERT INTO stock_batch (prod_id, batch_no, expiry, creator, created)
+VALUES (4, 'C3', current_date + 365, 'testuser', current_timestamp);
+-- id=3
+INSERT INTO stock_movement (prod_id, mov_type, quantity, mov_date, batch_id, creator, created)
+VALUES (4, 0, 5.0, to_timestamp('10 Feb 2017 10:00:00', 'DD Mon YYYY HH24:MI:SS'), 1, 'testuser', current_timestamp);
+-- id=4
+INSERT INTO stock_movement (prod_id, mov_type, quantity, mov_date, batch_id, creator, created)
+VALUES (4, 0, 3.0, to_timestamp('10 Feb 2017 10:00:00', 'DD Mon YYYY HH24:MI:SS'), 2, 'testuser', current_timestamp);
+-- id=5
+INSERT INTO stock_movement (prod_id, mov_type, quantity, mov_date, batch_id, creator, created)
+VALUES (4, 0, 2.0, to_timestamp('10 Feb 2017 10:00:00', 'DD Mon YYYY HH24:MI:SS'), 3, 'testuser', current_timestamp);
+
+-- Suppliers and purchase orders
+-- id=6
+INSERT INTO lov_product (NAME, UNIT_ID, PRICE, MIN_STOCK, CREATOR, CREATED)
+VALUES ('Striekačka 5 ml', 2, 0.20, 50, 'testuser', current_timestamp);
+INSERT INTO product_price (prod_id, price, vat_rate, creator, created)
+VALUES (6, 0.20, 0, 'testuser', current_timestamp);
+-- id=1
+INSERT INTO supplier (name, ic, phone, email, creator, created)
+VALUES ('Veterinárna lekáreň', '12345678', '0900123456', 'objednavky@lekaren.sk', 'testuser', current_timestamp);
+-- id=2
+INSERT INTO supplier (name, creator, created)
+VALUES ('Zdravotnícke potreby', 'testuser', current_timestamp);
+
+-- Full-text search
+-- id=8
+INSERT INTO owner (first_name, last_name, phone_1, email, ic, creator, created)
+VALUES ('Ján', 'Kováč', '0905 123 456', 'ordinacia.kv@example.sk', '87654321', 'testuser', current_timestamp);
+-- id=5
+INSERT INTO patient (owner_id, name, creator, created) VALUES (8, 'Žofka', 'testuser', current_timestamp);
+-- id=3
+INSERT INTO tag (value, patient_id, tag_type_id, creator, created)
+VALUES ('SK-998877-01', 5, 2, 'testuser', current_timestamp);
+-- id=4, replaced by tag replacement test
+INSERT INTO tag (value, patient_id, tag_type_id, valid_from, creator, created)
+VALUES ('2018-SK-0100', 5, 1, '2018-01-01', 'testuser', current_timestamp);
+-- id=5, deactivated by tag deactivation test
+INSERT INTO tag (value, patient_id, tag_type_id, creator, created)
+VALUES ('2018-SK-0200', 5, 1, 'testuser', current_timestamp);
+
+-- Prescriptions and controlled drugs
+-- id=7
+INSERT INTO lov_product (NAME, UNIT_ID, PRICE, CONTROLLED, CREATOR, CREATED)
+VALUES ('Ketamín 10%', 1, 2.00, true, 'testuser', current_timestamp);
+INSERT INTO product_price (prod_id, price, vat_rate, creator, created)
+VALUES (7, 2.00, 0, 'testuser', current_timestamp);
+-- id=6
+INSERT INTO stock_movement (prod_id, mov_type, quantity, mov_date, creator, created)
+VALUES (7, 0, 20.0, to_timestamp('01 Mar 2017 10:00:00', 'DD Mon YYYY HH24:MI:SS'), 'testuser', current_timestamp);
+-- id=11, dispensed as prescribed
+INSERT INTO record (patient_id, rec_date, billed, creator, created)
+VALUES (5, to_timestamp('10 Mar 2017 10:00:00', 'DD Mon YYYY HH24:MI:SS'), false, 'testuser', current_timestamp);
+INSERT INTO record_item (record_id, prod_id, amount, item_price, prod_price, item_type)
+VALUES (11, 7, 2.0, 4.00, 2.00, 1);
+-- id=7
+INSERT INTO stock_movement (prod_id, mov_type, quantity, mov_date, record_id, creator, created)
+VALUES (7, 1, -2.0, to_timestamp('10 Mar 2017 10:00:00', 'DD Mon YYYY HH24:MI:SS'), 11, 'testuser', current_timestamp);
+-- id=12, dispensed without prescription
+INSERT INTO record (patient_id, rec_date, billed, creator, created)
+VALUES (5, to_timestamp('15 Mar 2017 10:00:00', 'DD Mon YYYY HH24:MI:SS'), false, 'testuser', current_timestamp);
+INSERT INTO record_item (record_id, prod_id, amount, item_price, prod_price, item_type)
+VALUES (12, 7, 1.5, 3.00, 2.00, 1);
+-- id=8
+INSERT INTO stock_movement (prod_id, mov_type, quantity, mov_date, record_id, creator, created)
+VALUES (7, 1, -1.5, to_timestamp('15 Mar 2017 10:00:00', 'DD Mon YYYY HH24:MI:SS'), 12, 'testuser', current_timestamp);
+-- id=1
+INSERT INTO prescription (record_id, patient_id, presc_date, note, creator, created)
+VALUES (11, 5, to_timestamp('10 Mar 2017 10:00:00', 'DD Mon YYYY HH24:MI:SS'), 'sedation', 'testuser', current_timestamp);
+INSERT INTO prescription_item (prescription_id, prod_id, amount, dose, frequency, duration_days, withdrawal_days)
+VALUES (1, 7, 2.0, '1 ml', 'once', 0, 28);
+-- id=2, not controlled drug
+INSERT INTO prescription (record_id, patient_id, presc_date, creator, created)
+VALUES (12, 5, to_timestamp('15 Mar 2017 10:00:00', 'DD Mon YYYY HH24:MI:SS'), 'testuser', current_timestamp);
+INSERT INTO prescription_item (prescription_id, prod_id, amount, dose, frequency, duration_days, withdrawal_days)
+VALUES (2, 4, 10.0, '1 tbl.', 'twice a day', 5, 0);
+
+-- Material of records created before stock ledger, dispensed by migration
+-- id=9
+INSERT INTO stock_movement (prod_id, mov_type, quantity, mov_date, record_id, creator, created)
+VALUES (1, 1, -1.0, to_timestamp('21 Apr 2003 23:58:00', 'DD Mon YYYY HH24:MI:SS'), 1, 'testuser', current_timestamp);
+-- id=10
+INSERT INTO stock_movement (prod_id, mov_type, quantity, mov_date, record_id, creator, created)
+VALUES (2, 1, -1.0, to_timestamp('21 Apr 2003 23:58:00', 'DD Mon YYYY HH24:MI:SS'), 1, 'testuser', current_timestamp);
+-- id=11
+INSERT INTO stock_movement (prod_id, mov_type, quantity, mov_date, record_id, creator, created)
+VALUES (1, 1, -2.0, to_timestamp('21 Apr 2003 23:50:00', 'DD Mon YYYY HH24:MI:SS'), 2, 'testuser', current_timestamp);
+-- id=12
+INSERT INTO stock_movement (prod_id, mov_type, quantity, mov_date, record_id, creator, created)
+VALUES (2, 1, -2.0, to_timestamp('10 May 2017 10:00:00', 'DD Mon YYYY HH24:MI:SS'), 8, 'testuser', current_timestamp);
@@ -120,3 +120,117 @@ func TestRevoke(t *testing.T) {
 		t.Fatalf("expected nil error, but was %+v", err)
 	}
 }
+
+func TestListUsers(t *testing.T) {
+	l, err := userService.List(testCtx)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	if len(l.Items) < 2 || l.Items[0].Login != "test" || l.Items[1].Login != "test2" {
+		t.Fatalf("unexpected result %+v", l)
+	}
+	if u := l.Items[1]; len(u.Permissions) != 2 || u.Permissions[0] != "EditRecord" ||
+		u.Permissions[1] != "ViewRecord" || u.Disabled {
+		t.Fatalf("unexpected user %+v", u)
+	}
+}
+
+func TestGetUser(t *testing.T) {
+	// non-existing user
+	_, err := userService.Get(testCtx, "jimi")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+
+	// OK
+	u, err := userService.Get(testCtx, "test2")
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if u.Login != "test2" || len(u.Permissions) != 2 || u.Disabled {
+		t.Fatalf("unexpected result %+v", u)
+	}
+}
+
+func TestDisableUser(t *testing.T) {
+	// non-existing user
+	err := userService.Disable(testCtx, "jimi")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+
+	// user in context
+	err = userService.Disable(testCtx, "testuser")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// OK, disabled user can't log in
+	if err = userService.Disable(testCtx, "test"); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	_, err = userService.Authenticate(testCtx, "test", "TestPassword")
+	if ok, actual := checkErrCode(err, 401); !ok {
+		t.Fatalf("expected error code 401 but was %d, %+v", actual, err)
+	}
+	if u, err := userService.Get(testCtx, "test"); err != nil || !u.Disabled {
+		t.Fatalf("expected disabled user, but was %+v, %+v", u, err)
+	}
+	// tokens issued before disabling are rejected
+	if ok, actual := checkErrCode(userService.CheckEnabled(testCtx, "test"), 401); !ok {
+		t.Fatalf("expected error code 401 but was %d", actual)
+	}
+	if ok, actual := checkErrCode(userService.CheckEnabled(testCtx, "jimi"), 401); !ok {
+		t.Fatalf("expected error code 401 but was %d", actual)
+	}
+
+	// enable
+	err = userService.Enable(testCtx, "jimi")
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+	if err = userService.Enable(testCtx, "test"); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if _, err = userService.Authenticate(testCtx, "test", "TestPassword"); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if err = userService.CheckEnabled(testCtx, "test"); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	// password empty
+	err := userService.ResetPassword(testCtx, "test2", "")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// non-existing user
+	err = userService.ResetPassword(testCtx, "jimi", "TestPassword")
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+
+	// OK
+	if err = userService.ResetPassword(testCtx, "test2", "TestPassword"); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if _, err = userService.Authenticate(testCtx, "test2", "TestPassword"); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+}
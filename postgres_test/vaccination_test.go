@@ -0,0 +1,182 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package postgres_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jkusniar/lara"
+)
+
+func vaccinationDate(year int, month time.Month, day int) time.Time {
+	loc, _ := time.LoadLocation("Europe/Bratislava")
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+func TestGetDueVaccinations(t *testing.T) {
+	r := &lara.ReportRequest{ValidFrom: vaccinationDate(2017, time.June, 1),
+		ValidTo: vaccinationDate(2017, time.June, 30)}
+
+	l, err := vaccinationService.GetDue(testCtx, r)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if l == nil {
+		t.Fatal("expected not nil result")
+	}
+
+	// vaccination 1 is superseded by 2
+	if len(l.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(l.Items))
+	}
+
+	if l.Items[0].VaccinationID != 2 || l.Items[1].VaccinationID != 3 {
+		t.Fatalf("unexpected result %+v", l)
+	}
+
+	if d := l.Items[0]; d.PatientID != 4 || d.PatientName != "scheduled-pet" ||
+		d.OwnerID != 7 || d.OwnerName != "Test Scheduling" || d.Phone1 != "0900111222" {
+		t.Fatalf("unexpected result %+v", d)
+	}
+
+	// nothing due
+	r.ValidFrom = vaccinationDate(2016, time.January, 1)
+	r.ValidTo = vaccinationDate(2016, time.December, 31)
+	if l, err = vaccinationService.GetDue(testCtx, r); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(l.Items) != 0 {
+		t.Fatalf("expected 0 items, got %d", len(l.Items))
+	}
+}
+
+func TestGetVaccination(t *testing.T) {
+	// get not existing
+	_, err := vaccinationService.Get(testCtx, 100)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+
+	// get OK
+	v, err := vaccinationService.Get(testCtx, 2)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if v == nil {
+		t.Fatal("expected not nil result")
+	}
+
+	if v.ID != 2 || v.PatientID != 4 || v.ProductID != 3 || v.Batch != "B-2016" ||
+		v.ValidMonths != 12 || v.Vet != "vet1" || v.Due.IsZero() {
+		t.Fatalf("unexpected result %+v", v)
+	}
+}
+
+func TestCreateVaccination(t *testing.T) {
+	x := &lara.CreateVaccination{}
+	var err error
+
+	// patient missing
+	if _, err = vaccinationService.Create(testCtx, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// product missing
+	x.PatientID = 4
+	if _, err = vaccinationService.Create(testCtx, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// administered missing
+	x.ProductID = 3
+	if _, err = vaccinationService.Create(testCtx, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// OK
+	x.Administered = vaccinationDate(2017, time.June, 1)
+	x.ValidMonths = 12
+	id, err := vaccinationService.Create(testCtx, x)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if id == 0 {
+		t.Fatal("incorrect ID returned")
+	}
+
+	v, err := vaccinationService.Get(testCtx, id)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if due := vaccinationDate(2018, time.June, 1); !v.Due.Equal(due) {
+		t.Fatalf("expected due date %v, but was %v", due, v.Due)
+	}
+}
+
+func TestUpdateVaccination(t *testing.T) {
+	x := &lara.UpdateVaccination{Version: 1}
+	var err error
+
+	// product missing
+	if err = vaccinationService.Update(testCtx, 1, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 400); !ok {
+		t.Fatalf("expected error code 400 but was %d, %+v", actual, err)
+	}
+
+	// bad ID
+	x.ProductID = 3
+	x.Administered = vaccinationDate(2015, time.June, 10)
+	if err = vaccinationService.Update(testCtx, 100, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 404); !ok {
+		t.Fatalf("expected error code 404 but was %d, %+v", actual, err)
+	}
+
+	// version mismatch
+	if err = vaccinationService.Update(testCtx, 1, x); err == nil {
+		t.Fatal("expected error")
+	}
+	if ok, actual := checkErrCode(err, 409); !ok {
+		t.Fatalf("expected error code 409 but was %d, %+v", actual, err)
+	}
+
+	// OK
+	x.Version = 0
+	x.Batch = "B-2015-fixed"
+	if err = vaccinationService.Update(testCtx, 1, x); err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+}
@@ -0,0 +1,166 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package lara
+
+import (
+	"context"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// PRESCRIPTION MANAGEMENT SERVICE
+
+// PrescriptionItem is JSON encoded prescribed drug
+type PrescriptionItem struct {
+	ProductID      uint64 `json:"productId"`      // drug from product catalogue
+	Amount         string `json:"amount"`         // total prescribed amount in product's unit (formatted decimal, precision: 10.4)
+	Dose           string `json:"dose"`           // single dose, e.g. "1 tablet"
+	Frequency      string `json:"frequency"`      // e.g. "twice a day"
+	DurationDays   uint   `json:"durationDays"`   // length of treatment, 0 if single dose
+	WithdrawalDays uint   `json:"withdrawalDays"` // withdrawal period of food-producing animals after last dose, 0 if none
+}
+
+// GetPrescriptionItem is JSON encoded retrievable prescribed drug
+type GetPrescriptionItem struct {
+	ID uint64 `json:"id"`
+	PrescriptionItem
+	Product       string    `json:"product"`
+	Unit          string    `json:"unit"`
+	Controlled    bool      `json:"controlled"`    // drug is logged in controlled-drug register
+	WithdrawalEnd time.Time `json:"withdrawalEnd"` // end of withdrawal period, zero if none
+}
+
+// Prescription is JSON encoded updatable prescription fields
+type Prescription struct {
+	Date  time.Time          `json:"date"` // now if empty
+	Note  string             `json:"note"`
+	Items []PrescriptionItem `json:"items"`
+}
+
+// GetPrescription is JSON encoded retrievable prescription data
+type GetPrescription struct {
+	Versioned
+	CreatorModifier
+	RecordID  uint64                `json:"recordId"`
+	PatientID uint64                `json:"patientId"`
+	Date      time.Time             `json:"date"`
+	Note      string                `json:"note"`
+	Items     []GetPrescriptionItem `json:"items"`
+	// WithdrawalEnd is the latest end of items' withdrawal periods, animal's
+	// products must not enter food chain before. Zero if none.
+	WithdrawalEnd time.Time `json:"withdrawalEnd"`
+}
+
+// CreatePrescription is JSON encoded create prescription data. Patient is
+// taken from record.
+type CreatePrescription struct {
+	RecordID uint64 `json:"recordId"`
+	Prescription
+}
+
+// UpdatePrescription is JSON encoded update prescription data
+type UpdatePrescription struct {
+	Version uint64 `json:"version"`
+	Prescription
+}
+
+// PrescriptionListItem is JSON encoded prescription summary
+type PrescriptionListItem struct {
+	ID            uint64    `json:"id"`
+	RecordID      uint64    `json:"recordId"`
+	Date          time.Time `json:"date"`
+	Drugs         string    `json:"drugs"` // comma separated names of prescribed products
+	WithdrawalEnd time.Time `json:"withdrawalEnd"`
+}
+
+// PrescriptionList is JSON encoded list of prescriptions, newest first
+type PrescriptionList struct {
+	Items []PrescriptionListItem `json:"items"`
+}
+
+// PrescriptionService manages prescriptions issued on records
+type PrescriptionService interface {
+	Get(ctx context.Context, id uint64) (*GetPrescription, error)
+	Create(ctx context.Context, p *CreatePrescription) (uint64, error)
+	Update(ctx context.Context, id uint64, p *UpdatePrescription) error
+	ListByRecord(ctx context.Context, recordID uint64) (*PrescriptionList, error)
+	ListByPatient(ctx context.Context, patientID uint64) (*PrescriptionList, error)
+}
+
+// WithdrawalEnd computes end of withdrawal period of drug prescribed on date.
+// Period starts after last dose. Zero time is returned if drug has no
+// withdrawal period.
+func (i *PrescriptionItem) WithdrawalEnd(date time.Time) time.Time {
+	if i.WithdrawalDays == 0 {
+		return time.Time{}
+	}
+
+	return date.AddDate(0, 0, int(i.DurationDays+i.WithdrawalDays))
+}
+
+// -----------------------------------------------------------------------------
+// CONTROLLED-DRUG REGISTER
+
+// ControlledDrugEntry is JSON encoded stock movement of controlled drug
+type ControlledDrugEntry struct {
+	MovementID     uint64            `json:"movementId"`
+	Date           time.Time         `json:"date"`
+	Type           StockMovementType `json:"type"`
+	Amount         string            `json:"amount"`         // signed, negative amount decreases stock
+	Balance        string            `json:"balance"`        // stock after movement
+	Batch          string            `json:"batch"`          // empty if batches are not tracked
+	RecordID       uint64            `json:"recordId"`       // record dispensing drug, 0 for other movements
+	PatientID      uint64            `json:"patientId"`      // 0 for other movements
+	Patient        string            `json:"patient"`        // patient's name
+	PrescriptionID uint64            `json:"prescriptionId"` // record's prescription of drug, 0 if not prescribed
+	Note           string            `json:"note"`
+	Creator        string            `json:"creator"`
+}
+
+// ControlledDrugRegister is JSON encoded register of single controlled drug
+type ControlledDrugRegister struct {
+	ProductID uint64                `json:"productId"`
+	Product   string                `json:"product"`
+	Unit      string                `json:"unit"`
+	Opening   string                `json:"opening"` // stock at start of period (formatted decimal, precision: 10.4)
+	Closing   string                `json:"closing"` // stock at end of period
+	Entries   []ControlledDrugEntry `json:"entries"` // oldest first
+}
+
+// ControlledDrugDiscrepancy is JSON encoded record where dispensed amount of
+// controlled drug differs from prescribed amount
+type ControlledDrugDiscrepancy struct {
+	RecordID   uint64    `json:"recordId"`
+	Date       time.Time `json:"date"`
+	PatientID  uint64    `json:"patientId"`
+	Patient    string    `json:"patient"`
+	ProductID  uint64    `json:"productId"`
+	Product    string    `json:"product"`
+	Unit       string    `json:"unit"`
+	Dispensed  string    `json:"dispensed"`  // sum of record's Material items
+	Prescribed string    `json:"prescribed"` // sum of record's prescription items
+}
+
+// ControlledDrugReport is JSON encoded controlled-drug register of all
+// controlled products and its reconciliation against records
+type ControlledDrugReport struct {
+	Products      []ControlledDrugRegister    `json:"products"`
+	Discrepancies []ControlledDrugDiscrepancy `json:"discrepancies"`
+}
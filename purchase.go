@@ -0,0 +1,227 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package lara
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// SUPPLIER MANAGEMENT SERVICE
+
+// Supplier is JSON encoded updatable supplier fields
+type Supplier struct {
+	Name    string `json:"name"`
+	IC      string `json:"IC"`
+	DIC     string `json:"DIC"`
+	Address string `json:"address"`
+	Contact string `json:"contact"` // contact person
+	Phone   string `json:"phone"`
+	Email   string `json:"email"`
+	Note    string `json:"note"`
+}
+
+// GetSupplier is JSON encoded retrievable supplier data
+type GetSupplier struct {
+	Versioned
+	Supplier
+	CreatorModifier
+}
+
+// UpdateSupplier is JSON encoded updatable supplier data
+type UpdateSupplier struct {
+	Version uint64 `json:"version"`
+	Supplier
+}
+
+// CreateSupplier is JSON encoded create supplier structure
+type CreateSupplier struct {
+	Supplier
+}
+
+// SupplierList is JSON encoded list of suppliers ordered by name
+type SupplierList struct {
+	Items []GetSupplier `json:"items"`
+}
+
+// SupplierService manages suppliers
+type SupplierService interface {
+	Get(ctx context.Context, id uint64) (*GetSupplier, error)
+	Update(ctx context.Context, id uint64, s *UpdateSupplier) error
+	Create(ctx context.Context, s *CreateSupplier) (uint64, error)
+	List(ctx context.Context) (*SupplierList, error)
+}
+
+// -----------------------------------------------------------------------------
+// PURCHASE ORDER MANAGEMENT SERVICE
+
+// PurchaseOrderStatus defines state of purchase order
+//go:generate stringer -type=PurchaseOrderStatus -output purchaseorderstatus_string.go
+//requires golang.org/x/tools/cmd/stringer installed locally
+//if new status added to enum, run "go generate"
+type PurchaseOrderStatus int
+
+// PurchaseOrderStatus enum
+const (
+	Ordered           PurchaseOrderStatus = iota // nothing received yet, order can be updated
+	PartiallyReceived                            // some of ordered amount received
+	Received                                     // all lines received in full
+	Withdrawn                                    // cancelled, rest of order won't be received
+)
+
+// MarshalJSON is JSON marshaller implementation for PurchaseOrderStatus
+func (i PurchaseOrderStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.String())
+}
+
+// UnmarshalJSON is JSON unmarshaller implementation for PurchaseOrderStatus
+func (i *PurchaseOrderStatus) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	switch strings.ToLower(s) {
+	case "ordered":
+		*i = Ordered
+	case "partiallyreceived":
+		*i = PartiallyReceived
+	case "received":
+		*i = Received
+	case "withdrawn":
+		*i = Withdrawn
+	default:
+		return fmt.Errorf("bad PurchaseOrderStatus: '%s'", s)
+	}
+
+	return nil
+}
+
+// PurchaseOrderLine is JSON encoded ordered product
+type PurchaseOrderLine struct {
+	ProductID uint64 `json:"productId"`
+	Amount    string `json:"amount"` // in product's unit (formatted decimal, precision: 10.4)
+	Price     string `json:"price"`  // agreed purchase price per unit, optional
+}
+
+// GetPurchaseOrderLine is JSON encoded retrievable purchase order line
+type GetPurchaseOrderLine struct {
+	ID uint64 `json:"id"`
+	PurchaseOrderLine
+	Product  string `json:"product"`
+	Unit     string `json:"unit"`
+	Received string `json:"received"` // amount received so far
+}
+
+// PurchaseOrder is JSON encoded updatable purchase order fields
+type PurchaseOrder struct {
+	SupplierID uint64              `json:"supplierId"`
+	Note       string              `json:"note"`
+	Lines      []PurchaseOrderLine `json:"lines"`
+}
+
+// GetPurchaseOrder is JSON encoded retrievable purchase order data
+type GetPurchaseOrder struct {
+	Versioned
+	CreatorModifier
+	SupplierID uint64                 `json:"supplierId"`
+	Supplier   string                 `json:"supplier"`
+	Note       string                 `json:"note"`
+	Status     PurchaseOrderStatus    `json:"status"`
+	Lines      []GetPurchaseOrderLine `json:"lines"`
+}
+
+// CreatePurchaseOrder is JSON encoded create purchase order data
+type CreatePurchaseOrder struct {
+	PurchaseOrder
+}
+
+// UpdatePurchaseOrder is JSON encoded update purchase order data. Only
+// Ordered purchase order can be updated.
+type UpdatePurchaseOrder struct {
+	Version uint64 `json:"version"`
+	PurchaseOrder
+}
+
+// ReceivedLine is JSON encoded amount received on purchase order's line
+type ReceivedLine struct {
+	LineID uint64    `json:"lineId"`
+	Amount string    `json:"amount"` // must not exceed amount not received yet
+	Batch  string    `json:"batch"`  // optional batch number
+	Expiry time.Time `json:"expiry"` // batch's expiry date
+}
+
+// ReceivePurchaseOrder is JSON encoded delivery of purchase order, complete
+// or partial. Each received line posts stock Receipt.
+type ReceivePurchaseOrder struct {
+	Version uint64         `json:"version"`
+	Date    time.Time      `json:"date"` // now if empty
+	Lines   []ReceivedLine `json:"lines"`
+}
+
+// WithdrawPurchaseOrder is JSON encoded request to cancel rest of order
+type WithdrawPurchaseOrder struct {
+	Version uint64 `json:"version"`
+}
+
+// SuppliersPurchaseOrder is JSON encoded purchase order in supplier's list
+type SuppliersPurchaseOrder struct {
+	ID      uint64              `json:"id"`
+	Created time.Time           `json:"created"`
+	Status  PurchaseOrderStatus `json:"status"`
+	Note    string              `json:"note"`
+}
+
+// SuppliersPurchaseOrderList is JSON encoded list of supplier's orders, newest first
+type SuppliersPurchaseOrderList struct {
+	Items []SuppliersPurchaseOrder `json:"items"`
+}
+
+// SuggestedReorder is JSON encoded product whose stock, including amount
+// ordered but not received yet, is below product's minimum stock level
+type SuggestedReorder struct {
+	ProductID uint64 `json:"productId"`
+	Product   string `json:"product"`
+	Unit      string `json:"unit"`
+	MinStock  string `json:"minStock"`
+	Stock     string `json:"stock"`     // current stock
+	Ordered   string `json:"ordered"`   // not received yet on open orders
+	Suggested string `json:"suggested"` // amount to order to reach minimum stock
+}
+
+// SuggestedReorderList is JSON encoded list of products to reorder
+type SuggestedReorderList struct {
+	Items []SuggestedReorder `json:"items"`
+}
+
+// PurchaseOrderService manages purchase orders
+type PurchaseOrderService interface {
+	Get(ctx context.Context, id uint64) (*GetPurchaseOrder, error)
+	Create(ctx context.Context, o *CreatePurchaseOrder) (uint64, error)
+	Update(ctx context.Context, id uint64, o *UpdatePurchaseOrder) error
+	Receive(ctx context.Context, id uint64, r *ReceivePurchaseOrder) error
+	Withdraw(ctx context.Context, id uint64, w *WithdrawPurchaseOrder) error
+	ListBySupplier(ctx context.Context, supplierID uint64) (*SuppliersPurchaseOrderList, error)
+	SuggestedReorder(ctx context.Context) (*SuggestedReorderList, error)
+}
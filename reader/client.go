@@ -0,0 +1,140 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package reader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/jkusniar/lara"
+	"github.com/pkg/errors"
+)
+
+// Client pushes scanned codes to lara server. Client logs in on first scan
+// and again when authentication token expires.
+type Client struct {
+	URL  string       // server URL, e.g. https://localhost:8443
+	User string       // login of user with view record permission
+	Pass string       // user's password
+	HTTP *http.Client // http.DefaultClient if nil
+
+	token string
+}
+
+// Scan pushes scanned ISO 11784 code to server and returns patient found by
+// it. Errors reported by server are returned as lara.CodedError with HTTP
+// status code.
+func (c *Client) Scan(ctx context.Context, code string) (*lara.PatientByTag, error) {
+	body, err := json.Marshal(&lara.TagScan{Value: code})
+	if err != nil {
+		return nil, errors.Wrap(err, "encode scan failed")
+	}
+
+	if c.token == "" {
+		if err := c.login(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.post(ctx, "/api/v1/tag/scan", body)
+	if err == nil {
+		return decodePatient(resp)
+	}
+	if ce, ok := errors.Cause(err).(lara.CodedError); !ok || ce.Code() != http.StatusUnauthorized {
+		return nil, err
+	}
+
+	// token expired, log in again
+	if err := c.login(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err = c.post(ctx, "/api/v1/tag/scan", body)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodePatient(resp)
+}
+
+func (c *Client) login(ctx context.Context) error {
+	body, err := json.Marshal(map[string]string{"username": c.User, "password": c.Pass})
+	if err != nil {
+		return errors.Wrap(err, "encode login failed")
+	}
+
+	c.token = ""
+	resp, err := c.post(ctx, "/login", body)
+	if err != nil {
+		return errors.Wrap(err, "login failed")
+	}
+
+	c.token = string(resp)
+	return nil
+}
+
+// post sends JSON body to server path and returns response body
+func (c *Client) post(ctx context.Context, path string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest("POST", strings.TrimRight(c.URL, "/")+path,
+		bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "create request failed")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	hc := c.HTTP
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+
+	resp, err := hc.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "request failed")
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read response failed")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, lara.NewCodedError(resp.StatusCode,
+			errors.New(strings.TrimSpace(string(b))))
+	}
+
+	return b, nil
+}
+
+func decodePatient(b []byte) (*lara.PatientByTag, error) {
+	var p lara.PatientByTag
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, errors.Wrap(err, "decode patient failed")
+	}
+
+	return &p, nil
+}
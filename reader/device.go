@@ -0,0 +1,107 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package reader
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Device reads frames from reader printing one frame per line. Serial readers
+// are opened as character devices (port speed is configured externally, e.g.
+// by stty), HID readers emulating keyboard type frames to standard input.
+type Device struct {
+	s         *bufio.Scanner
+	c         io.Closer
+	simulated bool
+	delay     time.Duration
+}
+
+// NewDevice creates Device reading frames from r
+func NewDevice(r io.Reader) *Device {
+	d := &Device{s: bufio.NewScanner(r)}
+	if c, ok := r.(io.Closer); ok {
+		d.c = c
+	}
+
+	return d
+}
+
+// NewSimulator creates Device replaying frames from r, usually file recorded
+// from real reader, waiting delay before each frame. Lines starting with '#'
+// are comments.
+func NewSimulator(r io.Reader, delay time.Duration) *Device {
+	d := NewDevice(r)
+	d.simulated = true
+	d.delay = delay
+	return d
+}
+
+// Open opens Device by name. Name "-" is standard input.
+func Open(name string) (*Device, error) {
+	if name == "-" {
+		return &Device{s: bufio.NewScanner(os.Stdin)}, nil
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open reader device %s failed", name)
+	}
+
+	return NewDevice(f), nil
+}
+
+// Next returns next decoded ISO 11784 code. Empty lines are skipped. Frames
+// which can't be decoded are returned as *FrameError, reading may continue.
+// io.EOF is returned when device has no more frames.
+func (d *Device) Next() (string, error) {
+	for d.s.Scan() {
+		line := strings.TrimSpace(d.s.Text())
+		if line == "" || d.simulated && strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if d.delay > 0 {
+			time.Sleep(d.delay)
+		}
+
+		return Decode(line)
+	}
+
+	if err := d.s.Err(); err != nil {
+		return "", errors.Wrap(err, "read from reader device failed")
+	}
+
+	return "", io.EOF
+}
+
+// Close closes underlying device
+func (d *Device) Close() error {
+	if d.c == nil {
+		return nil
+	}
+
+	return d.c.Close()
+}
@@ -0,0 +1,132 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package reader bridges handheld and desktop FDX-B microchip readers to lara
+// server. Frames read from serial or line based HID devices are decoded to 15
+// digit ISO 11784 codes and pushed to server which resolves scanned patient.
+package reader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jkusniar/lara"
+)
+
+// FDX-B identification block bit layout (bit 0 is transmitted first)
+const (
+	nationalBits = 38
+	countryBits  = 10
+	animalFlag   = 1 << 63
+)
+
+// FrameError is returned for frames which can't be decoded to ISO 11784 code
+type FrameError struct {
+	Frame string
+	Err   error
+}
+
+func (e *FrameError) Error() string {
+	return fmt.Sprintf("invalid frame %q: %v", e.Frame, e.Err)
+}
+
+// Decode decodes one frame as printed by FDX-B reader to 15 digit ISO 11784
+// code. Supported frame formats are:
+//
+//	941000012345678     decimal code, optionally separated by spaces
+//	941_000012345678    decimal country and national code separated by '_'
+//	3AD.0000BC614E      hexadecimal country and national code separated by '.'
+//	8000EB4000BC614E    raw hexadecimal 64 bit identification block
+//
+// Control characters (STX, ETX, CR) framing the output are ignored. Decoded
+// code is validated with lara.DecodeMicrochip.
+func Decode(frame string) (string, error) {
+	s := strings.TrimFunc(frame, func(r rune) bool {
+		return r <= ' ' || r == 0x7f
+	})
+
+	code, err := decode(s)
+	if err != nil {
+		return "", &FrameError{Frame: frame, Err: err}
+	}
+
+	if _, err := lara.DecodeMicrochip(code); err != nil {
+		return "", &FrameError{Frame: frame, Err: err}
+	}
+
+	return code, nil
+}
+
+func decode(s string) (string, error) {
+	switch {
+	case strings.Contains(s, "_"):
+		return decodeParts(s, "_", 10)
+	case strings.Contains(s, "."):
+		return decodeParts(s, ".", 16)
+	case len(s) == 16:
+		return decodeBlock(s)
+	}
+
+	code := strings.Replace(s, " ", "", -1)
+	if len(code) != 15 {
+		return "", fmt.Errorf("unknown frame format")
+	}
+
+	return code, nil
+}
+
+// decodeParts decodes country and national code separated by sep
+func decodeParts(s, sep string, base int) (string, error) {
+	p := strings.SplitN(s, sep, 2)
+
+	country, err := strconv.ParseUint(p[0], base, 64)
+	if err != nil || country >= 1<<countryBits {
+		return "", fmt.Errorf("invalid country code %q", p[0])
+	}
+
+	national, err := strconv.ParseUint(p[1], base, 64)
+	if err != nil || national >= 1<<nationalBits {
+		return "", fmt.Errorf("invalid national code %q", p[1])
+	}
+
+	return format(country, national), nil
+}
+
+// decodeBlock decodes 64 bit identification block: bits 0-37 national code,
+// bits 38-47 country code, bit 63 animal application flag
+func decodeBlock(s string) (string, error) {
+	b, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid identification block %q", s)
+	}
+
+	if b&animalFlag == 0 {
+		return "", fmt.Errorf("not an animal identification block")
+	}
+
+	national := b & (1<<nationalBits - 1)
+	country := b >> nationalBits & (1<<countryBits - 1)
+
+	return format(country, national), nil
+}
+
+func format(country, national uint64) string {
+	return fmt.Sprintf("%03d%012d", country, national)
+}
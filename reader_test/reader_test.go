@@ -0,0 +1,204 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package reader_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jkusniar/lara"
+	"github.com/jkusniar/lara/reader"
+	"github.com/pkg/errors"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		frame string
+		code  string
+		valid bool
+	}{
+		{"941000012345678", "941000012345678", true},
+		{" 941 0000 1234 5678\r\n", "941000012345678", true},
+		{"\x02941_000012345678\x03", "941000012345678", true},
+		{"941_12345678", "941000012345678", true},
+		{"3AD.0000BC614E", "941000012345678", true},
+		{"2bf.75bcd15", "703000123456789", true},
+		{"8000EB4000BC614E", "941000012345678", true},
+		{"0000EB4000BC614E", "", false}, // animal flag not set
+		{"94100001234567", "", false},
+		{"94100001234567x", "", false},
+		{"999000012345678", "", false}, // test transponder
+		{"941_4000000000000", "", false},
+		{"XYZ.0000BC614E", "", false},
+		{"", "", false},
+	}
+
+	for _, test := range tests {
+		code, err := reader.Decode(test.frame)
+		if !test.valid {
+			if _, ok := err.(*reader.FrameError); !ok {
+				t.Errorf("frame %q: expected frame error but was %v", test.frame, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("frame %q: expected nil error, but was %+v", test.frame, err)
+			continue
+		}
+		if code != test.code {
+			t.Errorf("frame %q: expected code %s but was %s", test.frame, test.code, code)
+		}
+	}
+}
+
+func readAll(d *reader.Device) (codes []string, invalid int, err error) {
+	for {
+		code, err := d.Next()
+		if err == io.EOF {
+			return codes, invalid, nil
+		}
+		if _, ok := err.(*reader.FrameError); ok {
+			invalid++
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		codes = append(codes, code)
+	}
+}
+
+func TestDevice(t *testing.T) {
+	frames := `# recorded from front desk reader
+941000012345678
+
+3AD.0000BC614E
+garbage
+703_000123456789
+`
+
+	codes, invalid, err := readAll(reader.NewSimulator(strings.NewReader(frames), 0))
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(codes) != 3 || codes[0] != "941000012345678" || codes[1] != "941000012345678" ||
+		codes[2] != "703000123456789" {
+		t.Fatalf("unexpected codes %v", codes)
+	}
+	if invalid != 1 {
+		t.Fatalf("expected 1 invalid frame but was %d", invalid)
+	}
+
+	// comments are data on real device
+	codes, invalid, err = readAll(reader.NewDevice(strings.NewReader(frames)))
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if len(codes) != 3 || invalid != 2 {
+		t.Fatalf("unexpected codes %v, %d invalid", codes, invalid)
+	}
+}
+
+// testServer emulates login and tag scan API of lara server. Each token is
+// valid for one request only.
+func testServer(t *testing.T) (*httptest.Server, *int) {
+	logins := 0
+	token := ""
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		var l map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&l); err != nil {
+			t.Errorf("unexpected login body: %v", err)
+			return
+		}
+		if l["username"] != "reader" || l["password"] != "secret" {
+			http.Error(w, "invalid credentials", http.StatusUnauthorized)
+			return
+		}
+		logins++
+		token = "token" + strings.Repeat("x", logins)
+		w.Write([]byte(token))
+	})
+	mux.HandleFunc("/api/v1/tag/scan", func(w http.ResponseWriter, r *http.Request) {
+		if token == "" || r.Header.Get("Authorization") != "Bearer "+token {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		token = ""
+
+		var s lara.TagScan
+		if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+			t.Errorf("unexpected scan body: %v", err)
+			return
+		}
+		if s.Value != "941000012345678" {
+			http.Error(w, "no patient with tag "+s.Value, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(&lara.PatientByTag{PatientID: 3, Name: "Rex"})
+	})
+
+	return httptest.NewServer(mux), &logins
+}
+
+func TestClient(t *testing.T) {
+	srv, logins := testServer(t)
+	defer srv.Close()
+
+	c := &reader.Client{URL: srv.URL, User: "reader", Pass: "secret"}
+	ctx := context.Background()
+
+	// first scan logs in
+	p, err := c.Scan(ctx, "941000012345678")
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if p.PatientID != 3 || p.Name != "Rex" || *logins != 1 {
+		t.Fatalf("unexpected result %+v, %d logins", p, *logins)
+	}
+
+	// expired token is renewed
+	p, err = c.Scan(ctx, "941000012345678")
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+	if p.PatientID != 3 || *logins != 2 {
+		t.Fatalf("unexpected result %+v, %d logins", p, *logins)
+	}
+
+	// unknown tag
+	_, err = c.Scan(ctx, "941000000000001")
+	if ce, ok := errors.Cause(err).(lara.CodedError); !ok || ce.Code() != 404 {
+		t.Fatalf("expected error code 404 but was %+v", err)
+	}
+
+	// bad credentials
+	c = &reader.Client{URL: srv.URL, User: "reader", Pass: "wrong"}
+	_, err = c.Scan(ctx, "941000012345678")
+	if ce, ok := errors.Cause(err).(lara.CodedError); !ok || ce.Code() != 401 {
+		t.Fatalf("expected error code 401 but was %+v", err)
+	}
+}
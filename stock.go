@@ -0,0 +1,159 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package lara
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// STOCK MANAGEMENT SERVICE
+
+// StockMovementType defines kind of stock movement
+//go:generate stringer -type=StockMovementType -output stockmovementtype_string.go
+//requires golang.org/x/tools/cmd/stringer installed locally
+//if new movement type added to enum, run "go generate"
+type StockMovementType int
+
+// StockMovementType enum
+const (
+	Receipt    StockMovementType = iota // stock received from supplier
+	Dispense                            // material used on record, posted automatically
+	Correction                          // stock-taking difference, positive or negative
+	WriteOff                            // damaged or lost stock
+)
+
+// MarshalJSON is JSON marshaller implementation for StockMovementType
+func (i StockMovementType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.String())
+}
+
+// UnmarshalJSON is JSON unmarshaller implementation for StockMovementType
+func (i *StockMovementType) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	switch strings.ToLower(s) {
+	case "receipt":
+		*i = Receipt
+	case "dispense":
+		*i = Dispense
+	case "correction":
+		*i = Correction
+	case "writeoff":
+		*i = WriteOff
+	default:
+		return fmt.Errorf("bad StockMovementType: '%s'", s)
+	}
+
+	return nil
+}
+
+// StockMovement is JSON encoded change of product's stock
+type StockMovement struct {
+	ProductID uint64            `json:"productId"`
+	Type      StockMovementType `json:"type"`
+	Amount    string            `json:"amount"` // in product's unit, see CreateStockMovement for sign
+	Date      time.Time         `json:"date"`
+	Note      string            `json:"note"`
+	BatchID   uint64            `json:"batchId"` // 0 if product's batches are not tracked
+}
+
+// GetStockMovement is JSON encoded retrievable stock movement data. Amount
+// is signed, negative amount decreases stock.
+type GetStockMovement struct {
+	ID uint64 `json:"id"`
+	StockMovement
+	Product  string    `json:"product"`
+	Unit     string    `json:"unit"`
+	RecordID uint64    `json:"recordId"` // record dispensing material, 0 for other movements
+	Batch    string    `json:"batch"`
+	Expiry   time.Time `json:"expiry"`
+	Creator  string    `json:"creator"`
+	Created  time.Time `json:"created"`
+}
+
+// CreateStockMovement is JSON encoded data of manually posted stock movement.
+// Amount of Receipt and WriteOff is positive, Correction's amount is signed.
+// Dispense movements are posted by RecordService only. Date is now if empty.
+// Receipt may specify batch by its number and expiry date instead of BatchID,
+// new batch is created if product has no batch with such number.
+type CreateStockMovement struct {
+	StockMovement
+	Batch  string    `json:"batch"`
+	Expiry time.Time `json:"expiry"`
+}
+
+// StockMovementList is JSON encoded list of stock movements, oldest first
+type StockMovementList struct {
+	Items []GetStockMovement `json:"items"`
+}
+
+// StockLevel is JSON encoded current stock of product
+type StockLevel struct {
+	ProductID uint64 `json:"productId"`
+	Product   string `json:"product"`
+	Unit      string `json:"unit"`
+	Quantity  string `json:"quantity"` // sum of all product's movements (formatted decimal, precision: 10.4)
+}
+
+// StockLevelList is JSON encoded list of stock levels
+type StockLevelList struct {
+	Items []StockLevel `json:"items"`
+}
+
+// StockBatch is JSON encoded current stock of product's batch
+type StockBatch struct {
+	ID        uint64    `json:"id"`
+	ProductID uint64    `json:"productId"`
+	Product   string    `json:"product"`
+	Unit      string    `json:"unit"`
+	Number    string    `json:"number"`
+	Expiry    time.Time `json:"expiry"` // empty if batch doesn't expire
+	Quantity  string    `json:"quantity"`
+}
+
+// StockBatchList is JSON encoded list of stock batches, earliest expiring first
+type StockBatchList struct {
+	Items []StockBatch `json:"items"`
+}
+
+// StockService manages stock movement ledger. Material record items are
+// dispensed from stock by RecordService, expired batches can't be dispensed.
+type StockService interface {
+	GetMovement(ctx context.Context, id uint64) (*GetStockMovement, error)
+	Post(ctx context.Context, m *CreateStockMovement) (uint64, error)
+	// ListMovements returns product's movements dated from - to (inclusive)
+	ListMovements(ctx context.Context, productID uint64, from, to time.Time) (*StockMovementList, error)
+	Get(ctx context.Context, productID uint64) (*StockLevel, error)
+	// List returns stock of all products having any movement
+	List(ctx context.Context) (*StockLevelList, error)
+	// Batches returns product's batches in stock
+	Batches(ctx context.Context, productID uint64) (*StockBatchList, error)
+	// Expiring returns batches in stock expiring within days from today,
+	// including already expired ones
+	Expiring(ctx context.Context, days int) (*StockBatchList, error)
+}
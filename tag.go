@@ -22,18 +22,24 @@ package lara
 import (
 	"context"
 	"fmt"
+	"io"
+	"time"
 )
 
 // -----------------------------------------------------------------------------
 // PATIENT'S TAG MANAGEMENT SERVICE
 
+// DefaultMaxTagDataSize is size limit of tag's data in bytes used if no limit
+// is configured
+const DefaultMaxTagDataSize = 5 << 20
+
 // TagType defines patient's tag type
 //go:generate stringer -type=TagType -output tag_string.go
 //requires golang.org/x/tools/cmd/stringer installed locally
 //if new object permission added to enum, run "go generate"
 type TagType int
 
-// Tag types enum
+// Tag types enum. Tag values are checked and normalised by TagValidators.
 const (
 	LyssaVirus  TagType = iota // Canine Rabies Tags, tag format: YYYY-SK-9999
 	Tattoo                     // Pet tattoo, tag format: regular string
@@ -60,33 +66,67 @@ func (i *TagType) FromString(tt string) error {
 	return nil
 }
 
-// GetTag is JSON encoded retrievable tag data
+// GetTag is JSON encoded retrievable tag data. Tag is active if it was not
+// deactivated or replaced and today is within its validity period.
 type GetTag struct {
 	Versioned
 	CreatorModifier
-	Type  string `json:"type"`
-	Value string `json:"value"`
-	Data  []byte `json:"data"`
+	Type       string     `json:"type"`
+	Value      string     `json:"value"`
+	DataType   string     `json:"dataType"`   // content type of tag's data, empty if no data
+	DataSize   int64      `json:"dataSize"`   // bytes
+	Thumbnail  bool       `json:"thumbnail"`  // true if data is image with thumbnail
+	Microchip  *Microchip `json:"microchip"`  // decoded value of RFID tag, nil for other types
+	ValidFrom  time.Time  `json:"validFrom"`  // empty if valid since issue
+	ValidTo    time.Time  `json:"validTo"`    // empty if valid indefinitely
+	Active     bool       `json:"active"`     // not deactivated and valid today
+	Replaces   uint64     `json:"replaces"`   // ID of tag replaced by this one, 0 if none
+	ReplacedBy uint64     `json:"replacedBy"` // ID of tag replacing this one, 0 if none
 }
 
 // CreateTag is JSON encoded create tag data
 type CreateTag struct {
-	PatientID uint64 `json:"patientId"`
-	Type      string `json:"type"`
-	Value     string `json:"value"`
-	Data      []byte `json:"data"`
+	PatientID uint64    `json:"patientId"`
+	Type      string    `json:"type"`
+	Value     string    `json:"value"`
+	ValidFrom time.Time `json:"validFrom"` // empty if valid since issue
+	ValidTo   time.Time `json:"validTo"`   // empty if valid indefinitely
 }
 
-// UpdateTag is JSON encoded update tag data
+// UpdateTag is JSON encoded update tag data. Only active tags can be updated,
+// reissued tags should be replaced to keep old value for lookups.
 type UpdateTag struct {
-	Version uint64 `json:"version"`
-	Value   string `json:"value"`
-	Data    []byte `json:"data"`
+	Version   uint64    `json:"version"`
+	Value     string    `json:"value"`
+	ValidFrom time.Time `json:"validFrom"` // empty if valid since issue
+	ValidTo   time.Time `json:"validTo"`   // empty if valid indefinitely
+}
+
+// ReplaceTag is JSON encoded request to replace reissued tag by new tag of
+// the same type. Replaced tag is deactivated, its validity ends when new tag's
+// validity starts.
+type ReplaceTag struct {
+	Version   uint64    `json:"version"` // version of replaced tag
+	Value     string    `json:"value"`
+	ValidFrom time.Time `json:"validFrom"` // today if empty
+	ValidTo   time.Time `json:"validTo"`   // empty if valid indefinitely
+}
+
+// DeactivateTag is JSON encoded request to deactivate lost or invalidated tag.
+// Deactivated tag's value can be reused by new tag.
+type DeactivateTag struct {
+	Version uint64    `json:"version"`
+	ValidTo time.Time `json:"validTo"` // last day tag is valid, today if empty
 }
 
-// PatientByTag is JSON encoded patient's data found by tag value
+// PatientByTag is JSON encoded patient's data found by tag value. Historical
+// values of replaced or deactivated tags are found too, flagged inactive.
 type PatientByTag struct {
+	TagID        uint64 `json:"tagId"` // DB primary key
 	TagType      string `json:"tagType"`
+	Active       bool   `json:"active"`     // false if tag is not valid anymore
+	ReplacedBy   string `json:"replacedBy"` // value of tag replacing inactive tag, empty if none
+	PatientID    uint64 `json:"patientId"`  // DB primary key
 	Name         string `json:"name"`
 	Species      string `json:"species"`
 	Breed        string `json:"breed"`
@@ -96,10 +136,35 @@ type PatientByTag struct {
 	OwnerAddress string `json:"ownerAddress"` // owner's address
 }
 
+// TagData is binary data of tag, e.g. scan of pet passport or photo of
+// vaccination certificate
+type TagData struct {
+	ContentType string
+	Content     []byte
+}
+
+// TagScan is JSON encoded tag value scanned by hardware tag reader
+type TagScan struct {
+	Value string `json:"value"`
+}
+
 // TagService manages patient's tags
 type TagService interface {
 	Get(ctx context.Context, id uint64) (*GetTag, error)
 	Update(ctx context.Context, id uint64, o *UpdateTag) error
 	Create(ctx context.Context, o *CreateTag) (uint64, error)
+	// Replace replaces tag by new one, returns new tag's ID
+	Replace(ctx context.Context, id uint64, o *ReplaceTag) (uint64, error)
+	Deactivate(ctx context.Context, id uint64, o *DeactivateTag) error
+	// PutData replaces tag's data by content. Content type is sniffed from
+	// content, unsupported type is rejected with coded error 415, content
+	// exceeding size limit with 413. Thumbnail is made for images.
+	PutData(ctx context.Context, id uint64, content io.Reader) error
+	// GetData returns tag's data, coded error 404 if tag has no data
+	GetData(ctx context.Context, id uint64) (*TagData, error)
+	// GetThumbnail returns thumbnail of tag's image data, coded error 404 if
+	// tag has no thumbnail
+	GetThumbnail(ctx context.Context, id uint64) (*TagData, error)
+	DeleteData(ctx context.Context, id uint64) error
 	GetPatientByTag(ctx context.Context, tagValue string) (*PatientByTag, error)
 }
@@ -4,9 +4,9 @@ package lara
 
 import "fmt"
 
-const _TagType_name = "LyssaVirusTattooPetPassport"
+const _TagType_name = "LyssaVirusTattooPetPassportRFID"
 
-var _TagType_index = [...]uint8{0, 10, 16, 27}
+var _TagType_index = [...]uint8{0, 10, 16, 27, 31}
 
 func (i TagType) String() string {
 	if i < 0 || i >= TagType(len(_TagType_index)-1) {
@@ -0,0 +1,149 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package lara
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// TagValidator checks value of single TagType
+type TagValidator interface {
+	// Normalize returns canonical form of tag value. Returned error
+	// explains expected format.
+	Normalize(value string) (string, error)
+}
+
+// TagValidatorFunc is an adapter to use ordinary function as TagValidator
+type TagValidatorFunc func(value string) (string, error)
+
+// Normalize calls f(value)
+func (f TagValidatorFunc) Normalize(value string) (string, error) {
+	return f(value)
+}
+
+// TagValidators maps tag types to their validators
+type TagValidators map[TagType]TagValidator
+
+// DefaultTagValidators returns validators of formats documented on TagType
+// enum. Returned map may be modified to plug in other validators.
+func DefaultTagValidators() TagValidators {
+	return TagValidators{
+		LyssaVirus:  TagValidatorFunc(normalizeLyssaVirus),
+		Tattoo:      TagValidatorFunc(normalizeTattoo),
+		PetPassport: TagValidatorFunc(normalizePetPassport),
+		RFID:        TagValidatorFunc(normalizeRFID),
+	}
+}
+
+// Normalize validates value of tag type t and returns its canonical form.
+// Invalid value is reported as CodedError 400. Value of type without
+// validator is only trimmed.
+func (v TagValidators) Normalize(t TagType, value string) (string, error) {
+	tv, ok := v[t]
+	if !ok {
+		return strings.TrimSpace(value), nil
+	}
+
+	n, err := tv.Normalize(value)
+	if err != nil {
+		return "", NewCodedError(400, fmt.Errorf("invalid %s tag '%s': %s", t, value, err))
+	}
+
+	return n, nil
+}
+
+// Candidates returns distinct values tag value may be stored as, i.e. value
+// itself followed by its canonical forms of all types it is valid for.
+func (v TagValidators) Candidates(value string) []string {
+	result := []string{value}
+	for t := LyssaVirus; t <= RFID; t++ {
+		n, err := v.Normalize(t, value)
+		if err != nil || n == "" {
+			continue
+		}
+
+		found := false
+		for _, c := range result {
+			found = found || c == n
+		}
+		if !found {
+			result = append(result, n)
+		}
+	}
+
+	return result
+}
+
+// removeSpace drops all white space from s
+func removeSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+var lyssaVirusRe = regexp.MustCompile(`^[0-9]{4}-[A-Z]{2}-[0-9]{4}$`)
+
+func normalizeLyssaVirus(value string) (string, error) {
+	n := strings.ToUpper(removeSpace(value))
+	if !lyssaVirusRe.MatchString(n) {
+		return "", errors.New("expected year, country code and 4 digit number, e.g. 2017-SK-0007")
+	}
+
+	return n, nil
+}
+
+var tattooRe = regexp.MustCompile(`^[0-9A-Z][0-9A-Z ./-]{0,29}$`)
+
+func normalizeTattoo(value string) (string, error) {
+	n := strings.ToUpper(strings.Join(strings.Fields(value), " "))
+	if !tattooRe.MatchString(n) {
+		return "", errors.New("expected up to 30 letters, digits, spaces, dots, slashes or dashes, e.g. AB 1234")
+	}
+
+	return n, nil
+}
+
+var petPassportRe = regexp.MustCompile(`^[A-Z]{2}[0-9]{9}$`)
+
+func normalizePetPassport(value string) (string, error) {
+	n := strings.ToUpper(removeSpace(value))
+	if !petPassportRe.MatchString(n) {
+		return "", errors.New("expected 2 letter country code followed by 9 digits, e.g. SK012345678")
+	}
+
+	return n, nil
+}
+
+func normalizeRFID(value string) (string, error) {
+	n := removeSpace(value)
+	if _, err := DecodeMicrochip(n); err != nil {
+		return "", fmt.Errorf("%s; expected 15 digit ISO 11784 code of 3 digit country or "+
+			"manufacturer code followed by 12 digit national code, e.g. 703000012345678", err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,133 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package thumbnail creates previews of images using only standard library
+// decoders
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/gif" // register GIF decoder
+	"image/jpeg"
+	_ "image/png" // register PNG decoder
+
+	"github.com/pkg/errors"
+)
+
+// ContentType is content type of thumbnails
+const ContentType = "image/jpeg"
+
+// DefaultSize is thumbnail's maximal width and height in pixels
+const DefaultSize = 200
+
+// maxPixels limits size of decoded images, larger images are refused
+const maxPixels = 50 * 1000 * 1000
+
+// Supported returns true if thumbnail can be made from content of type
+func Supported(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+		return true
+	}
+
+	return false
+}
+
+// Make returns JPEG encoded thumbnail of JPEG, PNG or GIF image data fitting
+// into size x size square. Aspect ratio is kept, smaller images are not
+// enlarged. Transparent areas are white.
+func Make(data []byte, size int) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "decode image failed")
+	}
+
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, errors.Errorf("image %dx%d has more than %d pixels",
+			cfg.Width, cfg.Height, maxPixels)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "decode image failed")
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, scale(src, size), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, errors.Wrap(err, "encode thumbnail failed")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// fit returns dimensions of w x h rectangle scaled down to fit into size x
+// size square
+func fit(w, h, size int) (int, int) {
+	switch {
+	case w <= size && h <= size:
+		return w, h
+	case w >= h:
+		return size, max(1, h*size/w)
+	default:
+		return max(1, w*size/h), size
+	}
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// scale scales src down averaging source pixels covered by each pixel of
+// result (box filter). Colors are blended with white by their transparency.
+func scale(src image.Image, size int) *image.RGBA {
+	b := src.Bounds()
+	w, h := fit(b.Dx(), b.Dy(), size)
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := b.Min.Y+y*b.Dy()/h, b.Min.Y+(y+1)*b.Dy()/h
+		for x := 0; x < w; x++ {
+			x0, x1 := b.Min.X+x*b.Dx()/w, b.Min.X+(x+1)*b.Dx()/w
+
+			// sums of alpha premultiplied color components
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+
+			white := n*0xffff - a
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8((r + white) / n >> 8),
+				G: uint8((g + white) / n >> 8),
+				B: uint8((bl + white) / n >> 8),
+				A: 0xff})
+		}
+	}
+
+	return dst
+}
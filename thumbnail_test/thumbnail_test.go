@@ -0,0 +1,108 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package thumbnail_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/jkusniar/lara/thumbnail"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode test image failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		w, h       int
+		expW, expH int
+	}{
+		{800, 600, 200, 150},
+		{600, 800, 150, 200},
+		{1000, 10, 200, 2},
+		{1000, 1, 200, 1},
+		{120, 80, 120, 80}, // not enlarged
+	}
+
+	for _, test := range tests {
+		img := image.NewNRGBA(image.Rect(0, 0, test.w, test.h))
+		for y := 0; y < test.h; y++ {
+			for x := 0; x < test.w; x++ {
+				img.Set(x, y, color.NRGBA{R: 0xff, A: 0xff})
+			}
+		}
+
+		data, err := thumbnail.Make(encodePNG(t, img), thumbnail.DefaultSize)
+		if err != nil {
+			t.Fatalf("%dx%d: expected nil error, but was %+v", test.w, test.h, err)
+		}
+
+		th, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%dx%d: thumbnail is not JPEG: %v", test.w, test.h, err)
+		}
+		if b := th.Bounds(); b.Dx() != test.expW || b.Dy() != test.expH {
+			t.Errorf("%dx%d: expected %dx%d thumbnail but was %dx%d", test.w, test.h,
+				test.expW, test.expH, b.Dx(), b.Dy())
+		}
+
+		r, g, b, _ := th.At(test.expW/2, test.expH/2).RGBA()
+		if r>>8 < 0xe0 || g>>8 > 0x20 || b>>8 > 0x20 {
+			t.Errorf("%dx%d: expected red thumbnail but was %x %x %x", test.w, test.h,
+				r>>8, g>>8, b>>8)
+		}
+	}
+}
+
+func TestMakeTransparent(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 400, 400))
+
+	data, err := thumbnail.Make(encodePNG(t, img), 100)
+	if err != nil {
+		t.Fatalf("expected nil error, but was %+v", err)
+	}
+
+	th, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("thumbnail is not JPEG: %v", err)
+	}
+	if r, g, b, _ := th.At(50, 50).RGBA(); r>>8 < 0xf0 || g>>8 < 0xf0 || b>>8 < 0xf0 {
+		t.Errorf("expected white thumbnail but was %x %x %x", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestMakeInvalid(t *testing.T) {
+	if _, err := thumbnail.Make([]byte("%PDF-1.4 not an image"), 100); err == nil {
+		t.Error("expected error")
+	}
+
+	if !thumbnail.Supported("image/png") || thumbnail.Supported("application/pdf") {
+		t.Error("unexpected supported types")
+	}
+}
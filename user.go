@@ -66,6 +66,7 @@ const (
 	EditRecord
 	ViewReports
 	EditProducts
+	Admin // manages users
 )
 
 // FromString creates PermissionType from string
@@ -80,6 +81,8 @@ func (i *PermissionType) FromString(perm string) error {
 		*i = ViewReports
 	case "EditProducts":
 		*i = EditProducts
+	case "Admin":
+		*i = Admin
 	default:
 		return fmt.Errorf("bad PermissionType: '%s'", perm)
 	}
@@ -90,12 +93,39 @@ func (i *PermissionType) FromString(perm string) error {
 // DefaultPermissions is set of default permissions for new user
 var DefaultPermissions = []PermissionType{ViewRecord}
 
+// UserAccount is JSON encoded user's account data
+type UserAccount struct {
+	Login       string   `json:"login"`
+	Permissions []string `json:"permissions"` // sorted by name
+	Disabled    bool     `json:"disabled"`    // disabled user can't log in
+}
+
+// UserList is JSON encoded list of user accounts sorted by login
+type UserList struct {
+	Items []UserAccount `json:"items"`
+}
+
+// ResetPassword is JSON encoded request to set user's new password
+type ResetPassword struct {
+	Password string `json:"password"`
+}
+
 // UserService manages application's users
 type UserService interface {
 	Authenticate(ctx context.Context, login, password string) (*User, error)
+	// CheckEnabled returns CodedError 401 if user doesn't exist or is
+	// disabled. Used to reject tokens of users disabled after login.
+	CheckEnabled(ctx context.Context, login string) error
 	Register(ctx context.Context, login, password string, permissions []PermissionType) error
 	Grant(ctx context.Context, login string, permissions []PermissionType) error
 	Revoke(ctx context.Context, login string, permissions []PermissionType) error
+	List(ctx context.Context) (*UserList, error)
+	Get(ctx context.Context, login string) (*UserAccount, error)
+	// Disable prevents user from logging in. Tokens issued before are
+	// rejected by CheckEnabled. Users can't disable themselves.
+	Disable(ctx context.Context, login string) error
+	Enable(ctx context.Context, login string) error
+	ResetPassword(ctx context.Context, login, password string) error
 }
 
 // -----------------------------------------------------------------------------
@@ -0,0 +1,101 @@
+/*
+   Copyright (C) 2016-2017 Contributors as noted in the AUTHORS file
+
+   This file is part of lara, veterinary practice support software.
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package lara
+
+import (
+	"context"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// VACCINATION MANAGEMENT SERVICE
+
+// Vaccination is JSON encoded updatable vaccination fields
+type Vaccination struct {
+	ProductID    uint64    `json:"productId"`    // vaccine from product catalogue
+	Batch        string    `json:"batch"`        // vaccine's batch number
+	Administered time.Time `json:"administered"` // date vaccine was given
+	ValidMonths  uint      `json:"validMonths"`  // validity period, 0 if revaccination is not required
+	Vet          string    `json:"vet"`          // login of user who administered vaccine
+	Note         string    `json:"note"`
+}
+
+// GetVaccination is JSON encoded retrievable vaccination data
+type GetVaccination struct {
+	Versioned
+	CreatorModifier
+	Vaccination
+	PatientID uint64    `json:"patientId"`
+	Vaccine   string    `json:"vaccine"` // vaccine's product name
+	Due       time.Time `json:"due"`     // next vaccination due date, zero if not required
+}
+
+// CreateVaccination is JSON encoded create vaccination data
+type CreateVaccination struct {
+	PatientID uint64 `json:"patientId"`
+	Vaccination
+}
+
+// UpdateVaccination is JSON encoded update vaccination data
+type UpdateVaccination struct {
+	Version uint64 `json:"version"`
+	Vaccination
+}
+
+// PatientsVaccination is JSON encoded patient's vaccination data
+type PatientsVaccination struct {
+	ID           uint64    `json:"id"`
+	Vaccine      string    `json:"vaccine"`
+	Administered time.Time `json:"administered"`
+	Due          time.Time `json:"due"`
+}
+
+// DueVaccination is JSON encoded vaccination due for patient together
+// with owner's contact data
+type DueVaccination struct {
+	VaccinationID uint64    `json:"vaccinationId"`
+	Vaccine       string    `json:"vaccine"`
+	Administered  time.Time `json:"administered"`
+	Due           time.Time `json:"due"`
+	PatientID     uint64    `json:"patientId"`
+	PatientName   string    `json:"patientName"`
+	Species       string    `json:"species"`
+	OwnerID       uint64    `json:"ownerId"`      // DB primary key
+	OwnerName     string    `json:"ownerName"`    // formatted owner name (first, last, title)
+	OwnerAddress  string    `json:"ownerAddress"` // owner's address
+	Phone1        string    `json:"phone1"`
+	Phone2        string    `json:"phone2"`
+	Email         string    `json:"email"`
+}
+
+// DueVaccinationList is JSON encoded list of due vaccinations
+type DueVaccinationList struct {
+	Items []DueVaccination `json:"items"`
+}
+
+// VaccinationService manages patient's vaccinations
+type VaccinationService interface {
+	Get(ctx context.Context, id uint64) (*GetVaccination, error)
+	Update(ctx context.Context, id uint64, v *UpdateVaccination) error
+	Create(ctx context.Context, v *CreateVaccination) (uint64, error)
+	// GetDue lists latest vaccinations of living patients falling due in
+	// requested period
+	GetDue(ctx context.Context, r *ReportRequest) (*DueVaccinationList, error)
+}